@@ -0,0 +1,232 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/Hamed0406/gofind/internal/actions"
+	"github.com/Hamed0406/gofind/internal/audit"
+	"github.com/Hamed0406/gofind/internal/finder"
+	"github.com/Hamed0406/gofind/internal/safety"
+)
+
+// runActions collects matching files and either prints the plan (planOnly)
+// or performs it, recording each operation in the auditLog system log if one
+// is named. Directories are never acted on. The whole search finishes before
+// anything is touched, so actions can't disturb the walk.
+func runActions(ctx context.Context, out io.Writer, cfg finder.Config, del, toTrash bool, moveTo string, planOnly bool, auditLog string, force bool) int {
+	if del && moveTo != "" {
+		fmt.Fprintln(os.Stderr, "--delete and --move-to are mutually exclusive")
+		return 2
+	}
+	if planOnly && auditLog != "" {
+		fmt.Fprintln(os.Stderr, "--audit records performed operations; pass it to gofind apply instead of --plan")
+		return 2
+	}
+	al, err := openAudit(auditLog)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	if al != nil {
+		defer al.Close()
+	}
+	if moveTo != "" && (len(cfg.Roots) > 0 || cfg.Paths != nil) {
+		// Moves keep paths relative to a single --root.
+		fmt.Fprintln(os.Stderr, "--move-to cannot be combined with --files-from or --roots-from")
+		return 2
+	}
+	// --files-from lists the files itself, so the directory of each is
+	// checked as it is found instead; they are the roots of the plan.
+	var roots []string
+	if cfg.Paths == nil {
+		roots = cfg.Roots
+		if len(roots) == 0 {
+			roots = []string{cfg.Root}
+		}
+	}
+	if err := checkActionRoots(roots, force); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	var (
+		ops     []actions.Op
+		checked = map[string]bool{}
+		refusal error
+	)
+	_, err = finder.Walk(ctx, cfg, func(e finder.Entry) error {
+		if e.IsDir {
+			return nil
+		}
+		if dir := filepath.Dir(e.Path); cfg.Paths != nil && !checked[dir] {
+			checked[dir] = true
+			if refusal = checkActionRoots([]string{dir}, force); refusal != nil {
+				return refusal
+			}
+			roots = append(roots, dir)
+		}
+		if del && toTrash {
+			ops = append(ops, actions.Trash(e.Path, e.Size))
+			return nil
+		}
+		if del {
+			ops = append(ops, actions.Delete(e.Path, e.Size))
+			return nil
+		}
+		op, err := actions.Move(cfg.Root, e.Path, moveTo, e.Size)
+		if err != nil {
+			return err
+		}
+		ops = append(ops, op)
+		return nil
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		if refusal != nil {
+			return 2
+		}
+		return 1
+	}
+
+	if planOnly {
+		if err := actions.WritePlan(out, actions.Plan{Roots: absRoots(roots), Ops: ops}); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		return 0
+	}
+	return applyOps(ctx, out, ops, al)
+}
+
+// actionFlags are the search flags acting on matches, or on how they are
+// acted on, which only the main search uses.
+var actionFlags = []string{"delete", "move-to", "trash", "plan", "audit", safety.OverrideFlag[2:]}
+
+// checkActions rejects action flags given to commands other than the main
+// search, --plan without --delete or --move-to and --trash without --delete,
+// rather than ignore them.
+func (sf *searchFlags) checkActions() error {
+	if !sf.actions {
+		for _, name := range actionFlags {
+			if sf.cliFlags[name] {
+				return fmt.Errorf("--%s only applies to a search, not gofind %s", name, sf.fs.Name())
+			}
+		}
+		return nil
+	}
+	if sf.cliFlags["plan"] && *sf.planOnly && !*sf.deleteMatches && *sf.moveTo == "" {
+		return errors.New("--plan needs --delete or --move-to")
+	}
+	// GOFIND_TRASH and the config file make trashing the norm for every
+	// --delete; only --trash itself asks for one.
+	if sf.cliFlags["trash"] && *sf.toTrash && !*sf.deleteMatches {
+		return errors.New("--trash needs --delete")
+	}
+	return nil
+}
+
+// runApply executes a plan file previously written with --plan.
+func runApply(args []string) int {
+	fs := flag.NewFlagSet("apply", flag.ContinueOnError)
+	auditLog := fs.String("audit", "", "also record each operation in the system log: syslog or journald")
+	force := fs.Bool("i-know-what-im-doing", false, "apply a plan made below the root of a filesystem, your home directory or a directory containing it")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: gofind apply [--audit syslog|journald] [--i-know-what-im-doing] PLAN.json")
+		return 2
+	}
+	al, err := openAudit(*auditLog)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	if al != nil {
+		defer al.Close()
+	}
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	plan, err := actions.ReadPlan(f)
+	_ = f.Close()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if err := checkActionRoots(plan.Roots, *force); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	ctx, cancel := signalContext(0)
+	defer cancel()
+	return applyOps(ctx, os.Stdout, plan.Ops, al)
+}
+
+// checkActionRoots refuses actions below a dangerous root unless forced; see
+// the safety package.
+func checkActionRoots(roots []string, force bool) error {
+	if force {
+		return nil
+	}
+	return safety.CheckRoots(roots)
+}
+
+// absRoots returns roots made absolute, for a plan applied from elsewhere.
+func absRoots(roots []string) []string {
+	out := make([]string, 0, len(roots))
+	for _, r := range roots {
+		if abs, err := filepath.Abs(r); err == nil {
+			r = abs
+		}
+		out = append(out, r)
+	}
+	return out
+}
+
+// openAudit connects to the --audit system log, returning nil without one.
+func openAudit(dest string) (audit.Logger, error) {
+	if dest == "" {
+		return nil, nil
+	}
+	al, err := audit.Open(dest)
+	if err != nil {
+		return nil, fmt.Errorf("--audit: %v", err)
+	}
+	return al, nil
+}
+
+// applyOps performs ops, reporting each completed one on out and failures on
+// stderr, and recording every outcome in al unless it is nil. A record that
+// cannot be logged fails the run but does not stop it.
+func applyOps(ctx context.Context, out io.Writer, ops []actions.Op, al audit.Logger) int {
+	var auditErr error
+	err := actions.Apply(ctx, ops, func(op actions.Op, err error) {
+		if al != nil {
+			rec := audit.Record{Op: op.Op, Src: op.Src, Dst: op.Dst, Bytes: op.Bytes, Err: err}
+			if lerr := al.Log(rec); lerr != nil && auditErr == nil {
+				auditErr = lerr
+				fmt.Fprintf(os.Stderr, "--audit: %v\n", lerr)
+			}
+		}
+		switch {
+		case err != nil:
+			fmt.Fprintln(os.Stderr, err)
+		case op.Dst != "":
+			fmt.Fprintf(out, "%s %s -> %s\n", op.Op, op.Src, op.Dst)
+		default:
+			fmt.Fprintf(out, "%s %s\n", op.Op, op.Src)
+		}
+	})
+	if err != nil || auditErr != nil {
+		return 1
+	}
+	return 0
+}
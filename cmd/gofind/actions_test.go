@@ -0,0 +1,166 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestCLI_DeletePlanAndApply(t *testing.T) {
+	bin := buildCLI(t)
+	td := t.TempDir()
+	victim := mk(t, td, "logs/old.log", 3)
+	keep := mk(t, td, "logs/keep.txt", 3)
+	planPath := filepath.Join(t.TempDir(), "plan.json")
+
+	out, err := exec.Command(bin, "-root", td, "-ext", ".log", "-delete", "-plan", "-out", planPath).CombinedOutput()
+	if err != nil {
+		t.Fatalf("plan: %v; out=%s", err, out)
+	}
+	if _, err := os.Stat(victim); err != nil {
+		t.Fatalf("--plan must not delete anything: %v", err)
+	}
+	data, err := os.ReadFile(planPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var plan struct {
+		Ops []struct {
+			Op    string `json:"op"`
+			Src   string `json:"src"`
+			Bytes int64  `json:"bytes"`
+		} `json:"ops"`
+	}
+	if err := json.Unmarshal(data, &plan); err != nil {
+		t.Fatalf("plan is not JSON: %v\n%s", err, data)
+	}
+	if len(plan.Ops) != 1 || plan.Ops[0].Op != "delete" || plan.Ops[0].Src != victim || plan.Ops[0].Bytes != 3 {
+		t.Fatalf("unexpected plan: %+v", plan)
+	}
+
+	if out, err := exec.Command(bin, "apply", planPath).CombinedOutput(); err != nil {
+		t.Fatalf("apply: %v; out=%s", err, out)
+	}
+	if _, err := os.Stat(victim); !os.IsNotExist(err) {
+		t.Fatalf("expected %s deleted", victim)
+	}
+	if _, err := os.Stat(keep); err != nil {
+		t.Fatalf("non-matching file must survive: %v", err)
+	}
+}
+
+func TestCLI_DangerousRoot(t *testing.T) {
+	bin := buildCLI(t)
+	home := t.TempDir()
+	victim := mk(t, home, "old.log", 3)
+	planPath := filepath.Join(t.TempDir(), "plan.json")
+	gofind := func(args ...string) ([]byte, error) {
+		cmd := exec.Command(bin, args...)
+		cmd.Env = append(os.Environ(), "HOME="+home, "USERPROFILE="+home)
+		return cmd.CombinedOutput()
+	}
+
+	out, err := gofind("--root", home, "--ext", ".log", "--delete")
+	if err == nil || !strings.Contains(string(out), "home directory") || !strings.Contains(string(out), "--i-know-what-im-doing") {
+		t.Fatalf("--delete in the home directory: err=%v out=%s", err, out)
+	}
+	if _, err := os.Stat(victim); err != nil {
+		t.Fatalf("refused --delete removed a file: %v", err)
+	}
+
+	// Only the command line lifts the check.
+	cmd := exec.Command(bin, "--root", home, "--ext", ".log", "--delete")
+	cmd.Env = append(os.Environ(), "HOME="+home, "USERPROFILE="+home, "GOFIND_I_KNOW_WHAT_IM_DOING=true")
+	if out, err := cmd.CombinedOutput(); err == nil {
+		t.Fatalf("--delete in the home directory forced from the environment: %s", out)
+	}
+
+	// --files-from has no root; the directory of each listed file counts.
+	cmd = exec.Command(bin, "--files-from", "-", "--delete")
+	cmd.Env = append(os.Environ(), "HOME="+home, "USERPROFILE="+home)
+	cmd.Stdin = strings.NewReader(victim + "\n")
+	out, err = cmd.CombinedOutput()
+	if err == nil || !strings.Contains(string(out), "home directory") {
+		t.Fatalf("--files-from --delete in the home directory: err=%v out=%s", err, out)
+	}
+	if _, err := os.Stat(victim); err != nil {
+		t.Fatalf("refused --files-from --delete removed a file: %v", err)
+	}
+
+	if out, err := gofind("--root", home, "--ext", ".log", "--delete", "--plan", "--out", planPath, "--i-know-what-im-doing"); err != nil {
+		t.Fatalf("forced plan: %v; out=%s", err, out)
+	}
+	if out, err := gofind("apply", planPath); err == nil {
+		t.Fatalf("apply of a plan for the home directory was not refused: %s", out)
+	}
+	if out, err := gofind("apply", "--i-know-what-im-doing", planPath); err != nil {
+		t.Fatalf("forced apply: %v; out=%s", err, out)
+	}
+	if _, err := os.Stat(victim); !os.IsNotExist(err) {
+		t.Fatalf("expected %s deleted", victim)
+	}
+}
+
+func TestCLI_DeleteToTrash(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses the XDG trash layout")
+	}
+	bin := buildCLI(t)
+	home := t.TempDir()
+	td := t.TempDir()
+	victim := mk(t, td, "logs/old.log", 3)
+	gofind := func(args ...string) (string, error) {
+		cmd := exec.Command(bin, args...)
+		cmd.Env = append(os.Environ(), "HOME="+home, "XDG_DATA_HOME="+filepath.Join(home, "data"))
+		out, err := cmd.CombinedOutput()
+		return string(out), err
+	}
+
+	out, err := gofind("--root", td, "--ext", ".log", "--delete", "--trash")
+	if err != nil || out != "trash "+victim+"\n" {
+		t.Fatalf("--delete --trash: %q, %v", out, err)
+	}
+	if _, err := os.Stat(victim); !os.IsNotExist(err) {
+		t.Fatalf("expected %s trashed", victim)
+	}
+	if out, err = gofind("trash", "list", td); err != nil || !strings.HasSuffix(out, "  "+victim+"\n") {
+		t.Fatalf("trash list: %q, %v", out, err)
+	}
+	if out, err = gofind("trash", "restore", filepath.Join(td, "logs")); err != nil || out != "restore "+victim+"\n" {
+		t.Fatalf("trash restore: %q, %v", out, err)
+	}
+	if _, err := os.Stat(victim); err != nil {
+		t.Fatalf("not restored: %v", err)
+	}
+	if _, err = gofind("trash", "restore", td); err == nil {
+		t.Fatal("restore with nothing to restore succeeded")
+	}
+}
+
+func TestCLI_ActionFlagsOnlyForSearch(t *testing.T) {
+	bin := buildCLI(t)
+	td := t.TempDir()
+	victim := mk(t, td, "old.log", 3)
+
+	for _, args := range [][]string{
+		{"sql", "--root", td, "--delete", "SELECT path FROM files"},
+		{"top", "--root", td, "--delete"},
+		{"stale", "--root", td, "--move-to", t.TempDir()},
+		{"analyze", "--root", td, "--plan"},
+		{"--root", td, "--plan"},
+		{"--root", td, "--trash"},
+		{"--root", td, "--move-to", t.TempDir(), "--trash"},
+	} {
+		out, err := exec.Command(bin, args...).CombinedOutput()
+		if ee, ok := err.(*exec.ExitError); !ok || ee.ExitCode() != 2 {
+			t.Errorf("%v: want exit 2, got %v; out=%s", args, err, out)
+		}
+	}
+	if _, err := os.Stat(victim); err != nil {
+		t.Fatalf("nothing may be deleted: %v", err)
+	}
+}
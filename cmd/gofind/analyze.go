@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/Hamed0406/gofind/internal/finder"
+	"github.com/Hamed0406/gofind/internal/stats"
+)
+
+func init() {
+	subcommands["analyze"] = runAnalyze
+}
+
+// runAnalyze summarizes the matched files (size and age histograms, top
+// extensions) instead of listing them. It accepts all search flags.
+func runAnalyze(args []string) int {
+	fs := flag.NewFlagSet("analyze", flag.ContinueOnError)
+	sf := defineSearchFlags(fs)
+	topExt := fs.Int("top-ext", 10, "number of extensions to list (0 = all)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	cfg, err := sf.config()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	stopProfiles, err := sf.startProfiles()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	defer stopProfiles()
+	out, closeOut, err := createOutput(*sf.outPath, *sf.compress)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	defer closeOut()
+
+	ctx, cancel := signalContext(*sf.timeout)
+	defer cancel()
+	bounds, _ := sf.ageBounds() // checked by config
+	agg := stats.New(stats.Options{AgeBounds: bounds, Sample: cfg.Sample})
+	res, err := finder.Walk(ctx, cfg, func(e finder.Entry) error {
+		agg.Add(e)
+		return nil
+	})
+	code := searchStatus(res, err)
+	if cfg.Sample > 0 && err == nil {
+		saveDirCount(cfg, res) // for --sample-dirs
+	}
+	if code != 0 && !res.Interrupted {
+		return code
+	}
+	// An interrupted walk still reports what it saw.
+	report := agg.Report(*topExt)
+	report.Truncated = res.Truncated
+	if err := writeReport(out, report, cfg); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if err := closeOut(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return code
+}
+
+// writeReport prints r as JSON for the JSON output formats, otherwise as
+// text tables.
+func writeReport(w io.Writer, r stats.Report, cfg finder.Config) error {
+	if cfg.OutputFormat != finder.OutputText {
+		enc := json.NewEncoder(w)
+		if cfg.PrettyJSON {
+			enc.SetIndent("", "  ")
+		}
+		return enc.Encode(r)
+	}
+	if r.Sample > 0 {
+		if _, err := fmt.Fprintf(w, "estimated from %d files and %d dirs in a %.3g%% sample of the directories\n", r.SampledFiles, r.SampledDirs, 100*r.Sample); err != nil {
+			return err
+		}
+	}
+	if _, err := fmt.Fprintf(w, "%d files, %d dirs, %s\n", r.Files, r.Dirs, stats.FormatBytes(r.Bytes)); err != nil {
+		return err
+	}
+	if r.Lines > 0 {
+		if _, err := fmt.Fprintf(w, "%d lines (%d blank)\n", r.Lines, r.BlankLines); err != nil {
+			return err
+		}
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	buckets := func(title string, bs []stats.Bucket) {
+		fmt.Fprintf(tw, "\n%s\tFILES\tBYTES\n", title)
+		for _, b := range bs {
+			fmt.Fprintf(tw, "%s\t%d\t%s\n", b.Label, b.Files, stats.FormatBytes(b.Bytes))
+		}
+	}
+	exts := func(title string, es []stats.ExtStat) {
+		fmt.Fprintf(tw, "\n%s\tFILES\tBYTES\n", title)
+		for _, e := range es {
+			ext := e.Ext
+			if ext == "" {
+				ext = "(none)"
+			}
+			fmt.Fprintf(tw, "%s\t%d\t%s\n", ext, e.Files, stats.FormatBytes(e.Bytes))
+		}
+	}
+	buckets("SIZE", r.Sizes)
+	buckets("AGE", r.Ages)
+	exts("EXT (by count)", r.ExtByCount)
+	exts("EXT (by bytes)", r.ExtByBytes)
+	if len(r.Truncated) > 0 {
+		fmt.Fprintf(tw, "\nTRUNCATED DIR\tOMITTED\n")
+		for _, d := range r.Truncated {
+			fmt.Fprintf(tw, "%s\t%d\n", d.Path, d.Omitted)
+		}
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCLI_AnalyzeJSON(t *testing.T) {
+	bin := buildCLI(t)
+	td := t.TempDir()
+	_ = mk(t, td, "a.go", 10)
+	_ = mk(t, td, "b.go", 20)
+	_ = mk(t, td, "sub/big.bin", 4096)
+
+	out, err := exec.Command(bin, "analyze", "-root", td, "-json").Output()
+	if err != nil {
+		t.Fatalf("analyze: %v", err)
+	}
+	var r struct {
+		Files      int64 `json:"files"`
+		Dirs       int64 `json:"dirs"`
+		Bytes      int64 `json:"bytes"`
+		ExtByCount []struct {
+			Ext   string `json:"ext"`
+			Files int64  `json:"files"`
+		} `json:"extByCount"`
+		ExtByBytes []struct {
+			Ext string `json:"ext"`
+		} `json:"extByBytes"`
+	}
+	if err := json.Unmarshal(out, &r); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out)
+	}
+	if r.Files != 3 || r.Dirs != 1 || r.Bytes != 4126 {
+		t.Fatalf("unexpected totals: %+v", r)
+	}
+	if r.ExtByCount[0].Ext != ".go" || r.ExtByCount[0].Files != 2 || r.ExtByBytes[0].Ext != ".bin" {
+		t.Fatalf("unexpected extension ranking: %s", out)
+	}
+}
+
+func TestCLI_TopFilesAndDirs(t *testing.T) {
+	bin := buildCLI(t)
+	td := t.TempDir()
+	_ = mk(t, td, "small.txt", 10)
+	big := mk(t, td, "a/big.bin", 5000)
+	_ = mk(t, td, "b/mid.bin", 700)
+	_ = mk(t, td, "b/mid2.bin", 600)
+
+	out, err := exec.Command(bin, "top", "-root", td, "-files", "1", "-dirs", "1", "-json").Output()
+	if err != nil {
+		t.Fatalf("top: %v", err)
+	}
+	var r struct {
+		Files []cliEntry `json:"files"`
+		Dirs  []struct {
+			Path  string `json:"path"`
+			Bytes int64  `json:"bytes"`
+		} `json:"dirs"`
+	}
+	if err := json.Unmarshal(out, &r); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out)
+	}
+	if len(r.Files) != 1 || r.Files[0].Path != big {
+		t.Fatalf("expected %s as the largest file: %s", big, out)
+	}
+	if len(r.Dirs) != 1 || r.Dirs[0].Path != filepath.Dir(big) || r.Dirs[0].Bytes != 5000 {
+		t.Fatalf("expected %s as the largest directory: %s", filepath.Dir(big), out)
+	}
+}
+
+func TestCLI_AnalyzeMaxPerDir(t *testing.T) {
+	bin := buildCLI(t)
+	td := t.TempDir()
+	for _, name := range []string{"a", "b", "c", "d"} {
+		_ = mk(t, td, "mail/"+name, 1)
+	}
+
+	out, err := exec.Command(bin, "analyze", "-root", td, "-json", "--max-per-dir", "2").Output()
+	if err != nil {
+		t.Fatalf("analyze: %v", err)
+	}
+	var r struct {
+		Files     int64 `json:"files"`
+		Truncated []struct {
+			Path    string `json:"path"`
+			Omitted int64  `json:"omitted"`
+		} `json:"truncated"`
+	}
+	if err := json.Unmarshal(out, &r); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out)
+	}
+	if r.Files != 2 || len(r.Truncated) != 1 || r.Truncated[0].Path != filepath.Join(td, "mail") || r.Truncated[0].Omitted != 2 {
+		t.Fatalf("unexpected report: %s", out)
+	}
+}
+
+func TestCLI_AgeBuckets(t *testing.T) {
+	bin := buildCLI(t)
+	td := t.TempDir()
+	_ = mk(t, td, "new.txt", 1)
+	old := mk(t, td, "old.txt", 2)
+	mtime := time.Now().Add(-40 * 24 * time.Hour)
+	if err := os.Chtimes(old, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := exec.Command(bin, "-root", td, "-ndjson", "--age-buckets", "7d,30d").Output()
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	got := map[string]string{}
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		var e struct {
+			Name  string `json:"name"`
+			Extra struct {
+				AgeBucket string `json:"ageBucket"`
+			} `json:"extra"`
+		}
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			t.Fatalf("invalid JSON: %v\n%s", err, out)
+		}
+		got[e.Name] = e.Extra.AgeBucket
+	}
+	if got["new.txt"] != "<1w" || got["old.txt"] != ">=30d" {
+		t.Fatalf("unexpected buckets: %s", out)
+	}
+
+	out, err = exec.Command(bin, "analyze", "-root", td, "-json", "--age-buckets", "7d,30d").Output()
+	if err != nil {
+		t.Fatalf("analyze: %v", err)
+	}
+	var r struct {
+		Ages []struct {
+			Label string `json:"label"`
+			Files int64  `json:"files"`
+		} `json:"ages"`
+	}
+	if err := json.Unmarshal(out, &r); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out)
+	}
+	if len(r.Ages) != 3 || r.Ages[0].Label != "<1w" || r.Ages[0].Files != 1 || r.Ages[2].Label != ">=30d" || r.Ages[2].Files != 1 {
+		t.Fatalf("unexpected age summary: %s", out)
+	}
+
+	if err := exec.Command(bin, "-root", td, "--age-buckets", "30d,7d").Run(); err == nil {
+		t.Fatal("descending --age-buckets accepted")
+	}
+}
+
+func TestCLI_AnalyzeSample(t *testing.T) {
+	bin := buildCLI(t)
+	td := t.TempDir()
+	for i := range 40 {
+		_ = mk(t, td, fmt.Sprintf("d%02d/f.txt", i), 10)
+	}
+	cache := t.TempDir()
+	analyze := func(args ...string) ([]byte, error) {
+		cmd := exec.Command(bin, append([]string{"analyze", "-root", td, "-json"}, args...)...)
+		cmd.Env = append(os.Environ(), "XDG_CACHE_HOME="+cache, "HOME="+cache)
+		return cmd.Output()
+	}
+	var r struct {
+		Files        int64   `json:"files"`
+		Sample       float64 `json:"sample"`
+		SampledFiles int64   `json:"sampledFiles"`
+	}
+
+	if _, err := analyze("--sample-dirs", "10"); err == nil {
+		t.Fatal("--sample-dirs accepted without a recorded directory count")
+	}
+	out, err := analyze("--sample", "50%")
+	if err != nil {
+		t.Fatalf("analyze --sample: %v", err)
+	}
+	if err := json.Unmarshal(out, &r); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out)
+	}
+	if r.Sample != 0.5 || r.Files != 2*r.SampledFiles {
+		t.Fatalf("unexpected estimate: %s", out)
+	}
+
+	// The sampled search recorded the 41 directories.
+	if out, err = analyze("--sample-dirs", "10"); err != nil {
+		t.Fatalf("analyze --sample-dirs: %v", err)
+	}
+	if err := json.Unmarshal(out, &r); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out)
+	}
+	if want := 10.0 / 41; r.Sample != want {
+		t.Fatalf("sample %g, want %g", r.Sample, want)
+	}
+}
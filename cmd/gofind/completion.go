@@ -0,0 +1,216 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+func init() {
+	// Registered here rather than in the map literal: completion lists the
+	// subcommands, which would otherwise be an initialization cycle.
+	subcommands["completion"] = runCompletion
+}
+
+// completionFlag describes one search flag for the completion scripts.
+type completionFlag struct {
+	name   string
+	usage  string
+	isBool bool
+	values []string
+}
+
+// The scripts list saved queries after "gofind run" and named roots for
+// @NAME arguments by running these commands, so the names are those of the
+// config file when completing rather than when the script was made. Both
+// print a name and a tab before anything else on each line.
+const (
+	queriesCommand = "gofind run --list 2>/dev/null"
+	rootsCommand   = "gofind root list 2>/dev/null"
+)
+
+// pathFlags take file or directory arguments.
+var pathFlags = map[string]bool{
+	"root": true, "out": true, "move-to": true, "why": true,
+	"files-from": true, "roots-from": true,
+}
+
+// runCompletion prints a completion script for the requested shell.
+func runCompletion(args []string) int {
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, "usage: gofind completion bash|zsh|fish|powershell")
+		return 2
+	}
+	flags, cmds := completionData()
+	var err error
+	switch args[0] {
+	case "bash":
+		err = writeBashCompletion(os.Stdout, flags, cmds)
+	case "zsh":
+		err = writeZshCompletion(os.Stdout, flags, cmds)
+	case "fish":
+		err = writeFishCompletion(os.Stdout, flags, cmds)
+	case "powershell", "pwsh":
+		err = writePowerShellCompletion(os.Stdout, flags, cmds)
+	default:
+		fmt.Fprintf(os.Stderr, "unsupported shell %q (want bash, zsh, fish or powershell)\n", args[0])
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+// completionData collects the search flags (sorted, single-letter aliases
+// skipped) and subcommand names.
+func completionData() ([]completionFlag, []string) {
+	fs := flag.NewFlagSet("gofind", flag.ContinueOnError)
+	defineSearchFlags(fs)
+	var flags []completionFlag
+	fs.VisitAll(func(f *flag.Flag) {
+		if len(f.Name) == 1 {
+			return
+		}
+		bf, ok := f.Value.(interface{ IsBoolFlag() bool })
+		flags = append(flags, completionFlag{
+			name:   f.Name,
+			usage:  f.Usage,
+			isBool: ok && bf.IsBoolFlag(),
+			values: flagValues[f.Name],
+		})
+	})
+	cmds := make([]string, 0, len(subcommands))
+	for name := range subcommands {
+		cmds = append(cmds, name)
+	}
+	sort.Strings(cmds)
+	return flags, cmds
+}
+
+func writeBashCompletion(w io.Writer, flags []completionFlag, cmds []string) error {
+	var b strings.Builder
+	names := make([]string, 0, len(flags))
+	b.WriteString("# bash completion for gofind\n_gofind() {\n")
+	b.WriteString("    local cur=\"${COMP_WORDS[COMP_CWORD]}\" prev=\"${COMP_WORDS[COMP_CWORD-1]}\"\n")
+	b.WriteString("    case \"$prev\" in\n")
+	for _, f := range flags {
+		names = append(names, "--"+f.name)
+		if len(f.values) > 0 {
+			fmt.Fprintf(&b, "        --%s|-%s) COMPREPLY=($(compgen -W %q -- \"$cur\")); return ;;\n",
+				f.name, f.name, strings.Join(f.values, " "))
+		}
+	}
+	b.WriteString("    esac\n")
+	fmt.Fprintf(&b, "    if [[ $COMP_CWORD -eq 1 && \"$cur\" != -* ]]; then\n        COMPREPLY=($(compgen -W %q -- \"$cur\")); return\n    fi\n",
+		strings.Join(cmds, " "))
+	fmt.Fprintf(&b, "    if [[ $COMP_CWORD -eq 2 && \"${COMP_WORDS[1]}\" == run && \"$cur\" != -* ]]; then\n        COMPREPLY=($(compgen -W \"$(%s | cut -f1)\" -- \"$cur\")); return\n    fi\n",
+		queriesCommand)
+	fmt.Fprintf(&b, "    if [[ \"$cur\" == @* ]]; then\n        COMPREPLY=($(compgen -W \"$(%s | cut -f1)\" -- \"$cur\")); return\n    fi\n",
+		rootsCommand)
+	fmt.Fprintf(&b, "    if [[ \"$cur\" == -* ]]; then\n        COMPREPLY=($(compgen -W %q -- \"$cur\")); return\n    fi\n",
+		strings.Join(names, " "))
+	b.WriteString("    COMPREPLY=($(compgen -f -- \"$cur\"))\n}\ncomplete -o default -F _gofind gofind\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeZshCompletion(w io.Writer, flags []completionFlag, cmds []string) error {
+	esc := strings.NewReplacer(`'`, `'\''`, "[", `\[`, "]", `\]`)
+	var b strings.Builder
+	b.WriteString("#compdef gofind\n\n")
+	fmt.Fprintf(&b, "_gofind_roots() {\n    compadd -- ${(f)\"$(%s | cut -f1)\"}\n    _files\n}\n\n", rootsCommand)
+	b.WriteString("_gofind() {\n")
+	fmt.Fprintf(&b, "    if [[ $words[2] == run && CURRENT -eq 3 && $PREFIX != -* ]]; then\n        compadd -- ${(f)\"$(%s | cut -f1)\"}\n        return\n    fi\n", queriesCommand)
+	b.WriteString("    _arguments -s \\\n")
+	fmt.Fprintf(&b, "        '1:: :(%s)' \\\n", strings.Join(cmds, " "))
+	for i, f := range flags {
+		spec := fmt.Sprintf("'--%s[%s]", f.name, esc.Replace(f.usage))
+		switch {
+		case len(f.values) > 0:
+			spec += fmt.Sprintf(":%s:(%s)'", f.name, strings.Join(f.values, " "))
+		case f.name == "root":
+			spec += ":root:_gofind_roots'"
+		case pathFlags[f.name]:
+			spec += fmt.Sprintf(":%s:_files'", f.name)
+		case !f.isBool:
+			spec += fmt.Sprintf(":%s: '", f.name)
+		default:
+			spec += "'"
+		}
+		if i < len(flags)-1 {
+			spec += " \\"
+		}
+		b.WriteString("        " + spec + "\n")
+	}
+	b.WriteString("}\n\n_gofind \"$@\"\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeFishCompletion(w io.Writer, flags []completionFlag, cmds []string) error {
+	esc := strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+	var b strings.Builder
+	b.WriteString("# fish completion for gofind\n")
+	fmt.Fprintf(&b, "complete -c gofind -n '__fish_use_subcommand' -a '%s'\n", strings.Join(cmds, " "))
+	// gofind prints NAME<TAB>description lines, as fish reads them.
+	fmt.Fprintf(&b, "complete -c gofind -n '__fish_seen_subcommand_from run; and test (count (commandline -opc)) -eq 2' -f -a '(%s)'\n", queriesCommand)
+	for _, f := range flags {
+		line := fmt.Sprintf("complete -c gofind -l %s -o %s -d '%s'", f.name, f.name, esc.Replace(f.usage))
+		switch {
+		case len(f.values) > 0:
+			line += fmt.Sprintf(" -x -a '%s'", strings.Join(f.values, " "))
+		case f.name == "root":
+			line += fmt.Sprintf(" -r -a '(%s)'", rootsCommand)
+		case !f.isBool:
+			line += " -r"
+		}
+		b.WriteString(line + "\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writePowerShellCompletion(w io.Writer, flags []completionFlag, cmds []string) error {
+	quote := func(ss []string) string {
+		q := make([]string, len(ss))
+		for i, s := range ss {
+			q[i] = "'" + strings.ReplaceAll(s, "'", "''") + "'"
+		}
+		return "@(" + strings.Join(q, ", ") + ")"
+	}
+	var b strings.Builder
+	names := make([]string, 0, len(flags))
+	b.WriteString("# PowerShell completion for gofind\nRegister-ArgumentCompleter -Native -CommandName gofind -ScriptBlock {\n")
+	b.WriteString("    param($wordToComplete, $commandAst, $cursorPosition)\n")
+	b.WriteString("    $values = @{\n")
+	for _, f := range flags {
+		names = append(names, "--"+f.name)
+		if len(f.values) > 0 {
+			fmt.Fprintf(&b, "        '--%s' = %s\n", f.name, quote(f.values))
+		}
+	}
+	b.WriteString("    }\n")
+	fmt.Fprintf(&b, "    $subcommands = %s\n", quote(cmds))
+	fmt.Fprintf(&b, "    $flags = %s\n", quote(names))
+	b.WriteString(`    $words = @($commandAst.CommandElements | ForEach-Object { $_.ToString() })
+    $prev = if ($wordToComplete -ne '') { $words[-2] } else { $words[-1] }
+    if ($values.ContainsKey($prev)) { $candidates = $values[$prev] }
+    elseif ($wordToComplete.StartsWith('@')) { $candidates = @(gofind root list 2>$null | ForEach-Object { ($_ -split "` + "`" + `t")[0] }) }
+    elseif ($words[1] -eq 'run' -and $prev -eq 'run' -and -not $wordToComplete.StartsWith('-')) {
+        $candidates = @(gofind run --list 2>$null | ForEach-Object { ($_ -split "` + "`" + `t")[0] })
+    }
+    elseif ($words.Count -le 2 -and -not $wordToComplete.StartsWith('-')) { $candidates = $subcommands }
+    else { $candidates = $flags }
+    $candidates | Where-Object { $_ -like "$wordToComplete*" } | ForEach-Object {
+        [System.Management.Automation.CompletionResult]::new($_, $_, 'ParameterValue', $_)
+    }
+}
+`)
+	_, err := io.WriteString(w, b.String())
+	return err
+}
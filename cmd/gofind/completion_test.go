@@ -0,0 +1,107 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+func TestCLI_Completion(t *testing.T) {
+	bin := buildCLI(t)
+	for _, shell := range []string{"bash", "zsh", "fish", "powershell"} {
+		out, err := exec.Command(bin, "completion", shell).Output()
+		if err != nil {
+			t.Fatalf("completion %s: %v", shell, err)
+		}
+		s := string(out)
+		for _, want := range []string{"backend", "spotlight", "locate", "max-depth"} {
+			if !strings.Contains(s, want) {
+				t.Fatalf("%s script missing %q", shell, want)
+			}
+		}
+		if shell == "bash" {
+			if _, err := exec.LookPath("bash"); err != nil {
+				continue
+			}
+			script := filepath.Join(t.TempDir(), "gofind.bash")
+			if err := os.WriteFile(script, out, 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if out, err := exec.Command("bash", "-n", script).CombinedOutput(); err != nil {
+				t.Fatalf("bash script has syntax errors: %v\n%s", err, out)
+			}
+			testBashDynamicCompletion(t, bin, script)
+		}
+	}
+	if err := exec.Command(bin, "completion", "tcsh").Run(); err == nil {
+		t.Fatalf("unsupported shell should fail")
+	}
+}
+
+// testBashDynamicCompletion checks that the bash script completes the saved
+// queries and named roots of the config file it finds when completing.
+func testBashDynamicCompletion(t *testing.T, bin, script string) {
+	t.Helper()
+	// The script runs gofind from PATH.
+	binDir := t.TempDir()
+	if err := os.Symlink(bin, filepath.Join(binDir, "gofind")); err != nil {
+		t.Skip("no symlinks:", err)
+	}
+	cfgHome := t.TempDir()
+	conf := "roots: {proj: [/src], photos: [/pics]}\nqueries: {big-logs: {ext: .log}, recent: {after: 7d}}\n"
+	if err := os.MkdirAll(filepath.Join(cfgHome, "gofind"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(cfgHome, "gofind", "config.yaml"), []byte(conf), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	complete := func(words ...string) string {
+		cmd := exec.Command("bash", "-c", `source "$0"; COMP_WORDS=("$@"); COMP_CWORD=$(($# - 1)); _gofind; echo "${COMPREPLY[*]}"`, script)
+		cmd.Args = append(cmd.Args, words...)
+		cmd.Env = append(os.Environ(), "PATH="+binDir+string(os.PathListSeparator)+os.Getenv("PATH"), "XDG_CONFIG_HOME="+cfgHome)
+		out, err := cmd.Output()
+		if err != nil {
+			t.Fatalf("completing %q: %v", words, err)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	if got := complete("gofind", "run", ""); got != "big-logs recent" {
+		t.Errorf("gofind run: got %q, want the saved queries", got)
+	}
+	if got := complete("gofind", "--root", "@p"); got != "@photos @proj" {
+		t.Errorf("--root @p: got %q, want the named roots", got)
+	}
+}
+
+func TestFlagValues(t *testing.T) {
+	fs := flag.NewFlagSet("gofind", flag.ContinueOnError)
+	defineSearchFlags(fs)
+	for name, values := range flagValues {
+		f := fs.Lookup(name)
+		if f == nil {
+			t.Errorf("flagValues lists values of %q, which is not a search flag", name)
+			continue
+		}
+		if f.DefValue != "" && !slices.Contains(values, f.DefValue) {
+			t.Errorf("--%s: default %q not among %v", name, f.DefValue, values)
+		}
+	}
+	// Each enumerated flag is in the table; --encoding takes any name the
+	// encoding index knows, but the table lists common ones for completion.
+	for _, name := range []string{"backend", "output", "quote", "color", "hash-format", "encoding"} {
+		if len(flagValues[name]) == 0 {
+			t.Errorf("flagValues has no values for --%s", name)
+		}
+	}
+	for _, name := range flagValues["encoding"][1:] {
+		if _, err := htmlindex.Get(name); err != nil {
+			t.Errorf("--encoding %s: %v", name, err)
+		}
+	}
+}
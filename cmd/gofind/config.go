@@ -0,0 +1,275 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// userConfig is the config file:
+//
+//	defaults: {concurrency: 4, ignore: [node_modules/]}
+//	roots:
+//	  projects: [/home/me/src, /home/me/work]
+//	queries:
+//	  big-logs: {ext: [.log], larger: 100MB}
+//	  recent-go:
+//	    root: ~/src
+//	    ext: .go
+//	    after: 7d
+type userConfig struct {
+	// Defaults set the search flags neither the command line nor the
+	// environment gives; see resolveFlags.
+	Defaults savedQuery `yaml:"defaults"`
+	// Roots are named sets of search roots, given as --root @NAME and
+	// managed with `gofind root`.
+	Roots map[string][]string `yaml:"roots"`
+	// Queries are saved searches, run with `gofind run NAME`.
+	Queries map[string]savedQuery `yaml:"queries"`
+}
+
+// savedQuery maps search flag names, without dashes, to their values: a
+// string, number or boolean, or a list, which sets a repeatable flag once per
+// element and others to the elements joined by commas.
+type savedQuery map[string]any
+
+// queryAliases are names saved queries may use for search flags.
+var queryAliases = map[string]string{
+	"larger":  "min-size",
+	"smaller": "max-size",
+}
+
+// configPath returns the config file, $XDG_CONFIG_HOME/gofind/config.yaml or
+// ~/.config/gofind/config.yaml, or "" when no home directory is known.
+func configPath() string {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "gofind", "config.yaml")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".config", "gofind", "config.yaml")
+}
+
+// loadUserConfig reads the config file; a missing one is empty.
+func loadUserConfig() (*userConfig, error) {
+	var c userConfig
+	path := configPath()
+	if path == "" {
+		return &c, nil
+	}
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &c, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(b, &c); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return &c, nil
+}
+
+// saveRoots writes roots to the config file as its roots key, keeping the
+// rest of the file, comments included, as it is.
+func saveRoots(roots map[string][]string) error {
+	path := configPath()
+	if path == "" {
+		return errors.New("no config file: the home directory is unknown")
+	}
+	var doc yaml.Node
+	b, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	if doc.Kind == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+	top := doc.Content[0]
+	if top.Kind != yaml.MappingNode {
+		return fmt.Errorf("%s: not a mapping", path)
+	}
+	i := -1
+	for j := 0; j+1 < len(top.Content); j += 2 {
+		if top.Content[j].Value == "roots" {
+			i = j
+			break
+		}
+	}
+	var value yaml.Node
+	if err := value.Encode(roots); err != nil {
+		return err
+	}
+	switch {
+	case len(roots) == 0 && i >= 0:
+		top.Content = slices.Delete(top.Content, i, i+2)
+	case len(roots) == 0:
+	case i >= 0:
+		top.Content[i+1] = &value
+	default:
+		top.Content = append([]*yaml.Node{{Kind: yaml.ScalarNode, Value: "roots"}, &value}, top.Content...)
+	}
+	out, err := yaml.Marshal(&doc)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, out, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// resolveRoot returns the directories of root: those of the root alias
+// @NAME in the config file, or root itself.
+func resolveRoot(root string) ([]string, error) {
+	name, ok := strings.CutPrefix(root, "@")
+	if !ok {
+		return []string{root}, nil
+	}
+	uc, err := loadUserConfig()
+	if err != nil {
+		return nil, err
+	}
+	dirs := uc.Roots[name]
+	if len(dirs) == 0 {
+		return nil, fmt.Errorf("unknown root @%s (see gofind root list)", name)
+	}
+	out := make([]string, len(dirs))
+	for i, d := range dirs {
+		out[i] = expandHome(d)
+	}
+	return out, nil
+}
+
+// resolveSingleRoot is resolveRoot for commands that search one root.
+func resolveSingleRoot(root string) (string, error) {
+	dirs, err := resolveRoot(root)
+	if err != nil {
+		return "", err
+	}
+	if len(dirs) > 1 {
+		return "", fmt.Errorf("%s names %d directories; this command takes one", root, len(dirs))
+	}
+	return dirs[0], nil
+}
+
+// rootAliasArgs turns a leading @NAME argument into --root @NAME, so
+// `gofind @projects --ext .go` searches the roots named projects.
+func rootAliasArgs(args []string) []string {
+	if len(args) == 0 || len(args[0]) < 2 || args[0][0] != '@' {
+		return args
+	}
+	return append([]string{"--root", args[0]}, args[1:]...)
+}
+
+// args returns the search flags of q, in the order of their names.
+func (q savedQuery) args() ([]string, error) {
+	flags := flag.NewFlagSet("", flag.ContinueOnError)
+	defineSearchFlags(flags)
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	var args []string
+	for _, k := range keys {
+		name := strings.TrimLeft(k, "-")
+		if alias, ok := queryAliases[name]; ok {
+			name = alias
+		}
+		f := flags.Lookup(name)
+		if f == nil {
+			return nil, fmt.Errorf("unknown search flag %q", k)
+		}
+		if unlayered[name] {
+			// Deleting or moving files takes flags given by hand.
+			return nil, fmt.Errorf("--%s is not set from the config file", name)
+		}
+		values, err := queryValues(q[k])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", k, err)
+		}
+		if _, repeatable := f.Value.(*stringList); len(values) > 1 && !repeatable {
+			values = []string{strings.Join(values, ",")}
+		}
+		for _, v := range values {
+			args = append(args, "--"+name+"="+v)
+		}
+	}
+	return args, nil
+}
+
+// queryValues converts a value of a savedQuery to flag values.
+func queryValues(v any) ([]string, error) {
+	list, ok := v.([]any)
+	if !ok {
+		list = []any{v}
+	}
+	values := make([]string, len(list))
+	for i, x := range list {
+		switch x := x.(type) {
+		case string:
+			values[i] = expandHome(x)
+		case bool:
+			values[i] = strconv.FormatBool(x)
+		case int:
+			values[i] = strconv.Itoa(x)
+		case float64:
+			values[i] = strconv.FormatFloat(x, 'f', -1, 64)
+		case nil:
+			return nil, errors.New("no value")
+		default:
+			return nil, fmt.Errorf("unsupported value %v", x)
+		}
+	}
+	return values, nil
+}
+
+// expandHome replaces a leading ~ with the home directory, as a shell would
+// on the command line.
+func expandHome(s string) string {
+	if s != "~" && !strings.HasPrefix(s, "~/") && !strings.HasPrefix(s, `~\`) {
+		return s
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return s
+	}
+	return home + s[1:]
+}
+
+// checkQuery reports whether q is a valid search, as the daemon checks its
+// jobs.
+func checkQuery(q savedQuery) error {
+	args, err := q.args()
+	if err != nil {
+		return err
+	}
+	flags := flag.NewFlagSet("", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	sf := defineSearchFlags(flags)
+	sf.actions = true
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	_, err = sf.config()
+	return err
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSavedQueryArgs(t *testing.T) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skip("no home directory")
+	}
+	q := savedQuery{
+		"ext":              []any{".log", ".gz"},
+		"larger":           "100MB",
+		"root":             "~/logs",
+		"where":            []any{"size > 1MB", "name != 'x'"},
+		"max-depth":        2,
+		"--include-hidden": true,
+	}
+	got, err := q.args()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"--include-hidden=true", "--ext=.log,.gz", "--min-size=100MB", "--max-depth=2",
+		"--root=" + filepath.Join(home, "logs"), "--where=size > 1MB", "--where=name != 'x'",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("args = %q, want %q", got, want)
+	}
+
+	if _, err := (savedQuery{"bogus": 1}).args(); err == nil || !strings.Contains(err.Error(), "unknown search flag") {
+		t.Fatalf("unknown flag: err = %v", err)
+	}
+	for _, q := range []savedQuery{{"delete": true}, {"move-to": "/tmp/x"}, {"i-know-what-im-doing": true}} {
+		if _, err := q.args(); err == nil || !strings.Contains(err.Error(), "not set from the config file") {
+			t.Fatalf("%v: err = %v", q, err)
+		}
+	}
+	if err := checkQuery(savedQuery{"min-size": "huge"}); err == nil {
+		t.Fatal("invalid --min-size accepted")
+	}
+}
+
+func TestCLI_Run(t *testing.T) {
+	bin := buildCLI(t)
+	td := t.TempDir()
+	_ = mk(t, td, "a.log", 300)
+	_ = mk(t, td, "b.log", 10)
+	_ = mk(t, td, "c.txt", 300)
+	cfgDir := t.TempDir()
+	conf := "queries:\n  big-logs: {ext: [.log], larger: 100B, root: " + td + "}\n  broken: {bogus: 1}\n" +
+		"  nuke: {root: " + td + ", ext: .log, delete: true, i-know-what-im-doing: true}\n"
+	if err := os.MkdirAll(filepath.Join(cfgDir, "gofind"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(cfgDir, "gofind", "config.yaml"), []byte(conf), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	run := func(args ...string) (string, error) {
+		cmd := exec.Command(bin, append([]string{"run"}, args...)...)
+		cmd.Env = append(os.Environ(), "XDG_CONFIG_HOME="+cfgDir)
+		out, err := cmd.Output()
+		return string(out), err
+	}
+
+	out, err := run("big-logs")
+	if err != nil || out != filepath.Join(td, "a.log")+"\n" {
+		t.Fatalf("run big-logs: %q, %v", out, err)
+	}
+	// Later flags override the saved ones.
+	if out, err = run("big-logs", "--min-size", "1B"); err != nil || strings.Count(out, "\n") != 2 {
+		t.Fatalf("run big-logs --min-size 1B: %q, %v", out, err)
+	}
+	if out, err = run("--list"); err != nil || !strings.Contains(out, "big-logs\t--ext=.log --min-size=100B") || !strings.Contains(out, "broken\t(invalid") {
+		t.Fatalf("--list: %q, %v", out, err)
+	}
+	if _, err = run("--check", "big-logs"); err != nil {
+		t.Fatalf("--check big-logs: %v", err)
+	}
+	if _, err = run("--check"); err == nil {
+		t.Fatal("--check passed with an invalid query")
+	}
+	// Deleting takes flags given by hand.
+	if _, err = run("nuke"); err == nil {
+		t.Fatal("a saved query deleted files")
+	}
+	if _, err := os.Stat(filepath.Join(td, "a.log")); err != nil {
+		t.Fatalf("refused query deleted a file: %v", err)
+	}
+	if _, err = run("--check", "nuke"); err == nil {
+		t.Fatal("--check accepted a query with --delete")
+	}
+	if _, err = run("missing"); err == nil {
+		t.Fatal("unknown query ran")
+	}
+}
+
+func TestSaveRoots(t *testing.T) {
+	cfgDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", cfgDir)
+	path := filepath.Join(cfgDir, "gofind", "config.yaml")
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("# mine\nqueries:\n  logs: {ext: .log}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := saveRoots(map[string][]string{"p": {"/a", "/b"}}); err != nil {
+		t.Fatal(err)
+	}
+	uc, err := loadUserConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(uc.Roots["p"], []string{"/a", "/b"}) || uc.Queries["logs"] == nil {
+		t.Fatalf("config = %+v", uc)
+	}
+	if dirs, err := resolveRoot("@p"); err != nil || len(dirs) != 2 {
+		t.Fatalf("resolveRoot(@p) = %q, %v", dirs, err)
+	}
+	if _, err := resolveSingleRoot("@p"); err == nil {
+		t.Fatal("resolveSingleRoot accepted two directories")
+	}
+	if _, err := resolveRoot("@q"); err == nil {
+		t.Fatal("unknown root accepted")
+	}
+
+	if err := saveRoots(nil); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(b); !strings.Contains(got, "# mine") || strings.Contains(got, "roots") {
+		t.Fatalf("after removing the roots: %q", got)
+	}
+}
+
+func TestCLI_Root(t *testing.T) {
+	bin := buildCLI(t)
+	a, b := t.TempDir(), t.TempDir()
+	_ = mk(t, a, "x.go", 10)
+	_ = mk(t, b, "y.go", 10)
+	_ = mk(t, b, "z.txt", 10)
+	cfgDir := t.TempDir()
+	gofind := func(args ...string) (string, error) {
+		cmd := exec.Command(bin, args...)
+		cmd.Env = append(os.Environ(), "XDG_CONFIG_HOME="+cfgDir)
+		out, err := cmd.Output()
+		return string(out), err
+	}
+
+	if out, err := gofind("root", "add", "proj", a, b); err != nil {
+		t.Fatalf("root add: %q, %v", out, err)
+	}
+	if out, err := gofind("root"); err != nil || out != "@proj\t"+a+" "+b+"\n" {
+		t.Fatalf("root list: %q, %v", out, err)
+	}
+	out, err := gofind("@proj", "--ext", ".go")
+	if err != nil || out != filepath.Join(a, "x.go")+"\n"+filepath.Join(b, "y.go")+"\n" {
+		t.Fatalf("@proj --ext .go: %q, %v", out, err)
+	}
+	if out, err = gofind("sql", "@proj", "SELECT name FROM files WHERE ext = '.txt'"); err != nil || out != "name\nz.txt\n" {
+		t.Fatalf("sql @proj: %q, %v", out, err)
+	}
+	if _, err = gofind("updatedb", "@proj"); err == nil {
+		t.Fatal("updatedb accepted a root of two directories")
+	}
+
+	if _, err := gofind("root", "rm", "proj", a); err != nil {
+		t.Fatalf("root rm proj DIR: %v", err)
+	}
+	if out, err = gofind("--root", "@proj"); err != nil || strings.Count(out, "\n") != 2 {
+		t.Fatalf("--root @proj after rm: %q, %v", out, err)
+	}
+	if _, err := gofind("root", "rm", "proj"); err != nil {
+		t.Fatalf("root rm proj: %v", err)
+	}
+	if _, err = gofind("@proj"); err == nil {
+		t.Fatal("removed root accepted")
+	}
+}
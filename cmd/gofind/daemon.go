@@ -0,0 +1,334 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/Hamed0406/gofind/internal/cron"
+	"github.com/Hamed0406/gofind/internal/finder"
+)
+
+func init() {
+	subcommands["daemon"] = runDaemon
+}
+
+// daemonJobs is the --job file: searches to run on a schedule.
+//
+//	jobs:
+//	  - name: big-logs
+//	    schedule: "0 2 * * *"   # optional; defaults to --schedule
+//	    args: [--root, /var/log, --ext, .log, --min-size, 100MB, --ndjson]
+//	    sinks:
+//	      - file: /var/lib/gofind/big-logs-{time}.ndjson
+//	      - http: https://audit.example.com/ingest
+//	        headers: {Authorization: "Bearer ..."}
+//	      - s3: https://bucket.s3.amazonaws.com/big-logs.ndjson?X-Amz-Signature=...
+type daemonJobs struct {
+	Jobs []daemonJob `yaml:"jobs"`
+}
+
+type daemonJob struct {
+	Name     string    `yaml:"name"`
+	Schedule string    `yaml:"schedule"`
+	Args     []string  `yaml:"args"`
+	Sinks    []jobSink `yaml:"sinks"`
+
+	sched *cron.Schedule
+	next  time.Time
+}
+
+// jobSink receives a job's output: a file ({name} and {time} in the path are
+// replaced), an HTTP POST, or an HTTP PUT to a presigned S3 URL.
+type jobSink struct {
+	File    string            `yaml:"file"`
+	HTTP    string            `yaml:"http"`
+	S3      string            `yaml:"s3"`
+	Headers map[string]string `yaml:"headers"`
+}
+
+// runDaemon runs the searches of a job file on cron schedules until
+// interrupted, or once with --once.
+func runDaemon(args []string) int {
+	fs := flag.NewFlagSet("daemon", flag.ContinueOnError)
+	jobFile := fs.String("job", "", "YAML file listing the searches to run and where to send their results")
+	schedule := fs.String("schedule", "", "cron schedule (minute hour day month weekday, e.g. \"0 2 * * *\") for jobs without their own")
+	once := fs.Bool("once", false, "run every job once now and exit")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	jobs, err := loadJobs(*jobFile, *schedule, *once)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "daemon: %v\n", err)
+		return 2
+	}
+
+	ctx, cancel := signalContext(0)
+	defer cancel()
+	if *once {
+		code := 0
+		for i := range jobs {
+			if err := runJob(ctx, &jobs[i], time.Now()); err != nil {
+				code = 1
+			}
+		}
+		return code
+	}
+
+	now := time.Now()
+	for i := range jobs {
+		jobs[i].next = jobs[i].sched.Next(now)
+	}
+	for {
+		var due time.Time
+		for _, j := range jobs {
+			if !j.next.IsZero() && (due.IsZero() || j.next.Before(due)) {
+				due = j.next
+			}
+		}
+		if due.IsZero() {
+			fmt.Fprintln(os.Stderr, "daemon: no job will run again")
+			return 0
+		}
+		timer := time.NewTimer(time.Until(due))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return 0
+		case <-timer.C:
+		}
+		for i := range jobs {
+			if j := &jobs[i]; !j.next.After(due) {
+				_ = runJob(ctx, j, due) // reported by runJob
+				j.next = j.sched.Next(time.Now())
+			}
+		}
+	}
+}
+
+// loadJobs reads and checks the job file.
+func loadJobs(path, defaultSchedule string, once bool) ([]daemonJob, error) {
+	if path == "" {
+		return nil, errors.New("--job is required")
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var jf daemonJobs
+	if err := yaml.Unmarshal(b, &jf); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	if len(jf.Jobs) == 0 {
+		return nil, fmt.Errorf("%s: no jobs", path)
+	}
+	for i := range jf.Jobs {
+		j := &jf.Jobs[i]
+		if j.Name == "" {
+			j.Name = fmt.Sprintf("job%d", i+1)
+		}
+		spec := j.Schedule
+		if spec == "" {
+			spec = defaultSchedule
+		}
+		switch {
+		case spec != "":
+			if j.sched, err = cron.Parse(spec); err != nil {
+				return nil, fmt.Errorf("job %s: %v", j.Name, err)
+			}
+		case !once:
+			return nil, fmt.Errorf("job %s: no schedule (set schedule or --schedule)", j.Name)
+		}
+		if len(j.Sinks) == 0 {
+			return nil, fmt.Errorf("job %s: no sinks", j.Name)
+		}
+		for _, s := range j.Sinks {
+			n := 0
+			for _, v := range []string{s.File, s.HTTP, s.S3} {
+				if v != "" {
+					n++
+				}
+			}
+			if n != 1 {
+				return nil, fmt.Errorf("job %s: each sink needs exactly one of file, http or s3", j.Name)
+			}
+		}
+		// Check the search flags now rather than at 2 a.m.
+		if _, _, err := j.config(); err != nil {
+			return nil, fmt.Errorf("job %s: %v", j.Name, err)
+		}
+	}
+	return jf.Jobs, nil
+}
+
+// config parses the job's search flags.
+func (j *daemonJob) config() (finder.Config, *searchFlags, error) {
+	fs := flag.NewFlagSet(j.Name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	sf := defineSearchFlags(fs)
+	sf.selfContained = true
+	if err := fs.Parse(j.Args); err != nil {
+		return finder.Config{}, nil, err
+	}
+	if fs.NArg() > 0 {
+		return finder.Config{}, nil, fmt.Errorf("unexpected argument %q", fs.Arg(0))
+	}
+	if *sf.outPath != "" {
+		return finder.Config{}, nil, errors.New("--out is not used in jobs; list sinks instead")
+	}
+	if *sf.cpuProfile != "" || *sf.memProfile != "" || *sf.traceOut != "" {
+		return finder.Config{}, nil, errors.New("--cpuprofile, --memprofile and --trace profile one run and are not used in jobs")
+	}
+	if *sf.progress || *sf.dirCache != "" {
+		return finder.Config{}, nil, errors.New("--progress and --dir-cache are not used in jobs")
+	}
+	if *sf.deleteMatches || *sf.moveTo != "" || *sf.toTrash || *sf.planOnly || *sf.auditLog != "" || *sf.iKnow {
+		return finder.Config{}, nil, errors.New("--delete, --move-to, --trash, --plan, --audit and --i-know-what-im-doing act on the matches of a search and are not used in jobs")
+	}
+	if *sf.why != "" || *sf.checkpoint != "" || *sf.resume != "" || *sf.report != "" {
+		return finder.Config{}, nil, errors.New("--why, --checkpoint, --resume and --report are not used in jobs")
+	}
+	cfg, err := sf.config()
+	return cfg, sf, err
+}
+
+// runJob runs one search into a temporary file and delivers it to every
+// sink, reporting the outcome on stderr.
+func runJob(ctx context.Context, j *daemonJob, at time.Time) error {
+	err := j.run(ctx, at)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "daemon: job %s: %v\n", j.Name, err)
+	}
+	return err
+}
+
+func (j *daemonJob) run(ctx context.Context, at time.Time) error {
+	cfg, sf, err := j.config()
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp("", "gofind-"+j.Name+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	var out io.Writer = tmp
+	var cw io.WriteCloser
+	if newCompressor, _ := compressor(*sf.compress); newCompressor != nil {
+		if cw, err = newCompressor(tmp); err != nil {
+			return err
+		}
+		out = cw
+	}
+	closeHook, err := sf.addWebhook(&cfg)
+	if err != nil {
+		return err
+	}
+	closePub, err := sf.addPublisher(&cfg)
+	if err != nil {
+		return err
+	}
+	res, err := finder.Run(ctx, out, cfg)
+	err = errors.Join(err, closeHook(), closePub())
+	if cw != nil {
+		err = errors.Join(err, cw.Close())
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "daemon: job %s: %d entries in %s\n", j.Name, res.Matched, res.Duration.Round(time.Millisecond))
+
+	var errs []error
+	for _, s := range j.Sinks {
+		if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		if err := s.deliver(ctx, tmp, j.Name, at, contentType(cfg.OutputFormat, *sf.compress)); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// deliver copies r to the sink.
+func (s jobSink) deliver(ctx context.Context, r io.Reader, name string, at time.Time, ctype string) error {
+	if s.File != "" {
+		path := strings.NewReplacer("{name}", name, "{time}", at.Format("20060102T150405")).Replace(s.File)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return err
+		}
+		f, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(f, r); err != nil {
+			f.Close()
+			return err
+		}
+		return f.Close()
+	}
+	method, url := http.MethodPost, s.HTTP
+	if s.S3 != "" {
+		method, url = http.MethodPut, s.S3
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, r)
+	if err != nil {
+		return err
+	}
+	if f, ok := r.(*os.File); ok {
+		// S3 needs the length up front rather than a chunked body.
+		if fi, err := f.Stat(); err == nil {
+			req.ContentLength = fi.Size()
+		}
+	}
+	req.Header.Set("Content-Type", ctype)
+	for k, v := range s.Headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("%s %s: %s", method, redactQuery(url), resp.Status)
+	}
+	return nil
+}
+
+// redactQuery drops the query of a URL, which holds the signature of a
+// presigned S3 URL, from messages.
+func redactQuery(url string) string {
+	u, _, _ := strings.Cut(url, "?")
+	return u
+}
+
+func contentType(f finder.OutputFormat, compress string) string {
+	switch compress {
+	case "gzip":
+		return "application/gzip"
+	case "zstd":
+		return "application/zstd"
+	}
+	switch f {
+	case finder.OutputJSON:
+		return "application/json"
+	case finder.OutputNDJSON:
+		return "application/x-ndjson"
+	case finder.OutputJSONSeq:
+		return "application/json-seq"
+	}
+	return "text/plain; charset=utf-8"
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCLI_DaemonOnce(t *testing.T) {
+	bin := buildCLI(t)
+	td := t.TempDir()
+	_ = mk(t, td, "a.log", 3)
+	_ = mk(t, td, "b.txt", 3)
+
+	var posted []byte
+	var ctype string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("X-Token") != "secret" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		ctype = r.Header.Get("Content-Type")
+		posted, _ = io.ReadAll(r.Body)
+	}))
+	defer srv.Close()
+
+	outDir := filepath.Join(t.TempDir(), "out")
+	jobs := "jobs:\n" +
+		"  - name: logs\n" +
+		"    args: [--root, " + jsonString(td) + ", --ext, .log, --ndjson]\n" +
+		"    sinks:\n" +
+		"      - file: " + jsonString(filepath.Join(outDir, "{name}.ndjson")) + "\n" +
+		"      - http: " + srv.URL + "\n" +
+		"        headers: {X-Token: secret}\n"
+	jobFile := filepath.Join(t.TempDir(), "jobs.yaml")
+	if err := os.WriteFile(jobFile, []byte(jobs), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command(bin, "daemon", "--job", jobFile, "--once")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("daemon --once: %v\n%s", err, stderr.String())
+	}
+	file, err := os.ReadFile(filepath.Join(outDir, "logs.ndjson"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var e cliEntry
+	if err := json.Unmarshal(bytes.TrimSpace(file), &e); err != nil || e.Name != "a.log" {
+		t.Fatalf("file sink: %q, %v", file, err)
+	}
+	if !bytes.Equal(posted, file) || ctype != "application/x-ndjson" {
+		t.Fatalf("http sink got %q (%s), want %q", posted, ctype, file)
+	}
+}
+
+func TestCLI_DaemonRejectsBadJobs(t *testing.T) {
+	bin := buildCLI(t)
+	for name, jobs := range map[string]string{
+		"no schedule":  "jobs:\n  - args: [--ndjson]\n    sinks: [{file: out}]\n",
+		"bad schedule": "jobs:\n  - schedule: '61 * * * *'\n    sinks: [{file: out}]\n",
+		"no sinks":     "jobs:\n  - schedule: '@daily'\n",
+		"bad flag":     "jobs:\n  - schedule: '@daily'\n    args: [--nope]\n    sinks: [{file: out}]\n",
+		"out flag":     "jobs:\n  - schedule: '@daily'\n    args: [--out, x]\n    sinks: [{file: out}]\n",
+		"delete flag":  "jobs:\n  - schedule: '@daily'\n    args: [--delete]\n    sinks: [{file: out}]\n",
+		"plan flag":    "jobs:\n  - schedule: '@daily'\n    args: [--plan]\n    sinks: [{file: out}]\n",
+		"report flag":  "jobs:\n  - schedule: '@daily'\n    args: [--report, r.json]\n    sinks: [{file: out}]\n",
+		"resume flag":  "jobs:\n  - schedule: '@daily'\n    args: [--resume, cp]\n    sinks: [{file: out}]\n",
+	} {
+		jobFile := filepath.Join(t.TempDir(), "jobs.yaml")
+		if err := os.WriteFile(jobFile, []byte(jobs), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		out, err := exec.Command(bin, "daemon", "--job", jobFile).CombinedOutput()
+		if ee, ok := err.(*exec.ExitError); !ok || ee.ExitCode() != 2 || !strings.Contains(string(out), "daemon:") {
+			t.Errorf("%s: want exit status 2, got %v: %s", name, err, out)
+		}
+	}
+}
+
+// jsonString quotes s for YAML, which accepts JSON strings.
+func jsonString(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
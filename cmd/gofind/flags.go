@@ -0,0 +1,931 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Hamed0406/gofind/internal/audit"
+	"github.com/Hamed0406/gofind/internal/expr"
+	"github.com/Hamed0406/gofind/internal/finder"
+	"github.com/Hamed0406/gofind/internal/gitstatus"
+	"github.com/Hamed0406/gofind/internal/ignore"
+	"github.com/Hamed0406/gofind/internal/stats"
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+// searchFlags holds the flags of a regular search. They are defined on a
+// FlagSet so other commands (e.g. completion) can inspect them.
+type searchFlags struct {
+	// fs is the FlagSet the flags are defined on.
+	fs *flag.FlagSet
+	// selfContained keeps the environment and the config file's defaults
+	// from setting flags, for daemon jobs, which list all of theirs.
+	selfContained bool
+	// actions is set for the main search, the only one acting on matches
+	// with --delete and --move-to.
+	actions bool
+
+	showVersion *bool
+
+	root        *string
+	extsCSV     *string
+	nameReStr   *string
+	fixedStr    *bool
+	minSizeStr  *string
+	maxSizeStr  *string
+	afterStr    *string
+	beforeStr   *string
+	includeHid  *bool
+	includeSys  *bool
+	hidFiles    *bool
+	hidDirs     *bool
+	maxDepth    *int
+	findMaxDep  *int
+	minDepth    *int
+	maxPerDir   *int
+	jsonOut     *bool
+	ndjsonOut   *bool
+	prettyJSON  *bool
+	human       *bool
+	timeLayout  *string
+	quote       *string
+	outPath     *string
+	outputFmt   *string
+	compress    *string
+	shardSize   *string
+	shardByDir  *bool
+	outFormat   *string
+	checkpoint  *string
+	resume      *string
+	baseline    *string
+	webhook     *string
+	hookBatch   *int
+	hookRetries *int
+	hookHeaders stringList
+	publish     *string
+	pubBatch    *int
+	pubRetries  *int
+	followSyms  *bool
+	derefOutput *bool
+	types       *string
+	maxSymDepth *int
+	concurrency *string
+	recentFirst *bool
+	maxOpenDirs *int
+	timeout     *time.Duration
+	maxTime     *time.Duration
+	backendStr  *string
+	useIndex    *bool
+
+	deleteMatches *bool
+	toTrash       *bool
+	moveTo        *string
+	iKnow         *bool
+	planOnly      *bool
+	auditLog      *string
+	why           *string
+	verbose       bool
+	logFormat     *string
+	xattrs        stringList
+	showXattrs    *bool
+	hasACL        *bool
+	sparse        *bool
+	showAllocated *bool
+	dirStats      *bool
+	recDirStats   *bool
+	fsTypesCSV    *string
+	showSecurity  *bool
+	filesFrom     *string
+	rootsFrom     *string
+	skipMissing   *bool
+	includeRoot   *bool
+	pruneMatched  *bool
+
+	noIgnore       *bool
+	noIgnoreVCS    *bool
+	noIgnoreDot    *bool
+	noIgnoreFd     *bool
+	noIgnoreGlobal *bool
+	smartIgnore    *bool
+	ignorePats     stringList
+	enrichCSV      *string
+	hashFormat     *string
+	hashWorkers    *int
+	readMode       *string
+	gitStatus      *bool
+	countLines     *bool
+	mediaInfo      *bool
+	ageBuckets     *string
+	gitFilter      *string
+	where          stringList
+	noDedupe       *bool
+	retryTransient *int
+	statTimeout    *time.Duration
+	readDirTimeout *time.Duration
+	cleanPaths     *bool
+	dotSlash       *bool
+	slashPaths     *bool
+	normUnicode    *bool
+	emitMeta       *bool
+	report         *string
+	schema         *string
+	fields         *string
+	whereNot       stringList
+	contentRe      *string
+	extractText    *bool
+	encoding       *string
+	contextLines   *int
+	color          *string
+	cpuProfile     *string
+	memProfile     *string
+	traceOut       *string
+	progress       *bool
+	dirCache       *string
+	sample         *string
+	sampleDirs     *int
+
+	// listErr records a read error of the --files-from list, which is
+	// consumed lazily during the search.
+	listErr error
+	// cliFlags are the flags given on the command line, as opposed to
+	// those resolve took from the environment or the config file.
+	cliFlags map[string]bool
+}
+
+// flagValues lists the accepted values of enumerated flags, for validation
+// messages and shell completion.
+var flagValues = map[string][]string{
+	"backend":     {"walk", "mft", "spotlight"},
+	"log-format":  {"text", "json"},
+	"enrich":      finder.EnricherNames(),
+	"git":         {"tracked", "untracked", "ignored"},
+	"type":        {"f", "d", "l"},
+	"output":      {"text", "json", "ndjson", "json-seq", "grep"},
+	"out-format":  {"text", "json", "ndjson", "json-seq"},
+	"compress":    {"gzip", "zstd"},
+	"audit":       audit.Destinations,
+	"schema":      {"v1", "v2"},
+	"read-mode":   {"plain", "sequential", "mmap"},
+	"quote":       {"auto", "always", "never"},
+	"color":       {"auto", "always", "never"},
+	"hash-format": {"gnu", "bsd"},
+	"encoding":    {"auto", "utf-8", "utf-16le", "utf-16be", "latin1", "windows-1252", "shift_jis"},
+}
+
+// defineSearchFlags registers the search flags on fs.
+func defineSearchFlags(fs *flag.FlagSet) *searchFlags {
+	sf := &searchFlags{
+		fs:          fs,
+		showVersion: fs.Bool("version", false, "print gofind version and exit"),
+
+		root:        fs.String("root", ".", "root directory to search, or @NAME for the roots saved under NAME with gofind root add"),
+		extsCSV:     fs.String("ext", "", "comma-separated list of file extensions to include (e.g. \".go,.md\")"),
+		nameReStr:   fs.String("name-regex", "", "regex to match file/dir names"),
+		fixedStr:    fs.Bool("fixed-strings", false, "treat --name-regex as a literal substring, not a regex"),
+		minSizeStr:  fs.String("min-size", "", "minimum size to include (e.g. 10KB, 2MB, 1G)"),
+		maxSizeStr:  fs.String("max-size", "", "maximum size to include (e.g. 500KB, 10MB)"),
+		afterStr:    fs.String("after", "", "include entries modified after this time (YYYY-MM-DD or RFC3339)"),
+		beforeStr:   fs.String("before", "", "include entries modified before this time (YYYY-MM-DD or RFC3339)"),
+		includeHid:  fs.Bool("include-hidden", false, "include hidden files (Unix dotfiles; on Windows the hidden and system attributes, and dotfiles in Git working trees)"),
+		hidFiles:    fs.Bool("include-hidden-files", false, "include hidden files but still skip hidden directories (e.g. list dotfiles without descending into .cache or .git)"),
+		hidDirs:     fs.Bool("descend-hidden-dirs", false, "include hidden directories and search below them; hidden files are still skipped unless --include-hidden-files is given"),
+		includeSys:  fs.Bool("include-system", false, "on Windows, include entries with the system attribute (desktop.ini, $RECYCLE.BIN) while still skipping hidden ones"),
+		maxDepth:    fs.Int("max-depth", -1, "maximum directory depth (-1 = unlimited, 0 = only root's direct children)"),
+		findMaxDep:  fs.Int("maxdepth", -1, "find-compatible maximum depth, counting the root as 0: 0 = the root alone, 1 = it and its children (-1 = unlimited); includes the root unless --mindepth is 1 or more"),
+		minDepth:    fs.Int("mindepth", 0, "find-compatible minimum depth: leave out entries fewer than N levels below the root (the root is 0, its children 1); implies the find depth semantics of --maxdepth"),
+		maxPerDir:   fs.Int("max-per-dir", 0, "emit at most N matches from any one directory, e.g. for huge flat maildirs (0 = unlimited)"),
+		jsonOut:     fs.Bool("json", false, "stream JSON output instead of plain lines"),
+		ndjsonOut:   fs.Bool("ndjson", false, "stream newline-delimited JSON entries"),
+		prettyJSON:  fs.Bool("pretty", false, "pretty-print JSON output"),
+		human:       fs.Bool("human", false, "prefix text output with human-readable sizes (1.4 MB) and local modification times; JSON output is unchanged"),
+		timeLayout:  fs.String("time-layout", finder.DefaultTimeLayout, "Go time layout of the modification times printed by --human"),
+		quote:       fs.String("quote", "auto", "how text output writes paths: auto (shell-quote paths with spaces, shell or control characters on a terminal, and escape control characters elsewhere), always (shell-quote every path) or never (as they are)"),
+		outPath:     fs.String("out", "", "write output to this file instead of stdout"),
+		outputFmt:   fs.String("output", "", "output format: text, json, ndjson, json-seq (RFC 7464 record-separated JSON) or grep (path:line:text for each line --content-regex matches); overrides --json and --ndjson"),
+		compress:    fs.String("compress", "", "compress the output (usually an --out file) with gzip or zstd"),
+		shardSize:   fs.String("shard-size", "", "split --out into files of about this size (e.g. 1GB); the --out pattern must contain %d, the shard number"),
+		shardByDir:  fs.Bool("shard-by-dir", false, "write the entries of each top-level directory to its own file; the --out pattern must contain %s, the directory name"),
+		outFormat:   fs.String("out-format", "", "write --out in this format (text, json, ndjson or json-seq) and still print the regular output to stdout"),
+		checkpoint:  fs.String("checkpoint", "", "record fully searched directories in FILE so an interrupted search can be resumed"),
+		resume:      fs.String("resume", "", "skip the directories recorded in checkpoint FILE and keep recording to it"),
+		baseline:    fs.String("baseline", "", "compare with the JSON/NDJSON output of an earlier search in FILE and emit only added, removed and changed entries"),
+		webhook:     fs.String("webhook", "", "also POST the matches as JSON arrays to this http(s) URL, e.g. a SIEM or inventory endpoint"),
+		hookBatch:   fs.Int("webhook-batch", 500, "entries per --webhook request"),
+		hookRetries: fs.Int("webhook-retries", 3, "retry a failed --webhook request up to N times (network errors, 429 and 5xx responses)"),
+		publish:     fs.String("publish", "", "also publish every match as an NDJSON message to kafka://BROKER[,BROKER...]/TOPIC or nats://SERVER/SUBJECT (default $"+publishEnv+"; needs a build with -tags kafka or nats)"),
+		pubBatch:    fs.Int("publish-batch", 100, "messages per --publish batch"),
+		pubRetries:  fs.Int("publish-retries", 3, "resend a --publish batch that was not acknowledged up to N times"),
+		followSyms:  fs.Bool("follow-symlinks", false, "follow symlinked directories"),
+		maxSymDepth: fs.Int("max-symlink-depth", 0, "with --follow-symlinks, follow at most N symlinked directories along any path (0 = unlimited)"),
+		derefOutput: fs.Bool("dereference-output", false, "report the size and modification time of a symlink's target rather than the link's own"),
+		types:       fs.String("type", "", "comma-separated entry types to include: f (file), d (directory), l (symlink, also when followed)"),
+		concurrency: fs.String("concurrency", strconv.Itoa(runtime.NumCPU()), "number of concurrent directory workers, or auto to pick it from the storage under --root: 2 for spinning disks, more for SSDs and network filesystems"),
+		maxOpenDirs: fs.Int("max-open-dirs", 0, "most directories held open at once, apart from --concurrency (0 = half the open file limit, up to 4096; -1 = no limit)"),
+		recentFirst: fs.Bool("recent-first", false, "read recently modified directories first, so recent changes surface sooner on big trees"),
+		timeout:     fs.Duration("timeout", 0, "stop the search after this long (e.g. 30s, 5m; 0 = no limit)"),
+		maxTime:     fs.Duration("max-time", 0, "stop the search after this long but exit successfully with what was found, marked partial (0 = no limit)"),
+		backendStr:  fs.String("backend", "walk", "discovery backend: walk, mft (NTFS Master File Table; Windows, admin only) or spotlight (macOS)"),
+		useIndex:    fs.Bool("use-index", false, "answer queries from the platform search index when available (Spotlight on macOS)"),
+	}
+	fs.Var(&sf.hookHeaders, "webhook-header", "add this \"Name: value\" header to --webhook requests, e.g. for authentication (repeatable)")
+	sf.deleteMatches = fs.Bool("delete", false, "delete matching files (directories are never deleted)")
+	sf.toTrash = fs.Bool("trash", false, "with --delete, move files to the trash (XDG Trash, macOS Trash, Windows Recycle Bin) instead of unlinking them; see gofind trash restore")
+	sf.moveTo = fs.String("move-to", "", "move matching files into this directory, keeping paths relative to --root")
+	sf.iKnow = fs.Bool("i-know-what-im-doing", false, "let --delete and --move-to act below the root of a filesystem, your home directory or a directory containing it")
+	sf.planOnly = fs.Bool("plan", false, "with --delete/--move-to, print the intended operations as JSON instead of performing them")
+	sf.auditLog = fs.String("audit", "", "with --delete/--move-to, also record each operation in the system log: syslog or journald")
+	sf.why = fs.String("why", "", "explain why PATH is included or excluded by the current flags, then exit")
+	fs.BoolVar(&sf.verbose, "verbose", false, "log directories entered/skipped and sampled filter rejections to stderr")
+	fs.BoolVar(&sf.verbose, "v", false, "shorthand for --verbose")
+	sf.logFormat = fs.String("log-format", "text", "log format for --verbose: text or json")
+	fs.Var(&sf.xattrs, "xattr", "require extended attribute name[=value] (repeatable; Linux/macOS)")
+	sf.showXattrs = fs.Bool("show-xattrs", false, "include extended attributes in JSON/NDJSON output")
+	sf.hasACL = fs.Bool("has-acl", false, "only include entries with a POSIX ACL (Linux)")
+	sf.sparse = fs.Bool("sparse", false, "only include sparse files (allocated blocks well below logical size; Unix)")
+	sf.showAllocated = fs.Bool("show-allocated", false, "include allocatedSize (on-disk bytes) in JSON/NDJSON output (Unix)")
+	sf.dirStats = fs.Bool("dir-stats", false, "include fileCount, dirCount and totalSize of their immediate children for directories in JSON/NDJSON output")
+	sf.recDirStats = fs.Bool("recursive-dir-stats", false, "like --dir-stats, but count everything below each directory")
+	sf.fsTypesCSV = fs.String("fstype", "", "comma-separated filesystem types to include; prefix with ! to exclude (e.g. \"ext4,xfs\" or \"!nfs\")")
+	sf.showSecurity = fs.Bool("show-security", false, "include ACL presence and SELinux context in JSON/NDJSON output (Linux)")
+	sf.filesFrom = fs.String("files-from", "", "filter the newline- or NUL-delimited paths in FILE (- for stdin) instead of walking")
+	sf.rootsFrom = fs.String("roots-from", "", "search every root listed in FILE (- for stdin), newline- or NUL-delimited")
+	sf.skipMissing = fs.Bool("skip-missing-roots", false, "skip roots that do not exist, with a note on stderr, instead of failing")
+	sf.includeRoot = fs.Bool("include-root", false, "also emit the root directory itself when it matches the filters, as find does")
+	sf.pruneMatched = fs.Bool("prune-matched", false, "do not descend into directories that match, e.g. --name-regex '^node_modules$' --prune-matched lists vendor directories without their contents")
+	sf.noIgnore = fs.Bool("no-ignore", false, "do not read any ignore files")
+	sf.noIgnoreVCS = fs.Bool("no-ignore-vcs", false, "do not read .gitignore")
+	sf.noIgnoreDot = fs.Bool("no-ignore-dot", false, "do not read .ignore")
+	sf.noIgnoreFd = fs.Bool("no-ignore-fd", false, "do not read .fdignore")
+	sf.noIgnoreGlobal = fs.Bool("no-ignore-global", false, "do not read the global ignore file (~/.config/gofind/ignore)")
+	fs.Var(&sf.ignorePats, "ignore", "skip entries matching these comma-separated gitignore-style patterns, e.g. \"node_modules/,*.tmp\", over the ignore files (repeatable)")
+	sf.smartIgnore = fs.Bool("smart-ignore", false, "also skip common build artifacts, dependency caches and VCS metadata (node_modules, .git, target, __pycache__, ...)")
+	sf.hashFormat = fs.String("hash-format", "", "write text output as a checksum file sha256sum -c reads: gnu (SUM  PATH) or bsd (SHA256 (PATH) = SUM); implies --enrich hash")
+	sf.enrichCSV = fs.String("enrich", "", "comma-separated enrichers adding metadata under \"extra\" in JSON/NDJSON output: "+strings.Join(finder.EnricherNames(), ", "))
+	sf.hashWorkers = fs.Int("hash-workers", 0, "files --enrich hash reads at once, in a stage apart from the walk so slow reads do not hold it up (0 = --concurrency)")
+	sf.readMode = fs.String("read-mode", "plain", "how --enrich hash and --content-regex read files: plain, sequential (hint the kernel to read ahead; Linux) or mmap (map files of 1 MiB or more; Linux, macOS)")
+	sf.dirCache = fs.String("dir-cache", "", "keep directory listings in FILE and serve directories unchanged since the last search from it; files written in place keep their cached size and time until their directory changes")
+	sf.progress = fs.Bool("progress", false, "report directories, entries and entries/s on stderr every second, with an ETA once a complete search of the same roots has been recorded")
+	sf.sample = fs.String("sample", "", "search the entries of only this share of the directories, e.g. 1% or 0.01, for a quick estimate: analyze scales its counts up to the whole tree")
+	sf.sampleDirs = fs.Int("sample-dirs", 0, "like --sample, searching about N directories; needs the directory count of an earlier search of the same roots with --progress or --sample")
+	sf.cpuProfile = fs.String("cpuprofile", "", "write a CPU profile of the run to FILE, for go tool pprof")
+	sf.memProfile = fs.String("memprofile", "", "write a memory profile to FILE when the run ends, for go tool pprof")
+	sf.traceOut = fs.String("trace", "", "write an execution trace of the run to FILE, for go tool trace")
+	sf.countLines = fs.Bool("count-lines", false, "add lines, blankLines and bytesPerLine for text files to JSON/NDJSON output (same as --enrich lines); analyze totals them")
+	sf.mediaInfo = fs.Bool("media-info", false, "add width, height, taken and durationSeconds of images (JPEG, PNG, GIF) and videos (MP4, MOV) to JSON/NDJSON output (same as --enrich media)")
+	sf.ageBuckets = fs.String("age-buckets", "", "comma-separated ages (e.g. \"1d,7d,30d,365d\") bucketing modification times: adds ageBucket to JSON/NDJSON output, and analyze summarizes by these buckets")
+	sf.gitStatus = fs.Bool("git-status", false, "add the containing Git repository, branch and tracked/modified/untracked/ignored status to JSON/NDJSON output")
+	sf.gitFilter = fs.String("git", "", "only include entries with this Git status: tracked (including modified), untracked or ignored; ignored implies --no-ignore-vcs")
+	fs.Var(&sf.where, "where", "only include entries matching this expression, e.g. 'size > 10MB && ext in (\".log\", \".gz\") && mtime < now() - 30d' (repeatable; all must hold)")
+	fs.Var(&sf.whereNot, "where-not", "exclude entries matching this expression, e.g. 'rel =~ \"^docs/\"' (repeatable)")
+	sf.contentRe = fs.String("content-regex", "", "only include files with a line matching this regular expression; binary files never match")
+	sf.extractText = fs.Bool("extract-text", false, "with --content-regex, match the text of PDF, .docx and .xlsx documents rather than their bytes; much slower")
+	sf.encoding = fs.String("encoding", "auto", "with --content-regex, the encoding of files: auto (detect UTF-16 by byte order mark or NUL bytes, and Latin-1 by invalid UTF-8) or a name such as utf-8, utf-16le, utf-16be, latin1, windows-1252 or shift_jis")
+	sf.contextLines = fs.Int("context", 0, "with --output grep, also print N lines before and after each matching line")
+	fs.IntVar(sf.contextLines, "C", 0, "shorthand for --context")
+	sf.color = fs.String("color", "auto", "color --output grep: auto (on a terminal, unless NO_COLOR is set), always or never")
+	sf.noDedupe = fs.Bool("no-dedupe", false, "emit a file each time it is reached through overlapping roots, listed paths or followed symlinks")
+	sf.retryTransient = fs.Int("retry-transient", 0, "retry a stat or directory read that failed with a possibly temporary error (vanished entry, stale NFS handle, dropped SMB share) up to N times")
+	sf.statTimeout = fs.Duration("stat-timeout", 0, "skip an entry whose stat takes longer than this, e.g. on a hung network mount (0 = no limit)")
+	sf.readDirTimeout = fs.Duration("readdir-timeout", 0, "skip a directory whose listing takes longer than this (0 = no limit)")
+	sf.cleanPaths = fs.Bool("clean-paths", false, "print paths cleaned of redundant separators and . or .. elements")
+	sf.dotSlash = fs.Bool("dot-slash", false, "prefix relative paths with ./")
+	sf.slashPaths = fs.Bool("slash", false, "print paths with forward slashes (Windows)")
+	sf.normUnicode = fs.Bool("normalize-unicode", false, "compare names in Unicode NFC form so e.g. \"café\" matches decomposed (NFD) names as stored by macOS")
+	sf.emitMeta = fs.Bool("emit-meta", false, "frame JSON/NDJSON output with type=meta records holding the flags used, start and end times, counts and error totals, so consumers can tell a complete scan from a cut-off one")
+	sf.report = fs.String("report", "", "after the search, write a JSON report to FILE: version, host, times, counts, errors and the normalized flags that reproduce the run")
+	sf.schema = fs.String("schema", "v1", "shape of JSON/NDJSON records: v1 (the original) or v2, adding schemaVersion, depth, owner and a top-level hash; see gofind schema")
+	sf.fields = fs.String("fields", "", "comma-separated record fields (e.g. \"path,size,modTime\"; extra.KEY for one enricher value) to cut JSON/NDJSON records down to, in this order")
+	return sf
+}
+
+// config validates the parsed flags and builds the finder configuration.
+func (sf *searchFlags) config() (finder.Config, error) {
+	if err := sf.resolve(); err != nil {
+		return finder.Config{}, err
+	}
+	if err := sf.checkActions(); err != nil {
+		return finder.Config{}, err
+	}
+	cfg := finder.Config{
+		Root:           *sf.root,
+		IncludeHidden:  *sf.includeHid,
+		MaxDepth:       *sf.maxDepth,
+		MaxPerDir:      *sf.maxPerDir,
+		RecentFirst:    *sf.recentFirst,
+		MaxOpenDirs:    *sf.maxOpenDirs,
+		OutputFormat:   finder.OutputText,
+		PrettyJSON:     *sf.prettyJSON,
+		Human:          *sf.human,
+		TimeLayout:     *sf.timeLayout,
+		FollowSymlinks: *sf.followSyms,
+		NoDedupe:       *sf.noDedupe,
+		RetryTransient: *sf.retryTransient,
+		StatTimeout:    *sf.statTimeout,
+		ReadDirTimeout: *sf.readDirTimeout,
+		CleanPaths:     *sf.cleanPaths,
+		DotSlash:       *sf.dotSlash,
+		SlashPaths:     *sf.slashPaths,
+
+		NormalizeUnicode:   *sf.normUnicode,
+		MaxSymlinkDepth:    *sf.maxSymDepth,
+		DereferenceOutput:  *sf.derefOutput,
+		SkipMissingRoots:   *sf.skipMissing,
+		IncludeRoot:        *sf.includeRoot,
+		PruneMatched:       *sf.pruneMatched,
+		IncludeHiddenFiles: *sf.hidFiles,
+		DescendHiddenDirs:  *sf.hidDirs,
+	}
+
+	if sf.set("maxdepth") || sf.set("mindepth") {
+		if sf.set("max-depth") {
+			return cfg, errors.New("--max-depth cannot be combined with the find-compatible --maxdepth and --mindepth")
+		}
+		if *sf.minDepth < 0 {
+			return cfg, fmt.Errorf("invalid --mindepth: %d", *sf.minDepth)
+		}
+		cfg.FindDepth, cfg.MaxDepth, cfg.MinDepth = true, *sf.findMaxDep, *sf.minDepth
+	}
+
+	version, err := parseSchemaVersion(*sf.schema)
+	if err != nil {
+		return cfg, err
+	}
+	cfg.SchemaVersion = version
+	for _, f := range strings.Split(*sf.fields, ",") {
+		if f = strings.TrimSpace(f); f != "" {
+			cfg.Fields = append(cfg.Fields, f)
+		}
+	}
+	if *sf.emitMeta {
+		cfg.EmitMeta, cfg.MetaConfig = true, sf.metaConfig()
+	}
+	if *sf.maxTime > 0 {
+		cfg.Deadline = time.Now().Add(*sf.maxTime)
+	}
+	if *sf.includeSys {
+		cfg.HiddenPolicy = finder.DefaultHiddenPolicy &^ finder.HiddenSystem
+	}
+
+	// The filter flags are shorthand for --where terms, combined with it
+	// below into one expression.
+	filters := []*expr.Expr{finder.ExtWhere(parseExts(*sf.extsCSV))}
+
+	// name regex
+	if rs := strings.TrimSpace(*sf.nameReStr); rs != "" {
+		if cfg.NormalizeUnicode {
+			rs = finder.NFC(rs)
+		}
+		if *sf.fixedStr {
+			rs = regexp.QuoteMeta(rs)
+		}
+		re, err := regexp.Compile(rs)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid --name-regex: %v", err)
+		}
+		filters = append(filters, finder.NameWhere(re))
+	}
+
+	if *sf.contentRe != "" {
+		re, err := regexp.Compile(*sf.contentRe)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid --content-regex: %v", err)
+		}
+		cfg.ContentRegex = re
+	}
+	if *sf.extractText {
+		if cfg.ContentRegex == nil {
+			return cfg, errors.New("--extract-text only applies to --content-regex")
+		}
+		cfg.ExtractText = true
+	}
+	if name := strings.TrimSpace(*sf.encoding); !strings.EqualFold(name, "auto") {
+		if cfg.ContentRegex == nil {
+			return cfg, errors.New("--encoding only applies to --content-regex")
+		}
+		enc, err := htmlindex.Get(name)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid --encoding: %q (want auto or an encoding name such as utf-8, utf-16le or latin1)", name)
+		}
+		cfg.Encoding = enc
+	}
+
+	// size filters
+	if *sf.minSizeStr != "" {
+		n, err := parseSize(*sf.minSizeStr)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid --min-size: %v", err)
+		}
+		filters = append(filters, finder.MinSizeWhere(n))
+	}
+	if *sf.maxSizeStr != "" {
+		n, err := parseSize(*sf.maxSizeStr)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid --max-size: %v", err)
+		}
+		filters = append(filters, finder.MaxSizeWhere(n))
+	}
+
+	// time filters
+	if *sf.afterStr != "" {
+		t, err := parseTime(*sf.afterStr)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid --after: %v", err)
+		}
+		filters = append(filters, finder.AfterWhere(t))
+	}
+	if *sf.beforeStr != "" {
+		t, err := parseTime(*sf.beforeStr)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid --before: %v", err)
+		}
+		filters = append(filters, finder.BeforeWhere(t))
+	}
+
+	// extended attributes
+	for _, x := range sf.xattrs {
+		f, err := finder.ParseXAttrFilter(x)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid --xattr %q: %v", x, err)
+		}
+		cfg.XAttrs = append(cfg.XAttrs, f)
+	}
+	cfg.ShowXAttrs = *sf.showXattrs
+	cfg.HasACL = *sf.hasACL
+	cfg.ShowSecurity = *sf.showSecurity
+	cfg.Sparse = *sf.sparse
+	cfg.ShowAllocated = *sf.showAllocated
+	switch {
+	case *sf.recDirStats:
+		cfg.DirStats = finder.DirStatsRecursive
+	case *sf.dirStats:
+		cfg.DirStats = finder.DirStatsImmediate
+	}
+
+	// filesystem types
+	cfg.FSTypes, cfg.ExcludeFSTypes = parseFSTypes(*sf.fsTypesCSV)
+
+	// explicit path lists
+	if *sf.filesFrom != "" && *sf.rootsFrom != "" {
+		return cfg, fmt.Errorf("--files-from and --roots-from are mutually exclusive")
+	}
+	if *sf.rootsFrom != "" {
+		f, err := openList(*sf.rootsFrom)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid --roots-from: %v", err)
+		}
+		var rerr error
+		for root := range pathList(f, &rerr) {
+			cfg.Roots = append(cfg.Roots, root)
+		}
+		_ = f.Close()
+		if rerr != nil {
+			return cfg, fmt.Errorf("reading --roots-from: %v", rerr)
+		}
+		if len(cfg.Roots) == 0 {
+			return cfg, fmt.Errorf("--roots-from: no roots listed in %q", *sf.rootsFrom)
+		}
+	}
+	if strings.HasPrefix(*sf.root, "@") {
+		dirs, err := resolveRoot(*sf.root)
+		if err != nil {
+			return cfg, err
+		}
+		if len(dirs) > 1 && *sf.rootsFrom != "" {
+			return cfg, fmt.Errorf("--root %s and --roots-from are mutually exclusive", *sf.root)
+		}
+		cfg.Root = dirs[0]
+		if len(dirs) > 1 {
+			cfg.Roots = dirs
+		}
+	}
+	if *sf.filesFrom != "" {
+		f, err := openList(*sf.filesFrom)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid --files-from: %v", err)
+		}
+		cfg.Paths = pathList(f, &sf.listErr)
+	}
+	if *sf.baseline != "" {
+		f, err := openList(*sf.baseline)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid --baseline: %v", err)
+		}
+		cfg.Baseline, err = finder.LoadBaseline(f)
+		f.Close()
+		if err != nil {
+			return cfg, fmt.Errorf("invalid --baseline: %v", err)
+		}
+	}
+
+	// entry types
+	types, err := parseTypes(*sf.types)
+	if err != nil {
+		return cfg, err
+	}
+	filters = append(filters, finder.TypeWhere(types))
+
+	// filter expressions, combined with the flags into one; an empty one is
+	// no filter
+	for _, set := range []struct {
+		name  string
+		exprs []string
+		not   bool
+	}{{"where", sf.where, false}, {"where-not", sf.whereNot, true}} {
+		for _, w := range set.exprs {
+			src := w
+			if strings.TrimSpace(src) == "" {
+				continue
+			}
+			if cfg.NormalizeUnicode {
+				src = finder.NFC(src)
+			}
+			x, err := finder.CompileWhere(src)
+			if err != nil {
+				return cfg, fmt.Errorf("invalid --%s %q: %v", set.name, w, err)
+			}
+			if set.not {
+				x = expr.Not(x)
+			}
+			filters = append(filters, x)
+		}
+	}
+	cfg.Where = expr.And(filters...)
+
+	// git status filter
+	switch g := strings.ToLower(strings.TrimSpace(*sf.gitFilter)); g {
+	case "":
+	case "tracked", "untracked", "ignored":
+		cfg.Git = gitstatus.Status(g)
+	default:
+		return cfg, fmt.Errorf("invalid --git: %q (want %s)", *sf.gitFilter, strings.Join(flagValues["git"], ", "))
+	}
+
+	// ignore files and the built-in preset
+	if !*sf.noIgnore || *sf.smartIgnore || len(sf.ignorePats) > 0 {
+		ic := &ignore.Config{Enabled: true, CaseInsensitive: ignore.DefaultCaseInsensitive, Smart: *sf.smartIgnore}
+		for _, list := range sf.ignorePats {
+			for _, p := range strings.Split(list, ",") {
+				if p = strings.TrimSpace(p); p != "" {
+					ic.Patterns = append(ic.Patterns, p)
+				}
+			}
+		}
+		if !*sf.noIgnore {
+			for _, src := range []struct {
+				off  bool
+				name string
+			}{
+				// Files ignored by git are what --git ignored looks for.
+				{*sf.noIgnoreVCS || cfg.Git == gitstatus.Ignored, ignore.GitIgnoreFile},
+				{*sf.noIgnoreDot, ignore.DotIgnoreFile},
+				{*sf.noIgnoreFd, ignore.FdIgnoreFile},
+			} {
+				if !src.off {
+					ic.Files = append(ic.Files, src.name)
+				}
+			}
+			if !*sf.noIgnoreGlobal {
+				ic.GlobalFile = ignore.GlobalFilePath()
+			}
+		}
+		cfg.Ignore = ic
+	}
+
+	// enrichers
+	cfg.HashWorkers = *sf.hashWorkers
+	switch strings.ToLower(strings.TrimSpace(*sf.readMode)) {
+	case "plain":
+		cfg.ReadMode = finder.ReadPlain
+	case "sequential":
+		cfg.ReadMode = finder.ReadSequential
+	case "mmap":
+		cfg.ReadMode = finder.ReadMmap
+	default:
+		return cfg, fmt.Errorf("invalid --read-mode: %q (want %s)", *sf.readMode, strings.Join(flagValues["read-mode"], ", "))
+	}
+	names := strings.Split(*sf.enrichCSV, ",")
+	if *sf.countLines && !slices.Contains(names, "lines") {
+		names = append(names, "lines")
+	}
+	if *sf.mediaInfo && !slices.Contains(names, "media") {
+		names = append(names, "media")
+	}
+	if *sf.hashFormat != "" && !slices.Contains(names, "hash") {
+		names = append(names, "hash")
+	}
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		en, ok := finder.LookupEnricher(name)
+		if !ok {
+			return cfg, fmt.Errorf("invalid --enrich: %q (want %s)", name, strings.Join(finder.EnricherNames(), ", "))
+		}
+		cfg.Enrichers = append(cfg.Enrichers, en)
+	}
+	if *sf.gitStatus {
+		cfg.Enrichers = append(cfg.Enrichers, finder.GitStatusEnricher())
+	}
+	bounds, err := sf.ageBounds()
+	if err != nil {
+		return cfg, err
+	}
+	if bounds != nil {
+		cfg.Enrichers = append(cfg.Enrichers, ageBucketEnricher(bounds, time.Now()))
+	}
+
+	// logging
+	if sf.verbose {
+		logger, err := newLogger(*sf.logFormat)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid --log-format: %v", err)
+		}
+		cfg.Logger = logger
+	}
+
+	if *sf.progress {
+		cfg.OnProgress = progressReporter(os.Stderr, isTerminal(os.Stderr))
+		if key := dirCountKey(cfg); key != "" {
+			cfg.ExpectedDirs = loadDirCounts()[key]
+		}
+	}
+
+	if cfg.Sample, err = sf.sampleRate(cfg); err != nil {
+		return cfg, err
+	}
+
+	// concurrency, after logging so auto can report its pick
+	if c := strings.TrimSpace(*sf.concurrency); strings.EqualFold(c, "auto") {
+		storage := finder.DetectStorage(cfg.Root)
+		cfg.Concurrency = finder.AutoConcurrency(storage)
+		if cfg.Logger != nil {
+			cfg.Logger.Debug("concurrency auto", "root", cfg.Root, "storage", storage.String(), "workers", cfg.Concurrency)
+		}
+	} else if cfg.Concurrency, err = strconv.Atoi(c); err != nil {
+		return cfg, fmt.Errorf("invalid --concurrency: %q (want a number or auto)", *sf.concurrency)
+	}
+
+	// discovery backend
+	switch strings.ToLower(strings.TrimSpace(*sf.backendStr)) {
+	case "", "walk":
+		cfg.Backend = finder.BackendWalk
+	case "mft":
+		cfg.Backend = finder.BackendMFT
+	case "spotlight":
+		cfg.Backend = finder.BackendSpotlight
+	default:
+		return cfg, fmt.Errorf("invalid --backend: %q (want %s)", *sf.backendStr, strings.Join(flagValues["backend"], ", "))
+	}
+	if *sf.useIndex {
+		if cfg.Backend == finder.BackendMFT {
+			return cfg, errors.New("--use-index selects the spotlight backend and cannot be combined with --backend mft")
+		}
+		cfg.Backend = finder.BackendSpotlight
+	}
+
+	// output format selection
+	if *sf.jsonOut {
+		cfg.OutputFormat = finder.OutputJSON
+	}
+	if *sf.ndjsonOut {
+		// If both --json and --ndjson are given, prefer NDJSON.
+		cfg.OutputFormat = finder.OutputNDJSON
+	}
+	if *sf.outputFmt == "grep" {
+		if cfg.ContentRegex == nil {
+			return cfg, errors.New("--output grep prints the lines --content-regex matches; give one")
+		}
+		cfg.OutputFormat = finder.OutputText
+	} else if *sf.outputFmt != "" {
+		f, err := parseOutputFormat("output", *sf.outputFmt)
+		if err != nil {
+			return cfg, err
+		}
+		cfg.OutputFormat = f
+	}
+	// Matching lines are listed by grep output and in JSON records; a plain
+	// list of paths needs only the first.
+	cfg.MatchLines = cfg.ContentRegex != nil && (*sf.outputFmt == "grep" || cfg.OutputFormat != finder.OutputText)
+	if *sf.contextLines < 0 {
+		return cfg, fmt.Errorf("invalid --context: %d", *sf.contextLines)
+	}
+	cfg.ContextLines = *sf.contextLines
+	switch strings.ToLower(strings.TrimSpace(*sf.color)) {
+	case "auto":
+		_, noColor := os.LookupEnv("NO_COLOR")
+		cfg.Color = *sf.outPath == "" && !noColor && isTerminal(os.Stdout)
+	case "always":
+		cfg.Color = true
+	case "never":
+	default:
+		return cfg, fmt.Errorf("invalid --color: %q (want %s)", *sf.color, strings.Join(flagValues["color"], ", "))
+	}
+	switch strings.ToLower(strings.TrimSpace(*sf.hashFormat)) {
+	case "":
+	case "gnu":
+		cfg.HashFormat = finder.HashFormatGNU
+	case "bsd":
+		cfg.HashFormat = finder.HashFormatBSD
+	default:
+		return cfg, fmt.Errorf("invalid --hash-format: %q (want %s)", *sf.hashFormat, strings.Join(flagValues["hash-format"], ", "))
+	}
+	if cfg.HashFormat != finder.HashFormatNone {
+		// Checksum files are text; --out-format text writes one beside JSON
+		// on stdout.
+		if *sf.outputFmt == "grep" || cfg.OutputFormat != finder.OutputText && *sf.outFormat != "text" {
+			return cfg, errors.New("--hash-format writes text output; it does not go with --json, --ndjson or --output other than text")
+		}
+	}
+	// Paths are shell-quoted for a terminal, where they are read and may be
+	// pasted, but only escaped for files and pipes, which read them by line.
+	switch strings.ToLower(strings.TrimSpace(*sf.quote)) {
+	case "auto":
+		cfg.Quote = finder.QuoteEscape
+		if *sf.outPath == "" && isTerminal(os.Stdout) {
+			cfg.Quote = finder.QuoteShell
+		}
+	case "always":
+		cfg.Quote = finder.QuoteAlways
+	case "never":
+		cfg.Quote = finder.QuoteNone
+	default:
+		return cfg, fmt.Errorf("invalid --quote: %q (want %s)", *sf.quote, strings.Join(flagValues["quote"], ", "))
+	}
+	if _, err := sf.webhookConfig(); err != nil {
+		return cfg, err
+	}
+	if _, err := sf.publishURL(); err != nil {
+		return cfg, err
+	}
+	if *sf.outFormat != "" {
+		if strings.TrimSpace(*sf.outPath) == "" {
+			return cfg, errors.New("--out-format needs --out")
+		}
+		if _, err := parseOutputFormat("out-format", *sf.outFormat); err != nil {
+			return cfg, err
+		}
+	}
+	return cfg, nil
+}
+
+// outFileFormat returns the format of the --out file: --out-format when
+// given, otherwise the main output format.
+func (sf *searchFlags) outFileFormat(cfg finder.Config) finder.OutputFormat {
+	if *sf.outFormat == "" {
+		return cfg.OutputFormat
+	}
+	f, _ := parseOutputFormat("out-format", *sf.outFormat) // validated by config
+	return f
+}
+
+// parseOutputFormat parses the value of an --output-style flag.
+func parseOutputFormat(flagName, s string) (finder.OutputFormat, error) {
+	switch s {
+	case "text":
+		return finder.OutputText, nil
+	case "json":
+		return finder.OutputJSON, nil
+	case "ndjson":
+		return finder.OutputNDJSON, nil
+	case "json-seq":
+		return finder.OutputJSONSeq, nil
+	}
+	return 0, fmt.Errorf("invalid --%s: %q (want %s)", flagName, s, strings.Join(flagValues["out-format"], ", "))
+}
+
+// entryTypeNames maps the letters of --type to finder entry types.
+var entryTypeNames = map[string]string{"f": "file", "d": "dir", "l": "symlink"}
+
+// parseTypes parses a --type value such as "f,l".
+func parseTypes(csv string) (map[string]bool, error) {
+	var types map[string]bool
+	for _, t := range strings.Split(csv, ",") {
+		if t = strings.TrimSpace(t); t == "" {
+			continue
+		}
+		name, ok := entryTypeNames[t]
+		if !ok {
+			return nil, fmt.Errorf("invalid --type: %q (want %s)", t, strings.Join(flagValues["type"], ", "))
+		}
+		if types == nil {
+			types = map[string]bool{}
+		}
+		types[name] = true
+	}
+	return types, nil
+}
+
+// parseSchemaVersion parses a --schema value such as "v2".
+func parseSchemaVersion(s string) (int, error) {
+	if i := slices.Index(flagValues["schema"], s); i >= 0 {
+		return i + 1, nil
+	}
+	return 0, fmt.Errorf("invalid --schema: %q (want %s)", s, strings.Join(flagValues["schema"], ", "))
+}
+
+// ageBounds parses --age-buckets into ascending bucket bounds, or returns nil
+// without it.
+func (sf *searchFlags) ageBounds() ([]time.Duration, error) {
+	if strings.TrimSpace(*sf.ageBuckets) == "" {
+		return nil, nil
+	}
+	var bounds []time.Duration
+	for _, f := range strings.Split(*sf.ageBuckets, ",") {
+		d, err := parseAge(f)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --age-buckets: %v", err)
+		}
+		if n := len(bounds); n > 0 && d <= bounds[n-1] {
+			return nil, fmt.Errorf("invalid --age-buckets: %q: ages must be ascending", *sf.ageBuckets)
+		}
+		bounds = append(bounds, d)
+	}
+	return bounds, nil
+}
+
+// sampleRate parses --sample, a percentage or a fraction, or derives the
+// share from --sample-dirs and the recorded directory count of the roots. It
+// returns 0 without either.
+func (sf *searchFlags) sampleRate(cfg finder.Config) (float64, error) {
+	s := strings.TrimSpace(*sf.sample)
+	switch {
+	case s != "" && *sf.sampleDirs != 0:
+		return 0, errors.New("--sample cannot be combined with --sample-dirs")
+	case s != "":
+		f, err := strconv.ParseFloat(strings.TrimSuffix(s, "%"), 64)
+		if strings.HasSuffix(s, "%") {
+			f /= 100
+		}
+		if err != nil || !(f > 0 && f <= 1) {
+			return 0, fmt.Errorf("invalid --sample: %q (want a share such as 1%% or 0.01)", s)
+		}
+		return f, nil
+	case *sf.sampleDirs < 0:
+		return 0, fmt.Errorf("invalid --sample-dirs: %d", *sf.sampleDirs)
+	case *sf.sampleDirs > 0:
+		var total int64
+		if key := dirCountKey(cfg); key != "" {
+			total = loadDirCounts()[key]
+		}
+		if total <= 0 {
+			return 0, errors.New("--sample-dirs needs the directory count of an earlier search of the same roots, recorded by --progress or --sample; use --sample for a first estimate")
+		}
+		return min(float64(*sf.sampleDirs)/float64(total), 1), nil
+	}
+	return 0, nil
+}
+
+// ageBucketEnricher records under "ageBucket" the label of the age bucket
+// (as in analyze's AGE table) holding each entry's modification time,
+// measured from now.
+func ageBucketEnricher(bounds []time.Duration, now time.Time) finder.Enricher {
+	labels := stats.AgeLabels(bounds)
+	return finder.EnricherFunc(func(_ context.Context, e *finder.Entry) error {
+		e.SetExtra("ageBucket", labels[stats.AgeIndex(bounds, now.Sub(e.ModTime))])
+		return nil
+	})
+}
+
+// set reports whether the flag name was given on the command line.
+func (sf *searchFlags) set(name string) bool {
+	found := false
+	sf.fs.Visit(func(f *flag.Flag) { found = found || f.Name == name })
+	return found
+}
+
+// metaConfig lists the flags given, and the root, for the --emit-meta start
+// record. Credentials are masked.
+func (sf *searchFlags) metaConfig() map[string]string {
+	m := map[string]string{"root": *sf.root}
+	sf.fs.Visit(func(f *flag.Flag) {
+		m[f.Name] = redactFlag(f.Name, f.Value.String())
+	})
+	return m
+}
+
+// redactFlag returns the value v of the flag name with the secrets it may
+// carry, webhook tokens and broker passwords, masked.
+func redactFlag(name, v string) string {
+	switch name {
+	case "webhook":
+		return redactQuery(v)
+	case "publish":
+		if u, err := url.Parse(v); err == nil {
+			return u.Redacted()
+		}
+	case "webhook-header":
+		return "xxxxx"
+	}
+	return v
+}
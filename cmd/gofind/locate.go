@@ -0,0 +1,206 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/Hamed0406/gofind/internal/finder"
+	"github.com/Hamed0406/gofind/internal/locatedb"
+	"github.com/Hamed0406/gofind/internal/pathtab"
+)
+
+// defaultDBPath returns the locate database location under the user cache dir.
+func defaultDBPath() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "gofind", "locate.db")
+}
+
+// runUpdatedb walks a root and (re)writes the locate database.
+func runUpdatedb(args []string) int {
+	fs := flag.NewFlagSet("updatedb", flag.ContinueOnError)
+	root := fs.String("root", ".", "root directory to index, or @NAME for a saved root")
+	dbPath := fs.String("db", defaultDBPath(), "database file to write")
+	includeHid := fs.Bool("include-hidden", false, "index hidden files and directories")
+	followSyms := fs.Bool("follow-symlinks", false, "follow symlinked directories")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	dir, err := resolveSingleRoot(*root)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	absRoot, err := filepath.Abs(dir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid --root: %v\n", err)
+		return 2
+	}
+	cfg := finder.Config{
+		Root:           absRoot,
+		MaxDepth:       -1,
+		IncludeHidden:  *includeHid,
+		FollowSymlinks: *followSyms,
+	}
+	// Paths are held prefix-compressed until written: a big tree's full
+	// paths would take several times the memory.
+	type record struct {
+		key   pathtab.Key
+		isDir bool
+	}
+	var (
+		tab     pathtab.Table
+		records []record
+	)
+	_, err = finder.Walk(context.Background(), cfg, func(e finder.Entry) error {
+		records = append(records, record{tab.Key(e.Path), e.IsDir})
+		return nil
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	if err := os.MkdirAll(filepath.Dir(*dbPath), 0o755); err != nil {
+		fmt.Fprintf(os.Stderr, "cannot create database directory: %v\n", err)
+		return 1
+	}
+	// Write to a sibling temp file and rename so concurrent locates never see
+	// a half-written database.
+	tmp, err := os.CreateTemp(filepath.Dir(*dbPath), ".locate-*.tmp")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cannot create database: %v\n", err)
+		return 1
+	}
+	w := bufio.NewWriter(tmp)
+	slices.SortFunc(records, func(a, b record) int { return tab.Compare(a.key, b.key) })
+	err = locatedb.WriteSorted(w, absRoot, func(yield func(locatedb.Record) bool) {
+		for _, r := range records {
+			if !yield(locatedb.Record{Path: tab.Path(r.key), IsDir: r.isDir}) {
+				return
+			}
+		}
+	})
+	if err == nil {
+		err = w.Flush()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), *dbPath)
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+		fmt.Fprintf(os.Stderr, "cannot write database %q: %v\n", *dbPath, err)
+		return 1
+	}
+	return 0
+}
+
+// runLocate answers a substring or glob query from the locate database.
+// Patterns without glob metacharacters match as substrings of the full path;
+// glob patterns match the base name, or the full path if they contain a slash.
+func runLocate(args []string) int {
+	fs := flag.NewFlagSet("locate", flag.ContinueOnError)
+	dbPath := fs.String("db", defaultDBPath(), "database file to query")
+	ignoreCase := fs.Bool("i", false, "match case-insensitively")
+	limit := fs.Int("limit", 0, "stop after this many results (0 = no limit)")
+	extsCSV := fs.String("ext", "", "comma-separated list of file extensions to include")
+	nameReStr := fs.String("name-regex", "", "regex to match file/dir names")
+	normUnicode := fs.Bool("normalize-unicode", false, "compare names in Unicode NFC form (matches macOS NFD names)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: gofind locate [flags] PATTERN")
+		return 2
+	}
+
+	match, err := locateMatcher(fs.Arg(0), *ignoreCase, *normUnicode)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid pattern: %v\n", err)
+		return 2
+	}
+	cfg := finder.Config{Extensions: parseExts(*extsCSV), NormalizeUnicode: *normUnicode}
+	if rs := strings.TrimSpace(*nameReStr); rs != "" {
+		if *normUnicode {
+			rs = finder.NFC(rs)
+		}
+		re, err := regexp.Compile(rs)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid --name-regex: %v\n", err)
+			return 2
+		}
+		cfg.NameRegex = re
+	}
+
+	f, err := os.Open(*dbPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cannot open database (run \"gofind updatedb\" first): %v\n", err)
+		return 1
+	}
+	defer func() { _ = f.Close() }()
+
+	out := bufio.NewWriter(os.Stdout)
+	n := 0
+	_, err = locatedb.Read(f, func(r locatedb.Record) bool {
+		if !match(r.Path) || !cfg.MatchName(filepath.Base(r.Path), r.IsDir) {
+			return true
+		}
+		_, _ = fmt.Fprintln(out, r.Path)
+		n++
+		return *limit <= 0 || n < *limit
+	})
+	if ferr := out.Flush(); err == nil {
+		err = ferr
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "read database %q: %v\n", *dbPath, err)
+		return 1
+	}
+	if n == 0 {
+		return 1
+	}
+	return 0
+}
+
+// locateMatcher compiles a locate pattern into a path predicate. With nfc,
+// pattern and paths are compared in Unicode normalization form C.
+func locateMatcher(pattern string, ignoreCase, nfc bool) (func(string) bool, error) {
+	fold := func(s string) string {
+		if nfc {
+			s = finder.NFC(s)
+		}
+		if ignoreCase {
+			return strings.ToLower(s)
+		}
+		return s
+	}
+	pattern = fold(pattern)
+	if !strings.ContainsAny(pattern, "*?[") {
+		return func(p string) bool { return strings.Contains(fold(p), pattern) }, nil
+	}
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return nil, err
+	}
+	wholePath := strings.ContainsRune(pattern, '/')
+	return func(p string) bool {
+		target := filepath.Base(p)
+		if wholePath {
+			target = filepath.ToSlash(p)
+		}
+		ok, _ := filepath.Match(pattern, fold(target))
+		return ok
+	}, nil
+}
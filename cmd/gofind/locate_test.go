@@ -0,0 +1,53 @@
+package main
+
+import (
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestCLI_UpdatedbAndLocate(t *testing.T) {
+	bin := buildCLI(t)
+	td := t.TempDir()
+	_ = mk(t, td, "src/alpha.go", 1)
+	_ = mk(t, td, "src/beta.md", 1)
+	_ = mk(t, td, "docs/alpha.txt", 1)
+	db := filepath.Join(t.TempDir(), "locate.db")
+
+	if out, err := exec.Command(bin, "updatedb", "-root", td, "-db", db).CombinedOutput(); err != nil {
+		t.Fatalf("updatedb: %v; out=%s", err, out)
+	}
+
+	locate := func(args ...string) []string {
+		args = append([]string{"locate", "-db", db}, args...)
+		out, err := exec.Command(bin, args...).Output()
+		if err != nil {
+			if ee, ok := err.(*exec.ExitError); !ok || ee.ExitCode() != 1 {
+				t.Fatalf("locate %v: %v", args, err)
+			}
+		}
+		var names []string
+		for _, ln := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+			if ln != "" {
+				names = append(names, filepath.Base(ln))
+			}
+		}
+		sort.Strings(names)
+		return names
+	}
+
+	if got := locate("alpha"); strings.Join(got, ",") != "alpha.go,alpha.txt" {
+		t.Fatalf("substring query: got %v", got)
+	}
+	if got := locate("*.md"); strings.Join(got, ",") != "beta.md" {
+		t.Fatalf("glob query: got %v", got)
+	}
+	if got := locate("-ext", ".go", "ALPHA"); len(got) != 0 {
+		t.Fatalf("case-sensitive query should miss: got %v", got)
+	}
+	if got := locate("-i", "-ext", ".go", "ALPHA"); strings.Join(got, ",") != "alpha.go" {
+		t.Fatalf("-i with --ext: got %v", got)
+	}
+}
@@ -3,12 +3,13 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
+	"log/slog"
 	"os"
-	"regexp"
-	"runtime"
 	"strings"
 	"time"
 
@@ -16,137 +17,315 @@ import (
 	"github.com/Hamed0406/gofind/pkg/version"
 )
 
+// subcommands maps the first CLI argument to a handler returning the exit code.
+// Anything else is parsed as flags for a regular search. A leading @NAME
+// argument, of a subcommand or of the search, stands for --root @NAME.
+var subcommands = map[string]func(args []string) int{
+	"updatedb": runUpdatedb,
+	"locate":   runLocate,
+	"apply":    runApply,
+}
+
 func main() {
-	var (
-		showVersion = flag.Bool("version", false, "print gofind version and exit")
-
-		root        = flag.String("root", ".", "root directory to search")
-		extsCSV     = flag.String("ext", "", "comma-separated list of file extensions to include (e.g. \".go,.md\")")
-		nameReStr   = flag.String("name-regex", "", "regex to match file/dir names")
-		minSizeStr  = flag.String("min-size", "", "minimum size to include (e.g. 10KB, 2MB, 1G)")
-		maxSizeStr  = flag.String("max-size", "", "maximum size to include (e.g. 500KB, 10MB)")
-		afterStr    = flag.String("after", "", "include entries modified after this time (YYYY-MM-DD or RFC3339)")
-		beforeStr   = flag.String("before", "", "include entries modified before this time (YYYY-MM-DD or RFC3339)")
-		includeHid  = flag.Bool("include-hidden", false, "include hidden files (Unix dotfiles and Windows hidden attribute)")
-		maxDepth    = flag.Int("max-depth", -1, "maximum directory depth (-1 = unlimited, 0 = only root's direct children)")
-		jsonOut     = flag.Bool("json", false, "stream JSON output instead of plain lines")
-		ndjsonOut   = flag.Bool("ndjson", false, "stream newline-delimited JSON entries")
-		prettyJSON  = flag.Bool("pretty", false, "pretty-print JSON output")
-		outPath     = flag.String("out", "", "write output to this file instead of stdout")
-		followSyms  = flag.Bool("follow-symlinks", false, "follow symlinked directories")
-		concurrency = flag.Int("concurrency", runtime.NumCPU(), "number of concurrent directory workers")
-	)
-	flag.Parse()
+	if len(os.Args) > 1 {
+		if cmd, ok := subcommands[os.Args[1]]; ok {
+			os.Exit(cmd(rootAliasArgs(os.Args[2:])))
+		}
+	}
+
+	os.Exit(runMain(flag.CommandLine, rootAliasArgs(os.Args[1:])))
+}
+
+// runMain runs the regular search, or --version or --why, with the flags in
+// args parsed into fs, and returns the exit status.
+func runMain(fs *flag.FlagSet, args []string) int {
+	sf := defineSearchFlags(fs)
+	sf.actions = true
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
 
 	// --version: print and exit
-	if *showVersion {
+	if *sf.showVersion {
 		// version.Version is set via -ldflags, defaults to "dev"
 		fmt.Println(version.Version)
-		return
+		return 0
 	}
 
-	cfg := finder.Config{
-		Root:           *root,
-		IncludeHidden:  *includeHid,
-		MaxDepth:       *maxDepth,
-		Concurrency:    *concurrency,
-		OutputFormat:   finder.OutputText,
-		PrettyJSON:     *prettyJSON,
-		FollowSymlinks: *followSyms,
+	cfg, err := sf.config()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
 	}
 
-	// extensions
-	if s := strings.TrimSpace(*extsCSV); s != "" {
-		cfg.Extensions = make(map[string]bool)
-		for _, e := range strings.Split(s, ",") {
-			e = strings.ToLower(strings.TrimSpace(e))
-			if e == "" {
-				continue
-			}
-			if !strings.HasPrefix(e, ".") {
-				e = "." + e
-			}
-			cfg.Extensions[e] = true
-		}
+	// --why: explain a single path instead of searching
+	if *sf.why != "" {
+		return runExplain(cfg, *sf.why)
 	}
 
-	// name regex
-	if rs := strings.TrimSpace(*nameReStr); rs != "" {
-		re, err := regexp.Compile(rs)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "invalid --name-regex: %v\n", err)
-			os.Exit(2)
-		}
-		cfg.NameRegex = re
+	stopProfiles, err := sf.startProfiles()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
 	}
+	defer stopProfiles()
+	return runSearch(sf, cfg)
+}
 
-	// size filters
-	if *minSizeStr != "" {
-		n, err := parseSize(*minSizeStr)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "invalid --min-size: %v\n", err)
-			os.Exit(2)
+// runSearch runs the regular search and returns the exit status.
+func runSearch(sf *searchFlags, cfg finder.Config) int {
+	start := time.Now()
+	// choose output writer (stdout by default; file if -out given, in
+	// addition to stdout with --out-format)
+	var (
+		out      io.Writer
+		closeOut func() error
+		err      error
+	)
+	if *sf.shardSize != "" || *sf.shardByDir {
+		out, closeOut, err = createShardedOutput(sf, cfg)
+	} else {
+		out, closeOut, err = createOutput(*sf.outPath, *sf.compress)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	defer closeOut()
+	if *sf.outFormat != "" {
+		cfg.Sinks = append(cfg.Sinks, finder.Sink{Writer: out, Format: sf.outFileFormat(cfg), Pretty: cfg.PrettyJSON})
+		out = os.Stdout
+	}
+
+	ctx, cancel := signalContext(*sf.timeout)
+	defer cancel()
+
+	// actions
+	if *sf.deleteMatches || *sf.moveTo != "" {
+		if *sf.report != "" {
+			fmt.Fprintln(os.Stderr, "--report describes searches; record --delete and --move-to with --plan or --audit")
+			return 2
+		}
+		code := runActions(ctx, out, cfg, *sf.deleteMatches, *sf.toTrash, *sf.moveTo, *sf.planOnly, *sf.auditLog, sf.forced())
+		if err := closeOut(); err != nil && code == 0 {
+			fmt.Fprintln(os.Stderr, err)
+			code = 1
 		}
-		cfg.MinSize = n
+		return code
 	}
-	if *maxSizeStr != "" {
-		n, err := parseSize(*maxSizeStr)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "invalid --max-size: %v\n", err)
-			os.Exit(2)
+
+	if cfg.Checkpoint, err = openCheckpoint(*sf.checkpoint, *sf.resume); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	if *sf.dirCache != "" {
+		if cfg.DirCache, err = finder.OpenDirCache(*sf.dirCache); err != nil {
+			fmt.Fprintf(os.Stderr, "--dir-cache: %v\n", err)
+			return 2
 		}
-		cfg.MaxSize = n
 	}
 
-	// time filters
-	if *afterStr != "" {
-		t, err := parseTime(*afterStr)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "invalid --after: %v\n", err)
-			os.Exit(2)
+	closeHook, err := sf.addWebhook(&cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	closePub, err := sf.addPublisher(&cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	res, err := finder.Run(ctx, out, cfg)
+	if err == nil && sf.listErr != nil {
+		err = fmt.Errorf("reading --files-from: %v", sf.listErr)
+	}
+	if herr := errors.Join(closeHook(), closePub()); err == nil && herr != nil {
+		err = herr
+	}
+	if cfg.Checkpoint != nil {
+		if cerr := cfg.Checkpoint.Close(); err == nil && cerr != nil {
+			err = fmt.Errorf("writing checkpoint: %w", cerr)
 		}
-		cfg.After = t
 	}
-	if *beforeStr != "" {
-		t, err := parseTime(*beforeStr)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "invalid --before: %v\n", err)
-			os.Exit(2)
+	if cfg.DirCache != nil {
+		if cerr := cfg.DirCache.Close(); err == nil && cerr != nil {
+			err = fmt.Errorf("writing --dir-cache: %w", cerr)
+		}
+		if cfg.Logger != nil {
+			hits, misses := cfg.DirCache.Stats()
+			cfg.Logger.Debug("dir cache", "hits", hits, "misses", misses)
 		}
-		cfg.Before = t
 	}
+	// Closing flushes a --compress stream, so its error matters too.
+	if cerr := closeOut(); err == nil && cerr != nil {
+		err = fmt.Errorf("closing output: %w", cerr)
+	}
+	if (*sf.progress || cfg.Sample > 0) && err == nil {
+		saveDirCount(cfg, res)
+	}
+	reportTruncated(res)
+	for _, root := range res.SkippedRoots {
+		fmt.Fprintf(os.Stderr, "--skip-missing-roots: skipped %s, which does not exist\n", root)
+	}
+	code := searchStatus(res, err)
+	if *sf.report != "" {
+		if rerr := writeScanReport(*sf.report, sf, start, res, err, code); rerr != nil {
+			fmt.Fprintf(os.Stderr, "--report: %v\n", rerr)
+			if code == 0 {
+				code = 1
+			}
+		}
+	}
+	return code
+}
+
+// openCheckpoint opens the --checkpoint file, or continues the --resume one.
+func openCheckpoint(checkpoint, resume string) (*finder.Checkpoint, error) {
+	switch {
+	case resume != "" && checkpoint != "" && checkpoint != resume:
+		return nil, fmt.Errorf("--resume %s keeps recording to that file; drop --checkpoint or name the same file", resume)
+	case resume != "":
+		return finder.OpenCheckpoint(resume, true)
+	case checkpoint != "":
+		return finder.OpenCheckpoint(checkpoint, false)
+	}
+	return nil, nil
+}
 
-	// output format selection
-	if *jsonOut {
-		cfg.OutputFormat = finder.OutputJSON
+// reportTruncated notes on stderr how many matches --max-per-dir left out.
+func reportTruncated(res finder.Result) {
+	if res.TruncatedCount == 0 {
+		return
 	}
-	if *ndjsonOut {
-		// If both --json and --ndjson are given, prefer NDJSON.
-		cfg.OutputFormat = finder.OutputNDJSON
+	var omitted int64
+	for _, d := range res.Truncated {
+		omitted += d.Omitted
 	}
+	more := ""
+	if int64(len(res.Truncated)) < res.TruncatedCount {
+		more = " or more"
+	}
+	fmt.Fprintf(os.Stderr, "--max-per-dir: %d%s matches omitted from %d directories (see gofind analyze)\n", omitted, more, res.TruncatedCount)
+}
 
-	// choose output writer (stdout by default; file if -out given)
-	var out io.Writer = os.Stdout
-	if s := strings.TrimSpace(*outPath); s != "" {
-		f, err := os.Create(s)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "cannot create output file %q: %v\n", s, err)
-			os.Exit(2)
+// searchStatus reports the outcome of a search on stderr and returns the exit
+// status: 130 when interrupted, 124 when --timeout expired, 1 on other errors.
+// A search stopped by --max-time succeeds with a note.
+func searchStatus(res finder.Result, err error) int {
+	if res.Interrupted {
+		reason, code := "interrupted", 130
+		if errors.Is(err, context.DeadlineExceeded) {
+			reason, code = "timed out", 124
 		}
-		// Close best-effort; log/ignore to satisfy errcheck.
-		defer func() {
-			_ = f.Close()
-		}()
-		out = f
+		fmt.Fprintf(os.Stderr, "%s: %d entries emitted, %d directories visited\n", reason, res.Matched, res.DirsVisited)
+		return code
 	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if res.Partial {
+		fmt.Fprintf(os.Stderr, "partial: --max-time reached; %d entries emitted, %d directories visited\n", res.Matched, res.DirsVisited)
+	}
+	return 0
+}
 
-	ctx := context.Background()
-	if err := finder.Run(ctx, out, cfg); err != nil {
+// runExplain prints the verdict for a single path (as JSON when a JSON output
+// format was selected). The exit status is 0 if the path would be emitted.
+func runExplain(cfg finder.Config, path string) int {
+	ex, err := finder.Explain(cfg, path)
+	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+		return 2
+	}
+	if cfg.OutputFormat != finder.OutputText {
+		b, _ := json.Marshal(ex)
+		fmt.Println(string(b))
+	} else {
+		verdict := "included"
+		if !ex.Included {
+			verdict = "excluded by " + ex.Rule
+		}
+		fmt.Printf("%s: %s (%s)\n", ex.Path, verdict, ex.Detail)
+	}
+	if !ex.Included {
+		return 1
+	}
+	return 0
+}
+
+// newLogger builds a debug-level slog logger writing to stderr.
+func newLogger(format string) (*slog.Logger, error) {
+	opts := &slog.HandlerOptions{Level: slog.LevelDebug}
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case "", "text":
+		return slog.New(slog.NewTextHandler(os.Stderr, opts)), nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(os.Stderr, opts)), nil
+	default:
+		return nil, fmt.Errorf("%q (want text or json)", format)
 	}
 }
 
+// stringList is a repeatable string flag.
+type stringList []string
+
+func (s *stringList) String() string { return strings.Join(*s, ",") }
+
+func (s *stringList) Set(v string) error {
+	*s = append(*s, v)
+	return nil
+}
+
+// parseExts turns a comma-separated extension list into the lowercase,
+// dot-prefixed set used by finder.Config. It returns nil for an empty list.
+func parseExts(csv string) map[string]bool {
+	s := strings.TrimSpace(csv)
+	if s == "" {
+		return nil
+	}
+	exts := make(map[string]bool)
+	for _, e := range strings.Split(s, ",") {
+		e = strings.ToLower(strings.TrimSpace(e))
+		if e == "" {
+			continue
+		}
+		if !strings.HasPrefix(e, ".") {
+			e = "." + e
+		}
+		exts[e] = true
+	}
+	return exts
+}
+
+// parseFSTypes splits a comma-separated filesystem type list into include and
+// exclude ("!type") sets.
+func parseFSTypes(csv string) (include, exclude map[string]bool) {
+	for _, t := range strings.Split(csv, ",") {
+		t = strings.TrimSpace(t)
+		neg := strings.HasPrefix(t, "!")
+		// Detected types are normalized, so ext3 must become ext4 too.
+		t = finder.NormalizeFSType(strings.TrimPrefix(t, "!"))
+		if t == "" {
+			continue
+		}
+		if neg {
+			if exclude == nil {
+				exclude = make(map[string]bool)
+			}
+			exclude[t] = true
+			continue
+		}
+		if include == nil {
+			include = make(map[string]bool)
+		}
+		include[t] = true
+	}
+	return include, exclude
+}
+
 func parseSize(s string) (int64, error) {
 	s = strings.TrimSpace(strings.ToUpper(s))
 	mult := int64(1)
@@ -179,6 +358,27 @@ func parseSize(s string) (int64, error) {
 	return n * mult, nil
 }
 
+// parseAge parses an age such as "180d", "2w", "1y" or any time.Duration
+// ("36h"). A day is 24h and a year 365 days.
+func parseAge(s string) (time.Duration, error) {
+	s = strings.TrimSpace(strings.ToLower(s))
+	units := map[byte]time.Duration{'d': 24 * time.Hour, 'w': 7 * 24 * time.Hour, 'y': 365 * 24 * time.Hour}
+	if n := len(s); n > 1 {
+		if unit, ok := units[s[n-1]]; ok {
+			var v int64
+			if _, err := fmt.Sscan(s[:n-1], &v); err != nil || v < 0 {
+				return 0, fmt.Errorf("could not parse age %q", s)
+			}
+			return time.Duration(v) * unit, nil
+		}
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("could not parse age %q (want e.g. 180d, 2w, 1y, 36h)", s)
+	}
+	return d, nil
+}
+
 func parseTime(s string) (time.Time, error) {
 	s = strings.TrimSpace(s)
 	// Try YYYY-MM-DD
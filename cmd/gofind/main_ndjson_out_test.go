@@ -3,6 +3,7 @@ package main
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -98,3 +99,83 @@ func TestCLI_OutFile_WritesAndMutesStdout(t *testing.T) {
 		t.Fatalf("expected some entries in output file")
 	}
 }
+
+func TestCLI_OutFormat_TeesToStdout(t *testing.T) {
+	bin := buildCLI(t)
+	td := t.TempDir()
+	if err := os.WriteFile(filepath.Join(td, "d.txt"), []byte("z"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	outFile := filepath.Join(t.TempDir(), "out.ndjson")
+
+	stdout, err := exec.Command(bin, "-root", td, "-out", outFile, "-out-format", "ndjson").Output()
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if strings.TrimSpace(string(stdout)) != filepath.Join(td, "d.txt") {
+		t.Fatalf("stdout should list paths as text; got %q", stdout)
+	}
+	data, err := os.ReadFile(outFile)
+	if err != nil {
+		t.Fatalf("read out file: %v", err)
+	}
+	var e cliEntry
+	if err := json.Unmarshal(data, &e); err != nil || e.Name != "d.txt" {
+		t.Fatalf("out file should hold one NDJSON entry; got %q (%v)", data, err)
+	}
+}
+
+func TestCLI_EmitMeta(t *testing.T) {
+	bin := buildCLI(t)
+	td := t.TempDir()
+	_ = mk(t, td, "a.txt", 1)
+
+	out, err := exec.Command(bin, "-root", td, "-ndjson", "--emit-meta", "--ext", ".txt").Output()
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected start, entry and end records: %s", out)
+	}
+	var start, end struct {
+		Type    string            `json:"type"`
+		Event   string            `json:"event"`
+		Config  map[string]string `json:"config"`
+		Matched int64             `json:"matched"`
+	}
+	if err := json.Unmarshal([]byte(lines[0]), &start); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(lines[2]), &end); err != nil {
+		t.Fatal(err)
+	}
+	if start.Type != "meta" || start.Event != "start" || start.Config["root"] != td || start.Config["ext"] != ".txt" {
+		t.Fatalf("unexpected start record: %s", lines[0])
+	}
+	if end.Type != "meta" || end.Event != "end" || end.Matched != 1 {
+		t.Fatalf("unexpected end record: %s", lines[2])
+	}
+}
+
+func TestCLI_Fields(t *testing.T) {
+	bin := buildCLI(t)
+	td := t.TempDir()
+	_ = mk(t, td, "a.txt", 4)
+
+	out, err := exec.Command(bin, "-root", td, "-ndjson", "--fields", "size, path").Output()
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	want := fmt.Sprintf(`{"size":4,"path":%q}`, filepath.Join(td, "a.txt"))
+	if got := strings.TrimSpace(string(out)); got != want {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+
+	cmd := exec.Command(bin, "-root", td, "-ndjson", "--fields", "path,bogus")
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err == nil || !strings.Contains(stderr.String(), `"bogus"`) {
+		t.Fatalf("unknown field accepted: %v %q", err, stderr.String())
+	}
+}
@@ -141,6 +141,40 @@ func TestCLI_MaxDepth_Limits(t *testing.T) {
 	_ = runtime.GOMAXPROCS(1)
 }
 
+func TestCLI_FindDepth(t *testing.T) {
+	bin := buildCLI(t)
+	td := t.TempDir()
+	a := mk(t, td, "a.txt", 1)
+	b := mk(t, td, "d1/b.txt", 1)
+
+	run := func(args ...string) []string {
+		cmd := exec.Command(bin, append([]string{"-root", td}, args...)...)
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		out, err := cmd.Output()
+		if err != nil {
+			t.Fatalf("%v: %v; stderr=%s", args, err, stderr.String())
+		}
+		got := strings.Fields(string(out))
+		sort.Strings(got)
+		return got
+	}
+
+	// As in find(1), the root is depth 0 and is listed itself.
+	if got := run("-maxdepth", "0"); len(got) != 1 || got[0] != td {
+		t.Fatalf("-maxdepth 0: %q", got)
+	}
+	if got := run("-maxdepth", "1", "-mindepth", "1", "-type", "f"); len(got) != 1 || got[0] != a {
+		t.Fatalf("-maxdepth 1 -mindepth 1: %q", got)
+	}
+	if got := run("-mindepth", "2"); len(got) != 1 || got[0] != b {
+		t.Fatalf("-mindepth 2: %q", got)
+	}
+	if err := exec.Command(bin, "-root", td, "-maxdepth", "1", "-max-depth", "1").Run(); err == nil {
+		t.Fatal("--max-depth combined with --maxdepth accepted")
+	}
+}
+
 func TestCLI_NDJSON(t *testing.T) {
 	bin := buildCLI(t)
 	td := t.TempDir()
@@ -165,6 +199,24 @@ func TestCLI_NDJSON(t *testing.T) {
 	}
 }
 
+func TestCLI_OutputJSONSeq(t *testing.T) {
+	bin := buildCLI(t)
+	td := t.TempDir()
+	_ = mk(t, td, "a.txt", 1)
+
+	out, err := exec.Command(bin, "-root", td, "--output", "json-seq").Output()
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	var e cliEntry
+	if !bytes.HasPrefix(out, []byte{0x1e}) || json.Unmarshal(out[1:], &e) != nil || e.Name != "a.txt" {
+		t.Fatalf("want one RS-prefixed record for a.txt, got %q", out)
+	}
+	if err := exec.Command(bin, "-root", td, "--output", "xml").Run(); err == nil {
+		t.Fatal("--output xml should be rejected")
+	}
+}
+
 func TestCLI_PrettyJSON(t *testing.T) {
 	bin := buildCLI(t)
 	td := t.TempDir()
@@ -293,3 +345,316 @@ func intToStr(n int) string {
 	}
 	return string(b[i:])
 }
+
+func TestCLI_TimeoutFlushesValidJSON(t *testing.T) {
+	bin := buildCLI(t)
+	td := t.TempDir()
+	_ = mk(t, td, "a.txt", 1)
+
+	cmd := exec.Command(bin, "-root", td, "-json", "-timeout", "1ns")
+	var out, stderr bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	ee, ok := err.(*exec.ExitError)
+	if !ok || ee.ExitCode() != 124 {
+		t.Fatalf("expected exit status 124, got %v; stderr=%s", err, stderr.String())
+	}
+	if !strings.Contains(stderr.String(), "timed out") {
+		t.Fatalf("expected summary on stderr, got %q", stderr.String())
+	}
+	var arr []cliEntry
+	if err := json.Unmarshal(out.Bytes(), &arr); err != nil {
+		t.Fatalf("output should stay valid JSON: %v\nraw: %s", err, out.String())
+	}
+}
+
+func TestCLI_VerboseJSONLogs(t *testing.T) {
+	bin := buildCLI(t)
+	td := t.TempDir()
+	_ = mk(t, td, "a.txt", 1)
+	_ = mk(t, td, "b.md", 1)
+
+	cmd := exec.Command(bin, "-root", td, "-ext", ".txt", "-v", "-log-format", "json")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("run: %v; stderr=%s", err, stderr.String())
+	}
+	var sawEnter, sawReject bool
+	for _, ln := range strings.Split(strings.TrimSpace(stderr.String()), "\n") {
+		var rec map[string]any
+		if err := json.Unmarshal([]byte(ln), &rec); err != nil {
+			t.Fatalf("log line is not JSON: %q", ln)
+		}
+		switch rec["msg"] {
+		case "enter directory":
+			sawEnter = true
+		case "filter rejected":
+			sawReject = rec["filter"] == "ext" && strings.HasSuffix(rec["path"].(string), "b.md")
+		}
+	}
+	if !sawEnter || !sawReject {
+		t.Fatalf("expected enter and reject logs, got:\n%s", stderr.String())
+	}
+}
+
+func TestCLI_FilesFromStdin(t *testing.T) {
+	bin := buildCLI(t)
+	td := t.TempDir()
+	a := mk(t, td, "a.txt", 1)
+	b := mk(t, td, "b.md", 1)
+	spaced := mk(t, td, "with space.txt", 1)
+
+	for name, list := range map[string]string{
+		"newline": a + "\n" + b + "\n" + spaced + "\n",
+		"nul":     a + "\x00" + b + "\x00" + spaced + "\x00",
+	} {
+		cmd := exec.Command(bin, "-files-from", "-", "-ext", ".txt")
+		cmd.Stdin = strings.NewReader(list)
+		out, err := cmd.Output()
+		if err != nil {
+			t.Fatalf("%s: run: %v", name, err)
+		}
+		got := strings.Split(strings.TrimSpace(string(out)), "\n")
+		sort.Strings(got)
+		if len(got) != 2 || got[0] != a || got[1] != spaced {
+			t.Fatalf("%s: expected %q and %q, got %q", name, a, spaced, got)
+		}
+	}
+}
+
+func TestCLI_MissingRoots(t *testing.T) {
+	bin := buildCLI(t)
+	td := t.TempDir()
+	a := mk(t, td, "here/a.txt", 1)
+	missing := filepath.Join(td, "gone")
+
+	cmd := exec.Command(bin, "-root", missing, "-json")
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err == nil || len(out) != 0 || !strings.Contains(stderr.String(), missing+" does not exist") {
+		t.Fatalf("missing root: err=%v stdout=%q stderr=%q", err, out, stderr.String())
+	}
+
+	cmd = exec.Command(bin, "-roots-from", "-", "-skip-missing-roots", "-ext", ".txt")
+	cmd.Stdin = strings.NewReader(missing + "\n" + filepath.Join(td, "here") + "\n")
+	stderr.Reset()
+	cmd.Stderr = &stderr
+	out, err = cmd.Output()
+	if err != nil || strings.TrimSpace(string(out)) != a || !strings.Contains(stderr.String(), missing) {
+		t.Fatalf("--skip-missing-roots: err=%v stdout=%q stderr=%q", err, out, stderr.String())
+	}
+}
+
+func TestCLI_IgnoreFiles(t *testing.T) {
+	bin := buildCLI(t)
+	td := t.TempDir()
+	_ = mk(t, td, "main.go", 1)
+	_ = mk(t, td, "debug.log", 1)
+	_ = mk(t, td, "dist/app.js", 1)
+	if err := os.WriteFile(filepath.Join(td, ".gitignore"), []byte("dist/\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(td, ".ignore"), []byte("*.log\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	run := func(args ...string) []string {
+		t.Helper()
+		cmd := exec.Command(bin, append([]string{"-root", td}, args...)...)
+		cmd.Env = append(os.Environ(), "XDG_CONFIG_HOME="+t.TempDir())
+		out, err := cmd.Output()
+		if err != nil {
+			t.Fatalf("run %v: %v", args, err)
+		}
+		var names []string
+		for _, ln := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+			rel, _ := filepath.Rel(td, ln)
+			names = append(names, filepath.ToSlash(rel))
+		}
+		sort.Strings(names)
+		return names
+	}
+
+	if got := strings.Join(run(), ","); got != "main.go" {
+		t.Fatalf("default: got %s", got)
+	}
+	if got := strings.Join(run("-no-ignore-dot"), ","); got != "debug.log,main.go" {
+		t.Fatalf("--no-ignore-dot: got %s", got)
+	}
+	if got := strings.Join(run("-no-ignore"), ","); got != "debug.log,dist,dist/app.js,main.go" {
+		t.Fatalf("--no-ignore: got %s", got)
+	}
+}
+
+func TestCLI_WhereAnyOfAndNot(t *testing.T) {
+	bin := buildCLI(t)
+	td := t.TempDir()
+	_ = mk(t, td, "Makefile", 1)
+	_ = mk(t, td, "README.md", 1)
+	_ = mk(t, td, "main.go", 1)
+	_ = mk(t, td, "docs/guide.md", 1)
+
+	// name matches X OR extension is .md, but not under docs/
+	cmd := exec.Command(bin, "-root", td,
+		"-where", `name =~ "^Make" || ext == ".md"`,
+		"-where-not", `rel =~ "^docs/"`)
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	var names []string
+	for _, ln := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		names = append(names, filepath.Base(ln))
+	}
+	sort.Strings(names)
+	if got := strings.Join(names, ","); got != "Makefile,README.md" {
+		t.Fatalf("got %s", got)
+	}
+
+	// An empty expression is no filter.
+	out, err = exec.Command(bin, "-root", td, "-where", "", "-where-not", " ").Output()
+	if err != nil {
+		t.Fatalf("empty --where: %v", err)
+	}
+	if n := strings.Count(string(out), "\n"); n != 5 {
+		t.Fatalf("empty --where printed %d entries, want all 5:\n%s", n, out)
+	}
+
+	cmd = exec.Command(bin, "-root", td, "-where-not", "size >")
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err == nil || !strings.Contains(stderr.String(), "invalid --where-not") {
+		t.Fatalf("want an invalid --where-not error, got %v: %s", err, stderr.String())
+	}
+}
+
+func TestCLI_Profiles(t *testing.T) {
+	bin := buildCLI(t)
+	td := t.TempDir()
+	mk(t, td, "a.txt", 10)
+	prof := t.TempDir()
+	cpu, mem, tr := filepath.Join(prof, "cpu.pprof"), filepath.Join(prof, "mem.pprof"), filepath.Join(prof, "trace.out")
+	out, err := exec.Command(bin, "--root", td, "--cpuprofile", cpu, "--memprofile", mem, "--trace", tr).CombinedOutput()
+	if err != nil {
+		t.Fatalf("run failed: %v; out=%s", err, out)
+	}
+	for _, p := range []string{cpu, mem, tr} {
+		if fi, err := os.Stat(p); err != nil || fi.Size() == 0 {
+			t.Errorf("%s: not written (%v)", filepath.Base(p), err)
+		}
+	}
+	// pprof files are gzipped protocol buffers.
+	for _, p := range []string{cpu, mem} {
+		if b, _ := os.ReadFile(p); !bytes.HasPrefix(b, []byte{0x1f, 0x8b}) {
+			t.Errorf("%s: not a pprof profile", filepath.Base(p))
+		}
+	}
+
+	bad := filepath.Join(td, "missing", "cpu.pprof")
+	if out, err := exec.Command(bin, "--root", td, "--cpuprofile", bad).CombinedOutput(); err == nil || !strings.Contains(string(out), "--cpuprofile") {
+		t.Errorf("unwritable --cpuprofile: err=%v out=%s", err, out)
+	}
+}
+
+func TestCLI_Quote(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Windows file names cannot hold control characters")
+	}
+	bin := buildCLI(t)
+	td := t.TempDir()
+	name := "evil\x1b]0;pwned\a name"
+	mk(t, td, name, 1)
+
+	// Piped output escapes control characters by default.
+	out, err := exec.Command(bin, "--root", td, "--type", "f").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(td, `evil\x1b]0;pwned\x07 name`) + "\n"; string(out) != want {
+		t.Errorf("default: got %q, want %q", out, want)
+	}
+	out, err = exec.Command(bin, "--root", td, "--type", "f", "--quote", "never").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(td, name) + "\n"; string(out) != want {
+		t.Errorf("--quote never: got %q, want %q", out, want)
+	}
+	out, err = exec.Command(bin, "--root", td, "--type", "f", "--quote", "always").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "$'" + filepath.Join(td, `evil\x1b]0;pwned\x07 name`) + "'\n"; string(out) != want {
+		t.Errorf("--quote always: got %q, want %q", out, want)
+	}
+	if err := exec.Command(bin, "--root", td, "--quote", "sometimes").Run(); err == nil {
+		t.Error("invalid --quote accepted")
+	}
+}
+
+func TestCLI_ContentRegex(t *testing.T) {
+	bin := buildCLI(t)
+	td := t.TempDir()
+	a := filepath.Join(td, "a.log")
+	if err := os.WriteFile(a, []byte("ok\nerror: disk full\nok\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	mk(t, td, "b.log", 10)
+
+	out, err := exec.Command(bin, "--root", td, "--content-regex", "error:", "--output", "grep", "-C", "1").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := a + "-1-ok\n" + a + ":2:error: disk full\n" + a + "-3-ok\n"; string(out) != want {
+		t.Errorf("--output grep: got %q, want %q", out, want)
+	}
+	out, err = exec.Command(bin, "--root", td, "--content-regex", "disk", "--ndjson").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var e struct {
+		Path    string
+		Matches []struct {
+			Line int
+			Text string
+		}
+	}
+	if err := json.Unmarshal(out, &e); err != nil || e.Path != a || len(e.Matches) != 1 || e.Matches[0].Line != 2 {
+		t.Errorf("--ndjson: %s (%v)", out, err)
+	}
+	if err := exec.Command(bin, "--root", td, "--output", "grep").Run(); err == nil {
+		t.Error("--output grep without --content-regex accepted")
+	}
+}
+
+func TestParseFSTypes(t *testing.T) {
+	for _, tc := range []struct {
+		csv              string
+		include, exclude []string
+	}{
+		{csv: "ext3", include: []string{"ext4"}},
+		{csv: "!ext3", exclude: []string{"ext4"}},
+		{csv: " XFS , ! ext2,nfs", include: []string{"xfs", "nfs"}, exclude: []string{"ext4"}},
+		{csv: ""},
+	} {
+		include, exclude := parseFSTypes(tc.csv)
+		if len(include) != len(tc.include) || len(exclude) != len(tc.exclude) {
+			t.Errorf("%q: got include=%v exclude=%v, want %v and %v", tc.csv, include, exclude, tc.include, tc.exclude)
+			continue
+		}
+		for _, ty := range tc.include {
+			if !include[ty] {
+				t.Errorf("%q: %s not included: %v", tc.csv, ty, include)
+			}
+		}
+		for _, ty := range tc.exclude {
+			if !exclude[ty] {
+				t.Errorf("%q: %s not excluded: %v", tc.csv, ty, exclude)
+			}
+		}
+	}
+}
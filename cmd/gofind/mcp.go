@@ -0,0 +1,332 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Hamed0406/gofind/internal/finder"
+	"github.com/Hamed0406/gofind/internal/ignore"
+	"github.com/Hamed0406/gofind/internal/mcp"
+	"github.com/Hamed0406/gofind/pkg/version"
+)
+
+func init() {
+	subcommands["mcp"] = runMCP
+}
+
+// errLimit stops a walk once a tool has collected enough entries.
+var errLimit = errors.New("result limit reached")
+
+// mcpSandbox confines the MCP tools to the allowed roots. Paths are resolved
+// through symlinks before they are checked, and symlinks are never followed
+// by a search, so nothing outside the roots is read.
+type mcpSandbox struct {
+	roots []string // absolute, symlinks resolved
+	// given are the roots as given, made absolute, which paths may use too.
+	given   []string
+	limit   int
+	timeout time.Duration
+}
+
+// runMCP serves the finder as Model Context Protocol tools over stdio.
+func runMCP(args []string) int {
+	fs := flag.NewFlagSet("mcp", flag.ContinueOnError)
+	var allow stringList
+	fs.Var(&allow, "allow-root", "directory the tools may read below (repeatable; default: the current directory)")
+	limit := fs.Int("max-results", 1000, "most entries a search_files or read_dir call returns")
+	timeout := fs.Duration("call-timeout", time.Minute, "stop a tool call after this long (0 = no limit)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if len(allow) == 0 {
+		allow = stringList{"."}
+	}
+	if *limit <= 0 {
+		fmt.Fprintf(os.Stderr, "mcp: invalid --max-results: %d\n", *limit)
+		return 2
+	}
+	sb := &mcpSandbox{limit: *limit, timeout: *timeout}
+	for _, r := range allow {
+		real, err := realPath(r)
+		if err == nil {
+			var fi os.FileInfo
+			if fi, err = os.Stat(real); err == nil && !fi.IsDir() {
+				err = errors.New("not a directory")
+			}
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "mcp: --allow-root %s: %v\n", r, err)
+			return 2
+		}
+		abs, _ := filepath.Abs(r) // realPath succeeded
+		sb.roots = append(sb.roots, real)
+		sb.given = append(sb.given, abs)
+	}
+
+	ctx, cancel := signalContext(0)
+	defer cancel()
+	srv := &mcp.Server{Name: "gofind", Version: version.Version, Tools: sb.tools()}
+	if err := srv.Serve(ctx, os.Stdin, os.Stdout); err != nil && ctx.Err() == nil {
+		fmt.Fprintf(os.Stderr, "mcp: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// realPath returns p as an absolute path with symlinks resolved.
+func realPath(p string) (string, error) {
+	abs, err := filepath.Abs(p)
+	if err != nil {
+		return "", err
+	}
+	return filepath.EvalSymlinks(abs)
+}
+
+// resolve maps a tool's path argument, absolute or relative to the first
+// allowed root, to a real path inside an allowed root.
+func (sb *mcpSandbox) resolve(p string) (string, error) {
+	if p == "" {
+		return sb.roots[0], nil
+	}
+	abs := p
+	if !filepath.IsAbs(abs) {
+		abs = filepath.Join(sb.roots[0], abs)
+	}
+	// Checked before resolving too, so errors say nothing about what
+	// exists outside the roots.
+	if clean := filepath.Clean(abs); !within(clean, sb.roots) && !within(clean, sb.given) {
+		return "", fmt.Errorf("%s is outside the allowed roots (%s)", p, strings.Join(sb.roots, ", "))
+	}
+	real, err := realPath(abs)
+	if err != nil {
+		return "", err
+	}
+	if !within(real, sb.roots) {
+		return "", fmt.Errorf("%s is outside the allowed roots (%s)", p, strings.Join(sb.roots, ", "))
+	}
+	return real, nil
+}
+
+// within reports whether p is one of roots or below one.
+func within(p string, roots []string) bool {
+	for _, root := range roots {
+		rel, err := filepath.Rel(root, p)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// collect runs a search for a tool, returning at most limit entries and
+// whether more were left out.
+func (sb *mcpSandbox) collect(ctx context.Context, cfg finder.Config, limit int) ([]finder.Entry, bool, finder.Result, error) {
+	if limit <= 0 || limit > sb.limit {
+		limit = sb.limit
+	}
+	if sb.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, sb.timeout)
+		defer cancel()
+	}
+	entries := []finder.Entry{}
+	truncated := false
+	res, err := finder.Walk(ctx, cfg, func(e finder.Entry) error {
+		if len(entries) == limit {
+			truncated = true
+			return errLimit
+		}
+		entries = append(entries, e)
+		return nil
+	})
+	if errors.Is(err, errLimit) {
+		err = nil
+	}
+	return entries, truncated || res.Interrupted, res, err
+}
+
+// listing is the result of search_files and read_dir.
+type listing struct {
+	Entries []finder.Entry `json:"entries"`
+	// Truncated is set when the limit or the call timeout cut the listing
+	// short.
+	Truncated bool `json:"truncated"`
+	// Errors counts entries that could not be read.
+	Errors int64 `json:"errors,omitempty"`
+}
+
+func (sb *mcpSandbox) tools() []mcp.Tool {
+	return []mcp.Tool{
+		{
+			Name:        "search_files",
+			Description: "Search a directory tree for files and directories matching filters. Files excluded by .gitignore, .ignore and .fdignore, and hidden entries, are skipped unless asked for.",
+			InputSchema: json.RawMessage(`{
+  "type": "object",
+  "properties": {
+    "root": {"type": "string", "description": "Directory to search, absolute or relative to the first allowed root (default: the first allowed root)"},
+    "extensions": {"type": "array", "items": {"type": "string"}, "description": "Only files with one of these extensions, e.g. [\".go\", \".md\"]"},
+    "nameRegex": {"type": "string", "description": "Regular expression (Go syntax) the base name must match"},
+    "where": {"type": "string", "description": "Filter expression over name, path, rel, ext, size, mtime and type (file, dir, symlink), e.g. size > 1MB && mtime > now() - 7d"},
+    "maxDepth": {"type": "integer", "description": "-1 for unlimited (the default), 0 for the root's entries only"},
+    "includeHidden": {"type": "boolean", "description": "Include hidden files and directories"},
+    "noIgnore": {"type": "boolean", "description": "Include files excluded by ignore files"},
+    "limit": {"type": "integer", "description": "Most entries to return"}
+  },
+  "additionalProperties": false
+}`),
+			Call: sb.searchFiles,
+		},
+		{
+			Name:        "stat_file",
+			Description: "Return the metadata (size, mode, modification time, type) of one file or directory.",
+			InputSchema: json.RawMessage(`{
+  "type": "object",
+  "properties": {
+    "path": {"type": "string", "description": "Path, absolute or relative to the first allowed root"}
+  },
+  "required": ["path"],
+  "additionalProperties": false
+}`),
+			Call: sb.statFile,
+		},
+		{
+			Name:        "read_dir",
+			Description: "List the entries of one directory with their metadata.",
+			InputSchema: json.RawMessage(`{
+  "type": "object",
+  "properties": {
+    "path": {"type": "string", "description": "Directory, absolute or relative to the first allowed root (default: the first allowed root)"},
+    "includeHidden": {"type": "boolean", "description": "Include hidden entries"},
+    "limit": {"type": "integer", "description": "Most entries to return"}
+  },
+  "additionalProperties": false
+}`),
+			Call: sb.readDir,
+		},
+	}
+}
+
+func (sb *mcpSandbox) searchFiles(ctx context.Context, raw json.RawMessage) (any, error) {
+	var args struct {
+		Root          string   `json:"root"`
+		Extensions    []string `json:"extensions"`
+		NameRegex     string   `json:"nameRegex"`
+		Where         string   `json:"where"`
+		MaxDepth      *int     `json:"maxDepth"`
+		IncludeHidden bool     `json:"includeHidden"`
+		NoIgnore      bool     `json:"noIgnore"`
+		Limit         int      `json:"limit"`
+	}
+	if err := strictUnmarshal(raw, &args); err != nil {
+		return nil, err
+	}
+	root, err := sb.resolve(args.Root)
+	if err != nil {
+		return nil, err
+	}
+	cfg := finder.Config{
+		Root:          root,
+		MaxDepth:      -1,
+		IncludeHidden: args.IncludeHidden,
+		Extensions:    parseExts(strings.Join(args.Extensions, ",")),
+	}
+	if args.MaxDepth != nil {
+		cfg.MaxDepth = *args.MaxDepth
+	}
+	if args.NameRegex != "" {
+		if cfg.NameRegex, err = regexp.Compile(args.NameRegex); err != nil {
+			return nil, fmt.Errorf("invalid nameRegex: %v", err)
+		}
+	}
+	if args.Where != "" {
+		if cfg.Where, err = finder.CompileWhere(args.Where); err != nil {
+			return nil, fmt.Errorf("invalid where: %v", err)
+		}
+	}
+	if !args.NoIgnore {
+		cfg.Ignore = &ignore.Config{
+			Enabled:         true,
+			CaseInsensitive: ignore.DefaultCaseInsensitive,
+			Files:           []string{ignore.GitIgnoreFile, ignore.DotIgnoreFile, ignore.FdIgnoreFile},
+		}
+	}
+	entries, truncated, res, err := sb.collect(ctx, cfg, args.Limit)
+	if err != nil {
+		return nil, err
+	}
+	return listing{Entries: entries, Truncated: truncated, Errors: res.ErrorCount}, nil
+}
+
+func (sb *mcpSandbox) statFile(ctx context.Context, raw json.RawMessage) (any, error) {
+	var args struct {
+		Path string `json:"path"`
+	}
+	if err := strictUnmarshal(raw, &args); err != nil {
+		return nil, err
+	}
+	if args.Path == "" {
+		return nil, errors.New("path is required")
+	}
+	p, err := sb.resolve(args.Path)
+	if err != nil {
+		return nil, err
+	}
+	cfg := finder.Config{
+		Root:          p,
+		IncludeHidden: true,
+		Paths:         func(yield func(string) bool) { yield(p) },
+	}
+	entries, _, res, err := sb.collect(ctx, cfg, 1)
+	switch {
+	case err != nil:
+		return nil, err
+	case len(res.Errors) > 0:
+		return nil, res.Errors[0]
+	case len(entries) == 0:
+		return nil, fmt.Errorf("%s: not found", args.Path)
+	}
+	return entries[0], nil
+}
+
+func (sb *mcpSandbox) readDir(ctx context.Context, raw json.RawMessage) (any, error) {
+	var args struct {
+		Path          string `json:"path"`
+		IncludeHidden bool   `json:"includeHidden"`
+		Limit         int    `json:"limit"`
+	}
+	if err := strictUnmarshal(raw, &args); err != nil {
+		return nil, err
+	}
+	dir, err := sb.resolve(args.Path)
+	if err != nil {
+		return nil, err
+	}
+	cfg := finder.Config{Root: dir, MaxDepth: 0, IncludeHidden: args.IncludeHidden}
+	entries, truncated, res, err := sb.collect(ctx, cfg, args.Limit)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 && len(res.Errors) > 0 {
+		return nil, res.Errors[0]
+	}
+	return listing{Entries: entries, Truncated: truncated, Errors: res.ErrorCount}, nil
+}
+
+// strictUnmarshal decodes tool arguments, rejecting unknown ones so a
+// misspelled filter is not silently ignored.
+func strictUnmarshal(raw json.RawMessage, v any) error {
+	dec := json.NewDecoder(strings.NewReader(string(raw)))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("invalid arguments: %v", err)
+	}
+	return nil
+}
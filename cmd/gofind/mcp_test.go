@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCLI_MCP(t *testing.T) {
+	bin := buildCLI(t)
+	td := t.TempDir()
+	root := filepath.Join(td, "root")
+	_ = mk(t, root, "a.go", 10)
+	_ = mk(t, root, "sub/b.go", 20)
+	_ = mk(t, root, "sub/c.txt", 30)
+	secret := mk(t, td, "secret.txt", 1)
+	if err := os.Symlink(secret, filepath.Join(root, "link")); err != nil {
+		t.Skip("symlinks unavailable:", err)
+	}
+
+	calls := []string{
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-06-18","capabilities":{}}}`,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/list"}`,
+		`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"search_files","arguments":{"extensions":[".go"]}}}`,
+		`{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"read_dir","arguments":{"path":"sub","limit":1}}}`,
+		`{"jsonrpc":"2.0","id":5,"method":"tools/call","params":{"name":"stat_file","arguments":{"path":"sub/c.txt"}}}`,
+		`{"jsonrpc":"2.0","id":6,"method":"tools/call","params":{"name":"stat_file","arguments":{"path":"` + filepath.ToSlash(secret) + `"}}}`,
+		`{"jsonrpc":"2.0","id":7,"method":"tools/call","params":{"name":"stat_file","arguments":{"path":"link"}}}`,
+		`{"jsonrpc":"2.0","id":8,"method":"tools/call","params":{"name":"search_files","arguments":{"exts":[".go"]}}}`,
+	}
+	cmd := exec.Command(bin, "mcp", "--allow-root", root)
+	cmd.Stdin = strings.NewReader(strings.Join(calls, "\n") + "\n")
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("mcp: %v", err)
+	}
+
+	type result struct {
+		Tools []struct {
+			Name string `json:"name"`
+		} `json:"tools"`
+		Content []struct {
+			Text string `json:"text"`
+		} `json:"content"`
+		IsError bool `json:"isError"`
+	}
+	results := map[int]result{}
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		var r struct {
+			ID     int    `json:"id"`
+			Result result `json:"result"`
+		}
+		if err := json.Unmarshal([]byte(line), &r); err != nil {
+			t.Fatalf("%v: %s", err, line)
+		}
+		results[r.ID] = r.Result
+	}
+	text := func(id int, v any) {
+		t.Helper()
+		r := results[id]
+		if r.IsError || len(r.Content) != 1 {
+			t.Fatalf("call %d failed: %+v", id, r)
+		}
+		if err := json.Unmarshal([]byte(r.Content[0].Text), v); err != nil {
+			t.Fatalf("call %d: %v: %s", id, err, r.Content[0].Text)
+		}
+	}
+
+	if len(results[2].Tools) != 3 {
+		t.Fatalf("tools/list: %+v", results[2])
+	}
+	var found listing
+	text(3, &found)
+	var files int
+	for _, e := range found.Entries {
+		if !e.IsDir {
+			files++
+		}
+	}
+	if files != 2 || found.Truncated {
+		t.Fatalf("search_files: %+v", found)
+	}
+	var dir listing
+	text(4, &dir)
+	if len(dir.Entries) != 1 || !dir.Truncated {
+		t.Fatalf("read_dir with limit: %+v", dir)
+	}
+	var e cliEntry
+	text(5, &e)
+	if e.Name != "c.txt" || e.Size != 30 {
+		t.Fatalf("stat_file: %+v", e)
+	}
+	// Paths outside the root are refused, through symlinks too, as are
+	// unknown arguments.
+	for _, id := range []int{6, 7, 8} {
+		if !results[id].IsError {
+			t.Errorf("call %d succeeded: %+v", id, results[id])
+		}
+	}
+}
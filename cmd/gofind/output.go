@@ -0,0 +1,72 @@
+package main
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// createOutput opens the --out file, or returns stdout when path is empty,
+// compressing what is written with the --compress algorithm if one is given.
+// The returned close function flushes the compressor and closes the file; it
+// is safe to call more than once and reports the first error.
+func createOutput(path, compress string) (io.Writer, func() error, error) {
+	newCompressor, err := compressor(compress)
+	if err != nil {
+		return nil, nil, err
+	}
+	var (
+		w      io.Writer = os.Stdout
+		closer []io.Closer
+	)
+	if path = strings.TrimSpace(path); path != "" {
+		f, err := os.Create(path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("cannot create output file %q: %v", path, err)
+		}
+		w, closer = f, append(closer, f)
+	}
+	if newCompressor != nil {
+		cw, err := newCompressor(w)
+		if err != nil {
+			for _, c := range closer {
+				_ = c.Close()
+			}
+			return nil, nil, err
+		}
+		// The compressor must be flushed before the file is closed.
+		w, closer = cw, append([]io.Closer{cw}, closer...)
+	}
+	var (
+		once     sync.Once
+		closeErr error
+	)
+	return w, func() error {
+		once.Do(func() {
+			for _, c := range closer {
+				closeErr = errors.Join(closeErr, c.Close())
+			}
+		})
+		return closeErr
+	}, nil
+}
+
+// compressor returns the constructor of the --compress writer, or nil for
+// no compression.
+func compressor(name string) (func(io.Writer) (io.WriteCloser, error), error) {
+	switch name {
+	case "":
+		return nil, nil
+	case "gzip":
+		return func(w io.Writer) (io.WriteCloser, error) { return gzip.NewWriter(w), nil }, nil
+	case "zstd":
+		return func(w io.Writer) (io.WriteCloser, error) { return zstd.NewWriter(w) }, nil
+	}
+	return nil, fmt.Errorf("invalid --compress: %q (want %s)", name, strings.Join(flagValues["compress"], ", "))
+}
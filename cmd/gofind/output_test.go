@@ -0,0 +1,52 @@
+package main
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+func TestCreateOutput_Compress(t *testing.T) {
+	decoders := map[string]func(io.Reader) (io.Reader, error){
+		"gzip": func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) },
+		"zstd": func(r io.Reader) (io.Reader, error) { return zstd.NewReader(r) },
+	}
+	for algo, decode := range decoders {
+		path := filepath.Join(t.TempDir(), "out."+algo)
+		w, closeOut, err := createOutput(path, algo)
+		if err != nil {
+			t.Fatalf("%s: %v", algo, err)
+		}
+		if _, err := io.WriteString(w, "a.txt\nb.txt\n"); err != nil {
+			t.Fatalf("%s: write: %v", algo, err)
+		}
+		if err := closeOut(); err != nil {
+			t.Fatalf("%s: close: %v", algo, err)
+		}
+		if err := closeOut(); err != nil {
+			t.Fatalf("%s: second close: %v", algo, err)
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, err := decode(f)
+		if err != nil {
+			t.Fatalf("%s: %v", algo, err)
+		}
+		got, err := io.ReadAll(r)
+		f.Close()
+		if err != nil || string(got) != "a.txt\nb.txt\n" {
+			t.Fatalf("%s: decompressed %q, %v", algo, got, err)
+		}
+	}
+
+	if _, _, err := createOutput("", "lz4"); err == nil {
+		t.Fatal("want an error for an unknown algorithm")
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"iter"
+	"os"
+)
+
+// openList opens a path list argument, where "-" means stdin.
+func openList(name string) (io.ReadCloser, error) {
+	if name == "-" {
+		return io.NopCloser(os.Stdin), nil
+	}
+	return os.Open(name)
+}
+
+// pathList yields the entries of a newline- or NUL-delimited list. The
+// delimiter is NUL when the first buffered chunk contains one (as written by
+// find -print0) and newline otherwise. Empty entries, and a trailing "\r" in
+// newline-delimited lists, are dropped. After iteration, *errp holds any read
+// error.
+func pathList(r io.Reader, errp *error) iter.Seq[string] {
+	return func(yield func(string) bool) {
+		sc := bufio.NewScanner(r)
+		sc.Buffer(make([]byte, 64*1024), 1<<20)
+		var delim byte
+		decided := false
+		sc.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+			if !decided {
+				switch {
+				case bytes.IndexByte(data, 0) >= 0:
+					delim = 0
+				case !atEOF && len(data) < 64*1024 && bytes.IndexByte(data, '\n') < 0:
+					return 0, nil, nil // need more data to decide
+				default:
+					delim = '\n'
+				}
+				decided = true
+			}
+			if i := bytes.IndexByte(data, delim); i >= 0 {
+				return i + 1, data[:i], nil
+			}
+			if atEOF && len(data) > 0 {
+				return len(data), data, nil
+			}
+			return 0, nil, nil
+		})
+		for sc.Scan() {
+			p := sc.Text()
+			if delim == '\n' {
+				p = trimCR(p)
+			}
+			if p == "" {
+				continue
+			}
+			if !yield(p) {
+				return
+			}
+		}
+		*errp = sc.Err()
+	}
+}
+
+func trimCR(s string) string {
+	if n := len(s); n > 0 && s[n-1] == '\r' {
+		return s[:n-1]
+	}
+	return s
+}
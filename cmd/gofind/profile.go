@@ -0,0 +1,78 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"runtime/trace"
+)
+
+// startProfiles starts the --cpuprofile and --trace recordings. The returned
+// function stops them and writes --memprofile, reporting failures on stderr;
+// it must run before the process exits for the files to be complete.
+func (sf *searchFlags) startProfiles() (stop func(), err error) {
+	var stops []func() error
+	stop = func() {
+		for i := len(stops) - 1; i >= 0; i-- {
+			if err := stops[i](); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+			}
+		}
+		stops = nil
+	}
+	if *sf.cpuProfile != "" {
+		f, err := os.Create(*sf.cpuProfile)
+		if err != nil {
+			return nil, fmt.Errorf("--cpuprofile: %w", err)
+		}
+		if err := pprof.StartCPUProfile(f); err != nil {
+			f.Close()
+			return nil, fmt.Errorf("--cpuprofile: %w", err)
+		}
+		stops = append(stops, func() error {
+			pprof.StopCPUProfile()
+			return wrapProfileErr("--cpuprofile", f.Close())
+		})
+	}
+	if *sf.traceOut != "" {
+		f, err := os.Create(*sf.traceOut)
+		if err == nil {
+			if err = trace.Start(f); err != nil {
+				f.Close()
+			}
+		}
+		if err != nil {
+			stop()
+			return nil, fmt.Errorf("--trace: %w", err)
+		}
+		stops = append(stops, func() error {
+			trace.Stop()
+			return wrapProfileErr("--trace", f.Close())
+		})
+	}
+	if *sf.memProfile != "" {
+		path := *sf.memProfile
+		// Created now, so a bad path fails before the search rather than
+		// after it.
+		f, err := os.Create(path)
+		if err != nil {
+			stop()
+			return nil, fmt.Errorf("--memprofile: %w", err)
+		}
+		stops = append(stops, func() error {
+			runtime.GC() // report live objects as of the end of the search
+			err := pprof.Lookup("allocs").WriteTo(f, 0)
+			return wrapProfileErr("--memprofile", errors.Join(err, f.Close()))
+		})
+	}
+	return stop, nil
+}
+
+func wrapProfileErr(flag string, err error) error {
+	if err != nil {
+		return fmt.Errorf("%s: %w", flag, err)
+	}
+	return nil
+}
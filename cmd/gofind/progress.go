@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Hamed0406/gofind/internal/finder"
+)
+
+// dirCountsPath is where --progress keeps the directory count of each
+// root's last complete search, to estimate the next one's ETA from.
+func dirCountsPath() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "gofind", "dircounts.json")
+}
+
+// dirCountKey identifies the roots of a search in the dirCountsPath file, or
+// is "" for a --files-from search, which reads no directories.
+func dirCountKey(cfg finder.Config) string {
+	if cfg.Paths != nil {
+		return ""
+	}
+	roots := cfg.Roots
+	if len(roots) == 0 {
+		roots = []string{cfg.Root}
+	}
+	abs := make([]string, len(roots))
+	for i, r := range roots {
+		var err error
+		if abs[i], err = filepath.Abs(r); err != nil {
+			return ""
+		}
+	}
+	return strings.Join(abs, "\x00")
+}
+
+// loadDirCounts reads the dirCountsPath file; a missing or damaged one
+// just means no estimates.
+func loadDirCounts() map[string]int64 {
+	counts := map[string]int64{}
+	if b, err := os.ReadFile(dirCountsPath()); err == nil {
+		json.Unmarshal(b, &counts)
+	}
+	return counts
+}
+
+// saveDirCount records the directories a complete search of cfg read, for
+// the next one's --progress ETA. Failing to is not worth failing the search
+// over.
+func saveDirCount(cfg finder.Config, res finder.Result) {
+	key := dirCountKey(cfg)
+	if key == "" || res.Interrupted || res.Partial {
+		return
+	}
+	counts := loadDirCounts()
+	counts[key] = res.DirsVisited
+	b, err := json.Marshal(counts)
+	if err != nil {
+		return
+	}
+	path := dirCountsPath()
+	if os.MkdirAll(filepath.Dir(path), 0o755) != nil {
+		return
+	}
+	tmp := path + ".tmp"
+	if os.WriteFile(tmp, b, 0o644) == nil {
+		os.Rename(tmp, path)
+	}
+}
+
+// progressReporter writes Config.OnProgress reports to w: one line per
+// report, or a single line rewritten in place on a terminal.
+func progressReporter(w io.Writer, terminal bool) func(finder.Progress) {
+	return func(p finder.Progress) {
+		var b strings.Builder
+		fmt.Fprintf(&b, "%d dirs, %d entries, %d matched, %.0f entries/s, %s",
+			p.DirsVisited, p.EntriesSeen, p.Matched, p.EntriesPerSec, p.Elapsed.Round(time.Second))
+		if f := p.Fraction(); f >= 0 && !p.Done {
+			fmt.Fprintf(&b, ", %.0f%% of %d dirs", 100*f, p.ExpectedDirs)
+			if p.ETA > 0 {
+				fmt.Fprintf(&b, ", ETA %s", p.ETA.Round(time.Second))
+			}
+		}
+		switch {
+		case !terminal:
+			fmt.Fprintf(w, "progress: %s\n", b.String())
+		case p.Done:
+			fmt.Fprintf(w, "\r\x1b[Kprogress: %s\n", b.String())
+		default:
+			fmt.Fprintf(w, "\r\x1b[Kprogress: %s", b.String())
+		}
+	}
+}
+
+// isTerminal reports whether f is a character device, such as a terminal.
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
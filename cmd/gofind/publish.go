@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Hamed0406/gofind/internal/finder"
+)
+
+// publishEnv holds the --publish URL when the flag is not given, which keeps
+// credentials in the URL off the command line.
+const publishEnv = "GOFIND_PUBLISH"
+
+// publishTimeout bounds a single attempt to publish a batch.
+const publishTimeout = 30 * time.Second
+
+// message is one entry to publish: its NDJSON record, keyed by path, which
+// Kafka uses to pick the partition.
+type message struct {
+	key, value []byte
+}
+
+// publisher sends messages to a broker. Publish returns nil only once the
+// broker has acknowledged every message of the batch.
+type publisher interface {
+	Publish(ctx context.Context, batch []message) error
+	Close() error
+}
+
+// publishers maps the scheme of a --publish URL to its publisher. Brokers
+// needing a client library register themselves from files behind a build tag
+// of the same name (go build -tags kafka,nats), so the default binary stays
+// small.
+var publishers = map[string]func(u *url.URL) (publisher, error){}
+
+// publishWriter batches NDJSON records into messages and publishes each
+// batch with at-least-once delivery: a batch that is not acknowledged is sent
+// again, so a consumer may see an entry twice. It implements
+// finder.RecordWriter.
+type publishWriter struct {
+	pub     publisher
+	target  string // the URL without credentials, for messages
+	batch   int
+	retries int
+	backoff time.Duration // before the first retry; doubles after each
+
+	pending []message
+}
+
+// publishURL validates the --publish flags and returns the broker URL, or nil
+// without --publish.
+func (sf *searchFlags) publishURL() (*url.URL, error) {
+	target := *sf.publish
+	if target == "" {
+		target = os.Getenv(publishEnv)
+	}
+	if target == "" {
+		return nil, nil
+	}
+	u, err := url.Parse(target)
+	if err != nil || u.Host == "" || strings.Trim(u.Path, "/") == "" {
+		return nil, errors.New("invalid --publish: want kafka://BROKER[,BROKER...]/TOPIC or nats://SERVER/SUBJECT")
+	}
+	if u.Scheme != "kafka" && u.Scheme != "nats" {
+		return nil, fmt.Errorf("invalid --publish: unknown scheme %q (want kafka or nats)", u.Scheme)
+	}
+	if _, ok := publishers[u.Scheme]; !ok {
+		return nil, fmt.Errorf("--publish %s://: gofind was built without %[1]s support (build with -tags %[1]s)", u.Scheme)
+	}
+	if *sf.pubBatch <= 0 {
+		return nil, fmt.Errorf("invalid --publish-batch: %d", *sf.pubBatch)
+	}
+	if *sf.pubRetries < 0 {
+		return nil, fmt.Errorf("invalid --publish-retries: %d", *sf.pubRetries)
+	}
+	return u, nil
+}
+
+// addPublisher connects to the --publish broker, if any, and adds it to cfg
+// as a sink. The returned function publishes the last batch and disconnects.
+func (sf *searchFlags) addPublisher(cfg *finder.Config) (func() error, error) {
+	u, err := sf.publishURL()
+	if u == nil || err != nil {
+		return func() error { return nil }, err
+	}
+	pub, err := publishers[u.Scheme](u)
+	if err != nil {
+		return nil, fmt.Errorf("--publish %s: %v", u.Redacted(), err)
+	}
+	w := &publishWriter{
+		pub:     pub,
+		target:  u.Redacted(),
+		batch:   *sf.pubBatch,
+		retries: *sf.pubRetries,
+		backoff: time.Second,
+	}
+	cfg.Sinks = append(cfg.Sinks, finder.Sink{Writer: w, Format: finder.OutputNDJSON})
+	return w.Close, nil
+}
+
+// Write rejects unframed output; see finder.RecordWriter.
+func (w *publishWriter) Write([]byte) (int, error) {
+	return 0, errors.New("published output needs NDJSON records")
+}
+
+// WriteRecord adds the entry to the pending batch, publishing it once full.
+func (w *publishWriter) WriteRecord(e finder.Entry, record []byte) error {
+	w.pending = append(w.pending, message{
+		key:   []byte(e.Path),
+		value: bytes.TrimSuffix(record, []byte("\n")),
+	})
+	if len(w.pending) < w.batch {
+		return nil
+	}
+	return w.flush()
+}
+
+// Close publishes the pending batch and disconnects.
+func (w *publishWriter) Close() error {
+	err := w.flush()
+	if cerr := w.pub.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("publish %s: %w", w.target, err)
+	}
+	return nil
+}
+
+func (w *publishWriter) flush() error {
+	if len(w.pending) == 0 {
+		return nil
+	}
+	batch := w.pending
+	w.pending = nil
+	delay := w.backoff
+	for attempt := 0; ; attempt++ {
+		// Publishing goes on after cancellation: the entries were found and
+		// are written to the other outputs as well.
+		ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+		err := w.pub.Publish(ctx, batch)
+		cancel()
+		if err == nil || attempt == w.retries {
+			return err
+		}
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
@@ -0,0 +1,69 @@
+//go:build kafka
+
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl/plain"
+)
+
+func init() {
+	publishers["kafka"] = openKafka
+}
+
+// kafkaPublisher produces to a Kafka topic, waiting for all in-sync replicas
+// to acknowledge every message.
+type kafkaPublisher struct {
+	w *kafka.Writer
+}
+
+// openKafka connects to kafka://[USER:PASSWORD@]BROKER[,BROKER...]/TOPIC,
+// authenticating with SASL/PLAIN when credentials are given and using TLS
+// with ?tls=true.
+func openKafka(u *url.URL) (publisher, error) {
+	var brokers []string
+	for _, b := range strings.Split(u.Host, ",") {
+		if _, _, err := net.SplitHostPort(b); err != nil {
+			b = net.JoinHostPort(b, "9092")
+		}
+		brokers = append(brokers, b)
+	}
+	tr := &kafka.Transport{ClientID: "gofind"}
+	if u.User != nil {
+		password, _ := u.User.Password()
+		tr.SASL = plain.Mechanism{Username: u.User.Username(), Password: password}
+	}
+	if u.Query().Get("tls") == "true" {
+		tr.TLS = &tls.Config{}
+	}
+	return &kafkaPublisher{&kafka.Writer{
+		Addr:         kafka.TCP(brokers...),
+		Topic:        strings.Trim(u.Path, "/"),
+		Balancer:     &kafka.Hash{},
+		RequiredAcks: kafka.RequireAll,
+		// publishWriter batches and retries; send what it hands over at once.
+		BatchSize:    1 << 20,
+		BatchTimeout: time.Millisecond,
+		MaxAttempts:  1,
+		Transport:    tr,
+	}}, nil
+}
+
+func (p *kafkaPublisher) Publish(ctx context.Context, batch []message) error {
+	msgs := make([]kafka.Message, len(batch))
+	for i, m := range batch {
+		msgs[i] = kafka.Message{Key: m.key, Value: m.value}
+	}
+	return p.w.WriteMessages(ctx, msgs...)
+}
+
+func (p *kafkaPublisher) Close() error {
+	return p.w.Close()
+}
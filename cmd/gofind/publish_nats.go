@@ -0,0 +1,68 @@
+//go:build nats
+
+package main
+
+import (
+	"context"
+	"net/url"
+	"strings"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+func init() {
+	publishers["nats"] = openNATS
+}
+
+// natsPublisher publishes to a subject captured by a JetStream stream, which
+// acknowledges each message once stored. Core NATS has no acknowledgements,
+// so publishing to a subject no stream listens on fails.
+type natsPublisher struct {
+	nc      *nats.Conn
+	js      jetstream.JetStream
+	subject string
+}
+
+// openNATS connects to nats://[USER:PASSWORD@]SERVER[,SERVER...]/SUBJECT.
+func openNATS(u *url.URL) (publisher, error) {
+	var servers []string
+	for _, h := range strings.Split(u.Host, ",") {
+		servers = append(servers, (&url.URL{Scheme: "nats", User: u.User, Host: h}).String())
+	}
+	nc, err := nats.Connect(strings.Join(servers, ","), nats.Name("gofind"))
+	if err != nil {
+		return nil, err
+	}
+	js, err := jetstream.New(nc)
+	if err != nil {
+		nc.Close()
+		return nil, err
+	}
+	return &natsPublisher{nc: nc, js: js, subject: strings.Trim(u.Path, "/")}, nil
+}
+
+func (p *natsPublisher) Publish(ctx context.Context, batch []message) error {
+	acks := make([]jetstream.PubAckFuture, 0, len(batch))
+	for _, m := range batch {
+		f, err := p.js.PublishAsync(p.subject, m.value)
+		if err != nil {
+			return err
+		}
+		acks = append(acks, f)
+	}
+	for _, f := range acks {
+		select {
+		case <-f.Ok():
+		case err := <-f.Err():
+			return err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+func (p *natsPublisher) Close() error {
+	return p.nc.Drain()
+}
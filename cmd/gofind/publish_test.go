@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"strings"
+	"testing"
+
+	"github.com/Hamed0406/gofind/internal/finder"
+)
+
+// fakePublisher fails the first fail calls to Publish.
+type fakePublisher struct {
+	fail    int
+	calls   int
+	batches [][]message
+	closed  bool
+}
+
+func (p *fakePublisher) Publish(_ context.Context, batch []message) error {
+	p.calls++
+	if p.calls <= p.fail {
+		return errors.New("not acknowledged")
+	}
+	p.batches = append(p.batches, batch)
+	return nil
+}
+
+func (p *fakePublisher) Close() error {
+	p.closed = true
+	return nil
+}
+
+func TestPublishWriter_BatchesAndResends(t *testing.T) {
+	pub := &fakePublisher{fail: 1}
+	w := &publishWriter{pub: pub, batch: 2, retries: 1}
+	for _, path := range []string{"a", "b", "c"} {
+		if err := w.WriteRecord(finder.Entry{Path: path}, []byte(`{"path":"`+path+`"}`+"\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !pub.closed || len(pub.batches) != 2 || len(pub.batches[0]) != 2 {
+		t.Fatalf("batches %v, closed %v", pub.batches, pub.closed)
+	}
+	if m := pub.batches[1][0]; string(m.key) != "c" || string(m.value) != `{"path":"c"}` {
+		t.Fatalf("message %q %q", m.key, m.value)
+	}
+
+	pub = &fakePublisher{fail: 3}
+	w = &publishWriter{pub: pub, target: "nats://host/subj", batch: 10, retries: 2}
+	_ = w.WriteRecord(finder.Entry{Path: "a"}, []byte("{}\n"))
+	if err := w.Close(); err == nil || pub.calls != 3 || !strings.Contains(err.Error(), "nats://host/subj") {
+		t.Fatalf("want failure after 3 attempts, got %d: %v", pub.calls, err)
+	}
+}
+
+func TestPublish_FlagValidation(t *testing.T) {
+	args := [][]string{
+		{"--publish", "amqp://host/queue"},
+		{"--publish", "kafka://broker:9092"},
+	}
+	for _, scheme := range []string{"kafka", "nats"} {
+		if _, ok := publishers[scheme]; !ok {
+			// Not compiled in: the flag says how to get it.
+			args = append(args, []string{"--publish", scheme + "://host/subject"})
+		}
+	}
+	t.Setenv(publishEnv, "")
+	for _, a := range args {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		sf := defineSearchFlags(fs)
+		if err := fs.Parse(a); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := sf.config(); err == nil {
+			t.Errorf("%q: want an error", a)
+		}
+	}
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Hamed0406/gofind/internal/expr"
+	"github.com/Hamed0406/gofind/internal/finder"
+	"github.com/Hamed0406/gofind/internal/stats"
+)
+
+func init() {
+	subcommands["repl"] = runREPL
+}
+
+const replHelp = `Each filter narrows the matches of the ones before it:
+  EXPR, where EXPR   keep entries matching a --where expression, e.g. size > 1MB
+  not EXPR           drop entries matching it
+  ext .go .md        keep these extensions
+  undo               drop the last filter
+  reset              drop every filter
+  filters            list the filters
+  sort FIELD [desc]  order show by a --where field, e.g. sort mtime desc
+  show [N]           list the first N matches (default 20)
+  count              count the matches
+  quit               leave (also Ctrl-D)
+`
+
+// replStep is a filter and the entries left after it.
+type replStep struct {
+	src     string
+	matches []*finder.Entry
+}
+
+// repl narrows the entries of one search in memory, so exploring filters
+// does not walk the tree again.
+type repl struct {
+	all   []*finder.Entry
+	steps []replStep
+	roots []string
+	// sortBy orders show, unless empty.
+	sortBy string
+	desc   bool
+	cfg    finder.Config
+}
+
+// runREPL searches once with the search flags, then reads filter commands
+// from stdin; see replHelp.
+func runREPL(args []string) int {
+	fs := flag.NewFlagSet("repl", flag.ContinueOnError)
+	sf := defineSearchFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	cfg, err := sf.config()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	ctx, cancel := signalContext(*sf.timeout)
+	r := &repl{cfg: cfg, roots: cfg.Roots}
+	if len(r.roots) == 0 {
+		r.roots = []string{cfg.Root}
+	}
+	start := time.Now()
+	res, err := finder.Walk(ctx, cfg, func(e finder.Entry) error {
+		r.all = append(r.all, &e)
+		return nil
+	})
+	cancel()
+	if code := searchStatus(res, err); code != 0 && !res.Interrupted {
+		return code
+	}
+	fmt.Fprintf(os.Stderr, "%d entries loaded in %s; type help for commands\n", len(r.all), time.Since(start).Round(time.Millisecond))
+
+	prompt := isTerminal(os.Stdin)
+	in := bufio.NewScanner(os.Stdin)
+	out := bufio.NewWriter(os.Stdout)
+	defer out.Flush()
+	for {
+		if prompt {
+			fmt.Fprint(out, "gofind> ")
+		}
+		out.Flush()
+		if !in.Scan() {
+			break
+		}
+		quit, err := r.exec(out, in.Text())
+		if err != nil {
+			fmt.Fprintln(out, err)
+		}
+		if quit {
+			break
+		}
+	}
+	return 0
+}
+
+// matches returns the entries left by the filters.
+func (r *repl) matches() []*finder.Entry {
+	if n := len(r.steps); n > 0 {
+		return r.steps[n-1].matches
+	}
+	return r.all
+}
+
+// exec runs one command line, writing its output to w.
+func (r *repl) exec(w io.Writer, line string) (quit bool, err error) {
+	line = strings.TrimSpace(line)
+	cmd, arg, _ := strings.Cut(line, " ")
+	arg = strings.TrimSpace(arg)
+	switch strings.ToLower(cmd) {
+	case "":
+		return false, nil
+	case "quit", "exit", "q":
+		return true, nil
+	case "help", "?":
+		fmt.Fprint(w, replHelp)
+		return false, nil
+	case "count":
+	case "filters":
+		for i, s := range r.steps {
+			fmt.Fprintf(w, "%d. %s (%d)\n", i+1, s.src, len(s.matches))
+		}
+		if r.sortBy != "" && r.desc {
+			fmt.Fprintf(w, "sorted by %s desc\n", r.sortBy)
+		} else if r.sortBy != "" {
+			fmt.Fprintf(w, "sorted by %s\n", r.sortBy)
+		}
+		return false, nil
+	case "undo":
+		if len(r.steps) == 0 {
+			return false, errors.New("no filter to undo")
+		}
+		r.steps = r.steps[:len(r.steps)-1]
+	case "reset":
+		r.steps = nil
+	case "sort":
+		if err := r.setSort(arg); err != nil {
+			return false, err
+		}
+		return false, nil
+	case "show", "ls":
+		return false, r.show(w, arg)
+	case "ext":
+		exts := strings.FieldsFunc(arg, func(c rune) bool { return c == ',' || c == ' ' })
+		if len(exts) == 0 {
+			return false, errors.New("usage: ext .go [.md ...]")
+		}
+		for i, e := range exts {
+			if !strings.HasPrefix(e, ".") {
+				e = "." + e
+			}
+			exts[i] = strconv.Quote(strings.ToLower(e))
+		}
+		err = r.filter("ext in ("+strings.Join(exts, ", ")+")", false)
+	case "not":
+		err = r.filter(arg, true)
+	case "where":
+		err = r.filter(arg, false)
+	default:
+		err = r.filter(line, false)
+	}
+	if err != nil {
+		return false, err
+	}
+	fmt.Fprintf(w, "%d of %d entries\n", len(r.matches()), len(r.all))
+	return false, nil
+}
+
+// filter adds the --where expression src, or its negation, as a step.
+func (r *repl) filter(src string, negate bool) error {
+	if src == "" {
+		return errors.New("usage: where EXPR")
+	}
+	x, err := finder.CompileWhere(src)
+	if err != nil {
+		return err
+	}
+	step := replStep{src: src}
+	if negate {
+		step.src = "not " + src
+	}
+	for _, e := range r.matches() {
+		root := sqlRoot(r.roots, e.Path)
+		if x.Match(func(f string) expr.Value { return finder.EntryField(root, e, f) }) != negate {
+			step.matches = append(step.matches, e)
+		}
+	}
+	r.steps = append(r.steps, step)
+	return nil
+}
+
+func (r *repl) setSort(arg string) error {
+	f := strings.Fields(arg)
+	if len(f) == 0 || len(f) > 2 {
+		return errors.New("usage: sort FIELD [asc|desc]")
+	}
+	if _, ok := finder.WhereField(f[0]); !ok {
+		return fmt.Errorf("unknown field %q", f[0])
+	}
+	desc := false
+	if len(f) == 2 {
+		switch strings.ToLower(f[1]) {
+		case "asc":
+		case "desc":
+			desc = true
+		default:
+			return errors.New("usage: sort FIELD [asc|desc]")
+		}
+	}
+	r.sortBy, r.desc = f[0], desc
+	return nil
+}
+
+// show lists the first matches, in the sort order.
+func (r *repl) show(w io.Writer, arg string) error {
+	n := 20
+	if arg != "" {
+		var err error
+		if n, err = strconv.Atoi(arg); err != nil || n < 0 {
+			return errors.New("usage: show [N]")
+		}
+	}
+	list := r.matches()
+	if r.sortBy != "" {
+		list = slices.Clone(list)
+		slices.SortStableFunc(list, func(a, b *finder.Entry) int {
+			c := expr.Compare(
+				finder.EntryField(sqlRoot(r.roots, a.Path), a, r.sortBy),
+				finder.EntryField(sqlRoot(r.roots, b.Path), b, r.sortBy))
+			if r.desc {
+				return -c
+			}
+			return c
+		})
+	}
+	layout := r.cfg.TimeLayout
+	if layout == "" {
+		layout = finder.DefaultTimeLayout
+	}
+	for _, e := range list[:min(n, len(list))] {
+		fmt.Fprintf(w, "%9s  %s  %s\n", stats.FormatBytes(e.Size), e.ModTime.Local().Format(layout), e.Path)
+	}
+	if len(list) > n {
+		fmt.Fprintf(w, "... %d more\n", len(list)-n)
+	}
+	return nil
+}
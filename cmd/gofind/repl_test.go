@@ -0,0 +1,71 @@
+package main
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Hamed0406/gofind/internal/finder"
+)
+
+func TestREPL(t *testing.T) {
+	now := time.Now()
+	r := &repl{roots: []string{"/r"}, all: []*finder.Entry{
+		{Path: "/r/a.go", Name: "a.go", Size: 3 << 20, ModTime: now.Add(-time.Hour)},
+		{Path: "/r/b.go", Name: "b.go", Size: 10, ModTime: now},
+		{Path: "/r/c.md", Name: "c.md", Size: 5 << 20, ModTime: now.Add(-2 * time.Hour)},
+	}}
+	run := func(line string) string {
+		t.Helper()
+		var b strings.Builder
+		if _, err := r.exec(&b, line); err != nil {
+			t.Fatalf("%s: %v", line, err)
+		}
+		return b.String()
+	}
+
+	if got := run("ext go"); got != "2 of 3 entries\n" {
+		t.Fatalf("ext: got %q", got)
+	}
+	if got := run("size > 1MB"); got != "1 of 3 entries\n" {
+		t.Fatalf("size: got %q", got)
+	}
+	if got := run("undo"); got != "2 of 3 entries\n" {
+		t.Fatalf("undo: got %q", got)
+	}
+	if got := run("not name = 'a.go'"); got != "1 of 3 entries\n" {
+		t.Fatalf("not: got %q", got)
+	}
+	run("reset")
+	run("sort mtime desc")
+	if got := run("show 2"); !strings.HasSuffix(strings.Split(got, "\n")[0], "/r/b.go") || !strings.Contains(got, "... 1 more") {
+		t.Fatalf("show: got %q", got)
+	}
+
+	for _, bad := range []string{"size > 'big'", "sort owner", "show x", "ext"} {
+		if _, err := r.exec(&strings.Builder{}, bad); err == nil {
+			t.Errorf("%q accepted", bad)
+		}
+	}
+	if quit, _ := r.exec(&strings.Builder{}, "quit"); !quit {
+		t.Error("quit did not quit")
+	}
+}
+
+func TestCLI_REPL(t *testing.T) {
+	bin := buildCLI(t)
+	td := t.TempDir()
+	_ = mk(t, td, "a.log", 300)
+	_ = mk(t, td, "sub/b.txt", 100)
+
+	cmd := exec.Command(bin, "repl", "--root", td, "--type", "f")
+	cmd.Stdin = strings.NewReader("ext .log\ncount\n")
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("repl: %v", err)
+	}
+	if got, want := string(out), "1 of 2 entries\n1 of 2 entries\n"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
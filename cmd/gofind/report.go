@@ -0,0 +1,194 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Hamed0406/gofind/internal/finder"
+	"github.com/Hamed0406/gofind/pkg/version"
+)
+
+// scanReport is the --report file: what ran, where, with which flags and
+// how it went, so a scan's output can be filed and rerun later.
+type scanReport struct {
+	Version   string `json:"version"`
+	GoVersion string `json:"goVersion"`
+	OS        string `json:"os"`
+	Arch      string `json:"arch"`
+	Hostname  string `json:"hostname,omitempty"`
+	WorkDir   string `json:"workDir,omitempty"`
+	// ConfigFile is the config file whose defaults applied, if there is one.
+	ConfigFile string `json:"configFile,omitempty"`
+
+	// Flags holds every flag set, by any layer, and the root; repeatable
+	// flags, and a root alias naming several directories, have a list of
+	// values. Sources tells where each came from: "flag", "env", "config"
+	// (a root alias included) or "default". Command is the same flags, but
+	// --report, as a command line that needs neither the environment nor the
+	// config file, unless a root alias names several directories: no flag
+	// lists them, so it is kept as @NAME.
+	Flags   map[string]any    `json:"flags"`
+	Sources map[string]string `json:"sources"`
+	Command []string          `json:"command"`
+
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	// DurationMs is the whole run, opening and closing the output included;
+	// SearchMs the search itself.
+	DurationMs int64 `json:"durationMs"`
+	SearchMs   int64 `json:"searchMs"`
+
+	Counts      reportCounts  `json:"counts"`
+	Errors      []reportError `json:"errors"`
+	Error       string        `json:"error,omitempty"`
+	Interrupted bool          `json:"interrupted"`
+	Partial     bool          `json:"partial"`
+	ExitStatus  int           `json:"exitStatus"`
+}
+
+type reportCounts struct {
+	DirsVisited   int64 `json:"dirsVisited"`
+	EntriesSeen   int64 `json:"entriesSeen"`
+	Matched       int64 `json:"matched"`
+	Errors        int64 `json:"errors"`
+	Vanished      int64 `json:"vanished"`
+	TruncatedDirs int64 `json:"truncatedDirs"`
+	SkippedRoots  int   `json:"skippedRoots"`
+}
+
+// reportError is one of the per-path errors of the search; the report
+// lists as many as finder.Result records.
+type reportError struct {
+	Path  string `json:"path"`
+	Op    string `json:"op"`
+	Error string `json:"error"`
+}
+
+// writeScanReport writes the --report file for a search that started at start
+// and ended with res, err and the exit status code.
+func writeScanReport(path string, sf *searchFlags, start time.Time, res finder.Result, err error, code int) error {
+	end := time.Now()
+	r := scanReport{
+		Version:    version.Version,
+		GoVersion:  runtime.Version(),
+		OS:         runtime.GOOS,
+		Arch:       runtime.GOARCH,
+		Start:      start,
+		End:        end,
+		DurationMs: end.Sub(start).Milliseconds(),
+		SearchMs:   res.Duration.Milliseconds(),
+		Counts: reportCounts{
+			DirsVisited:   res.DirsVisited,
+			EntriesSeen:   res.EntriesSeen,
+			Matched:       res.Matched,
+			Errors:        res.ErrorCount,
+			Vanished:      res.TransientCount,
+			TruncatedDirs: res.TruncatedCount,
+			SkippedRoots:  len(res.SkippedRoots),
+		},
+		Errors:      []reportError{},
+		Interrupted: res.Interrupted,
+		Partial:     res.Partial,
+		ExitStatus:  code,
+	}
+	r.Hostname, _ = os.Hostname()
+	r.WorkDir, _ = os.Getwd()
+	if p := configPath(); p != "" && !sf.selfContained {
+		if _, serr := os.Stat(p); serr == nil {
+			r.ConfigFile = p
+		}
+	}
+	r.Flags, r.Sources, r.Command = sf.normalized()
+	for _, e := range res.Errors {
+		r.Errors = append(r.Errors, reportError{Path: e.Path, Op: e.Op, Error: e.Err.Error()})
+	}
+	if err != nil {
+		r.Error = err.Error()
+	}
+
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(r); err != nil {
+		return err
+	}
+	return os.WriteFile(path, b.Bytes(), 0o644)
+}
+
+// normalized returns the flags set by any layer, and the root, with where
+// each came from and as a command line in flag name order, root aliases
+// resolved. Credentials are masked as for --emit-meta.
+func (sf *searchFlags) normalized() (flags map[string]any, sources map[string]string, command []string) {
+	flags = map[string]any{"root": *sf.root}
+	sources = map[string]string{"root": "default"}
+	var names []string
+	sf.fs.Visit(func(f *flag.Flag) {
+		names = append(names, f.Name)
+		switch {
+		case sf.cliFlags[f.Name]:
+			sources[f.Name] = "flag"
+		case envSet(envName(f.Name)):
+			sources[f.Name] = "env"
+		default:
+			sources[f.Name] = "config"
+		}
+		if l, ok := f.Value.(*stringList); ok {
+			vs := make([]string, len(*l))
+			for i, v := range *l {
+				vs[i] = redactFlag(f.Name, v)
+			}
+			flags[f.Name] = vs
+			return
+		}
+		flags[f.Name] = redactFlag(f.Name, f.Value.String())
+	})
+	if sources["root"] == "default" {
+		names = append(names, "root")
+	}
+	slices.Sort(names)
+	rootArg := "--root=" + *sf.root
+	if strings.HasPrefix(*sf.root, "@") {
+		// config has resolved it once already.
+		if dirs, err := resolveRoot(*sf.root); err == nil {
+			sources["root"] = "config"
+			flags["root"] = dirs
+			if len(dirs) == 1 {
+				flags["root"] = dirs[0]
+				rootArg = "--root=" + dirs[0]
+			}
+		}
+	}
+
+	command = []string{"gofind"}
+	for _, name := range names {
+		if name == "report" {
+			continue // the rerun writes no report
+		}
+		if name == "root" {
+			command = append(command, rootArg)
+			continue
+		}
+		switch v := flags[name].(type) {
+		case []string:
+			for _, s := range v {
+				command = append(command, "--"+name+"="+s)
+			}
+		case string:
+			command = append(command, "--"+name+"="+v)
+		}
+	}
+	return flags, sources, command
+}
+
+// envSet reports whether the environment variable name is set.
+func envSet(name string) bool {
+	_, ok := os.LookupEnv(name)
+	return ok
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCLI_Report(t *testing.T) {
+	bin := buildCLI(t)
+	td := t.TempDir()
+	_ = mk(t, td, "a.go", 10)
+	_ = mk(t, td, "b.md", 10)
+	_ = mk(t, td, "sub/c.go", 10)
+	cfgDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(cfgDir, "gofind"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(cfgDir, "gofind", "config.yaml"), []byte("defaults: {type: f}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	report := filepath.Join(t.TempDir(), "report.json")
+
+	cmd := exec.Command(bin, "--ext", ".go", "--where", "size > 0", "--where", "name != \"x, y\"", "--report", report)
+	cmd.Env = append(os.Environ(), "XDG_CONFIG_HOME="+cfgDir, "GOFIND_ROOT="+td)
+	want, err := cmd.Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var r scanReport
+	if err := json.Unmarshal(b, &r); err != nil {
+		t.Fatal(err)
+	}
+	if r.Counts.Matched != 2 || r.Counts.DirsVisited != 2 || r.ExitStatus != 0 || r.Hostname == "" || r.ConfigFile == "" {
+		t.Errorf("report = %s", b)
+	}
+	wantSources := map[string]string{"ext": "flag", "where": "flag", "report": "flag", "root": "env", "type": "config"}
+	for name, src := range wantSources {
+		if r.Sources[name] != src {
+			t.Errorf("source of --%s = %q, want %q", name, r.Sources[name], src)
+		}
+	}
+	if where, _ := r.Flags["where"].([]any); len(where) != 2 {
+		t.Errorf("--where = %v, want both values", r.Flags["where"])
+	}
+
+	// The command reproduces the search without the environment or the
+	// config file.
+	if r.Command[0] != "gofind" || !slices.Contains(r.Command, "--type=f") || slices.Contains(r.Command, "--report="+report) {
+		t.Fatalf("command = %q", r.Command)
+	}
+	cmd = exec.Command(bin, r.Command[1:]...)
+	cmd.Env = []string{"XDG_CONFIG_HOME=" + t.TempDir(), "HOME=" + t.TempDir()}
+	got, err := cmd.Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g, w := sortedLines(got), sortedLines(want); !slices.Equal(g, w) {
+		t.Errorf("rerun printed %q, want %q", g, w)
+	}
+
+	// A root alias is recorded as its directories, which the config file
+	// supplied.
+	roots := "roots: {proj: [" + jsonString(td) + "], both: [" + jsonString(td) + ", " + jsonString(t.TempDir()) + "]}\n"
+	if err := os.WriteFile(filepath.Join(cfgDir, "gofind", "config.yaml"), []byte(roots), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for alias, wantRoot := range map[string]string{"@proj": "--root=" + td, "@both": "--root=@both"} {
+		cmd = exec.Command(bin, alias, "--report", report)
+		cmd.Env = append(os.Environ(), "XDG_CONFIG_HOME="+cfgDir)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("%s: %v: %s", alias, err, out)
+		}
+		b, err := os.ReadFile(report)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r = scanReport{}
+		if err := json.Unmarshal(b, &r); err != nil {
+			t.Fatal(err)
+		}
+		if r.Sources["root"] != "config" || !slices.Contains(r.Command, wantRoot) {
+			t.Errorf("%s: source %q, command %q; want config and %s", alias, r.Sources["root"], r.Command, wantRoot)
+		}
+	}
+}
+
+func sortedLines(b []byte) []string {
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	slices.Sort(lines)
+	return lines
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Hamed0406/gofind/internal/safety"
+)
+
+// envPrefix starts the environment variables that set search flags: the
+// flag name in upper case with dashes as underscores, so GOFIND_MAX_DEPTH
+// sets --max-depth.
+const envPrefix = "GOFIND_"
+
+// unlayered are the search flags the environment and the config file do not
+// set, nor saved queries: one-off requests, profiles of one run, and
+// actions that delete or move files, which take an explicit flag.
+var unlayered = map[string]bool{
+	"version":              true,
+	"why":                  true,
+	"delete":               true,
+	"move-to":              true,
+	"i-know-what-im-doing": true,
+	"checkpoint":           true,
+	"resume":               true,
+	"cpuprofile":           true,
+	"memprofile":           true,
+	"trace":                true,
+	"report":               true,
+}
+
+// envName returns the environment variable of the flag name.
+func envName(name string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+// resolveFlags sets the flags of fs not given on the command line, first
+// from the environment through lookup and then from defaults, the defaults
+// of the config file: flags win over the environment, which wins over the
+// config file. Repeatable flags take a single value from each layer.
+func resolveFlags(fs *flag.FlagSet, lookup func(string) (string, bool), defaults savedQuery) error {
+	given := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { given[f.Name] = true })
+
+	var err error
+	fs.VisitAll(func(f *flag.Flag) {
+		if err != nil || given[f.Name] || unlayered[f.Name] {
+			return
+		}
+		v, ok := lookup(envName(f.Name))
+		if !ok {
+			return
+		}
+		if serr := fs.Set(f.Name, v); serr != nil {
+			err = fmt.Errorf("invalid %s: %v", envName(f.Name), serr)
+		}
+		given[f.Name] = true
+	})
+	if err != nil || len(defaults) == 0 {
+		return err
+	}
+
+	args, err := defaults.args()
+	if err != nil {
+		return fmt.Errorf("config file defaults: %v", err)
+	}
+	for _, a := range args {
+		name, v, _ := strings.Cut(strings.TrimPrefix(a, "--"), "=")
+		if given[name] {
+			continue
+		}
+		if err := fs.Set(name, v); err != nil {
+			return fmt.Errorf("config file defaults: invalid --%s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// resolve applies the environment and the config file's defaults to the
+// search flags, unless they are self-contained; see resolveFlags.
+func (sf *searchFlags) resolve() error {
+	sf.cliFlags = make(map[string]bool)
+	sf.fs.Visit(func(f *flag.Flag) { sf.cliFlags[f.Name] = true })
+	if sf.selfContained {
+		return nil
+	}
+	uc, err := loadUserConfig()
+	if err != nil {
+		return err
+	}
+	return resolveFlags(sf.fs, os.LookupEnv, uc.Defaults)
+}
+
+// forced reports whether --i-know-what-im-doing was given on the command
+// line; no other layer may lift the safety checks of --delete and --move-to.
+func (sf *searchFlags) forced() bool {
+	return *sf.iKnow && sf.cliFlags[safety.OverrideFlag[2:]]
+}
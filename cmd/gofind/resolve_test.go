@@ -0,0 +1,101 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestResolveFlags(t *testing.T) {
+	env := map[string]string{
+		"GOFIND_ROOT":        "/from/env",
+		"GOFIND_CONCURRENCY": "3",
+		"GOFIND_OUTPUT":      "ndjson",
+		"GOFIND_DELETE":      "true", // never set from the environment
+	}
+	lookup := func(k string) (string, bool) { v, ok := env[k]; return v, ok }
+	defaults := savedQuery{"concurrency": 8, "max-depth": 2, "ignore": []any{"a/", "*.tmp"}}
+
+	fs := flag.NewFlagSet("", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	sf := defineSearchFlags(fs)
+	if err := fs.Parse([]string{"--output", "json"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := resolveFlags(fs, lookup, defaults); err != nil {
+		t.Fatal(err)
+	}
+	if *sf.outputFmt != "json" {
+		t.Errorf("--output = %q; the flag wins over the environment", *sf.outputFmt)
+	}
+	if *sf.root != "/from/env" {
+		t.Errorf("--root = %q, want it from the environment", *sf.root)
+	}
+	if *sf.concurrency != "3" {
+		t.Errorf("--concurrency = %q; the environment wins over the config file", *sf.concurrency)
+	}
+	if *sf.maxDepth != 2 {
+		t.Errorf("--max-depth = %d, want it from the config file", *sf.maxDepth)
+	}
+	if *sf.deleteMatches {
+		t.Error("--delete set from the environment")
+	}
+	if want := (stringList{"a/", "*.tmp"}); !reflect.DeepEqual(sf.ignorePats, want) {
+		t.Errorf("ignore = %q, want %q", sf.ignorePats, want)
+	}
+
+	fs = flag.NewFlagSet("", flag.ContinueOnError)
+	defineSearchFlags(fs)
+	env = map[string]string{"GOFIND_MAX_DEPTH": "deep"}
+	if err := resolveFlags(fs, lookup, nil); err == nil || !strings.Contains(err.Error(), "GOFIND_MAX_DEPTH") {
+		t.Errorf("invalid environment value: err = %v", err)
+	}
+	fs = flag.NewFlagSet("", flag.ContinueOnError)
+	defineSearchFlags(fs)
+	if err := resolveFlags(fs, func(string) (string, bool) { return "", false }, savedQuery{"delete": true}); err == nil {
+		t.Error("--delete accepted from the config file")
+	}
+}
+
+func TestCLI_EnvConfig(t *testing.T) {
+	bin := buildCLI(t)
+	td := t.TempDir()
+	_ = mk(t, td, "a.go", 10)
+	_ = mk(t, td, "b.tmp", 10)
+	_ = mk(t, td, "sub/c.go", 10)
+	cfgDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(cfgDir, "gofind"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	conf := "defaults: {max-depth: 0, ignore: '*.tmp'}\n"
+	if err := os.WriteFile(filepath.Join(cfgDir, "gofind", "config.yaml"), []byte(conf), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	gofind := func(env []string, args ...string) string {
+		t.Helper()
+		cmd := exec.Command(bin, args...)
+		cmd.Env = append(os.Environ(), append(env, "XDG_CONFIG_HOME="+cfgDir, "GOFIND_ROOT="+td)...)
+		out, err := cmd.Output()
+		if err != nil {
+			t.Fatalf("%v %v: %v", env, args, err)
+		}
+		return string(out)
+	}
+
+	if got, want := gofind(nil, "--type", "f"), filepath.Join(td, "a.go")+"\n"; got != want {
+		t.Errorf("config file defaults: got %q, want %q", got, want)
+	}
+	if got := gofind([]string{"GOFIND_MAX_DEPTH=-1"}, "--type", "f"); strings.Count(got, "\n") != 2 {
+		t.Errorf("environment over config file: got %q", got)
+	}
+	// --ignore replaces the ignore patterns of the config file.
+	got := gofind([]string{"GOFIND_MAX_DEPTH=-1"}, "--type", "f", "--max-depth", "0", "--ignore", "a.go")
+	if want := filepath.Join(td, "b.tmp") + "\n"; got != want {
+		t.Errorf("flags over environment: got %q, want %q", got, want)
+	}
+}
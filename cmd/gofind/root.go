@@ -0,0 +1,102 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+func init() {
+	subcommands["root"] = runRoot
+}
+
+// runRoot manages the named roots of the config file, which --root @NAME
+// (or a leading @NAME argument) searches.
+func runRoot(args []string) int {
+	fs := flag.NewFlagSet("root", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), `usage: gofind root [list]
+       gofind root add NAME DIR...   add directories to the root NAME
+       gofind root rm NAME [DIR...]  remove directories from NAME, or NAME itself
+
+Roots are saved in %s.
+`, configPath())
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	uc, err := loadUserConfig()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	cmd, rest := "list", fs.Args()
+	if len(rest) > 0 {
+		cmd, rest = rest[0], rest[1:]
+	}
+	switch {
+	case cmd == "list" && len(rest) == 0:
+		names := make([]string, 0, len(uc.Roots))
+		for name := range uc.Roots {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		for _, name := range names {
+			fmt.Printf("@%s\t%s\n", name, strings.Join(uc.Roots[name], " "))
+		}
+		return 0
+	case cmd == "add" && len(rest) >= 2, cmd == "rm" && len(rest) >= 1:
+	default:
+		fs.Usage()
+		return 2
+	}
+
+	name := strings.TrimPrefix(rest[0], "@")
+	if name == "" || strings.ContainsAny(name, "@/\\ \t") {
+		fmt.Fprintf(os.Stderr, "invalid root name %q\n", rest[0])
+		return 2
+	}
+	if uc.Roots == nil {
+		uc.Roots = make(map[string][]string)
+	}
+	dirs := make([]string, 0, len(rest)-1)
+	for _, d := range rest[1:] {
+		abs, err := filepath.Abs(expandHome(d))
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+		dirs = append(dirs, abs)
+	}
+	if cmd == "add" {
+		for _, d := range dirs {
+			if fi, err := os.Stat(d); err != nil || !fi.IsDir() {
+				fmt.Fprintf(os.Stderr, "note: %s is not a directory now\n", d)
+			}
+			if !slices.Contains(uc.Roots[name], d) {
+				uc.Roots[name] = append(uc.Roots[name], d)
+			}
+		}
+	} else {
+		if _, ok := uc.Roots[name]; !ok {
+			fmt.Fprintf(os.Stderr, "unknown root @%s\n", name)
+			return 1
+		}
+		left := slices.DeleteFunc(uc.Roots[name], func(d string) bool {
+			return slices.Contains(dirs, d)
+		})
+		if len(dirs) == 0 || len(left) == 0 {
+			delete(uc.Roots, name)
+		} else {
+			uc.Roots[name] = left
+		}
+	}
+	if err := saveRoots(uc.Roots); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+func init() {
+	subcommands["run"] = runSaved
+}
+
+// runSaved runs a query saved in the config file, with any further search
+// flags appended to it, or lists or checks the saved queries.
+func runSaved(args []string) int {
+	fs := flag.NewFlagSet("run", flag.ContinueOnError)
+	list := fs.Bool("list", false, "list the saved queries with their flags")
+	check := fs.Bool("check", false, "check the saved queries named, or all of them, and report the invalid ones")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: gofind run [--list | --check [NAME...]] | NAME [search flags]\n\nQueries are saved in %s.\n", configPath())
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	uc, err := loadUserConfig()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	names := make([]string, 0, len(uc.Queries))
+	for name := range uc.Queries {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	switch {
+	case *list:
+		for _, name := range names {
+			qargs, err := uc.Queries[name].args()
+			if err != nil {
+				fmt.Printf("%s\t(invalid: %v)\n", name, err)
+				continue
+			}
+			for i, a := range qargs {
+				if strings.ContainsAny(a, " \t'\"") {
+					qargs[i] = strconv.Quote(a)
+				}
+			}
+			fmt.Printf("%s\t%s\n", name, strings.Join(qargs, " "))
+		}
+		return 0
+	case *check:
+		if fs.NArg() > 0 {
+			names = fs.Args()
+		}
+		code := 0
+		for _, name := range names {
+			q, ok := uc.Queries[name]
+			if !ok {
+				fmt.Fprintf(os.Stderr, "%s: no such query\n", name)
+				code = 1
+				continue
+			}
+			if err := checkQuery(q); err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %v\n", name, err)
+				code = 1
+			}
+		}
+		return code
+	case fs.NArg() == 0:
+		fs.Usage()
+		return 2
+	}
+
+	name := fs.Arg(0)
+	q, ok := uc.Queries[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "no saved query %q in %s (see gofind run --list)\n", name, configPath())
+		return 2
+	}
+	qargs, err := q.args()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "query %s: %v\n", name, err)
+		return 2
+	}
+	// Flags given here come last, so they override the saved ones.
+	return runMain(flag.NewFlagSet("run "+name, flag.ContinueOnError), append(qargs, fs.Args()[1:]...))
+}
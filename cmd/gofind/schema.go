@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Hamed0406/gofind/internal/finder"
+)
+
+func init() {
+	subcommands["schema"] = runSchema
+}
+
+// runSchema prints the JSON Schema of the output of --output FORMAT with
+// --schema VERSION: an array of entries for json, a single entry (one
+// record) for ndjson and json-seq.
+func runSchema(args []string) int {
+	fs := flag.NewFlagSet("schema", flag.ContinueOnError)
+	format := fs.String("output", "json", "output format whose schema to print: json, ndjson or json-seq")
+	versionStr := fs.String("schema", "v1", "output schema version: "+strings.Join(flagValues["schema"], ", "))
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	version, err := parseSchemaVersion(*versionStr)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	f, err := parseOutputFormat("output", *format)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	if f == finder.OutputText {
+		fmt.Fprintln(os.Stderr, "schema: text output has no schema")
+		return 2
+	}
+
+	if f != finder.OutputJSON {
+		if _, err := os.Stdout.Write(finder.EntrySchema(version)); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		return 0
+	}
+
+	var entry map[string]any
+	if err := json.Unmarshal(finder.EntrySchema(version), &entry); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	dialect := entry["$schema"]
+	delete(entry, "$schema")
+	delete(entry, "$id")
+	schema := map[string]any{
+		"$schema":     dialect,
+		"$id":         fmt.Sprintf("https://github.com/Hamed0406/gofind/schema/v%d/output.json", version),
+		"title":       "gofind JSON output",
+		"description": fmt.Sprintf("The array written by --output json. Version %d.", version),
+		"type":        "array",
+		"items":       entry,
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(schema); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"os/exec"
+	"testing"
+)
+
+func TestCLI_Schema(t *testing.T) {
+	bin := buildCLI(t)
+	td := t.TempDir()
+	_ = mk(t, td, "a.txt", 3)
+
+	out, err := exec.Command(bin, "schema", "--output", "json").Output()
+	if err != nil {
+		t.Fatalf("schema: %v", err)
+	}
+	var s struct {
+		ID    string `json:"$id"`
+		Type  string `json:"type"`
+		Items struct {
+			Required   []string                   `json:"required"`
+			Properties map[string]json.RawMessage `json:"properties"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(out, &s); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out)
+	}
+	if s.Type != "array" || s.ID == "" || len(s.Items.Required) == 0 {
+		t.Fatalf("unexpected schema: %s", out)
+	}
+
+	// Every field of real output is described.
+	out, err = exec.Command(bin, "-root", td, "-json", "--enrich", "hash,lines").Output()
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	var entries []map[string]any
+	if err := json.Unmarshal(out, &entries); err != nil || len(entries) != 1 {
+		t.Fatalf("invalid output: %v\n%s", err, out)
+	}
+	for k := range entries[0] {
+		if s.Items.Properties[k] == nil {
+			t.Errorf("field %q missing from the schema", k)
+		}
+	}
+	for _, k := range s.Items.Required {
+		if _, ok := entries[0][k]; !ok {
+			t.Errorf("required field %q missing from the output", k)
+		}
+	}
+
+	// Version 2 output matches the version 2 schema.
+	out, err = exec.Command(bin, "schema", "--output", "ndjson", "--schema", "v2").Output()
+	if err != nil {
+		t.Fatalf("schema v2: %v", err)
+	}
+	var v2 struct {
+		Required   []string                   `json:"required"`
+		Properties map[string]json.RawMessage `json:"properties"`
+	}
+	if err := json.Unmarshal(out, &v2); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out)
+	}
+	out, err = exec.Command(bin, "-root", td, "-ndjson", "--schema", "v2", "--enrich", "hash").Output()
+	if err != nil {
+		t.Fatalf("search v2: %v", err)
+	}
+	var entry map[string]any
+	if err := json.Unmarshal(out, &entry); err != nil {
+		t.Fatalf("invalid output: %v\n%s", err, out)
+	}
+	for k := range entry {
+		if v2.Properties[k] == nil {
+			t.Errorf("v2 field %q missing from the schema", k)
+		}
+	}
+	for _, k := range v2.Required {
+		if _, ok := entry[k]; !ok {
+			t.Errorf("required v2 field %q missing from the output", k)
+		}
+	}
+
+	if err := exec.Command(bin, "schema", "--output", "text").Run(); err == nil {
+		t.Fatal("schema of text output accepted")
+	}
+}
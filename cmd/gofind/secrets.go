@@ -0,0 +1,187 @@
+package main
+
+import (
+	"cmp"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/Hamed0406/gofind/internal/finder"
+	"github.com/Hamed0406/gofind/internal/secrets"
+)
+
+func init() {
+	subcommands["secrets"] = runSecrets
+}
+
+// secretIgnores are skipped by gofind secrets along with the --smart-ignore
+// preset: generated files whose hashes and digests look like secrets.
+var secretIgnores = []string{"package-lock.json", "yarn.lock", "pnpm-lock.yaml", "go.sum", "Cargo.lock", "poetry.lock", "composer.lock", "*.min.js", "*.map"}
+
+// secretFinding is a secret found in a file.
+type secretFinding struct {
+	Path string `json:"path"`
+	Line int    `json:"line"`
+	secrets.Finding
+}
+
+// secretsReport lists findings by path and line, with the number of each
+// severity.
+type secretsReport struct {
+	Files      int             `json:"files"`
+	Severities map[string]int  `json:"severities"`
+	Findings   []secretFinding `json:"findings"`
+}
+
+// runSecrets scans file contents for credentials with the rules of package
+// secrets. It accepts all search flags, but searches hidden files and
+// skips the --smart-ignore preset by default. The exit status is 1 when
+// secrets are found and 2 on errors.
+func runSecrets(args []string) int {
+	fs := flag.NewFlagSet("secrets", flag.ContinueOnError)
+	sf := defineSearchFlags(fs)
+	// .env files and .aws or .ssh directories are where secrets live.
+	setDefault(fs, "include-hidden", "true")
+	setDefault(fs, "smart-ignore", "true")
+	rulesCSV := fs.String("rules", "", "comma-separated rules to apply (default all): "+strings.Join(secrets.RuleNames(), ", "))
+	minSeverity := fs.String("min-severity", "low", "report only secrets of at least this severity: low, medium, high or critical")
+	minEntropy := fs.Float64("min-entropy", secrets.DefaultMinEntropy, "bits of entropy per character a password or other free-form secret needs to be reported")
+	show := fs.Bool("show-secrets", false, "print secrets in full instead of masking all but their first four characters")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *sf.contentRe != "" || *sf.outputFmt == "grep" {
+		fmt.Fprintln(os.Stderr, "gofind secrets sets the content pattern itself; --content-regex and --output grep do not apply")
+		return 2
+	}
+	sc := secrets.Scanner{MinEntropy: *minEntropy}
+	sev, err := secrets.ParseSeverity(*minSeverity)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid --min-severity: %v\n", err)
+		return 2
+	}
+	sc.MinSeverity = sev
+	if *rulesCSV == "" {
+		sc.Rules = secrets.Rules
+	}
+	for _, name := range strings.Split(*rulesCSV, ",") {
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+		i := slices.IndexFunc(secrets.Rules, func(r secrets.Rule) bool { return r.Name == name })
+		if i < 0 {
+			fmt.Fprintf(os.Stderr, "invalid --rules: unknown rule %q (want %s)\n", name, strings.Join(secrets.RuleNames(), ", "))
+			return 2
+		}
+		sc.Rules = append(sc.Rules, secrets.Rules[i])
+	}
+
+	cfg, err := sf.config()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	if cfg.Ignore != nil && cfg.Ignore.Smart {
+		cfg.Ignore.Patterns = append(cfg.Ignore.Patterns, secretIgnores...)
+	}
+	cfg.ContentRegex, cfg.MatchLines, cfg.ContextLines = sc.Pattern(), true, 0
+	stopProfiles, err := sf.startProfiles()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	defer stopProfiles()
+	out, closeOut, err := createOutput(*sf.outPath, *sf.compress)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	defer closeOut()
+
+	ctx, cancel := signalContext(*sf.timeout)
+	defer cancel()
+	rep := secretsReport{Severities: map[string]int{}, Findings: []secretFinding{}}
+	res, err := finder.Walk(ctx, cfg, func(e finder.Entry) error {
+		n := len(rep.Findings)
+		for _, m := range e.Matches {
+			for _, f := range sc.Scan(m.Text) {
+				if !*show {
+					f.Secret = secrets.Redact(f.Secret)
+				}
+				rep.Findings = append(rep.Findings, secretFinding{Path: e.Path, Line: m.Line, Finding: f})
+				rep.Severities[f.Severity.String()]++
+			}
+		}
+		if len(rep.Findings) > n {
+			rep.Files++
+		}
+		return nil
+	})
+	code := searchStatus(res, err)
+	if code != 0 && !res.Interrupted {
+		return 2
+	}
+
+	slices.SortFunc(rep.Findings, func(a, b secretFinding) int {
+		return cmp.Or(strings.Compare(a.Path, b.Path), cmp.Compare(a.Line, b.Line), cmp.Compare(a.Column, b.Column))
+	})
+	if err := writeSecretsReport(out, rep, cfg); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	if err := closeOut(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	if code == 0 && len(rep.Findings) > 0 {
+		return 1
+	}
+	return code
+}
+
+// setDefault changes the default of the flag name of fs, for commands that
+// need other defaults than a search; the environment and the config file
+// still override it.
+func setDefault(fs *flag.FlagSet, name, value string) {
+	f := fs.Lookup(name)
+	if err := f.Value.Set(value); err != nil {
+		panic(err)
+	}
+	f.DefValue = value
+}
+
+func writeSecretsReport(w io.Writer, r secretsReport, cfg finder.Config) error {
+	if cfg.OutputFormat != finder.OutputText {
+		enc := json.NewEncoder(w)
+		if cfg.PrettyJSON {
+			enc.SetIndent("", "  ")
+		}
+		return enc.Encode(r)
+	}
+	// Like compiler diagnostics, so editors can jump to each finding.
+	for _, f := range r.Findings {
+		line := fmt.Sprintf("%s:%d:%d: %s %s", f.Path, f.Line, f.Column, f.Severity, f.Rule)
+		if f.Secret != "" {
+			line += " " + f.Secret
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	if len(r.Findings) == 0 {
+		_, err := fmt.Fprintln(w, "no secrets found")
+		return err
+	}
+	var counts []string
+	for s := secrets.Critical; s >= secrets.Low; s-- {
+		if n := r.Severities[s.String()]; n > 0 {
+			counts = append(counts, fmt.Sprintf("%d %s", n, s))
+		}
+	}
+	_, err := fmt.Fprintf(w, "\n%d secrets in %d files (%s)\n", len(r.Findings), r.Files, strings.Join(counts, ", "))
+	return err
+}
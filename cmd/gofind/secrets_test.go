@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestCLI_Secrets(t *testing.T) {
+	bin := buildCLI(t)
+	td := t.TempDir()
+	// Assembled from parts so this file does not trip secret scanners.
+	keyID := "AKIA" + "Q3EGRTZ7B4XKM2PN"
+	for rel, content := range map[string]string{
+		".env":                  "DB_PASSWORD=changeme\naws_access_key_id=" + keyID + "\n",
+		"deploy/id_rsa":         "-----BEGIN RSA " + "PRIVATE KEY-----\nMIIEow\n",
+		"node_modules/a/key.js": "const k = '" + keyID + "'\n",
+		"package-lock.json":     `{"k": "` + keyID + `"}` + "\n",
+		"README.md":             "key: " + keyID + " <!-- gofind:ignore -->\n",
+	} {
+		p := filepath.Join(td, rel)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	out, err := exec.Command(bin, "secrets", "--root", td, "--json").Output()
+	var ee *exec.ExitError
+	if !errors.As(err, &ee) || ee.ExitCode() != 1 {
+		t.Fatalf("want exit status 1 for findings, got %v", err)
+	}
+	var rep struct {
+		Files      int            `json:"files"`
+		Severities map[string]int `json:"severities"`
+		Findings   []struct {
+			Path   string `json:"path"`
+			Line   int    `json:"line"`
+			Rule   string `json:"rule"`
+			Secret string `json:"secret"`
+		} `json:"findings"`
+	}
+	if err := json.Unmarshal(out, &rep); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out)
+	}
+	if rep.Files != 2 || len(rep.Findings) != 2 || rep.Severities["critical"] != 1 || rep.Severities["high"] != 1 {
+		t.Fatalf("unexpected findings: %s", out)
+	}
+	if f := rep.Findings[0]; f.Path != filepath.Join(td, ".env") || f.Line != 2 || f.Rule != "aws-access-key-id" || f.Secret != "AKIA****************" {
+		t.Fatalf("unexpected first finding: %+v", f)
+	}
+	if f := rep.Findings[1]; f.Rule != "private-key" || f.Secret != "" {
+		t.Fatalf("unexpected second finding: %+v", f)
+	}
+
+	if err := exec.Command(bin, "secrets", "--root", td, "--rules", "jwt").Run(); err != nil {
+		t.Fatalf("want exit status 0 without findings, got %v", err)
+	}
+	err = exec.Command(bin, "secrets", "--root", td, "--min-severity", "severe").Run()
+	if !errors.As(err, &ee) || ee.ExitCode() != 2 {
+		t.Fatalf("want exit status 2 for an invalid --min-severity, got %v", err)
+	}
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/Hamed0406/gofind/internal/finder"
+)
+
+// rootShard names the --shard-by-dir shard of entries directly below a root.
+// sanitizeShard doubles a leading underscore, so no directory shares it.
+const rootShard = "_root"
+
+// maxOpenShards bounds the files --shard-by-dir keeps open. A shard closed to
+// make room is reopened for appending; gzip and zstd readers both accept
+// the resulting concatenated streams.
+const maxOpenShards = 64
+
+// shardWriter splits the output into files named after a pattern, starting a
+// new numbered file (%d) once the current one holds limit bytes, or writing
+// each top-level directory below the roots to its own file (%s). With several
+// roots, top-level directories of the same name share a file, as the entries
+// directly below any of them do; the records keep their full paths. It relies
+// on finder.RecordWriter to split at record boundaries.
+type shardWriter struct {
+	pattern       string
+	limit         int64
+	byDir         bool
+	roots         []string
+	newCompressor func(io.Writer) (io.WriteCloser, error)
+
+	next  int                   // number of the next size shard
+	cur   *shardFile            // current size shard
+	open  map[string]*shardFile // open dir shards
+	seen  map[string]bool       // dir shards created so far
+	clock int64
+}
+
+type shardFile struct {
+	w       io.Writer
+	closers []io.Closer
+	size    int64
+	used    int64
+}
+
+func (s *shardFile) close() error {
+	var err error
+	for _, c := range s.closers {
+		err = errors.Join(err, c.Close())
+	}
+	return err
+}
+
+// createShardedOutput validates the sharding flags and returns the writer
+// for the --out files and its close function.
+func createShardedOutput(sf *searchFlags, cfg finder.Config) (io.Writer, func() error, error) {
+	pattern := strings.TrimSpace(*sf.outPath)
+	sw := &shardWriter{pattern: pattern, byDir: *sf.shardByDir}
+	switch {
+	case *sf.shardSize != "" && sw.byDir:
+		return nil, nil, errors.New("use either --shard-size or --shard-by-dir")
+	case sf.outFileFormat(cfg) == finder.OutputJSON:
+		return nil, nil, errors.New("sharded output needs a line-based format (text, --ndjson or --output json-seq)")
+	case sw.byDir && !strings.Contains(pattern, "%s"):
+		return nil, nil, fmt.Errorf("--shard-by-dir needs %%s in the --out pattern, e.g. results-%%s.ndjson")
+	case !sw.byDir && !strings.Contains(pattern, "%d"):
+		return nil, nil, fmt.Errorf("--shard-size needs %%d in the --out pattern, e.g. results-%%d.ndjson")
+	}
+	if !sw.byDir {
+		n, err := parseSize(*sf.shardSize)
+		if err != nil || n <= 0 {
+			return nil, nil, fmt.Errorf("invalid --shard-size: %q", *sf.shardSize)
+		}
+		sw.limit = n
+	}
+	var err error
+	if sw.newCompressor, err = compressor(*sf.compress); err != nil {
+		return nil, nil, err
+	}
+	sw.roots = cfg.Roots
+	if len(sw.roots) == 0 {
+		sw.roots = []string{cfg.Root}
+	}
+	sw.open = make(map[string]*shardFile)
+	sw.seen = make(map[string]bool)
+	return sw, sw.Close, nil
+}
+
+// Write rejects unframed output; see finder.RecordWriter.
+func (sw *shardWriter) Write([]byte) (int, error) {
+	return 0, errors.New("sharded output needs a line-based format")
+}
+
+// WriteRecord appends record to the shard e belongs to.
+func (sw *shardWriter) WriteRecord(e finder.Entry, record []byte) error {
+	var (
+		sh  *shardFile
+		err error
+	)
+	if sw.byDir {
+		sh, err = sw.dirShard(sw.topDir(e))
+	} else {
+		sh, err = sw.sizeShard(int64(len(record)))
+	}
+	if err != nil {
+		return err
+	}
+	n, err := sh.w.Write(record)
+	sh.size += int64(n)
+	return err
+}
+
+// sizeShard returns the current numbered shard, starting the next one when
+// n more bytes would overflow it.
+func (sw *shardWriter) sizeShard(n int64) (*shardFile, error) {
+	if sw.cur != nil && sw.cur.size > 0 && sw.cur.size+n > sw.limit {
+		err := sw.cur.close()
+		sw.cur = nil
+		if err != nil {
+			return nil, err
+		}
+	}
+	if sw.cur == nil {
+		sh, err := sw.create(strings.ReplaceAll(sw.pattern, "%d", strconv.Itoa(sw.next)), false)
+		if err != nil {
+			return nil, err
+		}
+		sw.cur = sh
+		sw.next++
+	}
+	return sw.cur, nil
+}
+
+// dirShard returns the open shard for the top-level directory dir, closing
+// the least recently used one when too many are open.
+func (sw *shardWriter) dirShard(dir string) (*shardFile, error) {
+	sw.clock++
+	if sh, ok := sw.open[dir]; ok {
+		sh.used = sw.clock
+		return sh, nil
+	}
+	if len(sw.open) >= maxOpenShards {
+		var lru string
+		for d, sh := range sw.open {
+			if lru == "" || sh.used < sw.open[lru].used {
+				lru = d
+			}
+		}
+		err := sw.open[lru].close()
+		delete(sw.open, lru)
+		if err != nil {
+			return nil, err
+		}
+	}
+	sh, err := sw.create(strings.ReplaceAll(sw.pattern, "%s", dir), sw.seen[dir])
+	if err != nil {
+		return nil, err
+	}
+	sh.used = sw.clock
+	sw.open[dir] = sh
+	sw.seen[dir] = true
+	return sh, nil
+}
+
+// create opens a shard file, truncating it unless reopen is set.
+func (sw *shardWriter) create(path string, reopen bool) (*shardFile, error) {
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if reopen {
+		flags = os.O_WRONLY | os.O_APPEND
+	}
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, err
+		}
+	}
+	f, err := os.OpenFile(path, flags, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create output file %q: %v", path, err)
+	}
+	sh := &shardFile{w: f, closers: []io.Closer{f}}
+	if sw.newCompressor != nil {
+		cw, err := sw.newCompressor(f)
+		if err != nil {
+			_ = f.Close()
+			return nil, err
+		}
+		sh.w, sh.closers = cw, []io.Closer{cw, f}
+	}
+	return sh, nil
+}
+
+// topDir returns the first element of e's path below the root containing
+// it, or rootShard for files directly below a root, made safe for use in a
+// file name.
+func (sw *shardWriter) topDir(e finder.Entry) string {
+	rel := ""
+	for _, root := range sw.roots {
+		r, err := filepath.Rel(root, e.Path)
+		if err != nil || r == ".." || strings.HasPrefix(r, ".."+string(filepath.Separator)) {
+			continue
+		}
+		if rel == "" || len(r) < len(rel) {
+			rel = r
+		}
+	}
+	top, _, nested := strings.Cut(filepath.ToSlash(rel), "/")
+	if top == "" || top == "." || !nested && !e.IsDir {
+		return rootShard
+	}
+	return sanitizeShard(top)
+}
+
+// sanitizeShard makes name safe for use in a file name, replacing the
+// characters file systems reject and doubling a leading underscore so that
+// no directory is named as rootShard.
+func sanitizeShard(name string) string {
+	name = strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '\\', ':', '*', '?', '"', '<', '>', '|':
+			return '_'
+		}
+		return r
+	}, name)
+	if strings.HasPrefix(name, "_") {
+		name = "_" + name
+	}
+	return name
+}
+
+// Close closes every open shard.
+func (sw *shardWriter) Close() error {
+	var err error
+	if sw.cur != nil {
+		err = sw.cur.close()
+		sw.cur = nil
+	}
+	for d, sh := range sw.open {
+		err = errors.Join(err, sh.close())
+		delete(sw.open, d)
+	}
+	return err
+}
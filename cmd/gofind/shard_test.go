@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/Hamed0406/gofind/internal/finder"
+)
+
+// runSharded searches root with the given flags and returns the created
+// files and their contents.
+func runSharded(t *testing.T, root string, args ...string) map[string]string {
+	t.Helper()
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	sf := defineSearchFlags(fs)
+	if err := fs.Parse(append([]string{"-root", root}, args...)); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := sf.config()
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, closeOut, err := createShardedOutput(sf, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := finder.Run(context.Background(), out, cfg); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if err := closeOut(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	files := map[string]string{}
+	matches, _ := filepath.Glob(filepath.Join(filepath.Dir(*sf.outPath), "*"))
+	for _, m := range matches {
+		b, err := os.ReadFile(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[filepath.Base(m)] = string(b)
+	}
+	return files
+}
+
+func baseNames(s string) []string {
+	ls := strings.Split(strings.TrimSpace(s), "\n")
+	for i, l := range ls {
+		ls[i] = filepath.Base(l)
+	}
+	sort.Strings(ls)
+	return ls
+}
+
+func TestShardByDir(t *testing.T) {
+	td := t.TempDir()
+	for _, rel := range []string{"a/x.txt", "a/deep/y.txt", "b/z.txt", "_root/w.txt", "top.txt"} {
+		mk(t, td, rel, 1)
+	}
+	files := runSharded(t, td, "-shard-by-dir", "-out", filepath.Join(t.TempDir(), "part-%s.txt"))
+
+	want := map[string][]string{
+		"part-a.txt":     {"a", "deep", "x.txt", "y.txt"},
+		"part-b.txt":     {"b", "z.txt"},
+		"part-_root.txt": {"top.txt"},
+		// A directory named like the root shard gets its own file.
+		"part-__root.txt": {"_root", "w.txt"},
+	}
+	if len(files) != len(want) {
+		t.Fatalf("got files %v", files)
+	}
+	for name, w := range want {
+		if got := baseNames(files[name]); strings.Join(got, ",") != strings.Join(w, ",") {
+			t.Errorf("%s = %v, want %v", name, got, w)
+		}
+	}
+}
+
+func TestShardBySize(t *testing.T) {
+	td := t.TempDir()
+	for _, n := range []string{"a", "b", "c", "d", "e", "f"} {
+		mk(t, td, n+".txt", 1)
+	}
+	limit := int64(2 * len(filepath.Join(td, "a.txt")+"\n"))
+	files := runSharded(t, td, "-shard-size", "2B", "-out", filepath.Join(t.TempDir(), "part-%d.txt"))
+	if len(files) != 6 { // every record exceeds 2 bytes: one per shard
+		t.Fatalf("2B shards: got %d files", len(files))
+	}
+
+	files = runSharded(t, td, "-shard-size", strconv.FormatInt(limit, 10)+"B", "-out", filepath.Join(t.TempDir(), "part-%d.txt"))
+	if len(files) != 3 {
+		t.Fatalf("two records per shard: got %d files: %v", len(files), files)
+	}
+	var all []string
+	for _, content := range files {
+		all = append(all, baseNames(content)...)
+	}
+	sort.Strings(all)
+	if strings.Join(all, ",") != "a.txt,b.txt,c.txt,d.txt,e.txt,f.txt" {
+		t.Fatalf("records = %v", all)
+	}
+}
+
+func TestShardFlagValidation(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	sf := defineSearchFlags(fs)
+	for _, args := range [][]string{
+		{"-shard-size", "1MB", "-out", "x.ndjson"},              // no %d
+		{"-shard-by-dir", "-out", "x-%d.ndjson"},                // no %s
+		{"-shard-size", "1MB", "-json", "-out", "x-%d.json"},    // JSON array
+		{"-shard-size", "1MB", "-shard-by-dir", "-out", "%s%d"}, // both
+	} {
+		if err := fs.Parse(args); err != nil {
+			t.Fatal(err)
+		}
+		cfg, err := sf.config()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := createShardedOutput(sf, cfg); err == nil {
+			t.Errorf("%v: want an error", args)
+		}
+		*sf.shardByDir, *sf.jsonOut = false, false
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/Hamed0406/gofind/internal/finder"
+)
+
+func init() {
+	subcommands["snapshot"] = runSnapshot
+}
+
+// runSnapshot compares two snapshots, the saved JSON, NDJSON or JSON
+// sequence output of earlier searches, without reading the tree they
+// describe. The exit status is 0 when they match and 1 when they differ,
+// as with diff.
+func runSnapshot(args []string) int {
+	fs := flag.NewFlagSet("snapshot", flag.ContinueOnError)
+	jsonOut := fs.Bool("json", false, "print the differences as a JSON array")
+	ndjsonOut := fs.Bool("ndjson", false, "print the differences as NDJSON")
+	outputFmt := fs.String("output", "", "output format: text, json, ndjson or json-seq; overrides --json and --ndjson")
+	pretty := fs.Bool("pretty", false, "pretty-print JSON")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), `usage: gofind snapshot diff [flags] OLD NEW
+
+diff lists the entries added, removed and changed (in size, modification
+time, mode, or sha256 when both have one) between two snapshots: files of
+--json, --ndjson or --output json-seq output, optionally compressed, or -
+for stdin. Text lines start with +, - or ~; JSON records carry "change".
+`)
+		fs.PrintDefaults()
+	}
+	if len(args) == 0 || args[0] != "diff" {
+		fs.Usage()
+		return 2
+	}
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return 2
+	}
+
+	cfg := finder.Config{OutputFormat: finder.OutputText, PrettyJSON: *pretty, Quote: finder.QuoteEscape}
+	if isTerminal(os.Stdout) {
+		cfg.Quote = finder.QuoteShell
+	}
+	switch {
+	case *outputFmt != "":
+		f, err := parseOutputFormat("output", *outputFmt)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+		cfg.OutputFormat = f
+	case *ndjsonOut:
+		cfg.OutputFormat = finder.OutputNDJSON
+	case *jsonOut:
+		cfg.OutputFormat = finder.OutputJSON
+	}
+
+	var snaps [2]*finder.Baseline
+	for i, name := range fs.Args() {
+		b, err := loadSnapshot(name)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", name, err)
+			return 2
+		}
+		snaps[i] = b
+	}
+	diff := snaps[0].Diff(snaps[1])
+	if err := finder.WriteEntries(os.Stdout, cfg, diff); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	if len(diff) > 0 {
+		return 1
+	}
+	return 0
+}
+
+// loadSnapshot reads the snapshot in the file name, or stdin for "-".
+func loadSnapshot(name string) (*finder.Baseline, error) {
+	if name == "-" {
+		return finder.LoadBaseline(os.Stdin)
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return finder.LoadBaseline(f)
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCLI_SnapshotDiff(t *testing.T) {
+	bin := buildCLI(t)
+	td := t.TempDir()
+	tree := filepath.Join(td, "tree")
+	a := mk(t, tree, "a.txt", 1)
+	_ = mk(t, tree, "b.txt", 1)
+	snapshot := func(name string, args ...string) string {
+		t.Helper()
+		path := filepath.Join(td, name)
+		out, err := exec.Command(bin, append([]string{"--root", tree, "--type", "f"}, args...)...).Output()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, out, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	old := snapshot("old.ndjson", "--ndjson", "--emit-meta")
+	if err := os.Remove(a); err != nil {
+		t.Fatal(err)
+	}
+	_ = mk(t, tree, "c.txt", 1)
+	newer := snapshot("new.json", "--json")
+	// The diff needs nothing but the snapshots.
+	if err := os.RemoveAll(tree); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := exec.Command(bin, "snapshot", "diff", "--ndjson", old, newer).Output()
+	var ee *exec.ExitError
+	if !errors.As(err, &ee) || ee.ExitCode() != 1 {
+		t.Fatalf("exit: %v", err)
+	}
+	var got []string
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		var e struct{ Path, Change string }
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			t.Fatalf("%q: %v", line, err)
+		}
+		got = append(got, e.Change+" "+filepath.Base(e.Path))
+	}
+	if want := "removed a.txt,added c.txt"; strings.Join(got, ",") != want {
+		t.Errorf("diff = %q, want %q", got, want)
+	}
+
+	out, err = exec.Command(bin, "snapshot", "diff", newer, newer).Output()
+	if err != nil || len(out) != 0 {
+		t.Errorf("identical snapshots: %q, %v", out, err)
+	}
+}
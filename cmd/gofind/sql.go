@@ -0,0 +1,524 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"container/heap"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/Hamed0406/gofind/internal/expr"
+	"github.com/Hamed0406/gofind/internal/finder"
+	"github.com/Hamed0406/gofind/internal/stats"
+)
+
+func init() {
+	subcommands["sql"] = runSQL
+}
+
+// sqlStarColumns are the columns of SELECT *.
+var sqlStarColumns = []string{"path", "type", "size", "mtime"}
+
+// sqlQuery is a parsed `gofind sql` statement:
+//
+//	SELECT columns FROM files [WHERE cond] [ORDER BY column [ASC|DESC], ...] [LIMIT n]
+//
+// The columns are --where fields, and the condition is a --where expression,
+// which accepts SQL's =, <>, AND, OR, NOT, IN and LIKE.
+type sqlQuery struct {
+	columns []string
+	where   string
+	orderBy []sqlOrder
+	// limit is the most rows returned, or -1 for all.
+	limit int
+}
+
+type sqlOrder struct {
+	column string
+	desc   bool
+}
+
+// sqlWord is a keyword or identifier of a statement, with its byte offsets.
+type sqlWord struct {
+	text       string // lowercased
+	start, end int
+}
+
+// sqlWords lists the words of q outside quoted strings.
+func sqlWords(q string) ([]sqlWord, error) {
+	var words []sqlWord
+	for i := 0; i < len(q); {
+		c := q[i]
+		switch {
+		case c == '\'' || c == '"':
+			j := i + 1
+			for ; j < len(q) && q[j] != c; j++ {
+				if q[j] == '\\' {
+					j++
+				}
+			}
+			if j >= len(q) {
+				return nil, fmt.Errorf("unterminated string at %d", i)
+			}
+			i = j + 1
+		case c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+			j := i
+			for j < len(q) && (q[j] == '_' || q[j] == '.' || q[j] >= 'a' && q[j] <= 'z' || q[j] >= 'A' && q[j] <= 'Z' || q[j] >= '0' && q[j] <= '9') {
+				j++
+			}
+			words = append(words, sqlWord{strings.ToLower(q[i:j]), i, j})
+			i = j
+		default:
+			i++
+		}
+	}
+	return words, nil
+}
+
+// parseSQL parses a statement; see sqlQuery.
+func parseSQL(q string) (sqlQuery, error) {
+	q = strings.TrimSuffix(strings.TrimSpace(q), ";")
+	words, err := sqlWords(q)
+	if err != nil {
+		return sqlQuery{}, err
+	}
+	if len(words) == 0 || words[0].text != "select" || strings.TrimSpace(q[:words[0].start]) != "" {
+		return sqlQuery{}, errors.New("expected SELECT")
+	}
+	// Find the clauses, which must come in this order.
+	clauses := []string{"from", "where", "order", "limit"}
+	at := map[string]int{} // word index of each clause keyword present
+	next := 0
+	for i, w := range words[1:] {
+		for k := next; k < len(clauses); k++ {
+			if w.text == clauses[k] {
+				at[w.text], next = i+1, k+1
+				break
+			}
+		}
+	}
+	from, ok := at["from"]
+	if !ok {
+		return sqlQuery{}, errors.New("expected FROM files")
+	}
+	// end returns where the clause starting at word index i ends: at the
+	// next clause keyword, or the end of q.
+	end := func(i int) int {
+		e := len(q)
+		for _, j := range at {
+			if j > i && words[j].start < e {
+				e = words[j].start
+			}
+		}
+		return e
+	}
+
+	var sq sqlQuery
+	if sq.columns, err = parseSQLColumns(q[words[0].end:words[from].start]); err != nil {
+		return sqlQuery{}, err
+	}
+	if table := strings.TrimSpace(q[words[from].end:end(from)]); !strings.EqualFold(table, "files") {
+		return sqlQuery{}, fmt.Errorf("unknown table %q (want files)", table)
+	}
+	if i, ok := at["where"]; ok {
+		if sq.where = strings.TrimSpace(q[words[i].end:end(i)]); sq.where == "" {
+			return sqlQuery{}, errors.New("empty WHERE clause")
+		}
+	}
+	if i, ok := at["order"]; ok {
+		if i+1 >= len(words) || words[i+1].text != "by" {
+			return sqlQuery{}, errors.New("expected ORDER BY")
+		}
+		if sq.orderBy, err = parseSQLOrder(q[words[i+1].end:end(i)]); err != nil {
+			return sqlQuery{}, err
+		}
+	}
+	sq.limit = -1
+	if i, ok := at["limit"]; ok {
+		s := strings.TrimSpace(q[words[i].end:])
+		if sq.limit, err = strconv.Atoi(s); err != nil || sq.limit < 0 {
+			return sqlQuery{}, fmt.Errorf("invalid LIMIT %q", s)
+		}
+	}
+	return sq, nil
+}
+
+func parseSQLColumns(s string) ([]string, error) {
+	var cols []string
+	for _, c := range strings.Split(s, ",") {
+		c = strings.TrimSpace(c)
+		switch {
+		case c == "*":
+			cols = append(cols, sqlStarColumns...)
+		case strings.Contains(c, "("):
+			return nil, fmt.Errorf("unsupported column %q: there are no functions or aggregates (see gofind analyze)", c)
+		default:
+			if err := checkSQLColumn(c); err != nil {
+				return nil, err
+			}
+			cols = append(cols, c)
+		}
+	}
+	return cols, nil
+}
+
+func parseSQLOrder(s string) ([]sqlOrder, error) {
+	var order []sqlOrder
+	for _, term := range strings.Split(s, ",") {
+		f := strings.Fields(term)
+		if len(f) == 0 || len(f) > 2 {
+			return nil, fmt.Errorf("invalid ORDER BY term %q", strings.TrimSpace(term))
+		}
+		o := sqlOrder{column: f[0]}
+		if len(f) == 2 {
+			switch strings.ToLower(f[1]) {
+			case "asc":
+			case "desc":
+				o.desc = true
+			default:
+				return nil, fmt.Errorf("invalid ORDER BY term %q (want ASC or DESC)", strings.TrimSpace(term))
+			}
+		}
+		if err := checkSQLColumn(o.column); err != nil {
+			return nil, err
+		}
+		order = append(order, o)
+	}
+	return order, nil
+}
+
+func checkSQLColumn(c string) error {
+	if c == "" {
+		return errors.New("empty column")
+	}
+	if _, ok := finder.WhereField(c); !ok {
+		return fmt.Errorf("unknown column %q (want a --where field such as path, name, ext, size or mtime)", c)
+	}
+	return nil
+}
+
+// sqlRow is a result row: the selected columns, then the ORDER BY ones.
+type sqlRow struct {
+	values []expr.Value
+	seq    int // arrival order, to keep sorting stable
+}
+
+// sqlTop keeps the first limit rows by the ORDER BY columns as a heap with
+// the last of them on top.
+type sqlTop struct {
+	rows  []sqlRow
+	less  func(a, b sqlRow) bool
+	limit int
+}
+
+func (h *sqlTop) Len() int           { return len(h.rows) }
+func (h *sqlTop) Less(i, j int) bool { return h.less(h.rows[j], h.rows[i]) }
+func (h *sqlTop) Swap(i, j int)      { h.rows[i], h.rows[j] = h.rows[j], h.rows[i] }
+func (h *sqlTop) Push(x any)         { h.rows = append(h.rows, x.(sqlRow)) }
+func (h *sqlTop) Pop() any {
+	r := h.rows[len(h.rows)-1]
+	h.rows = h.rows[:len(h.rows)-1]
+	return r
+}
+
+func (h *sqlTop) add(r sqlRow) {
+	if h.limit < 0 || len(h.rows) < h.limit {
+		heap.Push(h, r)
+	} else if len(h.rows) > 0 && h.less(r, h.rows[0]) {
+		h.rows[0] = r
+		heap.Fix(h, 0)
+	}
+}
+
+// sorted returns the rows in order.
+func (h *sqlTop) sorted() []sqlRow {
+	out := make([]sqlRow, len(h.rows))
+	for i := len(out) - 1; i >= 0; i-- {
+		out[i] = heap.Pop(h).(sqlRow)
+	}
+	return out
+}
+
+// errSQLLimit stops the search once an unordered query has its rows.
+var errSQLLimit = errors.New("limit reached")
+
+// runSQL answers a SELECT over the entries a search finds, streaming them
+// unless the rows must be sorted. It accepts all search flags.
+func runSQL(args []string) int {
+	fs := flag.NewFlagSet("sql", flag.ContinueOnError)
+	sf := defineSearchFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: gofind sql [flags] \"SELECT path, size FROM files WHERE ext = '.log' ORDER BY size DESC LIMIT 20\"")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+	sq, err := parseSQL(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid query: %v\n", err)
+		return 2
+	}
+	if sq.where != "" {
+		if _, err := finder.CompileWhere(sq.where); err != nil {
+			fmt.Fprintf(os.Stderr, "invalid WHERE clause: %v\n", err)
+			return 2
+		}
+		sf.where = append(sf.where, sq.where)
+	}
+	cfg, err := sf.config()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	stopProfiles, err := sf.startProfiles()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	defer stopProfiles()
+	out, closeOut, err := createOutput(*sf.outPath, *sf.compress)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	defer closeOut()
+
+	roots := cfg.Roots
+	if len(roots) == 0 {
+		roots = []string{cfg.Root}
+	}
+	fields := append([]string(nil), sq.columns...)
+	for _, o := range sq.orderBy {
+		fields = append(fields, o.column)
+	}
+	row := func(e *finder.Entry, seq int) sqlRow {
+		root := sqlRoot(roots, e.Path)
+		r := sqlRow{values: make([]expr.Value, len(fields)), seq: seq}
+		for i, f := range fields {
+			r.values[i] = finder.EntryField(root, e, f)
+		}
+		return r
+	}
+
+	bw := bufio.NewWriter(out)
+	w := newSQLWriter(bw, cfg, sq.columns)
+	ctx, cancel := signalContext(*sf.timeout)
+	defer cancel()
+	var res finder.Result
+	if len(sq.orderBy) == 0 {
+		n := 0
+		res, err = finder.Walk(ctx, cfg, func(e finder.Entry) error {
+			if n == sq.limit {
+				return errSQLLimit
+			}
+			n++
+			return w.row(row(&e, n))
+		})
+		if errors.Is(err, errSQLLimit) {
+			// Stopping the walk early is not an interruption.
+			err, res.Interrupted = nil, false
+		}
+	} else {
+		top := &sqlTop{limit: sq.limit, less: func(a, b sqlRow) bool {
+			for i, o := range sq.orderBy {
+				c := expr.Compare(a.values[len(sq.columns)+i], b.values[len(sq.columns)+i])
+				if o.desc {
+					c = -c
+				}
+				if c != 0 {
+					return c < 0
+				}
+			}
+			return a.seq < b.seq
+		}}
+		n := 0
+		res, err = finder.Walk(ctx, cfg, func(e finder.Entry) error {
+			n++
+			top.add(row(&e, n))
+			return nil
+		})
+		// An interrupted search still reports what it saw.
+		if err == nil || res.Interrupted {
+			for _, r := range top.sorted() {
+				if werr := w.row(r); werr != nil {
+					err = werr
+					break
+				}
+			}
+		}
+	}
+	code := searchStatus(res, err)
+	if code != 0 && !res.Interrupted {
+		return code
+	}
+	if err := errors.Join(w.close(), bw.Flush(), closeOut()); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return code
+}
+
+// sqlRoot returns the root of roots that path is below, for the rel column.
+func sqlRoot(roots []string, path string) string {
+	for _, r := range roots {
+		if rel, err := filepath.Rel(r, path); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return r
+		}
+	}
+	return roots[0]
+}
+
+// sqlWriter writes result rows: as tab-separated text under a header line, or
+// as JSON objects in the JSON output formats.
+type sqlWriter struct {
+	w       io.Writer
+	cfg     finder.Config
+	columns []string
+	n       int
+	err     error
+}
+
+func newSQLWriter(w io.Writer, cfg finder.Config, columns []string) *sqlWriter {
+	return &sqlWriter{w: w, cfg: cfg, columns: columns}
+}
+
+func (s *sqlWriter) row(r sqlRow) error {
+	if s.err != nil {
+		return s.err
+	}
+	var b bytes.Buffer
+	switch s.cfg.OutputFormat {
+	case finder.OutputText:
+		if s.n == 0 {
+			b.WriteString(strings.Join(s.columns, "\t"))
+			b.WriteByte('\n')
+		}
+		for i, c := range s.columns {
+			if i > 0 {
+				b.WriteByte('\t')
+			}
+			b.WriteString(s.text(c, r.values[i]))
+		}
+		b.WriteByte('\n')
+	default:
+		switch {
+		case s.cfg.OutputFormat == finder.OutputJSONSeq:
+			b.WriteByte(0x1e)
+		case s.cfg.OutputFormat != finder.OutputJSON:
+		case s.n == 0:
+			b.WriteByte('[')
+		default:
+			b.WriteByte(',')
+		}
+		if s.cfg.OutputFormat == finder.OutputJSON && s.cfg.PrettyJSON {
+			b.WriteString("\n  ")
+		}
+		obj, err := s.object(r)
+		if err != nil {
+			return err
+		}
+		b.Write(obj)
+		if s.cfg.OutputFormat != finder.OutputJSON {
+			b.WriteByte('\n')
+		}
+	}
+	s.n++
+	_, s.err = s.w.Write(b.Bytes())
+	return s.err
+}
+
+// close ends the output; a JSON array is written even without rows.
+func (s *sqlWriter) close() error {
+	if s.err != nil || s.cfg.OutputFormat != finder.OutputJSON {
+		return s.err
+	}
+	end := "]\n"
+	switch {
+	case s.n == 0:
+		end = "[]\n"
+	case s.cfg.PrettyJSON:
+		end = "\n]\n"
+	}
+	_, s.err = io.WriteString(s.w, end)
+	return s.err
+}
+
+// text formats a value for text output, like the main listing: times in
+// local time in --time-layout, and sizes with --human.
+func (s *sqlWriter) text(column string, v expr.Value) string {
+	switch v.Kind {
+	case expr.Invalid:
+		return ""
+	case expr.Number:
+		if column == "size" && s.cfg.Human {
+			return stats.FormatBytes(int64(v.Num))
+		}
+		return strconv.FormatFloat(v.Num, 'f', -1, 64)
+	case expr.Time:
+		layout := s.cfg.TimeLayout
+		if layout == "" {
+			layout = finder.DefaultTimeLayout
+		}
+		return v.Time.Local().Format(layout)
+	case expr.String:
+		return v.Str
+	}
+	return v.String()
+}
+
+// object encodes the selected columns of r as a JSON object, in order.
+func (s *sqlWriter) object(r sqlRow) ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, c := range s.columns {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		k, _ := json.Marshal(c)
+		b.Write(k)
+		b.WriteByte(':')
+		var x any
+		switch v := r.values[i]; v.Kind {
+		case expr.Bool:
+			x = v.Bool
+		case expr.Number:
+			x = v.Num
+		case expr.String:
+			x = v.Str
+		case expr.Time:
+			x = v.Time
+		case expr.Duration:
+			x = v.Dur.String()
+		}
+		vb, err := json.Marshal(x)
+		if err != nil {
+			return nil, err
+		}
+		b.Write(vb)
+	}
+	b.WriteByte('}')
+	if s.cfg.PrettyJSON {
+		prefix := ""
+		if s.cfg.OutputFormat == finder.OutputJSON {
+			prefix = "  " // inside the array
+		}
+		var pretty bytes.Buffer
+		if err := json.Indent(&pretty, b.Bytes(), prefix, "  "); err != nil {
+			return nil, err
+		}
+		return pretty.Bytes(), nil
+	}
+	return b.Bytes(), nil
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseSQL(t *testing.T) {
+	q, err := parseSQL(`select path, size FROM files where ext = '.log' and name <> 'order by x' ORDER BY size DESC, name limit 20;`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := sqlQuery{
+		columns: []string{"path", "size"},
+		where:   `ext = '.log' and name <> 'order by x'`,
+		orderBy: []sqlOrder{{"size", true}, {"name", false}},
+		limit:   20,
+	}
+	if !reflect.DeepEqual(q, want) {
+		t.Fatalf("got %+v, want %+v", q, want)
+	}
+
+	if q, err = parseSQL(`SELECT * FROM files`); err != nil || q.limit != -1 || q.where != "" || len(q.columns) != len(sqlStarColumns) {
+		t.Fatalf("SELECT *: %+v, %v", q, err)
+	}
+
+	for _, tc := range []struct{ q, want string }{
+		{`path FROM files`, "expected SELECT"},
+		{`SELECT path`, "expected FROM"},
+		{`SELECT path FROM dirs`, "unknown table"},
+		{`SELECT owner FROM files`, "unknown column"},
+		{`SELECT count(*) FROM files`, "no functions or aggregates"},
+		{`SELECT path FROM files WHERE`, "empty WHERE"},
+		{`SELECT path FROM files ORDER size`, "expected ORDER BY"},
+		{`SELECT path FROM files ORDER BY size DOWN`, "want ASC or DESC"},
+		{`SELECT path FROM files LIMIT -1`, "invalid LIMIT"},
+		{`SELECT path FROM files WHERE name = 'x`, "unterminated"},
+	} {
+		if _, err := parseSQL(tc.q); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: err = %v, want %q", tc.q, err, tc.want)
+		}
+	}
+}
+
+func TestCLI_SQL(t *testing.T) {
+	bin := buildCLI(t)
+	td := t.TempDir()
+	_ = mk(t, td, "a.log", 300)
+	_ = mk(t, td, "b.log", 100)
+	_ = mk(t, td, "sub/c.log", 200)
+	_ = mk(t, td, "sub/d.txt", 900)
+
+	out, err := exec.Command(bin, "sql", "--root", td,
+		"SELECT rel, size FROM files WHERE ext = '.log' AND size > 1e2 ORDER BY size DESC LIMIT 2").Output()
+	if err != nil {
+		t.Fatalf("sql: %v", err)
+	}
+	if got, want := string(out), "rel\tsize\na.log\t300\nsub/c.log\t200\n"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+
+	out, err = exec.Command(bin, "sql", "--root", td, "--ndjson", "SELECT name FROM files WHERE name LIKE '%.txt'").Output()
+	if err != nil {
+		t.Fatalf("sql --ndjson: %v", err)
+	}
+	if got := string(out); got != "{\"name\":\"d.txt\"}\n" {
+		t.Fatalf("got %q", got)
+	}
+
+	if err := exec.Command(bin, "sql", "--root", td, "SELECT path FROM files WHERE size > 'big'").Run(); err == nil {
+		t.Fatal("invalid WHERE clause accepted")
+	}
+}
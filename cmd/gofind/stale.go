@@ -0,0 +1,144 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Hamed0406/gofind/internal/finder"
+	"github.com/Hamed0406/gofind/internal/stats"
+)
+
+func init() {
+	subcommands["stale"] = runStale
+}
+
+// staleFile is a cleanup candidate.
+type staleFile struct {
+	Path       string    `json:"path"`
+	Size       int64     `json:"size"`
+	ModTime    time.Time `json:"modTime"`
+	AccessTime time.Time `json:"accessTime,omitzero"`
+	// LastUsed is the later of ModTime and AccessTime.
+	LastUsed time.Time `json:"lastUsed"`
+	Owner    string    `json:"owner,omitempty"`
+}
+
+// staleReport ranks candidates by size, largest first.
+type staleReport struct {
+	OlderThan        string      `json:"olderThan"`
+	Files            int         `json:"files"`
+	ReclaimableBytes int64       `json:"reclaimableBytes"`
+	Candidates       []staleFile `json:"candidates"`
+}
+
+// runStale reports files neither modified nor accessed within --older-than,
+// largest first, with the total space they take. It accepts all search flags.
+func runStale(args []string) int {
+	fs := flag.NewFlagSet("stale", flag.ContinueOnError)
+	sf := defineSearchFlags(fs)
+	olderThan := fs.String("older-than", "180d", "minimum time since last modification and access (e.g. 90d, 26w, 1y)")
+	limit := fs.Int("limit", 0, "list at most this many candidates (0 = all); the totals still cover every file")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	age, err := parseAge(*olderThan)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid --older-than: %v\n", err)
+		return 2
+	}
+	cfg, err := sf.config()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	stopProfiles, err := sf.startProfiles()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	defer stopProfiles()
+	cutoff := time.Now().Add(-age)
+	// The modification time check runs in the walker; access time below.
+	if cfg.Before.IsZero() || cutoff.Before(cfg.Before) {
+		cfg.Before = cutoff
+	}
+	cfg.ShowAccessTime, cfg.ShowOwner = true, true
+
+	out, closeOut, err := createOutput(*sf.outPath, *sf.compress)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	defer closeOut()
+
+	ctx, cancel := signalContext(*sf.timeout)
+	defer cancel()
+	rep := staleReport{OlderThan: *olderThan}
+	res, err := finder.Walk(ctx, cfg, func(e finder.Entry) error {
+		if e.IsDir {
+			return nil
+		}
+		c := staleFile{Path: e.Path, Size: e.Size, ModTime: e.ModTime, AccessTime: e.AccessTime, LastUsed: e.ModTime, Owner: e.Owner}
+		if e.AccessTime.After(c.LastUsed) {
+			c.LastUsed = e.AccessTime
+		}
+		if !c.LastUsed.Before(cutoff) {
+			return nil
+		}
+		rep.Candidates = append(rep.Candidates, c)
+		rep.ReclaimableBytes += c.Size
+		return nil
+	})
+	code := searchStatus(res, err)
+	if code != 0 && !res.Interrupted {
+		return code
+	}
+
+	rep.Files = len(rep.Candidates)
+	sort.Slice(rep.Candidates, func(i, j int) bool {
+		a, b := rep.Candidates[i], rep.Candidates[j]
+		if a.Size != b.Size {
+			return a.Size > b.Size
+		}
+		return a.LastUsed.Before(b.LastUsed)
+	})
+	if *limit > 0 && len(rep.Candidates) > *limit {
+		rep.Candidates = rep.Candidates[:*limit]
+	}
+	if err := writeStaleReport(out, rep, cfg); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if err := closeOut(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return code
+}
+
+func writeStaleReport(w io.Writer, r staleReport, cfg finder.Config) error {
+	if cfg.OutputFormat != finder.OutputText {
+		enc := json.NewEncoder(w)
+		if cfg.PrettyJSON {
+			enc.SetIndent("", "  ")
+		}
+		return enc.Encode(r)
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "SIZE\tLAST USED\tMODIFIED\tOWNER\tPATH")
+	for _, c := range r.Candidates {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", stats.FormatBytes(c.Size),
+			c.LastUsed.Format(time.DateOnly), c.ModTime.Format(time.DateOnly), c.Owner, c.Path)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "\n%d files unused for %s, %s reclaimable\n", r.Files, r.OlderThan, stats.FormatBytes(r.ReclaimableBytes))
+	return err
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCLI_StaleReport(t *testing.T) {
+	bin := buildCLI(t)
+	td := t.TempDir()
+	old := time.Now().Add(-400 * 24 * time.Hour)
+	for rel, size := range map[string]int{"old-big.iso": 3000, "old-small.txt": 10, "sub/old.log": 500} {
+		p := mk(t, td, rel, size)
+		if err := os.Chtimes(p, old, old); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_ = mk(t, td, "fresh.iso", 5000)
+
+	out, err := exec.Command(bin, "stale", "-root", td, "-older-than", "1y", "-min-size", "100", "-json").Output()
+	if err != nil {
+		t.Fatalf("stale: %v", err)
+	}
+	var rep struct {
+		Files            int   `json:"files"`
+		ReclaimableBytes int64 `json:"reclaimableBytes"`
+		Candidates       []struct {
+			Path string `json:"path"`
+			Size int64  `json:"size"`
+		} `json:"candidates"`
+	}
+	if err := json.Unmarshal(out, &rep); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out)
+	}
+	if rep.Files != 2 || rep.ReclaimableBytes != 3500 {
+		t.Fatalf("unexpected totals: %s", out)
+	}
+	if rep.Candidates[0].Path != filepath.Join(td, "old-big.iso") || rep.Candidates[1].Path != filepath.Join(td, "sub", "old.log") {
+		t.Fatalf("expected candidates ranked by size: %s", out)
+	}
+}
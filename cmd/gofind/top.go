@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/Hamed0406/gofind/internal/finder"
+	"github.com/Hamed0406/gofind/internal/stats"
+)
+
+func init() {
+	subcommands["top"] = runTop
+}
+
+// topReport holds the largest files and/or directories.
+type topReport struct {
+	Files []finder.Entry  `json:"files,omitempty"`
+	Dirs  []stats.DirSize `json:"dirs,omitempty"`
+}
+
+// runTop lists the largest matching files (--files N) and/or the directories
+// holding the most bytes of matching files (--dirs N). It accepts all search
+// flags.
+func runTop(args []string) int {
+	fs := flag.NewFlagSet("top", flag.ContinueOnError)
+	sf := defineSearchFlags(fs)
+	nFiles := fs.Int("files", 0, "list the N largest files (default 20 when --dirs is not given)")
+	nDirs := fs.Int("dirs", 0, "list the N directories with the most bytes below them")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *nFiles <= 0 && *nDirs <= 0 {
+		*nFiles = 20
+	}
+	cfg, err := sf.config()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	stopProfiles, err := sf.startProfiles()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	defer stopProfiles()
+	out, closeOut, err := createOutput(*sf.outPath, *sf.compress)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	defer closeOut()
+
+	roots := cfg.Roots
+	if len(roots) == 0 {
+		roots = []string{cfg.Root}
+	}
+	files := stats.NewTopFiles(*nFiles)
+	dirs := stats.NewDirSizes(roots...)
+	ctx, cancel := signalContext(*sf.timeout)
+	defer cancel()
+	res, err := finder.Walk(ctx, cfg, func(e finder.Entry) error {
+		files.Add(e)
+		if *nDirs > 0 {
+			dirs.Add(e)
+		}
+		return nil
+	})
+	code := searchStatus(res, err)
+	if code != 0 && !res.Interrupted {
+		return code
+	}
+
+	var rep topReport
+	if *nFiles > 0 {
+		rep.Files = files.Result()
+	}
+	if *nDirs > 0 {
+		rep.Dirs = dirs.Top(*nDirs)
+	}
+	if err := writeTopReport(out, rep, cfg); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if err := closeOut(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return code
+}
+
+func writeTopReport(w io.Writer, r topReport, cfg finder.Config) error {
+	if cfg.OutputFormat != finder.OutputText {
+		enc := json.NewEncoder(w)
+		if cfg.PrettyJSON {
+			enc.SetIndent("", "  ")
+		}
+		return enc.Encode(r)
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	if len(r.Files) > 0 {
+		fmt.Fprintln(tw, "SIZE\tFILE")
+		for _, e := range r.Files {
+			fmt.Fprintf(tw, "%s\t%s\n", stats.FormatBytes(e.Size), e.Path)
+		}
+	}
+	if len(r.Dirs) > 0 {
+		if len(r.Files) > 0 {
+			fmt.Fprintln(tw)
+		}
+		fmt.Fprintln(tw, "SIZE\tFILES\tDIRECTORY")
+		for _, d := range r.Dirs {
+			fmt.Fprintf(tw, "%s\t%d\t%s\n", stats.FormatBytes(d.Bytes), d.Files, d.Path)
+		}
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/Hamed0406/gofind/internal/finder"
+	"github.com/Hamed0406/gofind/internal/trash"
+)
+
+func init() {
+	subcommands["trash"] = runTrash
+}
+
+// runTrash lists the trash, or restores files that --delete --trash put
+// there (or any other program did).
+func runTrash(args []string) int {
+	fs := flag.NewFlagSet("trash", flag.ContinueOnError)
+	jsonOut := fs.Bool("json", false, "list items as NDJSON objects with path, deleted and trashed")
+	dryRun := fs.Bool("dry-run", false, "print what restore would restore without restoring it")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), `usage: gofind trash list [--json] [PATH...]
+       gofind trash restore [--dry-run] PATH...
+
+list shows the items trashed from PATH or below it (everything in the
+trash without one); restore moves the most recently trashed file of each
+original path at or below PATH back.
+`)
+		fs.PrintDefaults()
+	}
+	if len(args) == 0 {
+		fs.Usage()
+		return 2
+	}
+	cmd := args[0]
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+
+	var (
+		items []trash.Item
+		err   error
+	)
+	switch {
+	case cmd == "list" && fs.NArg() == 0:
+		items, err = trash.List()
+	case cmd == "list", cmd == "restore" && fs.NArg() > 0:
+		items, err = trash.Find(fs.Args()...)
+	default:
+		fs.Usage()
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	if cmd == "list" {
+		enc := json.NewEncoder(os.Stdout)
+		for _, it := range items {
+			if *jsonOut {
+				_ = enc.Encode(struct {
+					Path    string    `json:"path"`
+					Deleted time.Time `json:"deleted"`
+					Trashed string    `json:"trashed"`
+				}{it.Path, it.Deleted, it.Trashed})
+				continue
+			}
+			fmt.Printf("%s  %s\n", it.Deleted.Local().Format(finder.DefaultTimeLayout), it.Path)
+		}
+		return 0
+	}
+
+	if len(items) == 0 {
+		fmt.Fprintln(os.Stderr, "nothing in the trash was deleted from there")
+		return 1
+	}
+	code := 0
+	restored := make(map[string]bool)
+	for _, it := range items {
+		if restored[it.Path] {
+			continue // an older copy
+		}
+		restored[it.Path] = true
+		if !*dryRun {
+			if err := trash.Restore(it); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				code = 1
+				continue
+			}
+		}
+		fmt.Printf("restore %s\n", it.Path)
+	}
+	return code
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/Hamed0406/gofind/internal/finder"
+	"github.com/Hamed0406/gofind/internal/manifest"
+)
+
+func init() {
+	subcommands["verify"] = runVerify
+}
+
+// runVerify checks a tree against a sha256sum-style manifest (local file, "-"
+// or an http(s) URL) and streams every mismatching path as NDJSON.
+func runVerify(args []string) int {
+	fs := flag.NewFlagSet("verify", flag.ContinueOnError)
+	src := fs.String("manifest", "", "manifest to verify against: file, - for stdin, or http(s) URL (sha256sum format)")
+	root := fs.String("root", ".", "directory the manifest paths are relative to, or @NAME for a saved root")
+	all := fs.Bool("all", false, "also report files that verified ok")
+	extra := fs.Bool("extra", false, "also report files under --root that the manifest does not list")
+	concurrency := fs.Int("concurrency", runtime.NumCPU(), "number of files hashed in parallel")
+	timeout := fs.Duration("timeout", 0, "stop after this long (e.g. 30s, 5m; 0 = no limit)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *src == "" && fs.NArg() == 1 {
+		*src = fs.Arg(0)
+	}
+	if *src == "" {
+		fmt.Fprintln(os.Stderr, "usage: gofind verify [--root DIR] --manifest FILE|URL")
+		return 2
+	}
+
+	dir, err := resolveSingleRoot(*root)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	*root = dir
+
+	ctx, cancel := signalContext(*timeout)
+	defer cancel()
+	rc, err := manifest.Open(ctx, *src)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	entries, err := manifest.Parse(rc)
+	_ = rc.Close()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	counts := make(map[string]int)
+	emit := func(r manifest.Result) {
+		counts[r.Status]++
+		if r.Status != manifest.StatusOK || *all {
+			_ = enc.Encode(r)
+		}
+	}
+	err = manifest.Verify(ctx, *root, entries, *concurrency, emit)
+	if err == nil && *extra {
+		listed := make(map[string]bool, len(entries))
+		for _, e := range entries {
+			listed[e.Path] = true
+		}
+		_, err = finder.Walk(ctx, finder.Config{Root: *root, MaxDepth: -1, IncludeHidden: true}, func(e finder.Entry) error {
+			rel, rerr := filepath.Rel(*root, e.Path)
+			if rerr == nil && !e.IsDir && !listed[filepath.ToSlash(rel)] {
+				emit(manifest.Result{Path: filepath.ToSlash(rel), Status: manifest.StatusExtra})
+			}
+			return nil
+		})
+	}
+	fmt.Fprintf(os.Stderr, "%d ok, %d mismatched, %d missing, %d errors, %d extra\n",
+		counts[manifest.StatusOK], counts[manifest.StatusMismatch], counts[manifest.StatusMissing],
+		counts[manifest.StatusError], counts[manifest.StatusExtra])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if counts[manifest.StatusOK] != len(entries) || counts[manifest.StatusExtra] > 0 {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestCLI_VerifyRemoteManifest(t *testing.T) {
+	bin := buildCLI(t)
+	td := t.TempDir()
+	_ = mk(t, td, "ok.bin", 3)
+	_ = mk(t, td, "bad.bin", 4)
+	_ = mk(t, td, "unlisted.bin", 1)
+	digest := func(n int) string {
+		h := sha256.Sum256(bytes.Repeat([]byte("x"), n))
+		return hex.EncodeToString(h[:])
+	}
+	manifest := digest(3) + "  ok.bin\n" + digest(5) + "  bad.bin\n"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(manifest))
+	}))
+	defer srv.Close()
+
+	cmd := exec.Command(bin, "verify", "-root", td, "-extra", "-manifest", srv.URL)
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	err := cmd.Run()
+	if ee, ok := err.(*exec.ExitError); !ok || ee.ExitCode() != 1 {
+		t.Fatalf("expected exit status 1, got %v", err)
+	}
+	got := map[string]string{}
+	for _, ln := range strings.Split(strings.TrimSpace(stdout.String()), "\n") {
+		var r struct{ Path, Status string }
+		if err := json.Unmarshal([]byte(ln), &r); err != nil {
+			t.Fatalf("not NDJSON: %q", ln)
+		}
+		got[r.Path] = r.Status
+	}
+	if len(got) != 2 || got["bad.bin"] != "mismatch" || got["unlisted.bin"] != "extra" {
+		t.Fatalf("unexpected results: %v", got)
+	}
+}
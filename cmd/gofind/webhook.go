@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Hamed0406/gofind/internal/finder"
+)
+
+// webhookTimeout bounds a single --webhook request.
+const webhookTimeout = 30 * time.Second
+
+// webhookWriter batches NDJSON records and POSTs each batch as a JSON array
+// to a URL. It implements finder.RecordWriter, so it is used as a Sink with
+// finder.OutputNDJSON.
+type webhookWriter struct {
+	url     string
+	header  http.Header
+	batch   int
+	retries int
+	backoff time.Duration // before the first retry; doubles after each
+	client  *http.Client
+
+	buf []byte // records of the pending batch, as a JSON array without "]"
+	n   int    // records in buf
+}
+
+// webhookConfig validates the --webhook flags and returns the writer they
+// describe, or nil without --webhook.
+func (sf *searchFlags) webhookConfig() (*webhookWriter, error) {
+	if *sf.webhook == "" {
+		if len(sf.hookHeaders) > 0 {
+			return nil, errors.New("--webhook-header needs --webhook")
+		}
+		return nil, nil
+	}
+	u, err := url.Parse(*sf.webhook)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid --webhook: %q (want an http or https URL)", *sf.webhook)
+	}
+	if *sf.hookBatch <= 0 {
+		return nil, fmt.Errorf("invalid --webhook-batch: %d", *sf.hookBatch)
+	}
+	if *sf.hookRetries < 0 {
+		return nil, fmt.Errorf("invalid --webhook-retries: %d", *sf.hookRetries)
+	}
+	w := &webhookWriter{
+		url:     *sf.webhook,
+		header:  http.Header{"Content-Type": {"application/json"}},
+		batch:   *sf.hookBatch,
+		retries: *sf.hookRetries,
+		backoff: time.Second,
+		client:  &http.Client{Timeout: webhookTimeout},
+	}
+	for _, h := range sf.hookHeaders {
+		name, value, ok := strings.Cut(h, ":")
+		if name = strings.TrimSpace(name); !ok || name == "" {
+			return nil, fmt.Errorf("invalid --webhook-header: %q (want \"Name: value\")", h)
+		}
+		w.header.Set(name, strings.TrimSpace(value))
+	}
+	return w, nil
+}
+
+// addWebhook adds the --webhook sink, if any, to cfg. The returned function
+// sends the last batch.
+func (sf *searchFlags) addWebhook(cfg *finder.Config) (func() error, error) {
+	w, err := sf.webhookConfig()
+	if w == nil || err != nil {
+		return func() error { return nil }, err
+	}
+	cfg.Sinks = append(cfg.Sinks, finder.Sink{Writer: w, Format: finder.OutputNDJSON})
+	return w.Close, nil
+}
+
+// Write rejects unframed output; see finder.RecordWriter.
+func (w *webhookWriter) Write([]byte) (int, error) {
+	return 0, errors.New("webhook output needs NDJSON records")
+}
+
+// WriteRecord adds record to the pending batch, sending it once full.
+func (w *webhookWriter) WriteRecord(_ finder.Entry, record []byte) error {
+	if w.n == 0 {
+		w.buf = append(w.buf[:0], '[')
+	} else {
+		w.buf = append(w.buf, ',')
+	}
+	w.buf = append(w.buf, bytes.TrimSpace(record)...)
+	w.n++
+	if w.n < w.batch {
+		return nil
+	}
+	return w.flush()
+}
+
+// Close sends the pending batch.
+func (w *webhookWriter) Close() error {
+	if w.n == 0 {
+		return nil
+	}
+	if err := w.flush(); err != nil {
+		return fmt.Errorf("webhook: %w", err)
+	}
+	return nil
+}
+
+func (w *webhookWriter) flush() error {
+	body := append(w.buf, ']')
+	w.n = 0
+	delay := w.backoff
+	for attempt := 0; ; attempt++ {
+		err := w.post(body)
+		if err == nil || attempt == w.retries || !retryable(err) {
+			return err
+		}
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// statusError is a response with an unexpected status code.
+type statusError struct {
+	code   int
+	status string
+}
+
+func (e *statusError) Error() string { return "POST: " + e.status }
+
+// retryable reports whether a failed request may succeed when repeated:
+// network errors, 429 and 5xx responses.
+func retryable(err error) bool {
+	var se *statusError
+	if errors.As(err, &se) {
+		return se.code == http.StatusTooManyRequests || se.code >= 500
+	}
+	return true
+}
+
+func (w *webhookWriter) post(body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header = w.header.Clone()
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode/100 != 2 {
+		return &statusError{resp.StatusCode, resp.Status}
+	}
+	return nil
+}
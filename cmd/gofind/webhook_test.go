@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Hamed0406/gofind/internal/finder"
+)
+
+func TestWebhook_BatchesAndRetries(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		calls   int
+		batches [][]finder.Entry
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer t0k" || r.Header.Get("Content-Type") != "application/json" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var batch []finder.Entry
+		body, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &batch); err != nil {
+			t.Errorf("not a JSON array: %q", body)
+		}
+		batches = append(batches, batch)
+	}))
+	defer srv.Close()
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	sf := defineSearchFlags(fs)
+	if err := fs.Parse([]string{"--webhook", srv.URL, "--webhook-batch", "2", "--webhook-header", "Authorization: Bearer t0k"}); err != nil {
+		t.Fatal(err)
+	}
+	w, err := sf.webhookConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.backoff = 0
+	for _, name := range []string{"a", "b", "c"} {
+		b, _ := json.Marshal(finder.Entry{Name: name})
+		if err := w.WriteRecord(finder.Entry{}, append(b, '\n')); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 3 || len(batches) != 2 || len(batches[0]) != 2 || batches[1][0].Name != "c" {
+		t.Fatalf("%d calls, batches %+v", calls, batches)
+	}
+}
+
+func TestWebhook_GivesUp(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer srv.Close()
+	w := &webhookWriter{url: srv.URL, header: http.Header{}, batch: 10, retries: 3, client: srv.Client()}
+	_ = w.WriteRecord(finder.Entry{}, []byte("{}\n"))
+	if err := w.Close(); err == nil || !strings.Contains(err.Error(), "400") || calls != 1 {
+		t.Fatalf("want one rejected request, got %d calls, %v", calls, err)
+	}
+}
+
+func TestWebhook_FlagValidation(t *testing.T) {
+	for _, args := range [][]string{
+		{"--webhook", "ftp://example.com"},
+		{"--webhook", "http://example.com", "--webhook-batch", "0"},
+		{"--webhook", "http://example.com", "--webhook-header", "no colon"},
+		{"--webhook-header", "X-A: b"},
+	} {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		sf := defineSearchFlags(fs)
+		if err := fs.Parse(args); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := sf.config(); err == nil {
+			t.Errorf("%q: want an error", args)
+		}
+	}
+}
@@ -1,3 +1,21 @@
 module github.com/Hamed0406/gofind
 
 go 1.24.6
+
+require (
+	github.com/klauspost/compress v1.18.0
+	github.com/nats-io/nats.go v1.45.0
+	github.com/segmentio/kafka-go v0.4.51
+	go.opentelemetry.io/otel v1.34.0
+	go.opentelemetry.io/otel/trace v1.34.0
+	golang.org/x/sys v0.32.0
+	golang.org/x/text v0.24.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
+	github.com/nats-io/nkeys v0.4.11 // indirect
+	github.com/nats-io/nuid v1.0.1 // indirect
+	github.com/pierrec/lz4/v4 v4.1.15 // indirect
+	golang.org/x/crypto v0.37.0 // indirect
+)
@@ -0,0 +1,165 @@
+// Package actions implements the file operations gofind can perform on
+// matched entries (delete, trash, move) and the reviewable plan format that
+// describes them.
+package actions
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/Hamed0406/gofind/internal/trash"
+)
+
+// PlanVersion is the current plan file format version.
+const PlanVersion = 1
+
+// Op kinds.
+const (
+	OpDelete = "delete"
+	OpTrash  = "trash"
+	OpMove   = "move"
+)
+
+// Op is a single intended file operation.
+type Op struct {
+	Op    string `json:"op"`
+	Src   string `json:"src"`
+	Dst   string `json:"dst,omitempty"`
+	Bytes int64  `json:"bytes"`
+}
+
+// Plan is a machine-readable list of operations that can be reviewed and
+// later executed with Apply.
+type Plan struct {
+	Version int `json:"version"`
+	// Roots are the roots searched for the ops, which apply checks again
+	// with the safety package.
+	Roots []string `json:"roots,omitempty"`
+	Ops   []Op     `json:"ops"`
+}
+
+// Delete returns the op removing src.
+func Delete(src string, size int64) Op {
+	return Op{Op: OpDelete, Src: src, Bytes: size}
+}
+
+// Trash returns the op moving src to the platform trash, from where it can be
+// restored.
+func Trash(src string, size int64) Op {
+	return Op{Op: OpTrash, Src: src, Bytes: size}
+}
+
+// Move returns the op moving src (found below root) into destDir, keeping its
+// path relative to root so files with equal names don't collide.
+func Move(root, src, destDir string, size int64) (Op, error) {
+	rel, err := filepath.Rel(root, src)
+	if err != nil {
+		return Op{}, err
+	}
+	return Op{Op: OpMove, Src: src, Dst: filepath.Join(destDir, rel), Bytes: size}, nil
+}
+
+// WritePlan encodes p as indented JSON.
+func WritePlan(w io.Writer, p Plan) error {
+	if p.Version == 0 {
+		p.Version = PlanVersion
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(p)
+}
+
+// ReadPlan decodes a plan written by WritePlan.
+func ReadPlan(r io.Reader) (Plan, error) {
+	var p Plan
+	if err := json.NewDecoder(r).Decode(&p); err != nil {
+		return Plan{}, fmt.Errorf("decode plan: %w", err)
+	}
+	if p.Version != PlanVersion {
+		return Plan{}, fmt.Errorf("unsupported plan version %d", p.Version)
+	}
+	return p, nil
+}
+
+// ErrStale is returned for an op whose source changed since it was planned.
+var ErrStale = errors.New("source changed since the plan was made")
+
+// Apply executes ops in order. Each source is re-checked first: if it is gone
+// or its size differs from the planned Bytes, the op is skipped with ErrStale.
+// done is called after every op with its outcome (nil on success); Apply keeps
+// going after failures and returns the first error encountered.
+func Apply(ctx context.Context, ops []Op, done func(Op, error)) error {
+	var firstErr error
+	for _, op := range ops {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		err := applyOne(op)
+		if done != nil {
+			done(op, err)
+		}
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func applyOne(op Op) error {
+	info, err := os.Lstat(op.Src)
+	if err != nil {
+		return fmt.Errorf("%s %s: %w", op.Op, op.Src, err)
+	}
+	if info.IsDir() || info.Size() != op.Bytes {
+		return fmt.Errorf("%s %s: %w", op.Op, op.Src, ErrStale)
+	}
+	switch op.Op {
+	case OpDelete:
+		return os.Remove(op.Src)
+	case OpTrash:
+		return trash.Put(op.Src)
+	case OpMove:
+		if op.Dst == "" {
+			return fmt.Errorf("move %s: missing destination", op.Src)
+		}
+		if _, err := os.Lstat(op.Dst); err == nil {
+			return fmt.Errorf("move %s: destination %s exists", op.Src, op.Dst)
+		}
+		if err := os.MkdirAll(filepath.Dir(op.Dst), 0o755); err != nil {
+			return err
+		}
+		if err := os.Rename(op.Src, op.Dst); err == nil {
+			return nil
+		}
+		// Rename fails across filesystems; fall back to copy + remove.
+		if err := copyFile(op.Src, op.Dst, info.Mode().Perm()); err != nil {
+			return err
+		}
+		return os.Remove(op.Src)
+	default:
+		return fmt.Errorf("unknown op %q", op.Op)
+	}
+}
+
+func copyFile(src, dst string, perm os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = in.Close() }()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		_ = os.Remove(dst)
+		return err
+	}
+	return out.Close()
+}
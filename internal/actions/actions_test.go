@@ -0,0 +1,76 @@
+package actions_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Hamed0406/gofind/internal/actions"
+)
+
+func write(t *testing.T, p string, data string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(p, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPlanRoundTripAndApply(t *testing.T) {
+	root := t.TempDir()
+	dest := t.TempDir()
+	del := filepath.Join(root, "old.log")
+	mov := filepath.Join(root, "sub", "keep.txt")
+	write(t, del, "x")
+	write(t, mov, "yy")
+
+	moveOp, err := actions.Move(root, mov, dest, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := actions.WritePlan(&buf, actions.Plan{Ops: []actions.Op{actions.Delete(del, 1), moveOp}}); err != nil {
+		t.Fatal(err)
+	}
+	plan, err := actions.ReadPlan(&buf)
+	if err != nil {
+		t.Fatalf("read plan: %v", err)
+	}
+
+	var applied int
+	if err := actions.Apply(context.Background(), plan.Ops, func(_ actions.Op, err error) {
+		if err == nil {
+			applied++
+		}
+	}); err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	if applied != 2 {
+		t.Fatalf("expected 2 applied ops, got %d", applied)
+	}
+	if _, err := os.Stat(del); !os.IsNotExist(err) {
+		t.Fatalf("expected %s deleted", del)
+	}
+	if _, err := os.Stat(filepath.Join(dest, "sub", "keep.txt")); err != nil {
+		t.Fatalf("expected moved file under dest: %v", err)
+	}
+}
+
+func TestApplySkipsStaleSources(t *testing.T) {
+	root := t.TempDir()
+	p := filepath.Join(root, "f.txt")
+	write(t, p, "changed")
+
+	err := actions.Apply(context.Background(), []actions.Op{actions.Delete(p, 1)}, nil)
+	if !errors.Is(err, actions.ErrStale) {
+		t.Fatalf("expected ErrStale, got %v", err)
+	}
+	if _, err := os.Stat(p); err != nil {
+		t.Fatalf("stale source must not be touched: %v", err)
+	}
+}
@@ -0,0 +1,74 @@
+// Package audit records the file operations gofind performs in the system
+// log, so destructive runs leave a trail outside gofind's own output.
+package audit
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Record describes one completed (or failed) file operation.
+type Record struct {
+	Op    string // e.g. "delete" or "move"
+	Src   string
+	Dst   string // empty unless the operation has a destination
+	Bytes int64
+	Err   error // nil on success
+}
+
+// Logger writes audit records to a system log.
+type Logger interface {
+	Log(r Record) error
+	Close() error
+}
+
+// Destinations lists the accepted arguments of Open.
+var Destinations = []string{"syslog", "journald"}
+
+// Open connects to the named system log: "syslog" (the local syslog daemon;
+// Unix) or "journald" (the systemd journal; Linux).
+func Open(dest string) (Logger, error) {
+	switch dest {
+	case "syslog":
+		return openSyslog()
+	case "journald":
+		return openJournald()
+	}
+	return nil, fmt.Errorf("unknown audit log %q (want %s)", dest, strings.Join(Destinations, " or "))
+}
+
+// ident tags every record.
+const ident = "gofind"
+
+// Message formats r as one line of key=value pairs, quoting values that
+// need it, e.g.
+//
+//	op=move src=/data/a.log dst="/archive/my logs/a.log" bytes=120 result=ok
+func (r Record) Message() string {
+	var b strings.Builder
+	field := func(k, v string) {
+		if b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(k)
+		b.WriteByte('=')
+		if v == "" || strings.ContainsAny(v, " =") || strconv.Quote(v) != `"`+v+`"` {
+			v = strconv.Quote(v)
+		}
+		b.WriteString(v)
+	}
+	field("op", r.Op)
+	field("src", r.Src)
+	if r.Dst != "" {
+		field("dst", r.Dst)
+	}
+	field("bytes", strconv.FormatInt(r.Bytes, 10))
+	if r.Err != nil {
+		field("result", "error")
+		field("error", r.Err.Error())
+	} else {
+		field("result", "ok")
+	}
+	return b.String()
+}
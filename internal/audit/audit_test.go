@@ -0,0 +1,27 @@
+package audit
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRecordMessage(t *testing.T) {
+	for _, tc := range []struct {
+		r    Record
+		want string
+	}{
+		{Record{Op: "delete", Src: "/data/a.log", Bytes: 3}, `op=delete src=/data/a.log bytes=3 result=ok`},
+		{Record{Op: "move", Src: "/a b", Dst: "/x=y", Bytes: 1}, `op=move src="/a b" dst="/x=y" bytes=1 result=ok`},
+		{Record{Op: "delete", Src: "/n\nl", Err: errors.New("permission denied")}, `op=delete src="/n\nl" bytes=0 result=error error="permission denied"`},
+	} {
+		if got := tc.r.Message(); got != tc.want {
+			t.Errorf("got  %s\nwant %s", got, tc.want)
+		}
+	}
+}
+
+func TestOpenUnknown(t *testing.T) {
+	if _, err := Open("eventlog"); err == nil {
+		t.Fatal("want an error for an unknown destination")
+	}
+}
@@ -0,0 +1,44 @@
+package audit
+
+import (
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestJournald(t *testing.T) {
+	addr := filepath.Join(t.TempDir(), "socket")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: addr, Net: "unixgram"})
+	if err != nil {
+		t.Skipf("unix datagram sockets unavailable: %v", err)
+	}
+	defer conn.Close()
+	defer func(old string) { journalSocket = old }(journalSocket)
+	journalSocket = addr
+
+	l, err := Open("journald")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	if err := l.Log(Record{Op: "move", Src: "/a", Dst: "/b\nc", Bytes: 7}); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 4096)
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(buf[:n])
+	for _, want := range []string{
+		"PRIORITY=5\n", "SYSLOG_IDENTIFIER=gofind\n", "GOFIND_OP=move\n", "GOFIND_SRC=/a\n",
+		"GOFIND_BYTES=7\n", "GOFIND_RESULT=ok\n",
+		// A value with a newline is length-prefixed.
+		"GOFIND_DST\n\x04\x00\x00\x00\x00\x00\x00\x00/b\nc\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %q in %q", want, got)
+		}
+	}
+}
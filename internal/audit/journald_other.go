@@ -0,0 +1,10 @@
+//go:build !linux
+
+package audit
+
+import "errors"
+
+// openJournald is not implemented on this platform; the journal is Linux only.
+func openJournald() (Logger, error) {
+	return nil, errors.New("journald is only available on Linux")
+}
@@ -0,0 +1,10 @@
+//go:build windows || plan9
+
+package audit
+
+import "errors"
+
+// openSyslog is not implemented on this platform, which has no syslog.
+func openSyslog() (Logger, error) {
+	return nil, errors.New("syslog is not available on this platform")
+}
@@ -0,0 +1,36 @@
+//go:build !windows && !plan9
+
+package audit
+
+import "log/syslog"
+
+// syslogLogger sends records to the local syslog daemon under the user
+// facility, at notice level or error level for failures.
+type syslogLogger struct {
+	w *syslog.Writer
+}
+
+func openSyslog() (Logger, error) {
+	return dialSyslog("", "")
+}
+
+// dialSyslog connects to the syslog daemon at network and raddr, or the
+// local one when both are empty.
+func dialSyslog(network, raddr string) (Logger, error) {
+	w, err := syslog.Dial(network, raddr, syslog.LOG_USER|syslog.LOG_NOTICE, ident)
+	if err != nil {
+		return nil, err
+	}
+	return &syslogLogger{w}, nil
+}
+
+func (l *syslogLogger) Log(r Record) error {
+	if r.Err != nil {
+		return l.w.Err(r.Message())
+	}
+	return l.w.Notice(r.Message())
+}
+
+func (l *syslogLogger) Close() error {
+	return l.w.Close()
+}
@@ -0,0 +1,39 @@
+//go:build !windows && !plan9
+
+package audit
+
+import (
+	"errors"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSyslog(t *testing.T) {
+	addr := filepath.Join(t.TempDir(), "log")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: addr, Net: "unixgram"})
+	if err != nil {
+		t.Skipf("unix datagram sockets unavailable: %v", err)
+	}
+	defer conn.Close()
+
+	l, err := dialSyslog("unixgram", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	if err := l.Log(Record{Op: "delete", Src: "/a", Err: errors.New("busy")}); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 1024)
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// <PRI> is facility user (1) * 8 + level err (3).
+	msg := string(buf[:n])
+	if !strings.HasPrefix(msg, "<11>") || !strings.Contains(msg, "gofind") || !strings.Contains(msg, `op=delete src=/a bytes=0 result=error error=busy`) {
+		t.Fatalf("unexpected syslog message %q", msg)
+	}
+}
@@ -0,0 +1,273 @@
+// Package doctext extracts the plain text of common document formats, PDF,
+// Word (.docx) and Excel (.xlsx), so it can be searched like a text file.
+// The extractors are deliberately small: they read the text a document
+// stores and lay it out in lines, but do not render pages or resolve
+// embedded fonts, so text drawn with a font that has no standard encoding
+// may come out garbled or not at all.
+package doctext
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// maxText is the most text, and the most decompressed data of any one part
+// of a document, an extractor reads; past it Extract fails rather than
+// exhaust memory on a compression bomb.
+const maxText = 256 << 20
+
+// errTooLarge is returned for documents past maxText.
+var errTooLarge = fmt.Errorf("more than %d MiB of text", maxText>>20)
+
+var extractors = map[string]func(r io.ReaderAt, size int64) ([]byte, error){
+	".pdf":  pdfText,
+	".docx": docxText,
+	".xlsx": xlsxText,
+}
+
+// Supported reports whether Extract understands files with this name's
+// extension.
+func Supported(name string) bool {
+	_, ok := extractors[strings.ToLower(filepath.Ext(name))]
+	return ok
+}
+
+// Extract returns the text of the document of the given size read from r,
+// in UTF-8 with one line per paragraph, text line or spreadsheet row. The
+// format is chosen by the extension of name; see Supported.
+func Extract(r io.ReaderAt, size int64, name string) ([]byte, error) {
+	ext := strings.ToLower(filepath.Ext(name))
+	extract, ok := extractors[ext]
+	if !ok {
+		return nil, fmt.Errorf("%s: unsupported document format", ext)
+	}
+	text, err := extract(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", strings.TrimPrefix(ext, "."), err)
+	}
+	return text, nil
+}
+
+// docxText returns the paragraphs of the body of a Word document.
+func docxText(r io.ReaderAt, size int64) ([]byte, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+	f := zipFile(zr, "word/document.xml")
+	if f == nil {
+		return nil, errors.New("no word/document.xml")
+	}
+	var (
+		buf  bytes.Buffer
+		run  int  // depth of w:r run elements
+		text bool // in a w:t text element
+	)
+	err = walkXML(f, func(tok xml.Token) {
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "r":
+				run++
+			case "t":
+				text = true
+			case "tab":
+				// w:tab also sets tab stops in paragraph properties.
+				if run > 0 {
+					buf.WriteByte('\t')
+				}
+			case "br", "cr":
+				if run > 0 {
+					buf.WriteByte('\n')
+				}
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "r":
+				run--
+			case "t":
+				text = false
+			case "p":
+				buf.WriteByte('\n')
+			}
+		case xml.CharData:
+			if text {
+				buf.Write(t)
+			}
+		}
+	})
+	return buf.Bytes(), err
+}
+
+// xlsxText returns the rows of every sheet of an Excel workbook, with the
+// values of their cells separated by tabs.
+func xlsxText(r io.ReaderAt, size int64) ([]byte, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+
+	// Most cells hold an index into the table of shared strings.
+	var (
+		shared   []string
+		si       strings.Builder
+		text     bool // in a t element of an si item
+		phonetic bool // in an rPh run, whose text is a reading aid
+	)
+	if f := zipFile(zr, "xl/sharedStrings.xml"); f != nil {
+		err := walkXML(f, func(tok xml.Token) {
+			switch t := tok.(type) {
+			case xml.StartElement:
+				switch t.Name.Local {
+				case "si":
+					si.Reset()
+				case "t":
+					text = true
+				case "rPh":
+					phonetic = true
+				}
+			case xml.EndElement:
+				switch t.Name.Local {
+				case "si":
+					shared = append(shared, si.String())
+				case "t":
+					text = false
+				case "rPh":
+					phonetic = false
+				}
+			case xml.CharData:
+				if text && !phonetic {
+					si.Write(t)
+				}
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var sheets []*zip.File
+	for _, f := range zr.File {
+		if strings.HasPrefix(f.Name, "xl/worksheets/sheet") && path.Ext(f.Name) == ".xml" {
+			sheets = append(sheets, f)
+		}
+	}
+	// sheet10.xml comes after sheet9.xml.
+	num := func(f *zip.File) int {
+		n, _ := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(f.Name, "xl/worksheets/sheet"), ".xml"))
+		return n
+	}
+	sort.Slice(sheets, func(i, j int) bool { return num(sheets[i]) < num(sheets[j]) })
+
+	var buf bytes.Buffer
+	for _, f := range sheets {
+		var (
+			typ   string // t attribute of the current cell
+			value []byte
+			cells int // cells written in the current row
+			in    bool
+		)
+		err := walkXML(f, func(tok xml.Token) {
+			switch t := tok.(type) {
+			case xml.StartElement:
+				switch t.Name.Local {
+				case "c":
+					typ, value = "", value[:0]
+					for _, a := range t.Attr {
+						if a.Name.Local == "t" {
+							typ = a.Value
+						}
+					}
+				case "v", "t":
+					in = true
+				}
+			case xml.EndElement:
+				switch t.Name.Local {
+				case "v", "t":
+					in = false
+				case "c":
+					if len(value) == 0 {
+						return
+					}
+					if cells > 0 {
+						buf.WriteByte('\t')
+					}
+					cells++
+					if typ == "s" {
+						if i, err := strconv.Atoi(string(value)); err == nil && i >= 0 && i < len(shared) {
+							buf.WriteString(shared[i])
+						}
+						return
+					}
+					buf.Write(value)
+				case "row":
+					if cells > 0 {
+						buf.WriteByte('\n')
+					}
+					cells = 0
+				}
+			case xml.CharData:
+				if in {
+					value = append(value, t...)
+				}
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+		if buf.Len() > maxText {
+			return nil, errTooLarge
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// zipFile returns the member of zr called name, or nil.
+func zipFile(zr *zip.Reader, name string) *zip.File {
+	for _, f := range zr.File {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// walkXML calls fn with every token of the XML document f, failing past
+// maxText bytes of it.
+func walkXML(f *zip.File, fn func(xml.Token)) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	lr := &io.LimitedReader{R: rc, N: maxText + 1}
+	dec := xml.NewDecoder(lr)
+	// Office XML is UTF-8; tolerate undeclared entities and the like.
+	dec.Strict = false
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			if lr.N <= 0 {
+				return errTooLarge
+			}
+			return fmt.Errorf("%s: %w", f.Name, err)
+		}
+		fn(tok)
+	}
+	if lr.N <= 0 {
+		return errTooLarge
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package doctext
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"testing"
+)
+
+func extract(t *testing.T, name string, data []byte) string {
+	t.Helper()
+	text, err := Extract(bytes.NewReader(data), int64(len(data)), name)
+	if err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+	return string(text)
+}
+
+func zipOf(t *testing.T, files ...string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for i := 0; i < len(files); i += 2 {
+		w, err := zw.Create(files[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(files[i+1])); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestExtract_DOCX(t *testing.T) {
+	data := zipOf(t, "word/document.xml", `<?xml version="1.0" encoding="UTF-8"?>
+<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>
+<w:p><w:pPr><w:tabs><w:tab w:val="left" w:pos="720"/></w:tabs></w:pPr><w:r><w:t>Invoice</w:t></w:r><w:r><w:tab/><w:t xml:space="preserve">No. 42 </w:t></w:r><w:r><w:t>&amp; more</w:t></w:r></w:p>
+<w:p><w:r><w:t>second</w:t><w:br/><w:t>line</w:t></w:r></w:p>
+</w:body></w:document>`)
+	if got, want := extract(t, "a.DOCX", data), "Invoice\tNo. 42 & more\nsecond\nline\n"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestExtract_XLSX(t *testing.T) {
+	data := zipOf(t,
+		"xl/sharedStrings.xml", `<sst><si><t>name</t></si><si><r><t>total </t></r><r><t>due</t></r><rPh><t>reading</t></rPh></si></sst>`,
+		"xl/worksheets/sheet10.xml", `<worksheet><sheetData><row><c t="inlineStr"><is><t>last</t></is></c></row></sheetData></worksheet>`,
+		"xl/worksheets/sheet2.xml", `<worksheet><sheetData><row><c t="s"><v>0</v></c><c t="s"><v>1</v></c></row><row><c/><c><v>12.5</v></c></row></sheetData></worksheet>`,
+	)
+	if got, want := extract(t, "book.xlsx", data), "name\ttotal due\n12.5\nlast\n"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func pdfOf(streams ...string) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	for i, s := range streams {
+		var z bytes.Buffer
+		zw := zlib.NewWriter(&z)
+		zw.Write([]byte(s))
+		zw.Close()
+		// Alternate plain and compressed streams.
+		if i%2 == 0 {
+			fmt.Fprintf(&buf, "%d 0 obj\n<< /Length %d >>\nstream\n%s\nendstream\nendobj\n", i+1, len(s), s)
+		} else {
+			fmt.Fprintf(&buf, "%d 0 obj\n<< /Length %d /Filter [/FlateDecode] >>\nstream\r\n%s\r\nendstream\nendobj\n", i+1, z.Len(), z.Bytes())
+		}
+	}
+	buf.WriteString("trailer\n<< /Root 1 0 R >>\n%%EOF\n")
+	return buf.Bytes()
+}
+
+func TestExtract_PDF(t *testing.T) {
+	data := pdfOf(
+		"BT /F1 12 Tf 72 720 Td (Hello \\(world\\)) Tj 0 -14 Td [(Sp)-20(lit) -300 (words)] TJ ET",
+		"BT 1 0 0 1 72 600 Tm <FEFF00E9007400E9> Tj T* (upstream \\101) Tj ET",
+	)
+	if got, want := extract(t, "a.pdf", data), "Hello (world)\nSplit words\nété\nupstream A\n"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+
+	// Streams with other filters are skipped.
+	data = []byte("%PDF-1.4\n1 0 obj\n<< /Filter /DCTDecode >>\nstream\nBT (hidden) Tj ET\nendstream\nendobj\n")
+	if got := extract(t, "b.pdf", data); got != "" {
+		t.Fatalf("DCTDecode stream read: %q", got)
+	}
+	if _, err := Extract(bytes.NewReader([]byte("x")), 1, "c.pdf"); err == nil {
+		t.Fatal("not a PDF accepted")
+	}
+}
+
+func TestSupported(t *testing.T) {
+	for name, want := range map[string]bool{"a.pdf": true, "b.Docx": true, "c.xlsx": true, "d.doc": false, "e.txt": false} {
+		if Supported(name) != want {
+			t.Errorf("Supported(%q) = %v", name, !want)
+		}
+	}
+}
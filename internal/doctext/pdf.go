@@ -0,0 +1,389 @@
+package doctext
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"io"
+	"strconv"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// pdfText returns the text shown by the content streams of a PDF file, in
+// file order, which is usually page order. Only uncompressed and
+// FlateDecode streams are read; strings are decoded as PDFDocEncoding
+// (approximated by Latin-1) or, with a byte order mark, UTF-16BE.
+func pdfText(r io.ReaderAt, size int64) ([]byte, error) {
+	if size > maxText {
+		return nil, errTooLarge
+	}
+	data := make([]byte, size)
+	if _, err := r.ReadAt(data, 0); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	if !bytes.HasPrefix(data, []byte("%PDF-")) {
+		return nil, errors.New("not a PDF file")
+	}
+	if bytes.Contains(data, []byte("/Encrypt")) {
+		return nil, errors.New("encrypted")
+	}
+
+	var buf bytes.Buffer
+	for rest := data; ; {
+		i := bytes.Index(rest, []byte("stream"))
+		if i < 0 {
+			break
+		}
+		// The keyword follows the stream dictionary; skip endstream and
+		// the word in text.
+		if !bytes.HasSuffix(bytes.TrimRight(rest[:i], " \t\r\n"), []byte(">>")) {
+			rest = rest[i+len("stream"):]
+			continue
+		}
+		// The stream dictionary runs from the object header to here.
+		dict := rest[:i]
+		if j := bytes.LastIndex(dict, []byte("obj")); j >= 0 {
+			dict = dict[j:]
+		}
+		body := rest[i+len("stream"):]
+		body = bytes.TrimPrefix(body, []byte("\r"))
+		body = bytes.TrimPrefix(body, []byte("\n"))
+		end := bytes.Index(body, []byte("endstream"))
+		if end < 0 {
+			break
+		}
+		rest = body[end+len("endstream"):]
+		raw, ok := streamData(dict, body[:end])
+		if !ok {
+			continue
+		}
+		contentText(&buf, raw)
+		if buf.Len() > maxText {
+			return nil, errTooLarge
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// streamData returns the decoded data of a stream with the dictionary
+// dict, and false for streams that cannot hold page text or that use a
+// filter other than FlateDecode.
+func streamData(dict, raw []byte) ([]byte, bool) {
+	// Images, embedded fonts, cross-reference and object streams.
+	for _, skip := range []string{"/Image", "/Length1", "/Length2", "/Length3", "/FontFile", "/XRef", "/ObjStm"} {
+		if bytes.Contains(dict, []byte(skip)) {
+			return nil, false
+		}
+	}
+	switch f := filterNames(dict); {
+	case len(f) == 0:
+		return raw, true
+	case len(f) > 1 || f[0] != "FlateDecode":
+		return nil, false
+	}
+	zr, err := zlib.NewReader(bytes.NewReader(raw))
+	if err != nil {
+		return nil, false
+	}
+	defer zr.Close()
+	// Keep what inflates of a damaged stream.
+	data, _ := io.ReadAll(io.LimitReader(zr, maxText+1))
+	return data, len(data) > 0
+}
+
+// contentText appends to buf the text the operators of the content stream
+// c show: a line for each text line (T*, ', " and vertical moves), with
+// spaces for horizontal moves and wide gaps in TJ arrays.
+func contentText(buf *bytes.Buffer, c []byte) {
+	var (
+		strs  [][]byte  // decoded string and TJ array operands
+		nums  []float64 // number operands
+		lastY float64   // vertical position set by the last Tm
+	)
+	newline := func() {
+		if buf.Len() > 0 && buf.Bytes()[buf.Len()-1] != '\n' {
+			buf.WriteByte('\n')
+		}
+	}
+	space := func() {
+		if b := buf.Bytes(); len(b) > 0 && b[len(b)-1] != '\n' && b[len(b)-1] != ' ' {
+			buf.WriteByte(' ')
+		}
+	}
+	show := func() {
+		for _, s := range strs {
+			buf.Write(s)
+		}
+	}
+	for i := 0; i < len(c); {
+		ch := c[i]
+		switch {
+		case isPDFSpace(ch):
+			i++
+		case ch == '%':
+			for i < len(c) && c[i] != '\n' && c[i] != '\r' {
+				i++
+			}
+		case ch == '(':
+			var s []byte
+			s, i = literalString(c, i)
+			strs = append(strs, decodePDFString(s))
+		case ch == '<' && i+1 < len(c) && c[i+1] == '<', ch == '>' && i+1 < len(c) && c[i+1] == '>':
+			i += 2
+		case ch == '<':
+			var s []byte
+			s, i = hexString(c, i)
+			strs = append(strs, decodePDFString(s))
+		case ch == '[':
+			var s []byte
+			s, i = textArray(c, i)
+			strs = append(strs, s)
+		case ch == ']' || ch == '{' || ch == '}' || ch == '>' || ch == ')':
+			i++
+		case ch == '/':
+			i++
+			for i < len(c) && !isPDFSpace(c[i]) && !isPDFDelim(c[i]) {
+				i++
+			}
+		default:
+			j := i
+			for j < len(c) && !isPDFSpace(c[j]) && !isPDFDelim(c[j]) {
+				j++
+			}
+			if j == i {
+				j++
+			}
+			tok := string(c[i:j])
+			i = j
+			if n, err := strconv.ParseFloat(tok, 64); err == nil {
+				nums = append(nums, n)
+				continue
+			}
+			switch tok {
+			case "Tj", "TJ":
+				show()
+			case "'", `"`:
+				newline()
+				show()
+			case "T*", "BT":
+				newline()
+			case "Td", "TD":
+				if len(nums) == 2 && nums[1] != 0 {
+					newline()
+				} else {
+					space()
+				}
+			case "Tm":
+				if len(nums) == 6 {
+					if nums[5] != lastY {
+						newline()
+					} else {
+						space()
+					}
+					lastY = nums[5]
+				}
+			case "ID":
+				// Inline image data runs to EI.
+				if k := bytes.Index(c[i:], []byte("EI")); k >= 0 {
+					i += k + 2
+				} else {
+					i = len(c)
+				}
+			}
+			strs, nums = strs[:0], nums[:0]
+		}
+	}
+	newline()
+}
+
+// literalString returns the bytes of the literal string starting at c[i],
+// a '(', and the index after it.
+func literalString(c []byte, i int) ([]byte, int) {
+	var s []byte
+	depth := 0
+	for i++; i < len(c); i++ {
+		ch := c[i]
+		switch ch {
+		case '(':
+			depth++
+		case ')':
+			if depth == 0 {
+				return s, i + 1
+			}
+			depth--
+		case '\\':
+			i++
+			if i >= len(c) {
+				return s, i
+			}
+			switch e := c[i]; e {
+			case 'n':
+				ch = '\n'
+			case 'r':
+				ch = '\r'
+			case 't':
+				ch = '\t'
+			case 'b':
+				ch = '\b'
+			case 'f':
+				ch = '\f'
+			case '\r':
+				// A backslash at the end of a line continues the string.
+				if i+1 < len(c) && c[i+1] == '\n' {
+					i++
+				}
+				continue
+			case '\n':
+				continue
+			default:
+				if e < '0' || e > '7' {
+					ch = e
+					break
+				}
+				n := 0
+				for k := 0; k < 3 && i < len(c) && c[i] >= '0' && c[i] <= '7'; k++ {
+					n = n*8 + int(c[i]-'0')
+					i++
+				}
+				i--
+				ch = byte(n)
+			}
+		}
+		s = append(s, ch)
+	}
+	return s, i
+}
+
+// hexString returns the bytes of the hexadecimal string starting at c[i],
+// a '<', and the index after it.
+func hexString(c []byte, i int) ([]byte, int) {
+	var (
+		s    []byte
+		hi   byte
+		half bool
+	)
+	for i++; i < len(c) && c[i] != '>'; i++ {
+		var v byte
+		switch ch := c[i]; {
+		case ch >= '0' && ch <= '9':
+			v = ch - '0'
+		case ch >= 'a' && ch <= 'f':
+			v = ch - 'a' + 10
+		case ch >= 'A' && ch <= 'F':
+			v = ch - 'A' + 10
+		default:
+			continue
+		}
+		if half {
+			s = append(s, hi<<4|v)
+		} else {
+			hi = v
+		}
+		half = !half
+	}
+	if half {
+		s = append(s, hi<<4)
+	}
+	return s, i + 1
+}
+
+// textArray returns the strings of the array starting at c[i], a '[', as a
+// TJ operator shows them, and the index after it. An adjustment of more
+// than a fifth of the font size is taken as a space between words.
+func textArray(c []byte, i int) ([]byte, int) {
+	var s []byte
+	for i++; i < len(c); {
+		ch := c[i]
+		switch {
+		case ch == ']':
+			return s, i + 1
+		case ch == '(':
+			var t []byte
+			t, i = literalString(c, i)
+			s = append(s, decodePDFString(t)...)
+		case ch == '<':
+			var t []byte
+			t, i = hexString(c, i)
+			s = append(s, decodePDFString(t)...)
+		case isPDFSpace(ch):
+			i++
+		default:
+			j := i
+			for j < len(c) && !isPDFSpace(c[j]) && !isPDFDelim(c[j]) {
+				j++
+			}
+			if j == i {
+				j++
+			}
+			if n, err := strconv.ParseFloat(string(c[i:j]), 64); err == nil && n < -200 && len(s) > 0 && s[len(s)-1] != ' ' {
+				s = append(s, ' ')
+			}
+			i = j
+		}
+	}
+	return s, i
+}
+
+// decodePDFString returns s, the bytes of a string operand, in UTF-8.
+func decodePDFString(s []byte) []byte {
+	var out []byte
+	if len(s) >= 2 && s[0] == 0xfe && s[1] == 0xff {
+		u := make([]uint16, 0, len(s)/2)
+		for k := 2; k+1 < len(s); k += 2 {
+			u = append(u, uint16(s[k])<<8|uint16(s[k+1]))
+		}
+		for _, r := range utf16.Decode(u) {
+			out = utf8.AppendRune(out, r)
+		}
+		return out
+	}
+	for _, b := range s {
+		switch {
+		case b == '\t' || b >= ' ' && b != 0x7f:
+			out = utf8.AppendRune(out, rune(b))
+		case b == '\n' || b == '\r':
+			out = append(out, ' ')
+		}
+	}
+	return out
+}
+
+// filterNames returns the names of the filters a stream dictionary lists,
+// without their slashes.
+func filterNames(dict []byte) []string {
+	i := bytes.Index(dict, []byte("/Filter"))
+	if i < 0 {
+		return nil
+	}
+	rest := bytes.TrimLeft(dict[i+len("/Filter"):], " \t\r\n")
+	array := bytes.HasPrefix(rest, []byte("["))
+	if array {
+		if j := bytes.IndexByte(rest, ']'); j >= 0 {
+			rest = rest[1:j]
+		}
+	}
+	var names []string
+	for _, f := range bytes.Fields(bytes.ReplaceAll(rest, []byte("/"), []byte(" /"))) {
+		if f[0] != '/' {
+			break
+		}
+		end := bytes.IndexAny(f, "<>[]()")
+		if end < 0 {
+			end = len(f)
+		}
+		names = append(names, string(f[1:end]))
+		if !array {
+			break
+		}
+	}
+	return names
+}
+
+func isPDFSpace(c byte) bool {
+	return c == ' ' || c == '\n' || c == '\r' || c == '\t' || c == '\f' || c == 0
+}
+
+func isPDFDelim(c byte) bool {
+	return bytes.IndexByte([]byte("()<>[]{}/%"), c) >= 0
+}
@@ -0,0 +1,665 @@
+// Package expr implements the small filter language of --where, e.g.
+//
+//	size > 10MB && ext in (".log", ".gz") && mtime < now() - 30d
+//
+// Expressions are type-checked against a Schema when compiled, so mistakes
+// such as comparing a size with a string are reported before the search
+// starts. Fields under the schema's dynamic prefix are typed at run time.
+//
+// Syntax: || (or), && (and), ! (not), comparisons (== != < <= > >=, and =
+// and <> as in SQL), "x in (a, b)", "x not in (...)", regular-expression
+// matches (=~ and !~), SQL patterns ("x like 'a%'", "x not like '_b'"), + and
+// - on numbers, durations and times, parentheses, string literals in single
+// or double quotes, numbers with an optional exponent (1e8) or size (KB, MB,
+// GB, TB; binary) or duration (ms, s, m, h, d, w, y) suffix, true, false and
+// now().
+// A string compared with a time is parsed as YYYY-MM-DD, RFC 3339 or
+// "YYYY-MM-DD HH:MM".
+package expr
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Kind is the type of a value or field.
+type Kind uint8
+
+const (
+	// Invalid marks a missing dynamic field or a failed dynamic conversion;
+	// every comparison involving it is false.
+	Invalid Kind = iota
+	Bool
+	Number
+	String
+	Time
+	Duration
+	// Any is the static kind of dynamic fields.
+	Any
+)
+
+func (k Kind) String() string {
+	return [...]string{"invalid", "bool", "number", "string", "time", "duration", "any"}[k]
+}
+
+// is reports whether a value of static kind k may be used where want is
+// expected.
+func (k Kind) is(want Kind) bool { return k == want || k == Any }
+
+// Value is a typed value.
+type Value struct {
+	Kind Kind
+	Num  float64
+	Str  string
+	Time time.Time
+	Dur  time.Duration
+	Bool bool
+}
+
+// BoolValue returns a Bool value.
+func BoolValue(b bool) Value { return Value{Kind: Bool, Bool: b} }
+
+// NumberValue returns a Number value.
+func NumberValue(n float64) Value { return Value{Kind: Number, Num: n} }
+
+// StringValue returns a String value.
+func StringValue(s string) Value { return Value{Kind: String, Str: s} }
+
+// TimeValue returns a Time value.
+func TimeValue(t time.Time) Value { return Value{Kind: Time, Time: t} }
+
+// DurationValue returns a Duration value.
+func DurationValue(d time.Duration) Value { return Value{Kind: Duration, Dur: d} }
+
+func invalid() Value { return Value{} }
+
+func (v Value) truthy() bool { return v.Kind == Bool && v.Bool }
+
+func (v Value) sameKind(w Value) bool { return v.Kind == w.Kind && v.Kind != Invalid }
+
+func (v Value) String() string {
+	return fmt.Sprint([...]any{nil, v.Bool, v.Num, v.Str, v.Time, v.Dur, nil}[v.Kind])
+}
+
+// literal returns v as Compile reads it back.
+func (v Value) literal() string {
+	switch v.Kind {
+	case Bool:
+		return strconv.FormatBool(v.Bool)
+	case Number:
+		return strconv.FormatFloat(v.Num, 'f', -1, 64)
+	case String:
+		return quote(v.Str)
+	case Time:
+		return quote(v.Time.Format(time.RFC3339Nano))
+	case Duration:
+		return strconv.FormatFloat(v.Dur.Seconds(), 'f', -1, 64) + "s"
+	}
+	return "null"
+}
+
+// quote returns s as a string literal: in double quotes, with quotes and
+// backslashes escaped by a backslash.
+func quote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// ValueOf converts a Go value (as stored in a JSON-like map) to a Value;
+// unsupported types yield an invalid Value.
+func ValueOf(x any) Value {
+	switch x := x.(type) {
+	case bool:
+		return BoolValue(x)
+	case int:
+		return NumberValue(float64(x))
+	case int64:
+		return NumberValue(float64(x))
+	case float64:
+		return NumberValue(x)
+	case string:
+		return StringValue(x)
+	case time.Time:
+		return TimeValue(x)
+	case time.Duration:
+		return DurationValue(x)
+	}
+	return invalid()
+}
+
+// Schema declares the fields an expression may use.
+type Schema struct {
+	// Fields maps field names to their kinds.
+	Fields map[string]Kind
+	// DynamicPrefix, when set, admits any field starting with it (e.g.
+	// "extra."), typed at run time.
+	DynamicPrefix string
+}
+
+func (s *Schema) lookup(name string) (Kind, bool) {
+	if k, ok := s.Fields[name]; ok {
+		return k, true
+	}
+	if s.DynamicPrefix != "" && strings.HasPrefix(name, s.DynamicPrefix) && len(name) > len(s.DynamicPrefix) {
+		return Any, true
+	}
+	return Invalid, false
+}
+
+// field returns the node of the field name, or an error naming the fields
+// there are.
+func (s *Schema) field(name string) (fieldNode, error) {
+	k, ok := s.lookup(name)
+	if !ok {
+		return fieldNode{}, fmt.Errorf("unknown field %q (want %s)", name, s.names())
+	}
+	return fieldNode{name: name, k: k}, nil
+}
+
+func (s *Schema) names() string {
+	names := make([]string, 0, len(s.Fields)+1)
+	for n := range s.Fields {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	if s.DynamicPrefix != "" {
+		names = append(names, s.DynamicPrefix+"*")
+	}
+	return strings.Join(names, ", ")
+}
+
+// Expr is a compiled expression. It is safe for concurrent use.
+type Expr struct {
+	src    string
+	root   node
+	fields []string
+	// terms are the operands of an expression built by And.
+	terms []*Expr
+	// note is what Annotate attached.
+	note any
+}
+
+// Compile parses src against schema. now() evaluates to now.
+func Compile(src string, schema Schema, now time.Time) (*Expr, error) {
+	p := &parser{lex: lexer{src: src}, schema: &schema, now: now}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.tok.kind != tEOF {
+		return nil, p.errorf("unexpected")
+	}
+	if !root.kind().is(Bool) {
+		return nil, fmt.Errorf("expression is a %s, not a condition", root.kind())
+	}
+	return newExpr(src, root), nil
+}
+
+// newExpr returns the expression of root, written as src.
+func newExpr(src string, root node) *Expr {
+	e := &Expr{src: src, root: root}
+	seen := map[string]bool{}
+	walk(root, func(n node) {
+		if f, ok := n.(fieldNode); ok && !seen[f.name] {
+			seen[f.name] = true
+			e.fields = append(e.fields, f.name)
+		}
+	})
+	return e
+}
+
+// Comparison returns the condition "field op v" over schema, as Compile
+// would parse it; op is ==, !=, <, <=, > or >=.
+func Comparison(schema Schema, field, op string, v Value) (*Expr, error) {
+	if !slices.Contains([]string{"==", "!=", "<", "<=", ">", ">="}, op) {
+		return nil, fmt.Errorf("unknown comparison %q", op)
+	}
+	f, err := schema.field(field)
+	if err != nil {
+		return nil, err
+	}
+	if v, err = coerce(f.k, v); err != nil {
+		return nil, err
+	}
+	n, err := newCompare(op, f, litNode{v})
+	if err != nil {
+		return nil, err
+	}
+	return newExpr(field+" "+op+" "+v.literal(), n), nil
+}
+
+// In returns the condition "field in (vs...)" over schema.
+func In(schema Schema, field string, vs ...Value) (*Expr, error) {
+	f, err := schema.field(field)
+	if err != nil {
+		return nil, err
+	}
+	in := &inNode{x: f}
+	lits := make([]string, len(vs))
+	for i, v := range vs {
+		if v, err = coerce(f.k, v); err != nil {
+			return nil, err
+		}
+		in.list = append(in.list, v)
+		lits[i] = v.literal()
+	}
+	return newExpr(field+" in ("+strings.Join(lits, ", ")+")", in), nil
+}
+
+// MatchRegexp returns the condition "field =~ re" over schema.
+func MatchRegexp(schema Schema, field string, re *regexp.Regexp) (*Expr, error) {
+	f, err := schema.field(field)
+	if err != nil {
+		return nil, err
+	}
+	if !f.k.is(String) {
+		return nil, fmt.Errorf("=~ needs a string on the left, got %s", f.k)
+	}
+	return newExpr(field+" =~ "+quote(re.String()), matchNode{x: f, re: re}), nil
+}
+
+// And returns the conjunction of xs, skipping nil ones, or nil when there
+// are none. Terms lists its operands.
+func And(xs ...*Expr) *Expr {
+	var terms []*Expr
+	for _, x := range xs {
+		if x != nil {
+			terms = append(terms, x.Terms()...)
+		}
+	}
+	if len(terms) < 2 {
+		for _, x := range xs {
+			if x != nil {
+				return x
+			}
+		}
+		return nil
+	}
+	srcs := make([]string, len(terms))
+	root := terms[0].root
+	var fields []string
+	for i, t := range terms {
+		srcs[i] = "(" + t.src + ")"
+		if i > 0 {
+			root = logicalNode{and: true, l: root, r: t.root}
+		}
+		fields = mergeFields(fields, t.fields)
+	}
+	return &Expr{src: strings.Join(srcs, " && "), root: root, fields: fields, terms: terms}
+}
+
+// Or returns the disjunction of xs, skipping nil ones, or nil when there
+// are none.
+func Or(xs ...*Expr) *Expr {
+	var or *Expr
+	for _, x := range xs {
+		switch {
+		case x == nil:
+		case or == nil:
+			or = x
+		default:
+			or = &Expr{
+				src:    "(" + or.src + ") || (" + x.src + ")",
+				root:   logicalNode{and: false, l: or.root, r: x.root},
+				fields: mergeFields(or.fields, x.fields),
+			}
+		}
+	}
+	return or
+}
+
+// Not returns the negation of x.
+func Not(x *Expr) *Expr {
+	return &Expr{src: "!(" + x.src + ")", root: notNode{x.root}, fields: x.fields}
+}
+
+// Terms returns the operands of an expression And built, or e alone.
+func (e *Expr) Terms() []*Expr {
+	if e.terms == nil {
+		return []*Expr{e}
+	}
+	return slices.Clone(e.terms)
+}
+
+// Annotate returns x with note attached, for whoever built x to recognize
+// it later among the Terms of an expression, e.g. to evaluate it its own
+// way. The result is a single term.
+func Annotate(x *Expr, note any) *Expr {
+	y := *x
+	y.terms, y.note = nil, note
+	return &y
+}
+
+// Note returns what Annotate attached to e, or nil.
+func (e *Expr) Note() any { return e.note }
+
+// mergeFields returns the fields of a, then those of b not in a.
+func mergeFields(a, b []string) []string {
+	out := slices.Clone(a)
+	for _, f := range b {
+		if !slices.Contains(out, f) {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// String returns the source of the expression.
+func (e *Expr) String() string { return e.src }
+
+// Fields returns the names of the fields e refers to, in order of first use.
+func (e *Expr) Fields() []string { return append([]string(nil), e.fields...) }
+
+// Match evaluates e with field values from get.
+func (e *Expr) Match(get func(field string) Value) bool {
+	return e.root.eval(get).truthy()
+}
+
+type node interface {
+	kind() Kind
+	eval(get func(string) Value) Value
+}
+
+// walk calls fn on n and all its descendants.
+func walk(n node, fn func(node)) {
+	fn(n)
+	switch n := n.(type) {
+	case logicalNode:
+		walk(n.l, fn)
+		walk(n.r, fn)
+	case notNode:
+		walk(n.x, fn)
+	case compareNode:
+		walk(n.l, fn)
+		walk(n.r, fn)
+	case arithNode:
+		walk(n.l, fn)
+		walk(n.r, fn)
+	case *inNode:
+		walk(n.x, fn)
+	case matchNode:
+		walk(n.x, fn)
+	}
+}
+
+type litNode struct{ v Value }
+
+func (n litNode) kind() Kind                    { return n.v.Kind }
+func (n litNode) eval(func(string) Value) Value { return n.v }
+
+type fieldNode struct {
+	name string
+	k    Kind
+}
+
+func (n fieldNode) kind() Kind { return n.k }
+func (n fieldNode) eval(get func(string) Value) Value {
+	v := get(n.name)
+	if n.k != Any && v.Kind != n.k {
+		return invalid()
+	}
+	return v
+}
+
+type logicalNode struct {
+	and  bool
+	l, r node
+}
+
+func newLogical(and bool, l, r node) (node, error) {
+	for _, x := range []node{l, r} {
+		if !x.kind().is(Bool) {
+			return nil, fmt.Errorf("&& and || need conditions, got %s", x.kind())
+		}
+	}
+	return logicalNode{and: and, l: l, r: r}, nil
+}
+
+func (n logicalNode) kind() Kind { return Bool }
+func (n logicalNode) eval(get func(string) Value) Value {
+	l := n.l.eval(get).truthy()
+	if n.and != l {
+		return BoolValue(l)
+	}
+	return BoolValue(n.r.eval(get).truthy())
+}
+
+type notNode struct{ x node }
+
+func (n notNode) kind() Kind { return Bool }
+func (n notNode) eval(get func(string) Value) Value {
+	v := n.x.eval(get)
+	if v.Kind != Bool {
+		return invalid()
+	}
+	return BoolValue(!v.Bool)
+}
+
+type compareNode struct {
+	op   string
+	l, r node
+}
+
+func newCompare(op string, l, r node) (node, error) {
+	lk, rk := l.kind(), r.kind()
+	// Literals are converted to the field's kind up front, e.g. a date
+	// string compared with mtime.
+	if lit, ok := r.(litNode); ok && lk != Any {
+		v, err := coerce(lk, lit.v)
+		if err != nil {
+			return nil, err
+		}
+		r, rk = litNode{v}, lk
+	} else if lit, ok := l.(litNode); ok && rk != Any {
+		v, err := coerce(rk, lit.v)
+		if err != nil {
+			return nil, err
+		}
+		l, lk = litNode{v}, rk
+	}
+	if lk != rk && lk != Any && rk != Any {
+		return nil, fmt.Errorf("cannot compare %s with %s", lk, rk)
+	}
+	if (lk == Bool || rk == Bool) && op != "==" && op != "!=" {
+		return nil, fmt.Errorf("booleans only support == and !=")
+	}
+	return compareNode{op: op, l: l, r: r}, nil
+}
+
+func (n compareNode) kind() Kind { return Bool }
+func (n compareNode) eval(get func(string) Value) Value {
+	l, r := n.l.eval(get), n.r.eval(get)
+	if !l.sameKind(r) {
+		// Dynamic values: a string may still stand for a time.
+		var err error
+		if l.Kind == Time && r.Kind == String {
+			r, err = coerce(Time, r)
+		} else if r.Kind == Time && l.Kind == String {
+			l, err = coerce(Time, l)
+		}
+		if err != nil || !l.sameKind(r) {
+			return BoolValue(false)
+		}
+	}
+	c, ok := compare(l, r)
+	if !ok {
+		return BoolValue(n.op == "!=")
+	}
+	switch n.op {
+	case "==":
+		return BoolValue(c == 0)
+	case "!=":
+		return BoolValue(c != 0)
+	case "<":
+		return BoolValue(c < 0)
+	case "<=":
+		return BoolValue(c <= 0)
+	case ">":
+		return BoolValue(c > 0)
+	default:
+		return BoolValue(c >= 0)
+	}
+}
+
+// compare orders two values of the same kind; ok is false for unequal
+// booleans, which are not ordered.
+func compare(l, r Value) (c int, ok bool) {
+	switch l.Kind {
+	case Number:
+		return cmp3(l.Num < r.Num, l.Num > r.Num), true
+	case String:
+		return strings.Compare(l.Str, r.Str), true
+	case Time:
+		return l.Time.Compare(r.Time), true
+	case Duration:
+		return cmp3(l.Dur < r.Dur, l.Dur > r.Dur), true
+	case Bool:
+		return 0, l.Bool == r.Bool
+	}
+	return 0, false
+}
+
+// Compare orders a and b for sorting: by kind first, so that missing
+// (Invalid) values come before all others, then by value, with false before
+// true.
+func Compare(a, b Value) int {
+	if a.Kind != b.Kind {
+		return cmp3(a.Kind < b.Kind, a.Kind > b.Kind)
+	}
+	if a.Kind == Bool {
+		return cmp3(!a.Bool && b.Bool, a.Bool && !b.Bool)
+	}
+	c, _ := compare(a, b)
+	return c
+}
+
+func cmp3(less, more bool) int {
+	switch {
+	case less:
+		return -1
+	case more:
+		return 1
+	}
+	return 0
+}
+
+type arithNode struct {
+	sub  bool
+	k    Kind
+	l, r node
+}
+
+func newArith(op string, l, r node) (node, error) {
+	lk, rk := l.kind(), r.kind()
+	var k Kind
+	switch {
+	case lk == Any || rk == Any:
+		k = Any
+	case lk == Number && rk == Number, lk == Duration && rk == Duration:
+		k = lk
+	case lk == Time && rk == Duration:
+		k = Time
+	case lk == Duration && rk == Time && op == "+":
+		k = Time
+	case lk == Time && rk == Time && op == "-":
+		k = Duration
+	default:
+		return nil, fmt.Errorf("cannot %s %s and %s", map[string]string{"+": "add", "-": "subtract"}[op], lk, rk)
+	}
+	n := arithNode{sub: op == "-", k: k, l: l, r: r}
+	if _, ok := l.(litNode); ok {
+		if _, ok := r.(litNode); ok {
+			// Fold constants such as now() - 30d.
+			return litNode{n.eval(nil)}, nil
+		}
+	}
+	return n, nil
+}
+
+func (n arithNode) kind() Kind { return n.k }
+func (n arithNode) eval(get func(string) Value) Value {
+	l, r := n.l.eval(get), n.r.eval(get)
+	switch {
+	case l.Kind == Number && r.Kind == Number:
+		if n.sub {
+			return NumberValue(l.Num - r.Num)
+		}
+		return NumberValue(l.Num + r.Num)
+	case l.Kind == Duration && r.Kind == Duration:
+		if n.sub {
+			return DurationValue(l.Dur - r.Dur)
+		}
+		return DurationValue(l.Dur + r.Dur)
+	case l.Kind == Time && r.Kind == Duration:
+		if n.sub {
+			return TimeValue(l.Time.Add(-r.Dur))
+		}
+		return TimeValue(l.Time.Add(r.Dur))
+	case l.Kind == Duration && r.Kind == Time && !n.sub:
+		return TimeValue(r.Time.Add(l.Dur))
+	case l.Kind == Time && r.Kind == Time && n.sub:
+		return DurationValue(l.Time.Sub(r.Time))
+	}
+	return invalid()
+}
+
+type inNode struct {
+	x    node
+	list []Value
+}
+
+func (n *inNode) kind() Kind { return Bool }
+func (n *inNode) eval(get func(string) Value) Value {
+	v := n.x.eval(get)
+	for _, w := range n.list {
+		if w.Kind != v.Kind {
+			var err error
+			if w, err = coerce(v.Kind, w); err != nil {
+				continue
+			}
+		}
+		if c, ok := compare(v, w); ok && c == 0 {
+			return BoolValue(true)
+		}
+	}
+	return BoolValue(false)
+}
+
+type matchNode struct {
+	x      node
+	re     *regexp.Regexp
+	negate bool
+}
+
+func (n matchNode) kind() Kind { return Bool }
+func (n matchNode) eval(get func(string) Value) Value {
+	v := n.x.eval(get)
+	if v.Kind != String {
+		return BoolValue(false)
+	}
+	return BoolValue(n.re.MatchString(v.Str) != n.negate)
+}
+
+// coerce converts the literal v to kind k, parsing strings as times where a
+// time is expected.
+func coerce(k Kind, v Value) (Value, error) {
+	if k == Any || v.Kind == k {
+		return v, nil
+	}
+	if k == Time && v.Kind == String {
+		for _, layout := range []string{"2006-01-02", time.RFC3339, "2006-01-02 15:04"} {
+			if t, err := time.Parse(layout, strings.TrimSpace(v.Str)); err == nil {
+				return TimeValue(t), nil
+			}
+		}
+		return Value{}, fmt.Errorf("invalid time %q (want YYYY-MM-DD or RFC 3339)", v.Str)
+	}
+	return Value{}, fmt.Errorf("cannot use %s %v as %s", v.Kind, v, k)
+}
@@ -0,0 +1,190 @@
+package expr
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testSchema = Schema{
+	Fields: map[string]Kind{
+		"name":  String,
+		"ext":   String,
+		"size":  Number,
+		"mtime": Time,
+		"isDir": Bool,
+	},
+	DynamicPrefix: "extra.",
+}
+
+func TestMatch(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	fields := map[string]Value{
+		"name":        StringValue("app.log"),
+		"ext":         StringValue(".log"),
+		"size":        NumberValue(20 << 20),
+		"mtime":       TimeValue(now.Add(-40 * 24 * time.Hour)),
+		"isDir":       BoolValue(false),
+		"extra.lines": ValueOf(int64(120)),
+		"extra.taken": ValueOf("2014-05-01T10:00:00Z"),
+	}
+	get := func(f string) Value { return fields[f] }
+
+	for _, tc := range []struct {
+		src  string
+		want bool
+	}{
+		{`size > 10MB && ext in (".log", ".gz") && mtime < now() - 30d`, true},
+		{`size > 10MB and mtime > now() - 30d`, false},
+		{`size >= 20MiB && size <= 20971520`, true},
+		{`name =~ "^app\\." || isDir`, true},
+		{`name !~ '^app'`, false},
+		{`!isDir && not (ext == ".gz")`, true},
+		{`ext not in (".gz", ".zip")`, true},
+		{`mtime < "2024-05-01"`, true},
+		{`mtime + 1w > '2024-04-28' && now() - mtime > 39d`, true},
+		{`extra.lines > 100`, true},
+		{`extra.taken < "2015-01-01"`, true},
+		{`extra.missing > 1`, false},
+		{`extra.missing != 1`, false},
+		{`size = 20971520`, true},
+		{`isDir == false`, true},
+		{`size > 1e7 AND ext <> '.gz'`, true},
+		{`size < 2.5E-3`, false},
+		{`name like 'app.%'`, true},
+		{`name like 'app_log'`, true},
+		{`name LIKE 'App%'`, false},
+		{`name not like '%.gz'`, true},
+	} {
+		x, err := Compile(tc.src, testSchema, now)
+		if err != nil {
+			t.Errorf("%s: %v", tc.src, err)
+			continue
+		}
+		if got := x.Match(get); got != tc.want {
+			t.Errorf("%s = %v, want %v", tc.src, got, tc.want)
+		}
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	for _, tc := range []struct{ src, want string }{
+		{`size > "big"`, "cannot use string"},
+		{`mtime < "yesterday"`, "invalid time"},
+		{`owner == "root"`, "unknown field"},
+		{`size + 1`, "not a condition"},
+		{`size > 1 &&`, "unexpected"},
+		{`(size > 1`, `expected ")"`},
+		{`name =~ "("`, "error parsing regexp"},
+		{`size > 10XB`, "unknown unit"},
+		{`isDir < true`, "only support =="},
+		{`name in (size)`, "only literals"},
+		{`"a`, "unterminated"},
+		{`name like size`, "quoted pattern"},
+		{`size like '1%'`, "string on the left"},
+		{`name not size`, `expected "in" or "like"`},
+	} {
+		_, err := Compile(tc.src, testSchema, time.Now())
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: err = %v, want %q", tc.src, err, tc.want)
+		}
+	}
+}
+
+func TestFields(t *testing.T) {
+	x, err := Compile(`size > 1 && (name == "a" || size < 5) && extra.lines > 0`, testSchema, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(x.Fields(), ","); got != "size,name,extra.lines" {
+		t.Fatalf("fields = %s", got)
+	}
+}
+
+func TestAndNot(t *testing.T) {
+	compile := func(src string) *Expr {
+		x, err := Compile(src, testSchema, time.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return x
+	}
+	big, log := compile(`size > 1MB`), compile(`ext == ".log" || ext == ".gz"`)
+	if And() != nil || And(nil, nil) != nil || And(nil, big) != big {
+		t.Fatal("And should skip nil expressions")
+	}
+	x := And(big, Not(log))
+	if got, want := x.String(), `(size > 1MB) && (!(ext == ".log" || ext == ".gz"))`; got != want {
+		t.Errorf("String() = %s, want %s", got, want)
+	}
+	if got := strings.Join(x.Fields(), ","); got != "size,ext" {
+		t.Errorf("fields = %s", got)
+	}
+	for _, tc := range []struct {
+		size float64
+		ext  string
+		want bool
+	}{
+		{2 << 20, ".txt", true},
+		{2 << 20, ".gz", false},
+		{1, ".txt", false},
+	} {
+		get := func(f string) Value {
+			if f == "size" {
+				return NumberValue(tc.size)
+			}
+			return StringValue(tc.ext)
+		}
+		if got := x.Match(get); got != tc.want {
+			t.Errorf("size %v ext %s: got %v, want %v", tc.size, tc.ext, got, tc.want)
+		}
+	}
+}
+
+func TestConstructors(t *testing.T) {
+	mtime := time.Date(2024, 6, 1, 12, 30, 0, 5, time.UTC)
+	must := func(x *Expr, err error) *Expr {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return x
+	}
+	isDir := must(Comparison(testSchema, "isDir", "==", BoolValue(true)))
+	x := And(
+		Or(isDir, must(In(testSchema, "ext", StringValue(".log"), StringValue(`a"b\c`)))),
+		must(Comparison(testSchema, "size", ">=", NumberValue(10<<20))),
+		must(Comparison(testSchema, "mtime", "<=", TimeValue(mtime))),
+		Annotate(must(MatchRegexp(testSchema, "name", regexp.MustCompile(`^app\.`))), "name"),
+	)
+	want := `((isDir == true) || (ext in (".log", "a\"b\\c"))) && (size >= 10485760) && (mtime <= "2024-06-01T12:30:00.000000005Z") && (name =~ "^app\\.")`
+	if x.String() != want {
+		t.Errorf("String() = %s\nwant %s", x, want)
+	}
+	// The source compiles to the same condition.
+	y := must(Compile(x.String(), testSchema, time.Now()))
+	for _, fields := range []map[string]Value{
+		{"name": StringValue("app.log"), "ext": StringValue(".log"), "size": NumberValue(10 << 20), "mtime": TimeValue(mtime), "isDir": BoolValue(false)},
+		{"name": StringValue("app.txt"), "ext": StringValue(".txt"), "size": NumberValue(10 << 20), "mtime": TimeValue(mtime), "isDir": BoolValue(false)},
+		{"name": StringValue("app"), "ext": StringValue(""), "size": NumberValue(10 << 20), "mtime": TimeValue(mtime), "isDir": BoolValue(true)},
+		{"name": StringValue("app.log"), "ext": StringValue(".log"), "size": NumberValue(1), "mtime": TimeValue(mtime), "isDir": BoolValue(false)},
+		{"name": StringValue("app.log"), "ext": StringValue(".log"), "size": NumberValue(10 << 20), "mtime": TimeValue(mtime.Add(1)), "isDir": BoolValue(false)},
+	} {
+		get := func(f string) Value { return fields[f] }
+		if x.Match(get) != y.Match(get) {
+			t.Errorf("%v: built and compiled expressions disagree", fields)
+		}
+	}
+
+	terms := x.Terms()
+	if len(terms) != 4 || terms[3].Note() != "name" || terms[0].Note() != nil {
+		t.Errorf("terms = %v", terms)
+	}
+	if _, err := Comparison(testSchema, "size", "=~", NumberValue(1)); err == nil {
+		t.Error("Comparison accepted =~")
+	}
+	if _, err := In(testSchema, "nope", StringValue("x")); err == nil {
+		t.Error("In accepted an unknown field")
+	}
+}
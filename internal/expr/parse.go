@@ -0,0 +1,462 @@
+package expr
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// token kinds
+const (
+	tEOF = iota
+	tIdent
+	tNumber // num holds the value, unit the suffix
+	tString
+	tOp
+)
+
+type token struct {
+	kind int
+	text string
+	pos  int
+	num  float64
+	unit string
+}
+
+type lexer struct {
+	src string
+	pos int
+}
+
+func (l *lexer) next() (token, error) {
+	for l.pos < len(l.src) && unicode.IsSpace(rune(l.src[l.pos])) {
+		l.pos++
+	}
+	start := l.pos
+	if l.pos >= len(l.src) {
+		return token{kind: tEOF, pos: start}, nil
+	}
+	c := l.src[l.pos]
+	switch {
+	case isIdentStart(c):
+		for l.pos < len(l.src) && isIdentPart(l.src[l.pos]) {
+			l.pos++
+		}
+		return token{kind: tIdent, text: l.src[start:l.pos], pos: start}, nil
+	case c >= '0' && c <= '9':
+		for l.pos < len(l.src) && (l.src[l.pos] >= '0' && l.src[l.pos] <= '9' || l.src[l.pos] == '.') {
+			l.pos++
+		}
+		// An exponent, as in 1e8 or 2.5E-3.
+		if e := l.pos + 1; e < len(l.src) && (l.src[l.pos] == 'e' || l.src[l.pos] == 'E') {
+			if (l.src[e] == '+' || l.src[e] == '-') && e+1 < len(l.src) {
+				e++
+			}
+			for e < len(l.src) && l.src[e] >= '0' && l.src[e] <= '9' {
+				e++
+				l.pos = e
+			}
+		}
+		n, err := strconv.ParseFloat(l.src[start:l.pos], 64)
+		if err != nil {
+			return token{}, fmt.Errorf("invalid number %q at %d", l.src[start:l.pos], start)
+		}
+		us := l.pos
+		for l.pos < len(l.src) && unicode.IsLetter(rune(l.src[l.pos])) {
+			l.pos++
+		}
+		return token{kind: tNumber, text: l.src[start:l.pos], pos: start, num: n, unit: l.src[us:l.pos]}, nil
+	case c == '"' || c == '\'':
+		var b strings.Builder
+		l.pos++
+		for l.pos < len(l.src) && l.src[l.pos] != c {
+			if l.src[l.pos] == '\\' && l.pos+1 < len(l.src) {
+				l.pos++
+			}
+			b.WriteByte(l.src[l.pos])
+			l.pos++
+		}
+		if l.pos >= len(l.src) {
+			return token{}, fmt.Errorf("unterminated string at %d", start)
+		}
+		l.pos++
+		return token{kind: tString, text: b.String(), pos: start}, nil
+	}
+	for _, op := range []string{"&&", "||", "==", "!=", "<>", "<=", ">=", "=~", "!~", "(", ")", ",", "<", ">", "!", "+", "-", "="} {
+		if strings.HasPrefix(l.src[l.pos:], op) {
+			l.pos += len(op)
+			switch op {
+			case "=":
+				op = "=="
+			case "<>":
+				op = "!="
+			}
+			return token{kind: tOp, text: op, pos: start}, nil
+		}
+	}
+	return token{}, fmt.Errorf("unexpected %q at %d", c, start)
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isIdentPart(c byte) bool {
+	return isIdentStart(c) || c >= '0' && c <= '9' || c == '.'
+}
+
+type parser struct {
+	lex    lexer
+	tok    token
+	schema *Schema
+	now    time.Time
+}
+
+func (p *parser) advance() error {
+	t, err := p.lex.next()
+	if err != nil {
+		return err
+	}
+	p.tok = t
+	return nil
+}
+
+func (p *parser) isOp(ops ...string) bool {
+	if p.tok.kind == tOp {
+		for _, op := range ops {
+			if p.tok.text == op {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isWord reports whether the current token is the keyword w.
+func (p *parser) isWord(w string) bool {
+	return p.tok.kind == tIdent && strings.EqualFold(p.tok.text, w)
+}
+
+func (p *parser) expect(op string) error {
+	if !p.isOp(op) {
+		return p.errorf("expected %q", op)
+	}
+	return p.advance()
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	at := "end of expression"
+	if p.tok.kind != tEOF {
+		at = fmt.Sprintf("%q at %d", p.tokText(), p.tok.pos)
+	}
+	return fmt.Errorf("%s near %s", fmt.Sprintf(format, args...), at)
+}
+
+func (p *parser) tokText() string {
+	if p.tok.kind == tString {
+		return strconv.Quote(p.tok.text)
+	}
+	return p.tok.text
+}
+
+// parseOr: and ( ("||" | "or") and )*
+func (p *parser) parseOr() (node, error) {
+	l, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("||") || p.isWord("or") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		r, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		if l, err = newLogical(false, l, r); err != nil {
+			return nil, err
+		}
+	}
+	return l, nil
+}
+
+// parseAnd: not ( ("&&" | "and") not )*
+func (p *parser) parseAnd() (node, error) {
+	l, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("&&") || p.isWord("and") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		r, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		if l, err = newLogical(true, l, r); err != nil {
+			return nil, err
+		}
+	}
+	return l, nil
+}
+
+// parseNot: ("!" | "not") not | comparison
+func (p *parser) parseNot() (node, error) {
+	if p.isOp("!") || p.isWord("not") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		x, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		if !x.kind().is(Bool) {
+			return nil, fmt.Errorf("! needs a boolean, got %s", x.kind())
+		}
+		return notNode{x}, nil
+	}
+	return p.parseComparison()
+}
+
+// parseComparison: sum ( cmpop sum | ["not"] "in" "(" list ")" | ["not"] "like" string | ("=~"|"!~") string )?
+func (p *parser) parseComparison() (node, error) {
+	l, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case p.isOp("==", "!=", "<", "<=", ">", ">="):
+		op := p.tok.text
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		r, err := p.parseSum()
+		if err != nil {
+			return nil, err
+		}
+		return newCompare(op, l, r)
+	case p.isOp("=~", "!~"):
+		neg := p.tok.text == "!~"
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		if p.tok.kind != tString {
+			return nil, p.errorf("=~ needs a quoted regular expression")
+		}
+		re, err := regexp.Compile(p.tok.text)
+		if err != nil {
+			return nil, err
+		}
+		if !l.kind().is(String) {
+			return nil, fmt.Errorf("=~ needs a string on the left, got %s", l.kind())
+		}
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		return matchNode{x: l, re: re, negate: neg}, nil
+	case p.isWord("in"), p.isWord("like"), p.isWord("not"):
+		neg := p.isWord("not")
+		if neg {
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			if !p.isWord("in") && !p.isWord("like") {
+				return nil, p.errorf("expected \"in\" or \"like\"")
+			}
+		}
+		if p.isWord("like") {
+			return p.parseLike(l, neg)
+		}
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		if err := p.expect("("); err != nil {
+			return nil, err
+		}
+		in := &inNode{x: l}
+		for !p.isOp(")") {
+			v, err := p.parseSum()
+			if err != nil {
+				return nil, err
+			}
+			lit, ok := v.(litNode)
+			if !ok {
+				return nil, fmt.Errorf("in (...) accepts only literals")
+			}
+			c, err := coerce(l.kind(), lit.v)
+			if err != nil {
+				return nil, err
+			}
+			in.list = append(in.list, c)
+			if !p.isOp(",") {
+				break
+			}
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		if neg {
+			return notNode{in}, nil
+		}
+		return in, nil
+	}
+	return l, nil
+}
+
+// parseLike parses the pattern of "x like 'a%'", the current token being
+// "like". As in SQL, % matches any run of characters and _ any single one;
+// the match is case-sensitive.
+func (p *parser) parseLike(x node, neg bool) (node, error) {
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	if p.tok.kind != tString {
+		return nil, p.errorf("like needs a quoted pattern")
+	}
+	if !x.kind().is(String) {
+		return nil, fmt.Errorf("like needs a string on the left, got %s", x.kind())
+	}
+	var re strings.Builder
+	re.WriteString(`(?s)^`)
+	for _, r := range p.tok.text {
+		switch r {
+		case '%':
+			re.WriteString(".*")
+		case '_':
+			re.WriteString(".")
+		default:
+			re.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	re.WriteString("$")
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	return matchNode{x: x, re: regexp.MustCompile(re.String()), negate: neg}, nil
+}
+
+// parseSum: primary ( ("+"|"-") primary )*
+func (p *parser) parseSum() (node, error) {
+	l, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("+", "-") {
+		op := p.tok.text
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		r, err := p.parsePrimary()
+		if err != nil {
+			return nil, err
+		}
+		if l, err = newArith(op, l, r); err != nil {
+			return nil, err
+		}
+	}
+	return l, nil
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	t := p.tok
+	switch t.kind {
+	case tNumber:
+		v, err := numberLiteral(t)
+		if err != nil {
+			return nil, err
+		}
+		return litNode{v}, p.advance()
+	case tString:
+		return litNode{StringValue(t.text)}, p.advance()
+	case tIdent:
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		switch strings.ToLower(t.text) {
+		case "true":
+			return litNode{BoolValue(true)}, nil
+		case "false":
+			return litNode{BoolValue(false)}, nil
+		case "now":
+			if err := p.expect("("); err != nil {
+				return nil, err
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			return litNode{TimeValue(p.now)}, nil
+		}
+		k, ok := p.schema.lookup(t.text)
+		if !ok {
+			return nil, fmt.Errorf("unknown field %q (want %s)", t.text, p.schema.names())
+		}
+		return fieldNode{name: t.text, k: k}, nil
+	case tOp:
+		switch t.text {
+		case "(":
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			x, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			return x, p.expect(")")
+		case "-":
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			x, err := p.parsePrimary()
+			if err != nil {
+				return nil, err
+			}
+			return newArith("-", litNode{zeroOf(x.kind())}, x)
+		}
+	}
+	return nil, p.errorf("unexpected")
+}
+
+// Size and duration suffixes of number literals. Sizes are binary (1KB =
+// 1024 bytes) as in --min-size; a bare lowercase "m" means minutes.
+var (
+	sizeUnits = map[string]float64{
+		"b": 1, "k": 1 << 10, "kb": 1 << 10, "kib": 1 << 10,
+		"mb": 1 << 20, "mib": 1 << 20, "g": 1 << 30, "gb": 1 << 30, "gib": 1 << 30,
+		"t": 1 << 40, "tb": 1 << 40, "tib": 1 << 40,
+	}
+	durationUnits = map[string]time.Duration{
+		"ms": time.Millisecond, "s": time.Second, "m": time.Minute, "h": time.Hour,
+		"d": 24 * time.Hour, "w": 7 * 24 * time.Hour, "y": 365 * 24 * time.Hour,
+	}
+)
+
+func numberLiteral(t token) (Value, error) {
+	switch {
+	case t.unit == "":
+		return NumberValue(t.num), nil
+	case t.unit == "M":
+		return NumberValue(t.num * (1 << 20)), nil
+	}
+	if d, ok := durationUnits[t.unit]; ok {
+		return DurationValue(time.Duration(math.Round(t.num * float64(d)))), nil
+	}
+	if m, ok := sizeUnits[strings.ToLower(t.unit)]; ok {
+		return NumberValue(t.num * m), nil
+	}
+	return Value{}, fmt.Errorf("unknown unit %q in %q", t.unit, t.text)
+}
+
+func zeroOf(k Kind) Value {
+	if k == Duration {
+		return DurationValue(0)
+	}
+	return NumberValue(0)
+}
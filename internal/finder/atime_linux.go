@@ -0,0 +1,18 @@
+//go:build linux
+
+package finder
+
+import (
+	"io/fs"
+	"syscall"
+	"time"
+)
+
+// accessTime returns the last access time recorded for info.
+func accessTime(info fs.FileInfo) (time.Time, bool) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok || st == nil {
+		return time.Time{}, false
+	}
+	return time.Unix(st.Atim.Unix()), true
+}
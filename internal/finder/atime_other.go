@@ -0,0 +1,13 @@
+//go:build !linux && !darwin && !windows
+
+package finder
+
+import (
+	"io/fs"
+	"time"
+)
+
+// accessTime is not implemented on this platform.
+func accessTime(_ fs.FileInfo) (time.Time, bool) {
+	return time.Time{}, false
+}
@@ -0,0 +1,114 @@
+package finder
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"strings"
+)
+
+// Backend selects how candidate entries are discovered.
+type Backend int
+
+const (
+	// BackendWalk recursively reads directories. It works everywhere and is the default.
+	BackendWalk Backend = iota
+	// BackendMFT enumerates the NTFS Master File Table of the volume holding Root.
+	// It needs administrator rights on Windows and falls back to BackendWalk
+	// when the volume cannot be opened or on other platforms.
+	BackendMFT
+	// BackendSpotlight answers queries from the Spotlight index via mdfind on
+	// macOS, falling back to BackendWalk elsewhere or when mdfind fails to start.
+	BackendSpotlight
+)
+
+// errBackendUnavailable is returned by an index backend, before it has visited
+// anything, to request the regular directory walk instead.
+var errBackendUnavailable = errors.New("backend unavailable")
+
+// indexHit is a candidate path produced by an index backend.
+type indexHit struct {
+	path string
+	// depth uses the walker's convention: direct children of Root are depth 0.
+	depth int
+	// hidden is set when the entry is hidden, inHidden when an ancestor below
+	// Root is.
+	hidden, inHidden bool
+}
+
+// scanIndex runs the backend selected by cfg, calling visit for every
+// candidate below cfg.Root. Filters are applied by the caller.
+func scanIndex(ctx context.Context, cfg *Config, visit func(indexHit)) error {
+	switch cfg.Backend {
+	case BackendMFT:
+		return scanMFT(ctx, cfg, visit)
+	case BackendSpotlight:
+		return scanSpotlight(ctx, cfg, visit)
+	default:
+		return errBackendUnavailable
+	}
+}
+
+// hitFor builds an indexHit for an absolute path reported below absRoot,
+// deriving depth and hidden-ness from the relative path components. The hit's
+// path is rebased onto cfg.Root so output matches the walker's. ok is false
+// when p is the root itself or lies outside it.
+func hitFor(cfg *Config, absRoot, p string) (indexHit, bool) {
+	rel, err := filepath.Rel(absRoot, p)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return indexHit{}, false
+	}
+	parts := strings.Split(rel, string(filepath.Separator))
+	h := indexHit{path: filepath.Join(cfg.Root, rel), depth: len(parts) - 1}
+	for _, part := range parts[:len(parts)-1] {
+		if strings.HasPrefix(part, ".") {
+			h.inHidden = true
+			break
+		}
+	}
+	h.hidden = strings.HasPrefix(parts[len(parts)-1], ".")
+	return h, true
+}
+
+// prunedDirs answers Config.PruneMatched for index backends, which report
+// entries in no particular order: a hit is pruned when a directory between
+// the root and it matches the filters. Directories are checked once.
+type prunedDirs struct {
+	cfg     *Config
+	matched map[string]bool
+}
+
+func newPrunedDirs(cfg *Config) *prunedDirs {
+	return &prunedDirs{cfg: cfg, matched: make(map[string]bool)}
+}
+
+// below reports whether an ancestor of h matched.
+func (p *prunedDirs) below(ctx context.Context, h indexHit) bool {
+	dir := h.path
+	for level := h.depth; level > 0; level-- {
+		dir = filepath.Dir(dir)
+		if level >= p.cfg.MinDepth && p.matches(ctx, dir) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *prunedDirs) matches(ctx context.Context, dir string) bool {
+	m, ok := p.matched[dir]
+	if ok {
+		return m
+	}
+	link, err := p.cfg.lstat(ctx, dir)
+	info := link
+	if err == nil && link.Mode()&fs.ModeSymlink != 0 && p.cfg.FollowSymlinks {
+		info, err = p.cfg.stat(ctx, dir)
+	}
+	if err == nil {
+		_, reason := buildEntry(ctx, p.cfg, dir, filepath.Base(dir), info, link)
+		m = reason == ""
+	}
+	p.matched[dir] = m
+	return m
+}
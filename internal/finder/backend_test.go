@@ -0,0 +1,43 @@
+package finder
+
+import (
+	"bytes"
+	"context"
+	"runtime"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestBackendMFT_FallsBackToWalk(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("MFT enumeration needs administrator rights on Windows")
+	}
+	td := t.TempDir()
+	mk(t, td, "a.txt", 1, time.Now())
+	mk(t, td, "sub/b.txt", 1, time.Now())
+
+	run := func(b Backend) []string {
+		var out bytes.Buffer
+		cfg := Config{Root: td, MaxDepth: -1, OutputFormat: OutputJSON, Backend: b}
+		if _, err := Run(context.Background(), &out, cfg); err != nil {
+			t.Fatalf("run backend=%d: %v", b, err)
+		}
+		var paths []string
+		for _, e := range collectJSON(t, &out) {
+			paths = append(paths, e.Path)
+		}
+		sort.Strings(paths)
+		return paths
+	}
+
+	walk, mft := run(BackendWalk), run(BackendMFT)
+	if len(walk) != 3 || len(mft) != len(walk) {
+		t.Fatalf("walk=%v mft=%v", walk, mft)
+	}
+	for i := range walk {
+		if walk[i] != mft[i] {
+			t.Fatalf("mismatch at %d: walk=%q mft=%q", i, walk[i], mft[i])
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package finder
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/Hamed0406/gofind/internal/pathtab"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Values of Entry.Change in a search with a Baseline.
+const (
+	ChangeAdded   = "added"
+	ChangeRemoved = "removed"
+	ChangeChanged = "changed"
+)
+
+// Baseline is the result of an earlier search. A search with
+// Config.Baseline set emits only the entries that were added, removed or
+// changed (in size, modification time or mode) since, with Entry.Change set.
+type Baseline struct {
+	// entries are by output path, kept prefix-compressed in paths; their
+	// Path fields are cleared.
+	entries map[pathtab.Key]Entry
+	paths   pathtab.Table
+}
+
+// LoadBaseline reads earlier JSON, NDJSON or JSON sequence output, plain or
+// compressed with gzip or zstd.
+func LoadBaseline(r io.Reader) (*Baseline, error) {
+	br := bufio.NewReader(r)
+	magic, _ := br.Peek(4)
+	switch {
+	case bytes.HasPrefix(magic, []byte{0x1f, 0x8b}):
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		br = bufio.NewReader(zr)
+	case bytes.Equal(magic, []byte{0x28, 0xb5, 0x2f, 0xfd}):
+		zr, err := zstd.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		br = bufio.NewReader(zr)
+	}
+
+	b := &Baseline{entries: make(map[pathtab.Key]Entry)}
+	dec := json.NewDecoder(rsReader{br})
+	if first, err := firstNonSpace(br); err == nil && first == '[' {
+		if _, err := dec.Token(); err != nil {
+			return nil, fmt.Errorf("reading baseline: %w", err)
+		}
+	}
+	for dec.More() {
+		var rec struct {
+			Entry
+			// Hash is the sha256 of schema v2 records, which is in
+			// Extra["sha256"] otherwise.
+			Hash string `json:"hash"`
+		}
+		if err := dec.Decode(&rec); err != nil {
+			return nil, fmt.Errorf("reading baseline: %w", err)
+		}
+		e := rec.Entry
+		if e.Path == "" {
+			continue // a meta record (Config.EmitMeta)
+		}
+		if sum, ok := strings.CutPrefix(rec.Hash, "sha256:"); ok {
+			if e.Extra == nil {
+				e.Extra = make(map[string]any)
+			}
+			e.Extra["sha256"] = sum
+		}
+		k := b.paths.Key(e.Path)
+		e.Path = ""
+		b.entries[k] = e
+	}
+	return b, nil
+}
+
+// firstNonSpace peeks at the first byte of br that is not whitespace or a
+// record separator.
+func firstNonSpace(br *bufio.Reader) (byte, error) {
+	for n := 1; ; n++ {
+		p, err := br.Peek(n)
+		if len(p) < n {
+			return 0, err
+		}
+		switch c := p[n-1]; c {
+		case ' ', '\t', '\r', '\n', recordSeparator:
+		default:
+			return c, nil
+		}
+	}
+}
+
+// rsReader turns the record separators of RFC 7464 JSON sequences into
+// whitespace, so a json.Decoder reads them like NDJSON.
+type rsReader struct{ r io.Reader }
+
+func (r rsReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	for i, c := range p[:n] {
+		if c == recordSeparator {
+			p[i] = '\n'
+		}
+	}
+	return n, err
+}
+
+// diff classifies e against the baseline, removing its record; it returns ""
+// for an unchanged entry.
+func (b *Baseline) diff(e Entry) string {
+	k, ok := b.paths.Find(e.Path)
+	var old Entry
+	if ok {
+		old, ok = b.entries[k]
+	}
+	if !ok {
+		return ChangeAdded
+	}
+	delete(b.entries, k)
+	if changed(old, e) {
+		return ChangeChanged
+	}
+	return ""
+}
+
+// changed reports whether e differs from old, the same path in a baseline:
+// in size, modification time, mode or type, or in content when both carry
+// a sha256 (a search with --enrich hash).
+func changed(old, e Entry) bool {
+	if old.Size != e.Size || !old.ModTime.Equal(e.ModTime) || old.Mode != e.Mode || old.IsDir != e.IsDir {
+		return true
+	}
+	a, _ := old.Extra["sha256"].(string)
+	b, _ := e.Extra["sha256"].(string)
+	return a != "" && b != "" && a != b
+}
+
+// Diff compares two baselines without touching the filesystem: it returns
+// the entries of newer that were added or changed since b and the entries
+// of b that newer no longer has, with Entry.Change set, sorted by path.
+func (b *Baseline) Diff(newer *Baseline) []Entry {
+	var diff []Entry
+	seen := make(map[pathtab.Key]bool)
+	for k, e := range newer.entries {
+		e.Path = newer.paths.Path(k)
+		bk, ok := b.paths.Find(e.Path)
+		var old Entry
+		if ok {
+			old, ok = b.entries[bk]
+		}
+		switch {
+		case !ok:
+			e.Change = ChangeAdded
+		case changed(old, e):
+			e.Change = ChangeChanged
+		}
+		if ok {
+			seen[bk] = true
+		}
+		if e.Change != "" {
+			diff = append(diff, e)
+		}
+	}
+	for k, e := range b.entries {
+		if !seen[k] {
+			e.Path, e.Change = b.paths.Path(k), ChangeRemoved
+			diff = append(diff, e)
+		}
+	}
+	slices.SortFunc(diff, func(x, y Entry) int { return strings.Compare(x.Path, y.Path) })
+	return diff
+}
+
+// startBaseline returns the channel matches should be sent to. When
+// cfg.Baseline is set, a goroutine forwards to out only the entries added or
+// changed since the baseline and, once in is closed, the baseline entries
+// that were not seen again as removed (unless the search was cut short, when
+// that would be wrong). Like startEnrichers, the caller closes in and then
+// calls wait, which returns once out has been closed.
+//
+// A Baseline can be used for one search only.
+func startBaseline(ctx context.Context, cfg *Config, out chan Entry, t *tally) (in chan Entry, wait func()) {
+	if cfg.Baseline == nil {
+		return out, func() {}
+	}
+	in = make(chan Entry, cap(out))
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer close(out)
+		for e := range in {
+			e.Change = cfg.Baseline.diff(Entry{Path: cfg.outputPath(e.Path), Size: e.Size, ModTime: e.ModTime, Mode: e.Mode, IsDir: e.IsDir})
+			if e.Change == "" {
+				t.matched.Add(-1)
+				continue
+			}
+			out <- e
+		}
+		if ctx.Err() != nil {
+			t.abandon()
+			return
+		}
+		b := cfg.Baseline
+		removed := slices.SortedFunc(maps.Keys(b.entries), b.paths.Compare)
+		for _, k := range removed {
+			e := b.entries[k]
+			e.Path, e.Change = b.paths.Path(k), ChangeRemoved
+			t.matched.Add(1)
+			out <- e
+		}
+	}()
+	return in, func() { <-done }
+}
@@ -0,0 +1,119 @@
+package finder
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBaselineChanges(t *testing.T) {
+	td := t.TempDir()
+	old := time.Now().Add(-time.Hour)
+	mk(t, td, "same.txt", 1, old)
+	mk(t, td, "grown.txt", 1, old)
+	mk(t, td, "gone.txt", 1, old)
+
+	for _, format := range []OutputFormat{OutputNDJSON, OutputJSON, OutputJSONSeq} {
+		var prev bytes.Buffer
+		cfg := Config{Root: td, MaxDepth: -1, OutputFormat: format}
+		if _, err := Run(context.Background(), &prev, cfg); err != nil {
+			t.Fatalf("baseline run: %v", err)
+		}
+		data := prev.Bytes()
+		if format == OutputJSON { // also check compressed baselines
+			var gz bytes.Buffer
+			zw := gzip.NewWriter(&gz)
+			zw.Write(data)
+			zw.Close()
+			data = gz.Bytes()
+		}
+		base, err := LoadBaseline(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("format %d: load: %v", format, err)
+		}
+		if len(base.entries) != 3 {
+			t.Fatalf("format %d: loaded %d entries", format, len(base.entries))
+		}
+
+		// Change the tree, then diff against the baseline.
+		mk(t, td, "grown.txt", 5, old)
+		mk(t, td, "new.txt", 1, old)
+		os.Remove(filepath.Join(td, "gone.txt"))
+
+		got := map[string]string{}
+		cfg.Baseline = base
+		res, err := Walk(context.Background(), cfg, func(e Entry) error {
+			got[filepath.Base(e.Path)] = e.Change
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("walk: %v", err)
+		}
+		want := map[string]string{"grown.txt": ChangeChanged, "new.txt": ChangeAdded, "gone.txt": ChangeRemoved}
+		if len(got) != len(want) || res.Matched != 3 {
+			t.Fatalf("format %d: got %v (Matched %d), want %v", format, got, res.Matched, want)
+		}
+		for n, c := range want {
+			if got[n] != c {
+				t.Fatalf("format %d: %s: %q, want %q", format, n, got[n], c)
+			}
+		}
+
+		// Restore the tree for the next format.
+		mk(t, td, "grown.txt", 1, old)
+		mk(t, td, "gone.txt", 1, old)
+		os.Remove(filepath.Join(td, "new.txt"))
+	}
+}
+
+func TestBaselineTextMarksChanges(t *testing.T) {
+	td := t.TempDir()
+	mk(t, td, "a.txt", 1, time.Now())
+	base, err := LoadBaseline(strings.NewReader(`{"path":"` + filepath.ToSlash(filepath.Join(td, "b.txt")) + `","name":"b.txt"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if _, err := Run(context.Background(), &out, Config{Root: td, MaxDepth: -1, SlashPaths: true, Baseline: base}); err != nil {
+		t.Fatal(err)
+	}
+	want := "+ " + filepath.ToSlash(filepath.Join(td, "a.txt")) + "\n- " + filepath.ToSlash(filepath.Join(td, "b.txt")) + "\n"
+	if out.String() != want {
+		t.Fatalf("got %q, want %q", out.String(), want)
+	}
+}
+
+func TestBaselineDiff(t *testing.T) {
+	load := func(s string) *Baseline {
+		t.Helper()
+		b, err := LoadBaseline(strings.NewReader(s))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+	old := load(`{"type":"meta","event":"start"}
+{"path":"a","size":1,"modTime":"2024-01-01T00:00:00Z"}
+{"path":"b","size":1,"modTime":"2024-01-01T00:00:00Z"}
+{"path":"c","size":1,"modTime":"2024-01-01T00:00:00Z","extra":{"sha256":"11"}}
+{"path":"d","size":1,"modTime":"2024-01-01T00:00:00Z"}
+{"type":"meta","event":"end"}`)
+	newer := load(`[{"path":"a","size":1,"modTime":"2024-01-01T01:00:00+01:00"},
+{"path":"b","size":2,"modTime":"2024-01-01T00:00:00Z"},
+{"path":"c","size":1,"modTime":"2024-01-01T00:00:00Z","schemaVersion":2,"hash":"sha256:22"},
+{"path":"e","size":1,"modTime":"2024-01-01T00:00:00Z"}]`)
+
+	var got []string
+	for _, e := range old.Diff(newer) {
+		got = append(got, e.Change+" "+e.Path)
+	}
+	want := []string{"changed b", "changed c", "removed d", "added e"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("Diff = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,188 @@
+package finder
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+// BenchmarkWalkSkewed walks a tree whose files are nearly all in one
+// directory, with its listing searched whole and in chunks.
+func BenchmarkWalkSkewed(b *testing.B) {
+	td := b.TempDir()
+	for d := 0; d < 20; d++ {
+		dir := filepath.Join(td, fmt.Sprintf("small%d", d))
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			b.Fatal(err)
+		}
+		for f := 0; f < 50; f++ {
+			if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("f%d", f)), nil, 0o644); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+	big := filepath.Join(td, "big")
+	if err := os.MkdirAll(big, 0o755); err != nil {
+		b.Fatal(err)
+	}
+	for f := 0; f < 50_000; f++ {
+		if err := os.WriteFile(filepath.Join(big, fmt.Sprintf("f%d", f)), nil, 0o644); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	for _, bc := range []struct {
+		name  string
+		chunk int
+	}{{"whole", math.MaxInt}, {"chunked", dirChunk}} {
+		b.Run(bc.name, func(b *testing.B) {
+			defer func(old int) { dirChunk = old }(dirChunk)
+			dirChunk = bc.chunk
+			cfg := Config{Root: td, MaxDepth: -1, Concurrency: 8}
+			for i := 0; i < b.N; i++ {
+				if _, err := Walk(context.Background(), cfg, func(Entry) error { return nil }); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkWalk walks 20,000 files with no filters. Its allocations per
+// entry are what a scan of millions of entries costs the garbage collector.
+func BenchmarkWalk(b *testing.B) {
+	td := b.TempDir()
+	for d := 0; d < 20; d++ {
+		dir := filepath.Join(td, fmt.Sprintf("d%d", d))
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			b.Fatal(err)
+		}
+		for f := 0; f < 1000; f++ {
+			if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("f%d", f)), nil, 0o644); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+	cfg := Config{Root: td, MaxDepth: -1}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		res, err := Walk(context.Background(), cfg, func(Entry) error { return nil })
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.ReportMetric(float64(res.Matched), "entries/op")
+	}
+}
+
+// BenchmarkTextOutput measures the writer on the default text format, per
+// entry, as in an output of millions of paths.
+func BenchmarkTextOutput(b *testing.B) {
+	cfg := &Config{}
+	e := Entry{Path: "/home/user/src/project/internal/package/file_name.go"}
+	b.ReportAllocs()
+	b.ResetTimer()
+	ch := make(chan Entry, 256)
+	go func() {
+		for i := 0; i < b.N; i++ {
+			ch <- e
+		}
+		close(ch)
+	}()
+	if err := writeEntries(Sink{Writer: io.Discard}, cfg, ch, nil); err != nil {
+		b.Fatal(err)
+	}
+}
+
+// BenchmarkNameRegex matches a name against a literal --name-regex through
+// the regexp engine and through the literal fast path.
+func BenchmarkNameRegex(b *testing.B) {
+	re := regexp.MustCompile(`_test\.go$`)
+	name := "concurrency_equivalence_test.go"
+	for _, fast := range []bool{false, true} {
+		cfg := &Config{NameRegex: re}
+		if fast {
+			cfg.nameLiteral = literalRegexp(re)
+		}
+		b.Run(fmt.Sprintf("literal=%v", fast), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if !cfg.matchNameRegex(name) {
+					b.Fatal("no match")
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkExtensions matches names against --ext lists of a few and of
+// many extensions, as the map lookup did and as the compiled matcher does.
+func BenchmarkExtensions(b *testing.B) {
+	names := []string{"main.go", "README.MD", "archive.tar.gz", "Makefile", "photo.JPEG", "notes.txt"}
+	for _, n := range []int{3, 40} {
+		exts := map[string]bool{".go": true, ".md": true, ".jpeg": true}
+		for i := len(exts); i < n; i++ {
+			exts[fmt.Sprintf(".x%d", i)] = true
+		}
+		b.Run(fmt.Sprintf("exts=%d/map", n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				_ = exts[stringsToLower(filepath.Ext(names[i%len(names)]))]
+			}
+		})
+		m := compileExts(exts)
+		b.Run(fmt.Sprintf("exts=%d/matcher", n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				_ = m.match(names[i%len(names)])
+			}
+		})
+	}
+}
+
+// BenchmarkHashReadModes hashes a 64 MiB file with each ReadMode. The file
+// is created in GOFIND_BENCH_DIR when set, to compare disks (e.g. an HDD and
+// an SSD mount); drop the page cache between runs to measure cold reads.
+func BenchmarkHashReadModes(b *testing.B) {
+	dir := os.Getenv("GOFIND_BENCH_DIR")
+	if dir == "" {
+		dir = b.TempDir()
+	}
+	f, err := os.CreateTemp(dir, "gofind-bench-*")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	chunk := make([]byte, 1<<20)
+	for i := range chunk {
+		chunk[i] = byte(i)
+	}
+	for range 64 {
+		if _, err := f.Write(chunk); err != nil {
+			b.Fatal(err)
+		}
+	}
+	if err := f.Close(); err != nil {
+		b.Fatal(err)
+	}
+
+	for _, bc := range []struct {
+		name string
+		mode ReadMode
+	}{{"plain", ReadPlain}, {"sequential", ReadSequential}, {"mmap", ReadMmap}} {
+		b.Run(bc.name, func(b *testing.B) {
+			b.SetBytes(64 << 20)
+			h := hashEnricher{read: bc.mode}
+			for i := 0; i < b.N; i++ {
+				e := Entry{Path: f.Name(), Mode: 0o644}
+				if err := h.Enrich(context.Background(), &e); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
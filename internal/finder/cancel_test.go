@@ -0,0 +1,111 @@
+package finder
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// cancelWriter cancels the search once it has received its first entry.
+type cancelWriter struct {
+	bytes.Buffer
+	cancel context.CancelFunc
+	writes int
+}
+
+func (w *cancelWriter) Write(p []byte) (int, error) {
+	w.writes++
+	if w.writes == 2 { // "[" then the first entry
+		w.cancel()
+	}
+	return w.Buffer.Write(p)
+}
+
+func TestRun_CanceledStillProducesValidJSON(t *testing.T) {
+	td := t.TempDir()
+	for i := 0; i < 50; i++ {
+		mk(t, td, fmt.Sprintf("d%02d/f.txt", i), 1, time.Now())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	w := &cancelWriter{cancel: cancel}
+	cfg := Config{Root: td, MaxDepth: -1, OutputFormat: OutputJSON, Concurrency: 1}
+	res, err := Run(ctx, w, cfg)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if !res.Interrupted {
+		t.Fatalf("expected Result.Interrupted")
+	}
+	got := collectJSON(t, &w.Buffer)
+	if int64(len(got)) != res.Matched {
+		t.Fatalf("emitted %d entries but Result.Matched = %d", len(got), res.Matched)
+	}
+}
+
+func TestRun_DeadlineMarksPartial(t *testing.T) {
+	td := t.TempDir()
+	for i := 0; i < 50; i++ {
+		mk(t, td, fmt.Sprintf("d%02d/f.txt", i), 1, time.Now())
+	}
+
+	var buf bytes.Buffer
+	cfg := Config{Root: td, MaxDepth: -1, OutputFormat: OutputNDJSON, EmitMeta: true, Deadline: time.Now().Add(-time.Second)}
+	res, err := Run(context.Background(), &buf, cfg)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if !res.Partial || res.Interrupted {
+		t.Fatalf("Partial = %v, Interrupted = %v; want true, false", res.Partial, res.Interrupted)
+	}
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	var end MetaRecord
+	if err := json.Unmarshal(lines[len(lines)-1], &end); err != nil || end.Event != "end" || end.MetaTotals == nil {
+		t.Fatalf("last line is not the end meta record: %s (%v)", lines[len(lines)-1], err)
+	}
+	if !end.MetaTotals.Partial {
+		t.Fatalf("end meta record not marked partial: %s", lines[len(lines)-1])
+	}
+
+	buf.Reset()
+	cfg.Deadline = time.Now().Add(time.Hour)
+	if res, err := Run(context.Background(), &buf, cfg); err != nil || res.Partial || res.Matched != 100 {
+		t.Fatalf("before the deadline: Partial = %v, Matched = %d, err = %v", res.Partial, res.Matched, err)
+	}
+}
+
+func TestRun_CanceledAfterSearchIsComplete(t *testing.T) {
+	td := t.TempDir()
+	var paths []string
+	for i := 0; i < 5; i++ {
+		paths = append(paths, mk(t, td, fmt.Sprintf("f%d.txt", i), 1, time.Now()))
+	}
+
+	// Cancel once every path is listed, as a Ctrl-C while output is still
+	// being written would.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var buf bytes.Buffer
+	cfg := Config{Root: td, MaxDepth: -1, OutputFormat: OutputNDJSON, EmitMeta: true, Paths: func(yield func(string) bool) {
+		for _, p := range paths {
+			if !yield(p) {
+				return
+			}
+		}
+		cancel()
+	}}
+	res, err := Run(ctx, &buf, cfg)
+	if err != nil || res.Interrupted || res.Matched != 5 {
+		t.Fatalf("Interrupted = %v, Matched = %d, err = %v; want a complete search", res.Interrupted, res.Matched, err)
+	}
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	var end MetaRecord
+	if err := json.Unmarshal(lines[len(lines)-1], &end); err != nil || end.MetaTotals == nil || end.MetaTotals.Interrupted {
+		t.Fatalf("end meta record: %s (%v)", lines[len(lines)-1], err)
+	}
+}
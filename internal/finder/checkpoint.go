@@ -0,0 +1,134 @@
+package finder
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sync"
+	"time"
+)
+
+// checkpointInterval is how often completed directories are flushed to the
+// checkpoint file.
+const checkpointInterval = 5 * time.Second
+
+// Checkpoint records directories whose whole subtree has been searched, so an
+// interrupted search can resume without walking them again. Set it as
+// Config.Checkpoint. The file holds one JSON-quoted path per line and is
+// appended to as the walk goes, flushed every few seconds and on Close.
+//
+// A resumed search skips the recorded subtrees, but lists again every
+// directory that was still in progress, so some entries of those may be
+// emitted a second time. Directory paths are compared as walked, so resume
+// with the same roots as the interrupted run.
+type Checkpoint struct {
+	done map[string]bool // read-only after OpenCheckpoint
+
+	mu   sync.Mutex
+	f    *os.File
+	w    *bufio.Writer
+	last time.Time
+	err  error
+}
+
+// OpenCheckpoint creates the checkpoint file at path. With resume set, the
+// directories it already lists are skipped by the search and new ones are
+// appended; a missing file starts an empty checkpoint.
+func OpenCheckpoint(path string, resume bool) (*Checkpoint, error) {
+	c := &Checkpoint{done: make(map[string]bool), last: time.Now()}
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if resume {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+		if err := c.load(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+	f, err := os.OpenFile(path, flags, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	c.f, c.w = f, bufio.NewWriter(f)
+	if resume && !endsWithNewline(path) {
+		c.w.WriteByte('\n') // finish a line cut short by a crash
+	}
+	return c, nil
+}
+
+// endsWithNewline reports whether the file at path is empty or ends with a
+// newline.
+func endsWithNewline(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return true
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil || fi.Size() == 0 {
+		return true
+	}
+	var b [1]byte
+	_, err = f.ReadAt(b[:], fi.Size()-1)
+	return err != nil || b[0] == '\n'
+}
+
+func (c *Checkpoint) load(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64<<10), 1<<20)
+	for sc.Scan() {
+		var p string
+		if err := json.Unmarshal(sc.Bytes(), &p); err != nil {
+			// The last line may be cut short by a crash mid-write.
+			continue
+		}
+		c.done[p] = true
+	}
+	if err := sc.Err(); err != nil {
+		return fmt.Errorf("reading checkpoint %s: %w", path, err)
+	}
+	return nil
+}
+
+// completed reports whether dir's subtree was searched by an earlier run.
+func (c *Checkpoint) completed(dir string) bool {
+	return c.done[dir]
+}
+
+// record notes that dir's subtree has been searched.
+func (c *Checkpoint) record(dir string) {
+	b, _ := json.Marshal(dir)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return
+	}
+	if _, err := c.w.Write(append(b, '\n')); err != nil {
+		c.err = err
+		return
+	}
+	if time.Since(c.last) >= checkpointInterval {
+		c.last = time.Now()
+		c.err = c.w.Flush()
+	}
+}
+
+// Close flushes and closes the file, reporting the first write error.
+func (c *Checkpoint) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	err := c.err
+	if err == nil {
+		err = c.w.Flush()
+	}
+	if cerr := c.f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
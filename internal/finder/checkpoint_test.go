@@ -0,0 +1,104 @@
+package finder
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func readCheckpoint(t *testing.T, path string) map[string]bool {
+	t.Helper()
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := map[string]bool{}
+	for _, ln := range strings.Split(strings.TrimSpace(string(b)), "\n") {
+		var p string
+		if json.Unmarshal([]byte(ln), &p) == nil { // skip a line cut short
+			done[p] = true
+		}
+	}
+	return done
+}
+
+func TestCheckpointRecordsCompletedSubtrees(t *testing.T) {
+	td := t.TempDir()
+	mk(t, td, "a/deep/x.txt", 1, time.Now())
+	mk(t, td, "b/y.txt", 1, time.Now())
+	cpFile := filepath.Join(t.TempDir(), "cp")
+
+	cp, err := OpenCheckpoint(cpFile, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Walk(context.Background(), Config{Root: td, MaxDepth: -1, Checkpoint: cp}, func(Entry) error { return nil }); err != nil {
+		t.Fatalf("walk: %v", err)
+	}
+	if err := cp.Close(); err != nil {
+		t.Fatal(err)
+	}
+	done := readCheckpoint(t, cpFile)
+	for _, d := range []string{td, filepath.Join(td, "a"), filepath.Join(td, "a", "deep"), filepath.Join(td, "b")} {
+		if !done[d] {
+			t.Errorf("%s not recorded: %v", d, done)
+		}
+	}
+}
+
+func TestCheckpointResumeSkipsCompleted(t *testing.T) {
+	td := t.TempDir()
+	mk(t, td, "a/x.txt", 1, time.Now())
+	mk(t, td, "b/y.txt", 1, time.Now())
+	cpFile := filepath.Join(t.TempDir(), "cp")
+	b, _ := json.Marshal(filepath.Join(td, "a"))
+	// A truncated last line, as left by a crash, is ignored.
+	if err := os.WriteFile(cpFile, append(b, "\n\"trunc"...), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cp, err := OpenCheckpoint(cpFile, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]bool{}
+	if _, err := Walk(context.Background(), Config{Root: td, MaxDepth: -1, Checkpoint: cp}, func(e Entry) error {
+		got[e.Name] = true
+		return nil
+	}); err != nil {
+		t.Fatalf("walk: %v", err)
+	}
+	if err := cp.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got["x.txt"] || !got["y.txt"] || !got["a"] {
+		t.Fatalf("resumed walk emitted %v; want a and b's contents but not a's", got)
+	}
+	if done := readCheckpoint(t, cpFile); !done[td] || !done[filepath.Join(td, "b")] {
+		t.Fatalf("root and b should be recorded once the resumed walk completes: %v", done)
+	}
+}
+
+func TestCheckpointCanceledWalkLeavesRootOpen(t *testing.T) {
+	td := t.TempDir()
+	mk(t, td, "a/x.txt", 1, time.Now())
+	mk(t, td, "b/y.txt", 1, time.Now())
+	cpFile := filepath.Join(t.TempDir(), "cp")
+	cp, err := OpenCheckpoint(cpFile, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, _ = Walk(ctx, Config{Root: td, MaxDepth: -1, Checkpoint: cp}, func(Entry) error { return nil })
+	if err := cp.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := os.ReadFile(cpFile); len(b) > 0 && readCheckpoint(t, cpFile)[td] {
+		t.Fatalf("canceled walk recorded the root: %s", b)
+	}
+}
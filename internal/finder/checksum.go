@@ -0,0 +1,48 @@
+package finder
+
+import "strings"
+
+// HashFormat selects a checksum file layout for text output, for files that
+// sha256sum -c and other verification tools read. Entries need the "sha256"
+// the hash enricher sets; those without one, directories and files that
+// could not be read, are left out.
+type HashFormat int
+
+const (
+	// HashFormatNone writes text output as usual.
+	HashFormatNone HashFormat = iota
+	// HashFormatGNU writes "SUM  PATH" lines, as GNU sha256sum does.
+	HashFormatGNU
+	// HashFormatBSD writes "SHA256 (PATH) = SUM" lines, as BSD sha256 and
+	// sha256sum --tag do.
+	HashFormatBSD
+)
+
+// appendChecksum appends the checksum line of e in c.HashFormat to b. As in
+// GNU coreutils, a path with a backslash, newline or carriage return is
+// escaped and the line starts with a backslash; other paths are written as
+// they are, whatever c.Quote, so that tools can open them.
+func (c *Config) appendChecksum(b []byte, e Entry) []byte {
+	sum, _ := e.Extra["sha256"].(string)
+	if sum == "" || e.Change == ChangeRemoved {
+		return b
+	}
+	name := e.Path
+	if strings.ContainsAny(name, "\\\n\r") {
+		b = append(b, '\\')
+		name = checksumEscaper.Replace(name)
+	}
+	if c.HashFormat == HashFormatBSD {
+		b = append(b, "SHA256 ("...)
+		b = append(b, name...)
+		b = append(b, ") = "...)
+		b = append(b, sum...)
+	} else {
+		b = append(b, sum...)
+		b = append(b, "  "...)
+		b = append(b, name...)
+	}
+	return append(b, '\n')
+}
+
+var checksumEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, "\r", `\r`)
@@ -0,0 +1,45 @@
+package finder
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestHashFormat(t *testing.T) {
+	td := t.TempDir()
+	if err := os.WriteFile(filepath.Join(td, "a.txt"), []byte("abc"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(td, "dir"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	const sum = "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad" // sha256("abc")
+	a := filepath.ToSlash(filepath.Join(td, "a.txt"))
+	for format, want := range map[HashFormat]string{
+		HashFormatGNU: sum + "  " + a + "\n",
+		HashFormatBSD: "SHA256 (" + a + ") = " + sum + "\n",
+	} {
+		var out bytes.Buffer
+		cfg := Config{Root: td, MaxDepth: -1, SlashPaths: true, Quote: QuoteShell, HashFormat: format, Enrichers: []Enricher{hashEnricher{}}}
+		if _, err := Run(context.Background(), &out, cfg); err != nil {
+			t.Fatal(err)
+		}
+		if out.String() != want {
+			t.Errorf("format %d: got %q, want %q", format, out.String(), want)
+		}
+	}
+
+	var b []byte
+	cfg := Config{HashFormat: HashFormatGNU}
+	b = cfg.appendChecksum(b, Entry{Path: "a\\b\nc", Extra: map[string]any{"sha256": sum}})
+	if want := `\` + sum + `  a\\b\nc` + "\n"; string(b) != want {
+		t.Errorf("escaped: got %q, want %q", b, want)
+	}
+
+	if _, err := Run(context.Background(), &bytes.Buffer{}, Config{Root: td, HashFormat: HashFormatGNU}); err == nil {
+		t.Error("HashFormat without the hash enricher accepted")
+	}
+}
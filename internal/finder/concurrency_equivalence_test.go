@@ -8,6 +8,7 @@ import (
 	"path/filepath"
 	"sort"
 	"testing"
+	"time"
 )
 
 func TestResultsSameWithDifferentConcurrency(t *testing.T) {
@@ -30,7 +31,7 @@ func TestResultsSameWithDifferentConcurrency(t *testing.T) {
 			Concurrency:  conc,
 			MaxDepth:     -1,
 		}
-		if err := Run(context.Background(), &out, cfg); err != nil {
+		if _, err := Run(context.Background(), &out, cfg); err != nil {
 			t.Fatalf("run: %v", err)
 		}
 		var arr []Entry
@@ -71,3 +72,56 @@ func fmtInt(n int) string {
 	}
 	return string(b[i:])
 }
+
+func TestChunkedListing(t *testing.T) {
+	td := t.TempDir()
+	for i := 0; i < 10; i++ {
+		mk(t, td, filepath.Join("big", "f"+fmtInt(i)), i, time.Time{})
+	}
+	mk(t, td, "big/sub1/x", 100, time.Time{})
+	mk(t, td, "big/sub2/y", 200, time.Time{})
+
+	walk := func(chunk int, cfg Config) (map[string]*DirStats, Result) {
+		t.Helper()
+		defer func(old int) { dirChunk = old }(dirChunk)
+		dirChunk = chunk
+		cfg.Root, cfg.MaxDepth, cfg.Concurrency = td, -1, 4
+		got := map[string]*DirStats{}
+		res, err := Walk(context.Background(), cfg, func(e Entry) error {
+			rel, _ := filepath.Rel(td, e.Path)
+			got[filepath.ToSlash(rel)] = e.DirStats
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return got, res
+	}
+
+	whole, _ := walk(1024, Config{DirStats: DirStatsRecursive})
+	chunked, _ := walk(3, Config{DirStats: DirStatsRecursive})
+	if len(whole) != 15 || len(chunked) != len(whole) {
+		t.Fatalf("got %d entries chunked, %d whole, want 15", len(chunked), len(whole))
+	}
+	for p, ds := range whole {
+		c, ok := chunked[p]
+		if !ok || (ds == nil) != (c == nil) || ds != nil && *ds != *c {
+			t.Errorf("%s: chunked %+v, whole %+v", p, c, ds)
+		}
+	}
+	if want := (DirStats{FileCount: 12, DirCount: 2, TotalSize: 345}); *chunked["big"] != want {
+		t.Errorf("big: %+v, want %+v", *chunked["big"], want)
+	}
+
+	// MaxPerDir still counts across the whole listing.
+	got, res := walk(3, Config{MaxPerDir: 4})
+	var inBig int
+	for p := range got {
+		if filepath.Dir(p) == "big" {
+			inBig++
+		}
+	}
+	if inBig != 4 || len(res.Truncated) != 1 || res.Truncated[0].Omitted != 8 {
+		t.Errorf("MaxPerDir 4: %d entries in big, truncated %+v; want 4 and 8 omitted", inBig, res.Truncated)
+	}
+}
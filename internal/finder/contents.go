@@ -0,0 +1,114 @@
+package finder
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"runtime/debug"
+)
+
+// ReadMode selects how the hash enricher and ContentRegex matching read
+// file contents.
+type ReadMode int
+
+const (
+	// ReadPlain reads files with plain sequential reads.
+	ReadPlain ReadMode = iota
+	// ReadSequential also tells the kernel the file will be read start to
+	// end (posix_fadvise SEQUENTIAL on Linux), which widens readahead;
+	// elsewhere it is ReadPlain.
+	ReadSequential
+	// ReadMmap maps files of at least mmapMinSize bytes into memory
+	// instead of copying them through a buffer (Linux and macOS; elsewhere,
+	// and for smaller files, it is ReadSequential).
+	ReadMmap
+)
+
+// mmapMinSize is the smallest file ReadMmap maps; below it, setting up the
+// mapping costs more than copying.
+const mmapMinSize = 1 << 20
+
+// openContents opens the file at path for reading through to the end as
+// mode selects. The reader stops with ctx.Err() once ctx is done. close
+// releases the file.
+func openContents(ctx context.Context, path string, mode ReadMode) (r io.Reader, close func() error, err error) {
+	f, err := os.Open(sysPath(path))
+	if err != nil {
+		return nil, nil, err
+	}
+	if mode == ReadMmap {
+		if fi, err := f.Stat(); err == nil && fi.Mode().IsRegular() && fi.Size() >= mmapMinSize {
+			if data, unmap, err := mmapFile(f, fi.Size()); err == nil {
+				f.Close()
+				return &mappedReader{ctx: ctx, path: path, data: data}, unmap, nil
+			}
+		}
+		// Small or not mappable: read it instead.
+	}
+	if mode != ReadPlain {
+		adviseSequential(f)
+	}
+	return ctxReader{ctx, f}, f.Close, nil
+}
+
+// mappedChunk is how much of a mapping mappedReader hands over between
+// checks of its context.
+const mappedChunk = 1 << 20
+
+// mappedReader reads a memory-mapped file. WriteTo hands the mapping to the
+// writer directly, so io.Copy skips the copy through a buffer. A file
+// truncated while mapped faults on access; that is turned into an error
+// rather than a crash.
+type mappedReader struct {
+	ctx  context.Context
+	path string
+	data []byte
+}
+
+func (m *mappedReader) Read(p []byte) (n int, err error) {
+	if err := m.ctx.Err(); err != nil {
+		return 0, err
+	}
+	if len(m.data) == 0 {
+		return 0, io.EOF
+	}
+	defer m.recoverFault(&err)
+	defer debug.SetPanicOnFault(debug.SetPanicOnFault(true))
+	n = copy(p, m.data)
+	m.data = m.data[n:]
+	return n, nil
+}
+
+func (m *mappedReader) WriteTo(w io.Writer) (total int64, err error) {
+	defer m.recoverFault(&err)
+	defer debug.SetPanicOnFault(debug.SetPanicOnFault(true))
+	for len(m.data) > 0 {
+		if err := m.ctx.Err(); err != nil {
+			return total, err
+		}
+		n, err := w.Write(m.data[:min(len(m.data), mappedChunk)])
+		total += int64(n)
+		m.data = m.data[n:]
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+// recoverFault turns a memory fault on the mapping into *err. It must be
+// deferred before the mapping is touched, with SetPanicOnFault on.
+func (m *mappedReader) recoverFault(err *error) {
+	if r := recover(); r != nil {
+		if _, ok := r.(interface{ Addr() uintptr }); !ok {
+			panic(r)
+		}
+		*err = &fs.PathError{Op: "read", Path: m.path, Err: errMappedFault}
+	}
+}
+
+// errMappedFault reports a fault reading a mapped file, usually because it
+// was truncated.
+var errMappedFault = errors.New("file changed while mapped")
@@ -0,0 +1,13 @@
+package finder
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// adviseSequential hints that f will be read start to end. It is only a
+// hint, so failures are ignored.
+func adviseSequential(f *os.File) {
+	_ = unix.Fadvise(int(f.Fd()), 0, 0, unix.FADV_SEQUENTIAL)
+}
@@ -0,0 +1,9 @@
+//go:build !linux
+
+package finder
+
+import "os"
+
+// adviseSequential does nothing: posix_fadvise is Linux only here, and
+// macOS reads ahead on sequential access by default.
+func adviseSequential(*os.File) {}
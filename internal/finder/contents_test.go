@@ -0,0 +1,56 @@
+package finder
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestReadModes(t *testing.T) {
+	td := t.TempDir()
+	big := bytes.Repeat([]byte("0123456789abcdef"), (3*mmapMinSize)/16+5)
+	for name, data := range map[string][]byte{"small": []byte("hello"), "big": big, "empty": nil} {
+		p := filepath.Join(td, name)
+		if err := os.WriteFile(p, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		sum := sha256.Sum256(data)
+		want := hex.EncodeToString(sum[:])
+		for _, mode := range []ReadMode{ReadPlain, ReadSequential, ReadMmap} {
+			e := Entry{Path: p, Mode: 0o644}
+			if err := (hashEnricher{read: mode}).Enrich(context.Background(), &e); err != nil {
+				t.Fatalf("%s, mode %d: %v", name, mode, err)
+			}
+			if e.Extra["sha256"] != want {
+				t.Errorf("%s, mode %d: sha256 %v, want %s", name, mode, e.Extra["sha256"], want)
+			}
+		}
+	}
+
+	if runtime.GOOS != "linux" && runtime.GOOS != "darwin" {
+		return
+	}
+	// A mapped file truncated under the reader is an error, not a crash.
+	p := filepath.Join(td, "big")
+	r, closeFile, err := openContents(context.Background(), p, ReadMmap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeFile()
+	if _, ok := r.(*mappedReader); !ok {
+		t.Fatalf("big file read through %T, want a mapping", r)
+	}
+	if err := os.Truncate(p, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.Copy(sha256.New(), r); !errors.Is(err, errMappedFault) {
+		t.Fatalf("reading a truncated mapping: %v", err)
+	}
+}
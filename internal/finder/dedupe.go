@@ -0,0 +1,85 @@
+package finder
+
+import (
+	"io/fs"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/Hamed0406/gofind/internal/pathtab"
+)
+
+// fileKey identifies a file: by device and inode where the platform reports
+// them, otherwise by its normalized absolute path.
+type fileKey struct {
+	dev, ino uint64
+	path     string
+}
+
+// seenFiles remembers emitted files so overlapping roots and followed
+// symlinks don't yield the same file twice. Files without a device and inode
+// are remembered by path, prefix-compressed in byPath.
+type seenFiles struct {
+	mu     sync.Mutex
+	m      map[fileKey]struct{}
+	paths  pathtab.Table
+	byPath map[pathtab.Key]struct{}
+}
+
+// needsDedupe reports whether cfg can reach a file through more than one
+// path: several roots, an explicit path list, or followed symlinks.
+func (c *Config) needsDedupe() bool {
+	return !c.NoDedupe && (len(c.Roots) > 1 || c.Paths != nil || c.FollowSymlinks)
+}
+
+// first records the file at path and reports whether it was not seen before.
+func (s *seenFiles) first(path string, info fs.FileInfo) bool {
+	ino, dev, ok := statFromFileInfo(info)
+	if !ok {
+		path = normalizedPath(path)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !ok {
+		if k, known := s.paths.Find(path); known {
+			if _, dup := s.byPath[k]; dup {
+				return false
+			}
+		}
+		if s.byPath == nil {
+			s.byPath = make(map[pathtab.Key]struct{})
+		}
+		s.byPath[s.paths.Key(path)] = struct{}{}
+		return true
+	}
+	k := fileKey{dev: dev, ino: ino}
+	if _, dup := s.m[k]; dup {
+		return false
+	}
+	s.m[k] = struct{}{}
+	return true
+}
+
+// normalizedPath returns path absolute and cleaned, lowercased on Windows
+// where names are case-insensitive.
+func normalizedPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	if runtime.GOOS == "windows" {
+		path = strings.ToLower(path)
+	}
+	return path
+}
+
+// emit counts and sends a matched entry unless the same file was already
+// emitted; it reports whether e was sent.
+func emit(cfg *Config, t *tally, entryCh chan<- Entry, e Entry, info fs.FileInfo) bool {
+	if cfg.seen != nil && !cfg.seen.first(e.Path, info) {
+		return false
+	}
+	t.matched.Add(1)
+	entryCh <- e
+	return true
+}
@@ -0,0 +1,59 @@
+package finder
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func countMatches(t *testing.T, cfg Config) map[string]int {
+	t.Helper()
+	names := map[string]int{}
+	if _, err := Walk(context.Background(), cfg, func(e Entry) error {
+		if !e.IsDir {
+			names[e.Name]++
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("walk: %v", err)
+	}
+	return names
+}
+
+func TestDedupe_OverlappingRoots(t *testing.T) {
+	td := t.TempDir()
+	mk(t, td, "a.txt", 1, time.Now())
+	mk(t, td, "sub/b.txt", 1, time.Now())
+	roots := []string{td, filepath.Join(td, "sub")}
+
+	got := countMatches(t, Config{Root: ".", Roots: roots, MaxDepth: -1})
+	if got["a.txt"] != 1 || got["b.txt"] != 1 {
+		t.Fatalf("deduped: %v", got)
+	}
+	got = countMatches(t, Config{Root: ".", Roots: roots, MaxDepth: -1, NoDedupe: true})
+	if got["b.txt"] != 2 {
+		t.Fatalf("NoDedupe: %v", got)
+	}
+}
+
+func TestDedupe_FollowedSymlinkAlias(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks need privileges on Windows")
+	}
+	td := t.TempDir()
+	target := mk(t, td, "a.txt", 1, time.Now())
+	if err := os.Symlink(target, filepath.Join(td, "link.txt")); err != nil {
+		t.Fatal(err)
+	}
+	cfg := Config{Root: td, MaxDepth: -1, FollowSymlinks: true}
+	if got := countMatches(t, cfg); len(got) != 1 {
+		t.Fatalf("got %v, want the file once", got)
+	}
+	cfg.NoDedupe = true
+	if got := countMatches(t, cfg); len(got) != 2 {
+		t.Fatalf("NoDedupe: got %v, want both paths", got)
+	}
+}
@@ -0,0 +1,290 @@
+package finder
+
+import (
+	"bufio"
+	"context"
+	"encoding/gob"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// dirCacheVersion is the format of DirCache files; files of another version
+// are ignored.
+const dirCacheVersion = 1
+
+// dirCacheRacy is how long after its last change a directory's listing is
+// not cached: a change within the same tick of a coarse clock (2s on FAT)
+// would leave its modification time as it was.
+const dirCacheRacy = 2 * time.Second
+
+// DirCache remembers the listing of each directory with its entries'
+// metadata, keyed by the directory's path and modification time. Set it as
+// Config.DirCache: a directory whose modification time is unchanged is then
+// served from the cache, without reading it or stat-ing its entries, and
+// only changed directories are read again.
+//
+// A directory's modification time changes when entries are added, removed
+// or renamed, but not when a file is written to, so the size and
+// modification time of a file changed in place stay as cached until its
+// directory changes. Filters are applied to the cached metadata, so one
+// cache serves any search of the same tree.
+type DirCache struct {
+	path string
+
+	mu   sync.Mutex
+	dirs map[string]*cachedDir
+
+	hits, misses atomic.Int64
+}
+
+// cachedDir is the listing of a directory.
+type cachedDir struct {
+	modTime time.Time
+	entries []cachedInfo
+	// used is set when the listing was served or read by this run.
+	used bool
+	// racy is set for a listing read too soon after its directory changed,
+	// or with entries missing, which is not served or saved. It still tells
+	// Close which subdirectories are gone.
+	racy bool
+}
+
+// cachedInfo is the lstat result of a directory entry. It serves as both its
+// fs.DirEntry and its fs.FileInfo.
+type cachedInfo struct {
+	name    string
+	mode    fs.FileMode
+	size    int64
+	modTime time.Time
+	sys     sysStat
+	hasSys  bool
+}
+
+func (c *cachedInfo) Name() string               { return c.name }
+func (c *cachedInfo) Size() int64                { return c.size }
+func (c *cachedInfo) Mode() fs.FileMode          { return c.mode }
+func (c *cachedInfo) ModTime() time.Time         { return c.modTime }
+func (c *cachedInfo) IsDir() bool                { return c.mode.IsDir() }
+func (c *cachedInfo) Type() fs.FileMode          { return c.mode.Type() }
+func (c *cachedInfo) Info() (fs.FileInfo, error) { return c, nil }
+
+func (c *cachedInfo) Sys() any {
+	if !c.hasSys {
+		return nil
+	}
+	return &c.sys
+}
+
+func newCachedInfo(info fs.FileInfo) cachedInfo {
+	c := cachedInfo{name: info.Name(), mode: info.Mode(), size: info.Size(), modTime: info.ModTime()}
+	if st, ok := info.Sys().(*sysStat); ok && st != nil {
+		c.sys, c.hasSys = *st, true
+	}
+	return c
+}
+
+// dirCacheFile is the encoding of a DirCache file.
+type dirCacheFile struct {
+	Version int
+	// GOOS is the platform that wrote the file, as the stat data differ.
+	GOOS string
+	Dirs []dirCacheRecord
+}
+
+type dirCacheRecord struct {
+	Path    string
+	ModTime time.Time
+	Entries []entryRecord
+}
+
+type entryRecord struct {
+	Name    string
+	Mode    fs.FileMode
+	Size    int64
+	ModTime time.Time
+	Sys     *sysStat
+}
+
+// OpenDirCache loads the cache file at path, which Close writes back. A
+// missing file, or one written by another version or platform, starts an
+// empty cache.
+func OpenDirCache(path string) (*DirCache, error) {
+	d := &DirCache{path: path, dirs: make(map[string]*cachedDir)}
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return d, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var file dirCacheFile
+	if err := gob.NewDecoder(bufio.NewReader(f)).Decode(&file); err != nil || file.Version != dirCacheVersion || file.GOOS != runtime.GOOS {
+		return d, nil // rebuilt as the search goes
+	}
+	for _, r := range file.Dirs {
+		dir := &cachedDir{modTime: r.ModTime, entries: make([]cachedInfo, len(r.Entries))}
+		for i, e := range r.Entries {
+			dir.entries[i] = cachedInfo{name: e.Name, mode: e.Mode, size: e.Size, modTime: e.ModTime}
+			if e.Sys != nil {
+				dir.entries[i].sys, dir.entries[i].hasSys = *e.Sys, true
+			}
+		}
+		d.dirs[r.Path] = dir
+	}
+	return d, nil
+}
+
+// Stats returns how many directories were served from the cache and how many
+// were read.
+func (d *DirCache) Stats() (hits, misses int64) {
+	return d.hits.Load(), d.misses.Load()
+}
+
+// list returns the entries of dir, from the cache when its modification time
+// is unchanged. Entries read fresh are *cachedInfo too, unless their lstat
+// failed, so visit does not stat them again.
+func (d *DirCache) list(ctx context.Context, cfg *Config, dir string) ([]fs.DirEntry, error) {
+	fi, err := cfg.stat(ctx, dir)
+	if err != nil {
+		return nil, err
+	}
+	key := normalizedPath(dir)
+	d.mu.Lock()
+	cd := d.dirs[key]
+	if cd != nil && !cd.racy && cd.modTime.Equal(fi.ModTime()) {
+		cd.used = true
+		d.mu.Unlock()
+		d.hits.Add(1)
+		entries := make([]fs.DirEntry, len(cd.entries))
+		for i := range cd.entries {
+			entries[i] = &cd.entries[i]
+		}
+		return entries, nil
+	}
+	d.mu.Unlock()
+	d.misses.Add(1)
+
+	start := time.Now()
+	listed, err := cfg.readDir(ctx, dir)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]fs.DirEntry, len(listed))
+	infos := make([]cachedInfo, 0, len(listed)) // no reallocation: entries point into it
+	complete := true
+	for i, de := range listed {
+		info, err := cfg.lstat(ctx, filepath.Join(dir, de.Name()))
+		if err != nil {
+			// visit reports it when it stats the entry again.
+			entries[i], complete = de, false
+			continue
+		}
+		infos = append(infos, newCachedInfo(info))
+		entries[i] = &infos[len(infos)-1]
+	}
+	racy := !complete || start.Sub(fi.ModTime()) < dirCacheRacy
+	d.mu.Lock()
+	d.dirs[key] = &cachedDir{modTime: fi.ModTime(), entries: infos, used: true, racy: racy}
+	d.mu.Unlock()
+	return entries, nil
+}
+
+// entryInfo returns the lstat result of de, the entry of a listing at path:
+// the cached one, or a fresh one.
+func entryInfo(ctx context.Context, cfg *Config, path string, de fs.DirEntry) (fs.FileInfo, error) {
+	if c, ok := de.(*cachedInfo); ok {
+		return c, nil
+	}
+	return cfg.lstat(ctx, path)
+}
+
+// Close writes the cache back to its file, unless every directory was served
+// from it. Listings not used by this run are kept unless their directory is
+// gone; see gone.
+func (d *DirCache) Close() error {
+	if d.misses.Load() == 0 {
+		return nil // every listing was served as it is in the file
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	keys := make([]string, 0, len(d.dirs))
+	for k := range d.dirs {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys) // parents first
+	dropped := make(map[string]bool)
+	file := dirCacheFile{Version: dirCacheVersion, GOOS: runtime.GOOS}
+	for _, k := range keys {
+		cd := d.dirs[k]
+		if !cd.used && d.gone(k, dropped) {
+			dropped[k] = true
+			continue
+		}
+		if cd.racy {
+			continue
+		}
+		r := dirCacheRecord{Path: k, ModTime: cd.modTime, Entries: make([]entryRecord, len(cd.entries))}
+		for i := range cd.entries {
+			c := &cd.entries[i]
+			r.Entries[i] = entryRecord{Name: c.name, Mode: c.mode, Size: c.size, ModTime: c.modTime}
+			if c.hasSys {
+				r.Entries[i].Sys = &c.sys
+			}
+		}
+		file.Dirs = append(file.Dirs, r)
+	}
+
+	tmp := d.path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	err = gob.NewEncoder(w).Encode(&file)
+	if err == nil {
+		err = w.Flush()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp, d.path)
+	}
+	if err != nil {
+		os.Remove(tmp)
+	}
+	return err
+}
+
+// gone reports whether directory k no longer exists as far as this run
+// knows: the nearest ancestor with a listing was dropped, or was listed by
+// this run without the directory leading to k.
+func (d *DirCache) gone(k string, dropped map[string]bool) bool {
+	for child, p := k, filepath.Dir(k); p != child; child, p = p, filepath.Dir(p) {
+		if dropped[p] {
+			return true
+		}
+		if cd := d.dirs[p]; cd != nil {
+			return cd.used && !cd.hasDir(filepath.Base(child))
+		}
+	}
+	return false
+}
+
+// hasDir reports whether the listing has a directory named name.
+func (cd *cachedDir) hasDir(name string) bool {
+	for i := range cd.entries {
+		if cd.entries[i].name == name {
+			return cd.entries[i].IsDir()
+		}
+	}
+	return false
+}
@@ -0,0 +1,142 @@
+package finder
+
+import (
+	"context"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"testing"
+	"time"
+)
+
+// backdate sets the modification time of every directory below root an hour
+// back, out of DirCache's racy window.
+func backdate(t *testing.T, root string) {
+	t.Helper()
+	old := time.Now().Add(-time.Hour)
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err == nil && d.IsDir() {
+			err = os.Chtimes(p, old, old)
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDirCache(t *testing.T) {
+	root := t.TempDir()
+	for _, rel := range []string{"a/1.txt", "a/b/2.txt", "c/3.txt", "c/d/4.txt", "5.txt"} {
+		mk(t, root, rel, 10, time.Time{})
+	}
+	backdate(t, root)
+	cachePath := filepath.Join(t.TempDir(), "dirs.cache")
+
+	search := func() (got map[string]int64, hits, misses int64) {
+		t.Helper()
+		dc, err := OpenDirCache(cachePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = map[string]int64{}
+		_, err = Walk(context.Background(), Config{Root: root, MaxDepth: -1, DirCache: dc}, func(e Entry) error {
+			rel, _ := filepath.Rel(root, e.Path)
+			got[filepath.ToSlash(rel)] = e.Size
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		hits, misses = dc.Stats()
+		if err := dc.Close(); err != nil {
+			t.Fatal(err)
+		}
+		return got, hits, misses
+	}
+
+	first, hits, misses := search()
+	if hits != 0 || misses != 5 {
+		t.Errorf("first search: %d hits, %d misses, want 0 and 5", hits, misses)
+	}
+	second, hits, misses := search()
+	if hits != 5 || misses != 0 {
+		t.Errorf("second search: %d hits, %d misses, want 5 and 0", hits, misses)
+	}
+	if len(second) != len(first) || len(first) != 9 {
+		t.Errorf("cached search found %v, want %v", second, first)
+	}
+	for p, size := range first {
+		if second[p] != size {
+			t.Errorf("%s: size %d from the cache, want %d", p, second[p], size)
+		}
+	}
+
+	// A new file changes its directory, which is read again.
+	mk(t, root, "c/6.txt", 1, time.Time{})
+	third, hits, misses := search()
+	if _, ok := third["c/6.txt"]; !ok || hits != 4 || misses != 1 {
+		t.Errorf("after adding c/6.txt: found %v with %d hits, %d misses", slices.Sorted(maps.Keys(third)), hits, misses)
+	}
+
+	// Writing to a file leaves its directory as it was: the cached size
+	// stands, as documented.
+	if err := os.WriteFile(filepath.Join(root, "a/1.txt"), make([]byte, 99), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got, _, _ := search(); got["a/1.txt"] != 10 {
+		t.Errorf("a/1.txt: size %d, want the cached 10", got["a/1.txt"])
+	}
+
+	// Listings of removed directories are dropped when the cache is written.
+	if err := os.RemoveAll(filepath.Join(root, "c")); err != nil {
+		t.Fatal(err)
+	}
+	backdate(t, root)
+	search()
+	dc, err := OpenDirCache(cachePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k := range dc.dirs {
+		if rel, _ := filepath.Rel(root, k); rel == "c" || rel == filepath.Join("c", "d") {
+			t.Errorf("listing of removed %s kept", rel)
+		}
+	}
+	if len(dc.dirs) != 3 {
+		t.Errorf("cache holds %d listings, want 3", len(dc.dirs))
+	}
+}
+
+func TestDirCacheKeepsStatData(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("no inode numbers in FileInfo")
+	}
+	root := t.TempDir()
+	p := mk(t, root, "f.txt", 1, time.Time{})
+	backdate(t, root)
+	want, _ := os.Lstat(p)
+	wantIno, _, _ := statFromFileInfo(want)
+
+	cachePath := filepath.Join(t.TempDir(), "dirs.cache")
+	for range 2 {
+		dc, err := OpenDirCache(cachePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries, err := dc.list(context.Background(), &Config{Root: root}, root)
+		if err != nil || len(entries) != 1 {
+			t.Fatalf("list: %v, %v", entries, err)
+		}
+		info, _ := entries[0].Info()
+		if ino, _, ok := statFromFileInfo(info); !ok || ino != wantIno {
+			t.Errorf("cached inode %d (%v), want %d", ino, ok, wantIno)
+		}
+		if err := dc.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,9 @@
+//go:build windows
+
+package finder
+
+import "syscall"
+
+// sysStat is what lstat reports in FileInfo.Sys, kept by DirCache for the
+// access time.
+type sysStat = syscall.Win32FileAttributeData
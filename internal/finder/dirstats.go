@@ -0,0 +1,126 @@
+package finder
+
+import (
+	"context"
+	"io/fs"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+)
+
+// DirStatsMode selects whether matched directories carry a DirStats.
+type DirStatsMode int
+
+const (
+	// DirStatsOff leaves Entry.DirStats nil.
+	DirStatsOff DirStatsMode = iota
+	// DirStatsImmediate counts the immediate children of a directory.
+	DirStatsImmediate
+	// DirStatsRecursive counts everything below a directory.
+	DirStatsRecursive
+)
+
+// DirStats summarizes the contents of a matched directory. Hidden and ignored
+// entries are not counted, just as they are not searched. Symlinks count as
+// files unless Config.FollowSymlinks is set and they point to a directory.
+type DirStats struct {
+	FileCount int64 `json:"fileCount"`
+	DirCount  int64 `json:"dirCount"`
+	// TotalSize is the sum of the sizes of the files counted.
+	TotalSize int64 `json:"totalSize"`
+}
+
+// dirTotals accumulates a DirStats from concurrent walkers.
+type dirTotals struct {
+	files, dirs, size atomic.Int64
+}
+
+// count adds the entry described by info.
+func (t *dirTotals) count(info fs.FileInfo) {
+	if info.IsDir() {
+		t.dirs.Add(1)
+		return
+	}
+	t.files.Add(1)
+	t.size.Add(info.Size())
+}
+
+// add adds the totals of u, a subdirectory.
+func (t *dirTotals) add(u *dirTotals) {
+	t.files.Add(u.files.Load())
+	t.dirs.Add(u.dirs.Load())
+	t.size.Add(u.size.Load())
+}
+
+func (t *dirTotals) stats() *DirStats {
+	return &DirStats{FileCount: t.files.Load(), DirCount: t.dirs.Load(), TotalSize: t.size.Load()}
+}
+
+// dirNode tracks a directory being walked. pending counts its own listing
+// plus each chunk of it and each subdirectory walk not yet complete; once it drops to zero the
+// subtree is done, which Checkpoint records and DirStats waits for.
+type dirNode struct {
+	path    string
+	parent  *dirNode
+	pending atomic.Int64
+	// incomplete is set when part of the subtree could not be searched.
+	incomplete atomic.Bool
+	totals     dirTotals
+	// entry, when set, is the match for this directory, held back until its
+	// DirStats are known; info is its FileInfo, for deduplication.
+	entry *Entry
+	info  fs.FileInfo
+	// statsOnly is set below MaxDepth, where entries are only counted for
+	// DirStats.
+	statsOnly bool
+	// modTime orders the walk for Config.RecentFirst.
+	modTime time.Time
+}
+
+func newDirNode(path string, parent *dirNode) *dirNode {
+	n := &dirNode{path: path, parent: parent}
+	n.pending.Store(1)
+	if parent != nil {
+		parent.pending.Add(1)
+	}
+	return n
+}
+
+// statDir computes the DirStats of dir outside the walk, for directories
+// found by an index backend or listed in Config.Paths. Unreadable
+// subdirectories are left out; counting stopped by ctx abandons work of t.
+func statDir(ctx context.Context, cfg *Config, t *tally, dir string, recursive bool) *DirStats {
+	var totals dirTotals
+	var scan func(dir string)
+	scan = func(dir string) {
+		entries, err := cfg.readDir(ctx, dir)
+		if err != nil {
+			return
+		}
+		for _, de := range entries {
+			if ctx.Err() != nil {
+				t.abandon()
+				return
+			}
+			full := filepath.Join(dir, de.Name())
+			if cfg.hidden(full, de.Name(), de.IsDir()) || cfg.ignored(full, de.IsDir()) {
+				continue
+			}
+			info, err := cfg.lstat(ctx, full)
+			if err != nil {
+				continue
+			}
+			if info.Mode()&fs.ModeSymlink != 0 && cfg.FollowSymlinks {
+				if ti, err := cfg.stat(ctx, full); err == nil {
+					info = ti
+				}
+			}
+			totals.count(info)
+			if recursive && info.IsDir() && de.Type()&fs.ModeSymlink == 0 {
+				scan(full)
+			}
+		}
+	}
+	scan(dir)
+	return totals.stats()
+}
@@ -0,0 +1,95 @@
+package finder
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDirStats(t *testing.T) {
+	td := t.TempDir()
+	mk(t, td, "a/x.txt", 3, time.Time{})
+	mk(t, td, "a/y.txt", 4, time.Time{})
+	mk(t, td, "a/b/z.txt", 5, time.Time{})
+	mk(t, td, "a/.hidden", 100, time.Time{})
+	if err := os.MkdirAll(filepath.Join(td, "a", "b", "c"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	stats := func(cfg Config) map[string]*DirStats {
+		t.Helper()
+		cfg.Root, cfg.Concurrency = td, 4
+		got := map[string]*DirStats{}
+		_, err := Walk(context.Background(), cfg, func(e Entry) error {
+			if !e.IsDir && e.DirStats != nil {
+				t.Errorf("%s: file with DirStats", e.Path)
+			}
+			rel, _ := filepath.Rel(td, e.Path)
+			if e.IsDir {
+				got[filepath.ToSlash(rel)] = e.DirStats
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return got
+	}
+	check := func(name string, got map[string]*DirStats, want map[string]DirStats) {
+		t.Helper()
+		if len(got) != len(want) {
+			t.Errorf("%s: got %d directories, want %d", name, len(got), len(want))
+		}
+		for dir, w := range want {
+			if g := got[dir]; g == nil || *g != w {
+				t.Errorf("%s: %s: got %+v, want %+v", name, dir, g, w)
+			}
+		}
+	}
+
+	check("immediate", stats(Config{MaxDepth: -1, DirStats: DirStatsImmediate}), map[string]DirStats{
+		"a":     {FileCount: 2, DirCount: 1, TotalSize: 7},
+		"a/b":   {FileCount: 1, DirCount: 1, TotalSize: 5},
+		"a/b/c": {},
+	})
+	check("recursive", stats(Config{MaxDepth: -1, DirStats: DirStatsRecursive}), map[string]DirStats{
+		"a":     {FileCount: 3, DirCount: 2, TotalSize: 12},
+		"a/b":   {FileCount: 1, DirCount: 1, TotalSize: 5},
+		"a/b/c": {},
+	})
+	// Counts reach below MaxDepth.
+	check("max-depth immediate", stats(Config{MaxDepth: 0, DirStats: DirStatsImmediate}), map[string]DirStats{
+		"a": {FileCount: 2, DirCount: 1, TotalSize: 7},
+	})
+	check("max-depth recursive", stats(Config{MaxDepth: 0, DirStats: DirStatsRecursive}), map[string]DirStats{
+		"a": {FileCount: 3, DirCount: 2, TotalSize: 12},
+	})
+	if got := stats(Config{MaxDepth: -1}); got["a"] != nil {
+		t.Errorf("DirStats without Config.DirStats: %+v", got["a"])
+	}
+
+	// Listed directories are counted outside the walk.
+	var out strings.Builder
+	_, err := Run(context.Background(), &out, Config{
+		Root:         td,
+		OutputFormat: OutputNDJSON,
+		DirStats:     DirStatsRecursive,
+		Paths: func(yield func(string) bool) {
+			yield(filepath.Join(td, "a"))
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rec map[string]any
+	if err := json.Unmarshal([]byte(out.String()), &rec); err != nil {
+		t.Fatalf("%v: %q", err, out.String())
+	}
+	if rec["fileCount"] != 3.0 || rec["dirCount"] != 2.0 || rec["totalSize"] != 12.0 {
+		t.Fatalf("paths: %s", out.String())
+	}
+}
@@ -0,0 +1,88 @@
+package finder
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+// decodeText returns a reader of the text of br in UTF-8: br itself for
+// UTF-8, or br decoded from cfg.Encoding or, when that is nil, from the
+// encoding detectEncoding finds in its first binarySniff bytes. Contents
+// with a NUL byte once decoded are binary and fail with errBinary, so
+// UTF-16 text is not taken for binary.
+func decodeText(cfg *Config, br *bufio.Reader) (*bufio.Reader, error) {
+	head, err := br.Peek(binarySniff)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	enc := cfg.Encoding
+	if enc == nil {
+		enc = detectEncoding(head, err == nil)
+	}
+	if enc != nil {
+		br = bufio.NewReaderSize(transform.NewReader(br, enc.NewDecoder()), br.Size())
+		if head, err = br.Peek(binarySniff); err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+	}
+	if bytes.IndexByte(head, 0) >= 0 {
+		return nil, errBinary
+	}
+	return br, nil
+}
+
+// detectEncoding returns the encoding of text starting with head, or nil
+// for UTF-8 without a byte order mark. more is set when head is not the
+// whole text. A byte order mark marks UTF-8 or UTF-16; UTF-16 without one
+// is recognized by NUL bytes in the high half of nearly every character,
+// as in ASCII text. Text that is not valid UTF-8 is taken as Windows-1252,
+// the superset of Latin-1 that Windows programs write.
+func detectEncoding(head []byte, more bool) encoding.Encoding {
+	switch {
+	case bytes.HasPrefix(head, []byte{0xef, 0xbb, 0xbf}):
+		return unicode.UTF8BOM
+	case bytes.HasPrefix(head, []byte{0xff, 0xfe}):
+		return unicode.UTF16(unicode.LittleEndian, unicode.ExpectBOM)
+	case bytes.HasPrefix(head, []byte{0xfe, 0xff}):
+		return unicode.UTF16(unicode.BigEndian, unicode.ExpectBOM)
+	}
+	if pairs := len(head) / 2; pairs >= 2 {
+		var le, be int
+		for i := 0; i+1 < len(head); i += 2 {
+			switch {
+			case head[i] != 0 && head[i+1] == 0:
+				le++
+			case head[i] == 0 && head[i+1] != 0:
+				be++
+			}
+		}
+		switch {
+		case le*10 >= pairs*9:
+			return unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM)
+		case be*10 >= pairs*9:
+			return unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM)
+		}
+	}
+	if more {
+		// head may end inside a character.
+		i := len(head) - 1
+		for i > 0 && i > len(head)-utf8.UTFMax && !utf8.RuneStart(head[i]) {
+			i--
+		}
+		if i >= 0 && !utf8.FullRune(head[i:]) {
+			head = head[:i]
+		}
+	}
+	if !utf8.Valid(head) {
+		return charmap.Windows1252
+	}
+	return nil
+}
@@ -0,0 +1,205 @@
+package finder
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+)
+
+// Enricher adds metadata to matched entries before they are written, usually
+// through Entry.SetExtra. Enrichers run concurrently on different entries but
+// never on the same entry at once, so they need no locking of their own
+// unless they share state.
+type Enricher interface {
+	Enrich(ctx context.Context, e *Entry) error
+}
+
+// EnricherFunc adapts a function to the Enricher interface.
+type EnricherFunc func(ctx context.Context, e *Entry) error
+
+// Enrich calls f(ctx, e).
+func (f EnricherFunc) Enrich(ctx context.Context, e *Entry) error { return f(ctx, e) }
+
+// SetExtra records v under key in e.Extra, which is written to JSON output as
+// the "extra" object.
+func (e *Entry) SetExtra(key string, v any) {
+	if e.Extra == nil {
+		e.Extra = make(map[string]any)
+	}
+	e.Extra[key] = v
+}
+
+var (
+	enrichersMu sync.RWMutex
+	enrichers   = map[string]Enricher{}
+)
+
+// RegisterEnricher makes e selectable by name (e.g. from the --enrich flag),
+// replacing any enricher previously registered under that name.
+func RegisterEnricher(name string, e Enricher) {
+	enrichersMu.Lock()
+	defer enrichersMu.Unlock()
+	enrichers[name] = e
+}
+
+// LookupEnricher returns the enricher registered under name.
+func LookupEnricher(name string) (Enricher, bool) {
+	enrichersMu.RLock()
+	defer enrichersMu.RUnlock()
+	e, ok := enrichers[name]
+	return e, ok
+}
+
+// EnricherNames returns the registered enricher names, sorted.
+func EnricherNames() []string {
+	enrichersMu.RLock()
+	defer enrichersMu.RUnlock()
+	names := make([]string, 0, len(enrichers))
+	for n := range enrichers {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// startEnrichers returns the channel the search should send matches to. When
+// cfg.Enrichers is set, a pool of EnrichConcurrency workers runs every
+// enricher on each entry before forwarding it to out; a failing enricher is
+// recorded in t and the entry is still forwarded. Entries first go through
+// the ContentRegex stage, if any (see startContentStage); the hash enricher
+// then runs first, in a stage of its own with HashWorkers workers. A Where
+// expression over enrichment results is applied after the enrichers, dropping entries
+// that fail it. The caller closes the returned channel when the search is
+// done and then calls wait, which returns once out has been closed. Without
+// enrichers the returned channel is out itself and wait does nothing.
+func startEnrichers(ctx context.Context, cfg *Config, out chan Entry, t *tally) (in chan Entry, wait func()) {
+	var rest []Enricher
+	hashing := false
+	for _, en := range cfg.Enrichers {
+		if _, ok := en.(hashEnricher); ok {
+			hashing = true
+		} else {
+			rest = append(rest, en)
+		}
+	}
+	in, wait = out, func() {}
+	if len(rest) > 0 || cfg.whereLate {
+		n := cfg.EnrichConcurrency
+		if n <= 0 {
+			n = cfg.Concurrency
+		}
+		in, wait = startEnrichStage(ctx, cfg, rest, n, cfg.whereLate, in, t)
+	}
+	if hashing {
+		n := cfg.HashWorkers
+		if n <= 0 {
+			n = cfg.Concurrency
+		}
+		var waitHash func()
+		in, waitHash = startEnrichStage(ctx, cfg, []Enricher{hashEnricher{read: cfg.ReadMode}}, n, false, in, t)
+		// Hashing waits on file contents; queue entries for it rather
+		// than hold up the walk.
+		in = queueEntries(in, hashQueue)
+		waitRest := wait
+		wait = func() {
+			waitHash()
+			waitRest()
+		}
+	}
+	if cfg.ContentRegex != nil {
+		var waitContent func()
+		in, waitContent = startContentStage(ctx, cfg, in, t)
+		waitRest := wait
+		wait = func() {
+			waitContent()
+			waitRest()
+		}
+	}
+	return in, wait
+}
+
+// startEnrichStage starts n workers running ens, and Where when where is set,
+// on the entries sent to in, forwarding them to out. wait returns once out
+// has been closed.
+func startEnrichStage(ctx context.Context, cfg *Config, ens []Enricher, n int, where bool, out chan Entry, t *tally) (in chan Entry, wait func()) {
+	in = make(chan Entry, cap(out))
+	var wg sync.WaitGroup
+	for range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for e := range in {
+				// After cancellation entries are passed through untouched so
+				// everything already found is still written promptly.
+				ectx, endSpan := cfg.span(ctx, "gofind.enrich", Attr{"path", e.Path})
+				var enrichErr error
+				for _, en := range ens {
+					if ctx.Err() != nil {
+						t.abandon()
+						break
+					}
+					if err := en.Enrich(ectx, &e); err != nil {
+						if ctx.Err() != nil {
+							// Stopped, not failed.
+							t.abandon()
+							break
+						}
+						t.fail("enrich", e.Path, err)
+						enrichErr = errors.Join(enrichErr, err)
+					}
+				}
+				endSpan(enrichErr)
+				if where && !matchWhere(cfg, &e) {
+					t.matched.Add(-1)
+					continue
+				}
+				out <- e
+			}
+		}()
+	}
+	return in, func() {
+		wg.Wait()
+		close(out)
+	}
+}
+
+// hashQueue is the number of entries the walk may get ahead of hashing.
+const hashQueue = 1 << 16
+
+// queueEntries returns a channel whose entries are forwarded to out, holding
+// up to max of them in memory while out is busy. Closing the returned channel
+// closes out once the queue has drained.
+func queueEntries(out chan<- Entry, max int) chan Entry {
+	in := make(chan Entry, cap(out))
+	go func() {
+		defer close(out)
+		var q []Entry
+		open := true
+		for open || len(q) > 0 {
+			var (
+				recv <-chan Entry
+				send chan<- Entry
+				next Entry
+			)
+			if open && len(q) < max {
+				recv = in
+			}
+			if len(q) > 0 {
+				send, next = out, q[0]
+			}
+			select {
+			case e, ok := <-recv:
+				if !ok {
+					open = false
+					continue
+				}
+				q = append(q, e)
+			case send <- next:
+				q[0] = Entry{}
+				q = q[1:]
+			}
+		}
+	}()
+	return in
+}
@@ -0,0 +1,214 @@
+package finder
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"math"
+	"mime"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Hamed0406/gofind/internal/media"
+)
+
+// Built-in enrichers, registered under their names. They skip directories
+// and other non-regular files.
+func init() {
+	RegisterEnricher("hash", hashEnricher{})
+	RegisterEnricher("mime", EnricherFunc(enrichMIME))
+	RegisterEnricher("lines", EnricherFunc(enrichLines))
+	RegisterEnricher("git-age", EnricherFunc(enrichGitAge))
+	RegisterEnricher("media", EnricherFunc(enrichMedia))
+}
+
+// hashEnricher sets "sha256" to the hex SHA-256 of the file contents, read
+// as read selects. It is a type of its own so startEnrichers can give it a
+// stage of its own, and Config.ReadMode.
+type hashEnricher struct {
+	read ReadMode
+}
+
+func (h hashEnricher) Enrich(ctx context.Context, e *Entry) error {
+	if !e.Mode.IsRegular() {
+		return nil
+	}
+	r, closeFile, err := openContents(ctx, e.Path, h.read)
+	if err != nil {
+		return err
+	}
+	defer closeFile()
+	sum := sha256.New()
+	if _, err := io.Copy(sum, r); err != nil {
+		return err
+	}
+	e.SetExtra("sha256", hex.EncodeToString(sum.Sum(nil)))
+	return nil
+}
+
+// enrichMIME sets "mime" from the file extension, falling back to sniffing
+// the first 512 bytes.
+func enrichMIME(_ context.Context, e *Entry) error {
+	if !e.Mode.IsRegular() {
+		return nil
+	}
+	if t := mime.TypeByExtension(filepath.Ext(e.Name)); t != "" {
+		e.SetExtra("mime", t)
+		return nil
+	}
+	f, err := os.Open(sysPath(e.Path))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	buf := make([]byte, 512)
+	n, err := io.ReadFull(f, buf)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return err
+	}
+	e.SetExtra("mime", http.DetectContentType(buf[:n]))
+	return nil
+}
+
+// binarySniffLen is how much of a file is checked for NUL bytes before
+// counting lines, as git does to tell binary files from text.
+const binarySniffLen = 8000
+
+// enrichLines sets "lines", "blankLines" (empty or whitespace only) and
+// "bytesPerLine" for text files, counting a final line without a trailing
+// newline. Files with a NUL byte near the start are treated as binary and
+// left alone.
+func enrichLines(ctx context.Context, e *Entry) error {
+	if !e.Mode.IsRegular() {
+		return nil
+	}
+	f, err := os.Open(sysPath(e.Path))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	var (
+		lines, blank, size int64
+		inLine, nonSpace   bool
+	)
+	buf := make([]byte, 32<<10)
+	r := ctxReader{ctx, f}
+	for {
+		n, err := r.Read(buf)
+		if size < binarySniffLen {
+			if bytes.IndexByte(buf[:min(n, int(binarySniffLen-size))], 0) >= 0 {
+				return nil
+			}
+		}
+		size += int64(n)
+		for _, c := range buf[:n] {
+			switch c {
+			case '\n':
+				lines++
+				if !nonSpace {
+					blank++
+				}
+				inLine, nonSpace = false, false
+			case ' ', '\t', '\r', '\f', '\v':
+				inLine = true
+			default:
+				inLine, nonSpace = true, true
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if inLine {
+		lines++
+		if !nonSpace {
+			blank++
+		}
+	}
+	e.SetExtra("lines", lines)
+	e.SetExtra("blankLines", blank)
+	if lines > 0 {
+		e.SetExtra("bytesPerLine", math.Round(float64(size)/float64(lines)*10)/10)
+	}
+	return nil
+}
+
+// enrichGitAge sets "gitCommitTime" and "gitAgeDays" from the last commit
+// touching the file, using the git CLI. Files outside a repository or never
+// committed are left alone.
+func enrichGitAge(ctx context.Context, e *Entry) error {
+	if !e.Mode.IsRegular() {
+		return nil
+	}
+	cmd := exec.CommandContext(ctx, "git", "log", "-1", "--format=%ct", "--", filepath.Base(e.Path))
+	cmd.Dir = filepath.Dir(e.Path)
+	out, err := cmd.Output()
+	if err != nil {
+		var ee *exec.ExitError
+		if errors.As(err, &ee) {
+			return nil // not a repository
+		}
+		return err
+	}
+	s := strings.TrimSpace(string(out))
+	if s == "" {
+		return nil // untracked
+	}
+	sec, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return err
+	}
+	ct := time.Unix(sec, 0)
+	e.SetExtra("gitCommitTime", ct)
+	e.SetExtra("gitAgeDays", int64(time.Since(ct)/(24*time.Hour)))
+	return nil
+}
+
+// enrichMedia sets "width" and "height", "taken" (EXIF capture or video
+// creation time) and "durationSeconds" for supported images and videos, as
+// far as the file carries them.
+func enrichMedia(_ context.Context, e *Entry) error {
+	if !e.Mode.IsRegular() || !media.Supported(e.Name) {
+		return nil
+	}
+	info, _, err := media.Read(e.Path)
+	if err != nil {
+		return err
+	}
+	if info.Width > 0 {
+		e.SetExtra("width", info.Width)
+		e.SetExtra("height", info.Height)
+	}
+	if !info.Taken.IsZero() {
+		e.SetExtra("taken", info.Taken)
+	}
+	if info.Duration > 0 {
+		e.SetExtra("durationSeconds", math.Round(info.Duration.Seconds()*1000)/1000)
+	}
+	return nil
+}
+
+// ctxReader stops reading once ctx is canceled, so hashing a large file does
+// not delay shutdown.
+type ctxReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (c ctxReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.r.Read(p)
+}
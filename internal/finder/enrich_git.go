@@ -0,0 +1,25 @@
+package finder
+
+import (
+	"context"
+
+	"github.com/Hamed0406/gofind/internal/gitstatus"
+)
+
+// GitStatusEnricher returns an enricher setting "gitRepo", "gitBranch" and
+// "gitStatus" (tracked, modified, untracked or ignored) on entries inside a
+// Git working tree. Each repository is read once, on first use, by the
+// returned enricher; create a new one per search to see later changes.
+func GitStatusEnricher() Enricher {
+	c := gitstatus.New()
+	return EnricherFunc(func(ctx context.Context, e *Entry) error {
+		info, ok, err := c.Lookup(ctx, e.Path, e.IsDir)
+		if err != nil || !ok {
+			return err
+		}
+		e.SetExtra("gitRepo", info.Repo)
+		e.SetExtra("gitBranch", info.Branch)
+		e.SetExtra("gitStatus", string(info.Status))
+		return nil
+	})
+}
@@ -0,0 +1,134 @@
+package finder
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestEnrich_BuiltinsAndCustom(t *testing.T) {
+	td := t.TempDir()
+	p := filepath.Join(td, "a.txt")
+	if err := os.WriteFile(p, []byte("one\n\nthree"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	hash, _ := LookupEnricher("hash")
+	lines, _ := LookupEnricher("lines")
+	custom := EnricherFunc(func(_ context.Context, e *Entry) error {
+		e.SetExtra("custom", len(e.Name))
+		return nil
+	})
+
+	var buf bytes.Buffer
+	cfg := Config{Root: td, MaxDepth: -1, OutputFormat: OutputJSON, Enrichers: []Enricher{hash, lines, custom}}
+	if _, err := Run(context.Background(), &buf, cfg); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	var got []struct {
+		Extra map[string]any `json:"extra"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("decode %q: %v", buf.String(), err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("want 1 entry, got %d", len(got))
+	}
+	x := got[0].Extra
+	sum := sha256.Sum256([]byte("one\n\nthree"))
+	if x["sha256"] != hex.EncodeToString(sum[:]) {
+		t.Errorf("sha256 = %v", x["sha256"])
+	}
+	if x["lines"] != float64(3) || x["blankLines"] != float64(1) || x["bytesPerLine"] != 3.3 {
+		t.Errorf("lines = %v, blankLines = %v, bytesPerLine = %v; want 3, 1, 3.3", x["lines"], x["blankLines"], x["bytesPerLine"])
+	}
+	if x["custom"] != float64(len("a.txt")) {
+		t.Errorf("custom = %v", x["custom"])
+	}
+}
+
+func TestEnrich_ErrorIsRecordedAndEntryKept(t *testing.T) {
+	td := t.TempDir()
+	mk(t, td, "a.txt", 1, time.Now())
+	failing := EnricherFunc(func(context.Context, *Entry) error { return errors.New("boom") })
+
+	var n int
+	res, err := Walk(context.Background(), Config{Root: td, MaxDepth: -1, Enrichers: []Enricher{failing}}, func(Entry) error {
+		n++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walk: %v", err)
+	}
+	if n != 1 || res.ErrorCount != 1 || res.Errors[0].Op != "enrich" {
+		t.Fatalf("got %d entries, errors %v", n, res.Errors)
+	}
+}
+
+func TestEnrichLines_SkipsBinary(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "a.bin")
+	if err := os.WriteFile(p, []byte("a\x00b\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	e := Entry{Path: p, Name: "a.bin"}
+	if err := enrichLines(context.Background(), &e); err != nil {
+		t.Fatal(err)
+	}
+	if e.Extra != nil {
+		t.Fatalf("binary file got %v", e.Extra)
+	}
+}
+
+func TestHashStage(t *testing.T) {
+	td := t.TempDir()
+	for _, name := range []string{"a", "b", "c"} {
+		mk(t, td, name, 10, time.Time{})
+	}
+	// Hashing runs first, in its own stage, so later enrichers and Where
+	// see its result.
+	sawHash := EnricherFunc(func(_ context.Context, e *Entry) error {
+		_, ok := e.Extra["sha256"].(string)
+		e.SetExtra("sawHash", ok)
+		return nil
+	})
+	where, err := CompileWhere(`extra.sawHash`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var n int
+	cfg := Config{Root: td, MaxDepth: -1, HashWorkers: 2, Where: where, Enrichers: []Enricher{sawHash, hashEnricher{}}}
+	if _, err := Walk(context.Background(), cfg, func(Entry) error { n++; return nil }); err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 {
+		t.Errorf("%d entries saw their hash, want 3", n)
+	}
+
+	// The queue in front of hashing takes entries while nothing reads them.
+	out := make(chan Entry)
+	in := queueEntries(out, 100)
+	for i := range 100 {
+		select {
+		case in <- Entry{Size: int64(i)}:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("queue blocked after %d entries", i)
+		}
+	}
+	close(in)
+	var i int64
+	for e := range out {
+		if e.Size != i {
+			t.Fatalf("entry %d came out as %d", i, e.Size)
+		}
+		i++
+	}
+	if i != 100 {
+		t.Errorf("%d entries came out, want 100", i)
+	}
+}
@@ -0,0 +1,183 @@
+package finder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Explanation describes why a single path would or would not be emitted.
+type Explanation struct {
+	Path     string `json:"path"`
+	Included bool   `json:"included"`
+	// Rule names the check that excluded the path (e.g. "ext", "hidden",
+	// "max-depth"); it is empty when the path is included.
+	Rule   string `json:"rule,omitempty"`
+	Detail string `json:"detail"`
+}
+
+// Explain runs path through the same traversal and filter checks as Run,
+// in the same order, and reports the first one that rejects it.
+func Explain(cfg Config, path string) (Explanation, error) {
+	ctx := context.Background()
+	if err := cfg.validate(ctx); err != nil {
+		return Explanation{}, err
+	}
+	ex := Explanation{Path: path}
+	exclude := func(rule, format string, args ...any) (Explanation, error) {
+		ex.Rule, ex.Detail = rule, fmt.Sprintf(format, args...)
+		return ex, nil
+	}
+
+	absRoot, err := filepath.Abs(cfg.Root)
+	if err != nil {
+		return ex, err
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return ex, err
+	}
+	rel, err := filepath.Rel(absRoot, absPath)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return exclude("root", "not under root %q", cfg.Root)
+	}
+	var parts []string
+	switch {
+	case rel != ".":
+		parts = strings.Split(rel, string(filepath.Separator))
+	case !cfg.IncludeRoot:
+		return exclude("root", "the root itself is only emitted with --include-root")
+	}
+	if len(parts) < cfg.MinDepth {
+		return exclude("min-depth", "depth %d is below --mindepth %d", len(parts), cfg.MinDepth)
+	}
+	if cfg.rootOnly && len(parts) > 0 {
+		return exclude("max-depth", "depth %d exceeds --maxdepth 0", len(parts))
+	}
+
+	var pruned *prunedDirs
+	if cfg.PruneMatched {
+		pruned = newPrunedDirs(&cfg)
+		if len(parts) > 0 && cfg.IncludeRoot && cfg.MinDepth == 0 && pruned.matches(ctx, cfg.Root) {
+			return exclude("pruned", "the root matches, and --prune-matched does not descend into it")
+		}
+	}
+
+	// Walk the components from the root down, as the walker would.
+	cur := cfg.Root
+	for i, name := range parts {
+		cur = filepath.Join(cur, name)
+		isDir := i < len(parts)-1
+		if !isDir {
+			if li, err := lstat(cur); err == nil {
+				isDir = li.IsDir()
+			}
+		}
+		if cfg.hidden(cur, name, isDir) {
+			if i == len(parts)-1 {
+				return exclude("hidden", "%q is hidden (use --include-hidden)", name)
+			}
+			return exclude("hidden", "ancestor %q is hidden (use --include-hidden)", cur)
+		}
+		if cfg.ignorer != nil {
+			li, err := lstat(cur)
+			if err == nil && cfg.ignored(cur, li.IsDir()) {
+				if i == len(parts)-1 {
+					return exclude("ignore", "%q matches an ignore pattern (use --no-ignore)", name)
+				}
+				return exclude("ignore", "ancestor %q matches an ignore pattern (use --no-ignore)", cur)
+			}
+		}
+		if i == len(parts)-1 {
+			break
+		}
+		if cfg.MaxDepth >= 0 && i >= cfg.MaxDepth {
+			return exclude("max-depth", "depth %d exceeds --max-depth %d", len(parts)-1, cfg.MaxDepth)
+		}
+		li, err := lstat(cur)
+		if err != nil {
+			return exclude("lstat", "cannot stat ancestor %q: %v", cur, err)
+		}
+		if li.Mode()&fs.ModeSymlink != 0 && !cfg.FollowSymlinks {
+			return exclude("symlink", "ancestor %q is a symlink (use --follow-symlinks)", cur)
+		}
+		if pruned != nil && i+1 >= cfg.MinDepth && pruned.matches(ctx, cur) {
+			return exclude("pruned", "ancestor %q matches, and --prune-matched does not descend into it", cur)
+		}
+	}
+
+	if dir := filepath.Dir(cur); !cfg.sampled(dir) {
+		return exclude("sample", "directory %q was not picked by --sample", dir)
+	}
+	link, err := lstat(cur)
+	if err != nil {
+		return exclude("lstat", "cannot stat: %v", err)
+	}
+	info := link
+	// The root is always searched through a symlink.
+	if info.Mode()&fs.ModeSymlink != 0 && (cfg.FollowSymlinks || len(parts) == 0) {
+		if info, err = stat(cur); err != nil {
+			return exclude("stat", "cannot resolve symlink: %v", err)
+		}
+	}
+	e, reason := buildEntry(ctx, &cfg, cur, filepath.Base(cur), info, link)
+	if reason != "" {
+		return exclude(reason, "%s", describeReject(&cfg, reason, info))
+	}
+	if cfg.ContentRegex != nil {
+		cfg.MatchLines = false
+		switch ok, err := matchContent(context.Background(), &cfg, &e); {
+		case !e.Mode.IsRegular():
+			return exclude("content-regex", "only the contents of regular files are searched")
+		case errors.Is(err, errBinary):
+			return exclude("content-regex", "binary file")
+		case err != nil:
+			return exclude("content-regex", "cannot read: %v", err)
+		case !ok:
+			return exclude("content-regex", "no line matches --content-regex %q", cfg.ContentRegex.String())
+		}
+	}
+	ex.Included = true
+	ex.Detail = "all filters passed"
+	return ex, nil
+}
+
+// describeReject turns a filter name from buildEntry into a readable sentence.
+func describeReject(cfg *Config, reason string, info fs.FileInfo) string {
+	switch reason {
+	case "ext":
+		return fmt.Sprintf("extension %q is not in the --ext list", filepath.Ext(info.Name()))
+	case "name-regex":
+		return fmt.Sprintf("name %q does not match --name-regex %q", info.Name(), cfg.NameRegex.String())
+	case "min-size":
+		return fmt.Sprintf("size %d is below --min-size %d", info.Size(), cfg.MinSize)
+	case "max-size":
+		return fmt.Sprintf("size %d is above --max-size %d", info.Size(), cfg.MaxSize)
+	case "after":
+		return fmt.Sprintf("modified %s, not after %s", info.ModTime().Format(time.RFC3339), cfg.After.Format(time.RFC3339))
+	case "before":
+		return fmt.Sprintf("modified %s, not before %s", info.ModTime().Format(time.RFC3339), cfg.Before.Format(time.RFC3339))
+	case "type":
+		return "entry type excluded by --type"
+	case "fstype":
+		return "filesystem type excluded by --fstype"
+	case "xattr":
+		return "required extended attribute missing or different (--xattr)"
+	case "sparse":
+		return "not a sparse file (--sparse)"
+	case "has-acl":
+		return "no POSIX ACL (--has-acl)"
+	case "where":
+		return fmt.Sprintf("does not satisfy --where %q", cfg.Where)
+	case "filter":
+		return "rejected by a Config.Filters predicate"
+	case "git":
+		return fmt.Sprintf("Git status is not %s (--git)", cfg.Git)
+	default:
+		return "rejected by " + reason
+	}
+}
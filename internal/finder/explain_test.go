@@ -0,0 +1,63 @@
+package finder
+
+import (
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestExplain(t *testing.T) {
+	td := t.TempDir()
+	mk(t, td, "keep/alpha.go", 10, time.Now())
+	mk(t, td, "keep/readme.md", 10, time.Now())
+	mk(t, td, ".hidden/beta.go", 10, time.Now())
+	mk(t, td, "a/b/c/deep.go", 10, time.Now())
+
+	cfg := Config{
+		Root:       td,
+		MaxDepth:   1,
+		Extensions: map[string]bool{".go": true},
+		NameRegex:  regexp.MustCompile(`^[a-z]`),
+	}
+	cases := []struct {
+		rel  string
+		rule string
+	}{
+		{"keep/alpha.go", ""},
+		{"keep/readme.md", "ext"},
+		{".hidden/beta.go", "hidden"},
+		{"a/b/c/deep.go", "max-depth"},
+		{"keep/missing.go", "lstat"},
+		{".", "root"},
+	}
+	for _, c := range cases {
+		ex, err := Explain(cfg, filepath.Join(td, filepath.FromSlash(c.rel)))
+		if err != nil {
+			t.Fatalf("%s: %v", c.rel, err)
+		}
+		if ex.Rule != c.rule || ex.Included != (c.rule == "") {
+			t.Fatalf("%s: got rule=%q included=%v (%s), want rule=%q", c.rel, ex.Rule, ex.Included, ex.Detail, c.rule)
+		}
+		if ex.Detail == "" {
+			t.Fatalf("%s: empty detail", c.rel)
+		}
+	}
+}
+
+func TestExplainDepthAndPruning(t *testing.T) {
+	td := t.TempDir()
+	mk(t, td, "vendor/lib/x.go", 10, time.Now())
+
+	cfg := Config{Root: td, MaxDepth: -1, IncludeRoot: true, MinDepth: 1, NameRegex: regexp.MustCompile(`^(vendor|x\.go)$`)}
+	for rel, rule := range map[string]string{".": "min-depth", "vendor": "", "vendor/lib/x.go": ""} {
+		ex, err := Explain(cfg, filepath.Join(td, filepath.FromSlash(rel)))
+		if err != nil || ex.Rule != rule {
+			t.Fatalf("%s: got rule=%q (%s), %v, want %q", rel, ex.Rule, ex.Detail, err, rule)
+		}
+	}
+	cfg.PruneMatched = true
+	if ex, err := Explain(cfg, filepath.Join(td, "vendor", "lib", "x.go")); err != nil || ex.Rule != "pruned" {
+		t.Fatalf("pruned: got rule=%q (%s), %v", ex.Rule, ex.Detail, err)
+	}
+}
@@ -0,0 +1,104 @@
+package finder
+
+import (
+	"path/filepath"
+	"unicode/utf8"
+)
+
+// extMatcher is Config.Extensions compiled for the walk's hot path. It
+// matches an ASCII extension without allocating, comparing it against the
+// few extensions of the same length, or looking a lowered copy up in the map
+// when there are many. Other extensions are lowered by stringsToLower and
+// looked up in the map.
+type extMatcher struct {
+	// lens has bit n set when some extension is n bytes long.
+	lens uint64
+	// byLen holds the extensions by length, for lengths below 64.
+	byLen [64][]string
+	// set is used instead of byLen when any length has more than
+	// maxExtScan extensions.
+	set map[string]bool
+	// all is Config.Extensions, for non-ASCII extensions.
+	all map[string]bool
+}
+
+// maxExtScan is the most extensions of one length compared one by one;
+// beyond it a map lookup is faster.
+const maxExtScan = 8
+
+// compileExts returns a matcher for exts, or nil when it is empty.
+func compileExts(exts map[string]bool) *extMatcher {
+	m := extMatcher{all: exts}
+	for e, ok := range exts {
+		if !ok {
+			continue
+		}
+		if len(e) >= len(m.byLen) {
+			// Too long for the length mask; leave the whole set to the map.
+			return &extMatcher{lens: ^uint64(0), set: exts, all: exts}
+		}
+		m.lens |= 1 << len(e)
+		m.byLen[len(e)] = append(m.byLen[len(e)], e)
+		if len(m.byLen[len(e)]) > maxExtScan {
+			m.set = exts
+		}
+	}
+	if m.lens == 0 {
+		return nil
+	}
+	return &m
+}
+
+// match reports whether the extension of name, lowered by stringsToLower, is
+// one of the set.
+func (m *extMatcher) match(name string) bool {
+	ext := filepath.Ext(name)
+	for i := 0; i < len(ext); i++ {
+		if ext[i] >= utf8.RuneSelf {
+			// Folding may change its length, so neither the mask nor byLen
+			// applies.
+			return m.all[stringsToLower(ext)]
+		}
+	}
+	if len(ext) < len(m.byLen) && m.lens&(1<<len(ext)) == 0 {
+		return false
+	}
+	if m.set != nil {
+		var buf [64]byte
+		if len(ext) > len(buf) {
+			return m.set[stringsToLower(ext)]
+		}
+		b := buf[:len(ext)]
+		for i := 0; i < len(ext); i++ {
+			b[i] = lowerASCII(ext[i])
+		}
+		return m.set[string(b)] // no allocation for a map index
+	}
+	if len(ext) >= len(m.byLen) {
+		return false
+	}
+	for _, e := range m.byLen[len(ext)] {
+		if equalLowerASCII(ext, e) {
+			return true
+		}
+	}
+	return false
+}
+
+// equalLowerASCII reports whether s with its ASCII letters lowered equals
+// lower, which has the same length.
+func equalLowerASCII(s, lower string) bool {
+	for i := 0; i < len(s); i++ {
+		if lowerASCII(s[i]) != lower[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func lowerASCII(c byte) byte {
+	if 'A' <= c && c <= 'Z' {
+		return c + ('a' - 'A')
+	}
+	return c
+}
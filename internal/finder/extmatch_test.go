@@ -0,0 +1,53 @@
+package finder
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+)
+
+func TestExtMatcher(t *testing.T) {
+	names := []string{
+		"main.go", "MAIN.GO", "a.Go", "README.md", "x.markdown", "noext", ".go",
+		"dir/.hidden", "a.tar.gz", "a.GZ", "a.", "photo.JPEG", "ü.ÜBER", "a.über", "a.Über", "x.ÉTÉ", "x.été", "x.Ärger", "x.İ", "x.i̇",
+		"long." + string(make([]byte, 70)), "bad.\xff",
+	}
+	many := map[string]bool{}
+	for i := range 20 {
+		many[fmt.Sprintf(".e%d", i)] = true
+	}
+	many[".go"] = true
+	sets := []map[string]bool{
+		{".go": true},
+		{".go": true, ".md": true, ".gz": true, ".jpeg": true, ".über": true},
+		{".été": true, ".ärger": true, ".i": true},
+		{".go": false, ".md": true},
+		{".": true},
+		many,
+		{"." + string(make([]byte, 70)): true, ".go": true},
+	}
+	for i, set := range sets {
+		m := compileExts(set)
+		for _, name := range names {
+			want := set[stringsToLower(filepath.Ext(name))]
+			if got := m != nil && m.match(name); got != want {
+				t.Errorf("set %d: match(%q) = %v, want %v", i, name, got, want)
+			}
+		}
+	}
+	if compileExts(nil) != nil || compileExts(map[string]bool{".go": false}) != nil {
+		t.Error("empty set compiled to a matcher")
+	}
+}
+
+func TestStringsToLower(t *testing.T) {
+	for in, want := range map[string]string{
+		"": "", ".go": ".go", ".GO": ".go", ".Go": ".go",
+		".über": ".über", ".ÜBER": ".über", ".Über": ".über", ".ÉTÉ": ".été",
+		".ΑΒΓ": ".αβγ", ".İ": ".i", // one byte shorter
+	} {
+		if got := stringsToLower(in); got != want {
+			t.Errorf("stringsToLower(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package finder
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+)
+
+// maxAutoOpenDirs caps the budget picked from the open file limit, which
+// can be in the millions; far fewer handles already keep the disks busy.
+const maxAutoOpenDirs = 4096
+
+// openDirs bounds the directory handles the walker holds open at once,
+// separately from Concurrency; see Config.MaxOpenDirs.
+type openDirs struct {
+	slots chan struct{}
+	// n is the budget, for error messages.
+	n int
+}
+
+// newOpenDirs returns the budget for Config.MaxOpenDirs n, or nil for none.
+func newOpenDirs(n int) *openDirs {
+	if n == 0 {
+		// Leave half of the limit to output files, enrichers and the
+		// rest of the process.
+		limit := openFileLimit()
+		if limit <= 0 {
+			return nil // no limit known
+		}
+		n = max(min(limit/2, maxAutoOpenDirs), 1)
+	}
+	if n < 0 {
+		return nil
+	}
+	return &openDirs{slots: make(chan struct{}, n), n: n}
+}
+
+// hold runs op, a directory read, once a handle is free.
+func (o *openDirs) hold(op func() ([]fs.DirEntry, error)) ([]fs.DirEntry, error) {
+	if o == nil {
+		return op()
+	}
+	o.slots <- struct{}{}
+	defer func() { <-o.slots }()
+	return op()
+}
+
+// ErrTooManyOpenFiles is returned by Run and Walk when paths were skipped
+// because the process ran out of file descriptors, which would otherwise
+// pass for a few unreadable directories.
+var ErrTooManyOpenFiles = errors.New("too many open files")
+
+// exhausted returns an ErrTooManyOpenFiles naming the budget in effect if t
+// recorded failures for lack of file descriptors.
+func (t *tally) exhausted(cfg *Config) error {
+	t.mu.Lock()
+	n := t.fdErrCount
+	t.mu.Unlock()
+	if n == 0 {
+		return nil
+	}
+	budget := "no directory budget"
+	if o := cfg.openDirs; o != nil {
+		budget = fmt.Sprintf("up to %d directories open at once", o.n)
+	}
+	return fmt.Errorf("%w: %d paths skipped with %s; lower --max-open-dirs or raise the open file limit (ulimit -n)", ErrTooManyOpenFiles, n, budget)
+}
@@ -0,0 +1,21 @@
+//go:build !windows
+
+package finder
+
+import (
+	"errors"
+	"syscall"
+)
+
+// openFileLimit returns the soft RLIMIT_NOFILE, or 0 when it is unknown.
+func openFileLimit() int {
+	var rl syscall.Rlimit
+	if err := syscall.Getrlimit(syscall.RLIMIT_NOFILE, &rl); err != nil {
+		return 0
+	}
+	return int(min(rl.Cur, 1<<30)) // RLIM_INFINITY too
+}
+
+func isTooManyOpenFiles(err error) bool {
+	return errors.Is(err, syscall.EMFILE) || errors.Is(err, syscall.ENFILE)
+}
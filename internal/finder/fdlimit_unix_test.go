@@ -0,0 +1,54 @@
+//go:build !windows
+
+package finder
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestOpenDirsBudget(t *testing.T) {
+	if o := newOpenDirs(-1); o != nil {
+		t.Errorf("MaxOpenDirs -1: budget of %d", o.n)
+	}
+	if o := newOpenDirs(3); o == nil || o.n != 3 {
+		t.Errorf("MaxOpenDirs 3: got %+v", o)
+	}
+	if o := newOpenDirs(0); o == nil || o.n < 1 || o.n > maxAutoOpenDirs || o.n > openFileLimit() {
+		t.Errorf("MaxOpenDirs 0: got %+v with open file limit %d", o, openFileLimit())
+	}
+
+	// A budget far below Concurrency still finds everything.
+	td := t.TempDir()
+	for _, rel := range []string{"a/b/c/1", "a/b/2", "d/e/3", "d/4", "5"} {
+		mk(t, td, rel, 1, time.Time{})
+	}
+	var n int
+	_, err := Walk(context.Background(), Config{Root: td, MaxDepth: -1, Concurrency: 16, MaxOpenDirs: 1}, func(Entry) error {
+		n++
+		return nil
+	})
+	if err != nil || n != 10 {
+		t.Fatalf("got %d entries, %v; want 10", n, err)
+	}
+}
+
+func TestTooManyOpenFiles(t *testing.T) {
+	cfg := &Config{openDirs: newOpenDirs(8)}
+	tl := newTally(context.Background(), cfg)
+	tl.fail("readdir", "/x", &fs.PathError{Op: "open", Path: "/x", Err: syscall.EACCES})
+	if err := tl.exhausted(cfg); err != nil {
+		t.Fatalf("permission denied reported as %v", err)
+	}
+	tl.fail("readdir", "/y", &fs.PathError{Op: "open", Path: "/y", Err: syscall.EMFILE})
+	tl.fail("readdir", "/z", &fs.PathError{Op: "open", Path: "/z", Err: syscall.EMFILE})
+	err := tl.exhausted(cfg)
+	if !errors.Is(err, ErrTooManyOpenFiles) || !strings.Contains(err.Error(), "2 paths skipped with up to 8 directories open") {
+		t.Fatalf("got %v", err)
+	}
+}
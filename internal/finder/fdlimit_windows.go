@@ -0,0 +1,19 @@
+//go:build windows
+
+package finder
+
+import (
+	"errors"
+	"syscall"
+)
+
+// errorTooManyOpenFiles is ERROR_TOO_MANY_OPEN_FILES.
+const errorTooManyOpenFiles syscall.Errno = 4
+
+// openFileLimit returns 0: Windows has no fixed per-process handle limit
+// worth budgeting for.
+func openFileLimit() int { return 0 }
+
+func isTooManyOpenFiles(err error) bool {
+	return errors.Is(err, errorTooManyOpenFiles)
+}
@@ -0,0 +1,139 @@
+package finder
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// validateFields checks Config.Fields against the record fields of the
+// schema version.
+func (c *Config) validateFields() error {
+	if len(c.Fields) == 0 {
+		return nil
+	}
+	var s struct {
+		Properties map[string]json.RawMessage `json:"properties"`
+	}
+	if err := json.Unmarshal(EntrySchema(c.SchemaVersion), &s); err != nil {
+		return err
+	}
+	seen := make(map[string]bool, len(c.Fields))
+	var whole, single bool
+	for _, f := range c.Fields {
+		name := f
+		if key, ok := strings.CutPrefix(f, extraPrefix); ok {
+			if key == "" {
+				return fmt.Errorf("invalid field %q: want extra.KEY", f)
+			}
+			name, single = "extra", true
+		}
+		whole = whole || f == "extra"
+		if s.Properties[name] == nil {
+			return fmt.Errorf("unknown field %q (schema version %d)", f, c.SchemaVersion)
+		}
+		if seen[f] {
+			return fmt.Errorf("field %q given twice", f)
+		}
+		seen[f] = true
+	}
+	if whole && single {
+		return errors.New("fields extra and extra.KEY cannot be combined")
+	}
+	return nil
+}
+
+// projection is a record cut down to Config.Fields, encoded as a JSON object
+// with the fields in that order.
+type projection struct {
+	keys   []string
+	values []json.RawMessage
+}
+
+func (p projection) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, k := range p.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		kb, err := json.Marshal(k)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(kb)
+		buf.WriteByte(':')
+		buf.Write(p.values[i])
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// record returns the value JSON output encodes for e.
+func (c *Config) record(e Entry) (any, error) {
+	return c.project(c.versioned(e))
+}
+
+// project returns the fields of v, a record, selected by c.Fields, or v
+// itself without them. Fields the record leaves out are left out; the
+// extra.KEY fields are gathered into one extra object, placed where the first
+// of them is.
+func (c *Config) project(v any) (any, error) {
+	if len(c.Fields) == 0 {
+		return v, nil
+	}
+	b, err := marshalRaw(v)
+	if err != nil {
+		return nil, err
+	}
+	var rec map[string]json.RawMessage
+	if err := json.Unmarshal(b, &rec); err != nil {
+		return nil, err
+	}
+	var extra map[string]json.RawMessage
+	if rec["extra"] != nil {
+		if err := json.Unmarshal(rec["extra"], &extra); err != nil {
+			return nil, err
+		}
+	}
+	var (
+		p        projection
+		sub      projection
+		extraPos = -1
+	)
+	for _, f := range c.Fields {
+		if key, ok := strings.CutPrefix(f, extraPrefix); ok {
+			if val, ok := extra[key]; ok {
+				sub.keys, sub.values = append(sub.keys, key), append(sub.values, val)
+				if extraPos < 0 {
+					extraPos = len(p.keys)
+					p.keys, p.values = append(p.keys, "extra"), append(p.values, nil)
+				}
+			}
+			continue
+		}
+		if val, ok := rec[f]; ok {
+			p.keys, p.values = append(p.keys, f), append(p.values, val)
+		}
+	}
+	if extraPos >= 0 {
+		if p.values[extraPos], err = sub.MarshalJSON(); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// marshalRaw encodes v as JSON without escaping HTML characters, so
+// projected values read as they would unprojected.
+func marshalRaw(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
@@ -0,0 +1,62 @@
+package finder
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFields(t *testing.T) {
+	td := t.TempDir()
+	mk(t, td, "a&b.txt", 3, time.Time{})
+	path := filepath.Join(td, "a&b.txt")
+
+	run := func(cfg Config) (string, error) {
+		t.Helper()
+		cfg.Root, cfg.MaxDepth = td, -1
+		var out strings.Builder
+		_, err := Run(context.Background(), &out, cfg)
+		return out.String(), err
+	}
+	quoted := strings.ReplaceAll(path, `\`, `\\`)
+
+	got, err := run(Config{OutputFormat: OutputNDJSON, Fields: []string{"size", "path"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"size":3,"path":"` + quoted + `"}` + "\n"; got != want {
+		t.Errorf("ndjson: got %q, want %q", got, want)
+	}
+
+	// Enricher values are picked one by one; absent fields are left out. JSON
+	// array output escapes HTML characters as it does unprojected.
+	hash := EnricherFunc(func(_ context.Context, e *Entry) error {
+		e.SetExtra("sha256", "00")
+		e.SetExtra("other", 1)
+		return nil
+	})
+	got, err = run(Config{OutputFormat: OutputJSON, Enrichers: []Enricher{hash}, Fields: []string{"name", "extra.sha256", "owner", "extra.missing"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `[{"name":"a\u0026b.txt","extra":{"sha256":"00"}}]`; got != want {
+		t.Errorf("json: got %q, want %q", got, want)
+	}
+
+	// Version 2 fields are known only in version 2.
+	got, err = run(Config{OutputFormat: OutputNDJSON, SchemaVersion: 2, Fields: []string{"depth", "schemaVersion"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"depth":1,"schemaVersion":2}` + "\n"; got != want {
+		t.Errorf("v2: got %q, want %q", got, want)
+	}
+
+	for _, fields := range [][]string{{"depth"}, {"nope"}, {"path", "path"}, {"extra", "extra.sha256"}, {"extra."}} {
+		if _, err := run(Config{OutputFormat: OutputNDJSON, Fields: fields}); err == nil {
+			t.Errorf("fields %q accepted", fields)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package finder
+
+// Filter is a user-defined predicate over matched entries, for conditions the
+// built-in options cannot express (e.g. "the file's directory contains a
+// go.mod"). Filters are called concurrently from the directory workers.
+type Filter interface {
+	Match(Entry) bool
+}
+
+// FilterFunc adapts a function to the Filter interface.
+type FilterFunc func(Entry) bool
+
+// Match calls f(e).
+func (f FilterFunc) Match(e Entry) bool { return f(e) }
+
+// And matches entries matched by every filter (all entries when empty).
+func And(filters ...Filter) Filter {
+	return FilterFunc(func(e Entry) bool {
+		for _, f := range filters {
+			if !f.Match(e) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// Or matches entries matched by any filter (none when empty).
+func Or(filters ...Filter) Filter {
+	return FilterFunc(func(e Entry) bool {
+		for _, f := range filters {
+			if f.Match(e) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// Not matches entries f does not match.
+func Not(f Filter) Filter {
+	return FilterFunc(func(e Entry) bool { return !f.Match(e) })
+}
@@ -0,0 +1,51 @@
+package finder
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWalk_Filters(t *testing.T) {
+	td := t.TempDir()
+	mod := mk(t, td, "svc/go.mod", 1, time.Now())
+	main := mk(t, td, "svc/main.go", 1, time.Now())
+	mk(t, td, "scripts/gen.go", 1, time.Now())
+	readme := mk(t, td, "svc/README.md", 1, time.Now())
+
+	// A file whose directory contains go.mod.
+	inModule := FilterFunc(func(e Entry) bool {
+		_, err := os.Stat(filepath.Join(filepath.Dir(e.Path), "go.mod"))
+		return !e.IsDir && err == nil
+	})
+	isGo := FilterFunc(func(e Entry) bool { return strings.HasSuffix(e.Name, ".go") })
+	isMD := FilterFunc(func(e Entry) bool { return strings.HasSuffix(e.Name, ".md") })
+
+	for _, tc := range []struct {
+		name    string
+		filters []Filter
+		want    []string
+	}{
+		{"and", []Filter{inModule, isGo}, []string{main}},
+		{"or", []Filter{And(inModule, Or(isGo, isMD))}, []string{main, readme}},
+		{"not", []Filter{inModule, Not(Or(isGo, isMD))}, []string{mod}},
+	} {
+		var got []string
+		if _, err := Walk(context.Background(), Config{Root: td, MaxDepth: -1, Filters: tc.filters}, func(e Entry) error {
+			got = append(got, e.Path)
+			return nil
+		}); err != nil {
+			t.Fatalf("%s: walk: %v", tc.name, err)
+		}
+		sort.Strings(got)
+		sort.Strings(tc.want)
+		if !slices.Equal(got, tc.want) {
+			t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
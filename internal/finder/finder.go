@@ -7,17 +7,25 @@ package finder
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"io/fs"
-	"os"
+	"iter"
+	"log/slog"
 	"path/filepath"
 	"regexp"
 	"runtime"
+	"slices"
+	"strings"
 	"sync"
 	"time"
+	"unicode/utf8"
+
+	"github.com/Hamed0406/gofind/internal/expr"
+	"github.com/Hamed0406/gofind/internal/gitstatus"
+	"github.com/Hamed0406/gofind/internal/ignore"
+	"golang.org/x/text/encoding"
 )
 
 // OutputFormat controls how entries are written to the provided writer.
@@ -30,36 +38,306 @@ const (
 	OutputJSON
 	// OutputNDJSON writes newline-delimited JSON entries.
 	OutputNDJSON
+	// OutputJSONSeq writes an RFC 7464 JSON text sequence: each entry is
+	// preceded by an ASCII record separator (0x1E), so a consumer can resync
+	// after a truncated record.
+	OutputJSONSeq
 )
 
 // Config holds search options for the directory walk.
 type Config struct {
 	// Root is the starting directory.
 	Root string
+	// IncludeRoot makes the root directory (each of Roots) a candidate like
+	// the entries below it, as find does, rather than only its contents.
+	IncludeRoot bool
+	// Roots, when non-empty, are searched one after another instead of Root.
+	// Run and Walk check up front that every root is a directory that can be
+	// listed, and fail with a RootError otherwise.
+	Roots []string
+	// SkipMissingRoots skips roots that do not exist rather than failing;
+	// Result.SkippedRoots lists them.
+	SkipMissingRoots bool
+	// Paths, when set, replaces discovery: every path it yields is run through
+	// the filters but never walked into. Relative paths are resolved against
+	// the working directory, and MaxDepth does not apply.
+	Paths iter.Seq[string]
 	// Extensions, when non-empty, includes only files with these lowercase extensions (e.g. ".go").
 	Extensions map[string]bool
+	// Types, when non-empty, includes only entries of these types: "file",
+	// "dir", "symlink" (whether followed or not) or "other".
+	Types map[string]bool
 	// NameRegex, when set, must match the base name (file or directory) to be included.
 	NameRegex *regexp.Regexp
+	// NormalizeUnicode converts names to NFC before the name filters
+	// (Extensions, NameRegex, Where) see them, so NFD names as stored by macOS
+	// match NFC patterns. Patterns should be NFC too (see NFC).
+	NormalizeUnicode bool
 	// MinSize and MaxSize constrain file sizes in bytes (0 = no bound). Directories are unaffected.
 	MinSize int64
 	MaxSize int64
 	// After and Before filter by modification time (zero value = no bound).
 	After  time.Time
 	Before time.Time
-	// IncludeHidden includes dotfiles on Unix (and simple Windows dotfile heuristic).
+	// IncludeHidden includes the entries HiddenPolicy deems hidden. It is
+	// shorthand for IncludeHiddenFiles and DescendHiddenDirs together.
 	IncludeHidden bool
+	// IncludeHiddenFiles includes hidden files (anything but directories).
+	IncludeHiddenFiles bool
+	// DescendHiddenDirs includes hidden directories and searches below them.
+	// Without it, a hidden directory and everything in it are skipped.
+	DescendHiddenDirs bool
+	// HiddenPolicy selects what counts as hidden (0 = DefaultHiddenPolicy:
+	// dotfiles on Unix; hidden and system attributes, and dotfiles in Git
+	// working trees, on Windows).
+	HiddenPolicy HiddenPolicy
 	// MaxDepth controls recursion: -1 = unlimited, 0 = only children of root, 1 = one level deeper, etc.
 	MaxDepth int
+	// MinDepth leaves out entries fewer than MinDepth levels below the root
+	// (its children are level 1, the root itself level 0), though they are
+	// still searched. Like MaxDepth it does not apply to Paths.
+	MinDepth int
+	// PruneMatched does not descend into matched directories, e.g. to list
+	// every node_modules directory without their contents. DirStats still
+	// count what is below them.
+	PruneMatched bool
+	// FindDepth makes MaxDepth count like find's -maxdepth, from the root at
+	// 0: MaxDepth 0 is the root alone and 1 its children. The root is then a
+	// candidate (see IncludeRoot) unless MinDepth excludes it.
+	FindDepth bool
+	// MaxPerDir, when > 0, emits at most this many matches from the entries
+	// of any one directory; the rest are counted in Result.Truncated.
+	// Directories beyond the limit are still searched.
+	MaxPerDir int
+	// DirStats adds the counts of their contents to matched directories,
+	// which are then written only once their subtree has been searched. The
+	// counts cover directories below MaxDepth too.
+	DirStats DirStatsMode
 	// Concurrency is the max number of concurrent directory workers. <=0 defaults to NumCPU.
 	Concurrency int
+	// MaxOpenDirs bounds the directories held open at once, apart from
+	// Concurrency, so a high Concurrency cannot run the process out of file
+	// descriptors. 0 picks half the open file limit (RLIMIT_NOFILE), up to
+	// 4096, or no bound where there is no such limit; < 0 is unbounded.
+	MaxOpenDirs int
+	// RecentFirst reads the directories modified most recently first
+	// whenever more are waiting than Concurrency allows, so "what changed
+	// recently" searches surface results sooner on big trees. A directory's
+	// modification time only changes when entries are added, removed or
+	// renamed in it, so this is a heuristic.
+	RecentFirst bool
 	// OutputFormat selects the output writer format.
 	OutputFormat OutputFormat
+	// Baseline, if set, limits the output to the changes since an earlier
+	// search; see Baseline.
+	Baseline *Baseline
+	// Checkpoint, if set, records directories whose subtree has been
+	// searched and skips those an earlier run recorded.
+	Checkpoint *Checkpoint
+	// DirCache, if set, serves the listings of directories unchanged since
+	// an earlier run and stores the listings of the rest.
+	DirCache *DirCache
+	// Deadline, if set, stops the search at that time as a best effort:
+	// entries found until then are still written, without the enrichment
+	// they had not had yet, and Result.Partial is set instead of an error
+	// being returned.
+	Deadline time.Time
+	// Sample, between 0 and 1 exclusive, searches the entries of only that
+	// share of the directories, for a quick estimate of a big tree: scaled
+	// by 1/Sample, the counts estimate those of the whole search. The other
+	// directories are still read to reach the ones below them, but their
+	// entries are not stat-ed. An entry is searched when its parent
+	// directory is sampled, chosen by a hash of the directory's path. It
+	// cannot be combined with DirStats, Checkpoint or Baseline.
+	Sample float64
+	// Tracer, if set, receives spans around the scan, each directory and
+	// each entry's enrichment.
+	Tracer Tracer
+	// Sinks are further outputs Run writes every entry to, each in its own
+	// format.
+	Sinks []Sink
 	// PrettyJSON enables indentation for JSON/NDJSON outputs.
 	PrettyJSON bool
+	// Human adds the size (e.g. "1.4 MB", "-" for directories) and the
+	// modification time in local time, formatted with TimeLayout
+	// (DefaultTimeLayout when empty), before each path of text output. JSON
+	// output keeps raw bytes and RFC 3339 times.
+	Human      bool
+	TimeLayout string
+	// Quote selects how text output writes paths (QuoteNone, the zero value,
+	// writes them as they are).
+	Quote QuoteMode
+	// HashFormat, when set, makes text output a checksum file of the
+	// "sha256" the hash enricher adds, which must be among Enrichers.
+	HashFormat HashFormat
+	// SchemaVersion selects the shape of JSON, NDJSON and JSON sequence
+	// records (0 = 1, the original shape); see EntrySchema. Version 2 adds
+	// schemaVersion and depth to every record, always fills owner (Unix),
+	// and moves the hash enricher's result to a top-level hash field.
+	SchemaVersion int
+	// Fields, when non-empty, cuts JSON, NDJSON and JSON sequence records
+	// down to these fields, in this order. Names are the record's keys in
+	// EntrySchema, or extra.KEY for a single enricher value; fields a record
+	// leaves out (e.g. owner without ShowOwner) are left out. Meta records
+	// are written whole.
+	Fields []string
+	// EmitMeta frames JSON, NDJSON and JSON sequence output with a start and
+	// an end MetaRecord; MetaConfig is written in the start record to tell
+	// how the search was configured.
+	EmitMeta   bool
+	MetaConfig any
+	// CleanPaths, DotSlash and SlashPaths normalize paths as Run writes them
+	// (Walk callers get them unchanged). CleanPaths drops redundant
+	// separators and resolves "." and ".." elements; DotSlash prefixes
+	// relative paths with "./"; SlashPaths uses forward slashes on Windows.
+	CleanPaths bool
+	DotSlash   bool
+	SlashPaths bool
 	// FollowSymlinks descends into symlinked directories (with loop detection).
 	FollowSymlinks bool
+	// DereferenceOutput reports the size and modification time of a
+	// symlink's target in its Entry. Otherwise they are the link's own, also
+	// when FollowSymlinks matched the link by its target's metadata.
+	DereferenceOutput bool
+	// MaxSymlinkDepth caps how many symlinked directories may be followed
+	// along a single path from the root (0 = unlimited), bounding link
+	// chains that loop detection cannot recognize.
+	MaxSymlinkDepth int
+	// RetryTransient retries a failed stat or directory read up to this many
+	// times, with growing pauses, when the error may be temporary (a vanished
+	// entry, a stale NFS handle, a dropped SMB connection).
+	RetryTransient int
+	// StatTimeout and ReadDirTimeout bound a single stat or directory read
+	// (0 = wait forever), so a hung network mount costs a logged skip
+	// instead of a wedged worker.
+	StatTimeout    time.Duration
+	ReadDirTimeout time.Duration
+	// NoDedupe emits a file every time it is reached. By default a file
+	// reachable through several roots, listed paths or followed symlinks (or
+	// hard links, in those cases) is emitted once, keyed by device and inode
+	// (by normalized path on Windows).
+	NoDedupe bool
+	// Backend selects how candidates are discovered (default BackendWalk).
+	Backend Backend
+	// XAttrs, when non-empty, requires every listed extended attribute (Linux/macOS).
+	XAttrs []XAttrFilter
+	// ShowXAttrs adds each entry's extended attributes to the output.
+	ShowXAttrs bool
+	// HasACL includes only entries carrying a POSIX ACL (Linux).
+	HasACL bool
+	// ShowSecurity adds ACL presence and SELinux context to the output (Linux).
+	ShowSecurity bool
+	// Sparse includes only files whose allocated size is below sparseRatio of
+	// their logical size (Unix).
+	Sparse bool
+	// ShowAllocated adds the allocated on-disk size to the output (Unix).
+	ShowAllocated bool
+	// ShowAccessTime adds the last access time to the output (Linux, macOS,
+	// Windows). Filesystems mounted noatime/relatime may not keep it current.
+	ShowAccessTime bool
+	// ShowOwner adds the owning user's name to the output (Unix).
+	ShowOwner bool
+	// FSTypes, when non-empty, includes only entries on these filesystem types
+	// (as NormalizeFSType returns them, e.g. "ext4", "xfs"); ExcludeFSTypes
+	// drops entries on these.
+	FSTypes        map[string]bool
+	ExcludeFSTypes map[string]bool
+
+	// Ignore, when set, configures ignore-file handling; its Root is replaced
+	// by the root being searched. Ignored directories are not descended into.
+	Ignore *ignore.Config
+
+	// Where, when set, must hold for an entry to be included; see
+	// CompileWhere. Expressions using "extra." fields are evaluated after
+	// Enrichers have run. Its terms from ExtWhere and the like set the
+	// filter fields they stand for instead.
+	Where *expr.Expr
+
+	// ContentRegex, when set, includes only regular files with a line
+	// matching it. Files with a NUL byte in their first 8 KiB, once decoded
+	// (see Encoding), are taken as binary and do not match. Contents are read as ReadMode selects, in a
+	// stage of their own after the walk, before any enrichment.
+	ContentRegex *regexp.Regexp
+	// Encoding is the encoding of the files ContentRegex is matched
+	// against, which are decoded to UTF-8 first. When nil, UTF-8 and
+	// UTF-16 are detected by a byte order mark, UTF-16 without one by its
+	// NUL bytes, and text that is not valid UTF-8 is read as Windows-1252,
+	// all judged by the first 8 KiB.
+	Encoding encoding.Encoding
+	// ExtractText matches ContentRegex against the text of the PDF, .docx
+	// and .xlsx documents package doctext reads instead of their raw
+	// contents. Extraction holds a whole document in memory and is much
+	// slower than reading a text file.
+	ExtractText bool
+	// MatchLines reads every file ContentRegex matches to the end and
+	// records its matching lines, with ContextLines lines of context before
+	// and after each, in Entry.Matches. Text output then writes them like
+	// grep -n: path:line:text, or path-line-text for context, highlighting
+	// matches with ANSI colors when Color is set.
+	MatchLines   bool
+	ContextLines int
+	Color        bool
+
+	// Filters must all match an entry for it to be included. They run after
+	// the built-in filters, on the Entry that would be emitted.
+	Filters []Filter
+
+	// Git, when set, includes only entries inside a Git working tree with
+	// this status; gitstatus.Tracked also matches modified files.
+	Git gitstatus.Status
+
+	// Enrichers run on every match before it is written; see Enricher.
+	// EnrichConcurrency bounds how many entries are enriched at once
+	// (<=0 = Concurrency).
+	Enrichers         []Enricher
+	EnrichConcurrency int
+	// HashWorkers bounds how many files the hash enricher reads at once
+	// (<=0 = Concurrency). Hashing runs in a stage of its own, which the
+	// walk may run up to 65536 entries ahead of, so slow reads do not hold
+	// up directory traversal.
+	HashWorkers int
+	// ReadMode selects how the hash enricher and ContentRegex matching
+	// read files: plain reads, with a sequential-access hint, or
+	// memory-mapped. Documents read for ExtractText are read plainly.
+	ReadMode ReadMode
+
+	// Logger receives debug events (directories entered/skipped, sampled filter
+	// rejections). nil disables logging.
+	Logger *slog.Logger
+	// OnError, when set, is called for every path skipped because of an error
+	// (unreadable directory, vanished file). Calls are serialized.
+	OnError func(*fs.PathError)
+	// OnProgress, when set, is called every ProgressInterval (<=0 = 1s)
+	// while the search runs, and once more with Progress.Done when it ends.
+	// Calls are serialized.
+	OnProgress       func(Progress)
+	ProgressInterval time.Duration
+	// ExpectedDirs is how many directories the search is expected to read,
+	// such as Result.DirsVisited of an earlier run, for Progress.ETA.
+	ExpectedDirs int64
+
+	fsTypes *fsTypeCache
+	ignorer *ignore.Matcher
+	git     *gitstatus.Cache
+	// gitTrees answers HiddenGitDotfiles; it may be git.
+	gitTrees *gitstatus.Cache
+	seen     *seenFiles
+	openDirs *openDirs
+	// missing are the roots skipped by SkipMissingRoots.
+	missing []string
+	// rootOnly is set by FindDepth with MaxDepth 0.
+	rootOnly bool
+	// whereLate defers Where until after enrichment.
+	whereLate bool
+	// nameLiteral is NameRegex when it is a plain string.
+	nameLiteral *literalName
+	// exts is Extensions compiled by validate.
+	exts *extMatcher
 }
 
+// sparseRatio is the allocated/logical size ratio below which a file counts as sparse.
+const sparseRatio = 0.5
+
 // Entry describes a matched filesystem entry (file or directory).
 type Entry struct {
 	Path    string      `json:"path"`
@@ -68,296 +346,978 @@ type Entry struct {
 	Mode    fs.FileMode `json:"mode"`
 	ModTime time.Time   `json:"modTime"`
 	IsDir   bool        `json:"isDir"`
+	// IsSymlink is set when the path is a symbolic link, followed or not.
+	IsSymlink bool `json:"isSymlink,omitempty"`
+	// XAttrs holds extended attributes when Config.ShowXAttrs is set.
+	XAttrs map[string]string `json:"xattrs,omitempty"`
+	// HasACL and SELinux are filled when Config.ShowSecurity is set.
+	HasACL  bool   `json:"hasAcl,omitempty"`
+	SELinux string `json:"selinux,omitempty"`
+	// AllocatedSize is filled when Config.ShowAllocated is set.
+	AllocatedSize int64 `json:"allocatedSize,omitempty"`
+	// AccessTime is filled when Config.ShowAccessTime is set.
+	AccessTime time.Time `json:"accessTime,omitzero"`
+	// Owner is filled when Config.ShowOwner is set.
+	Owner string `json:"owner,omitempty"`
+	// DirStats is filled for directories when Config.DirStats is set.
+	*DirStats
+	// Extra holds metadata added by Config.Enrichers.
+	Extra map[string]any `json:"extra,omitempty"`
+	// Depth is the number of levels below the root (1 for its entries). It
+	// is filled when Config.SchemaVersion is 2, where it is written.
+	Depth int `json:"-"`
+	// Change is set when Config.Baseline is: ChangeAdded, ChangeRemoved or
+	// ChangeChanged.
+	Change string `json:"change,omitempty"`
+	// Matches holds the lines matching Config.ContentRegex when
+	// Config.MatchLines is set.
+	Matches []LineMatch `json:"matches,omitempty"`
 }
 
-func (c *Config) validate() error {
+func (c *Config) validate(ctx context.Context) error {
 	if c.Root == "" {
 		return errors.New("root directory is required")
 	}
 	if c.Concurrency <= 0 {
 		c.Concurrency = runtime.NumCPU()
 	}
+	if c.openDirs == nil {
+		c.openDirs = newOpenDirs(c.MaxOpenDirs)
+	}
+	if err := c.checkRoots(ctx); err != nil {
+		return err
+	}
+	if c.MinDepth < 0 {
+		return fmt.Errorf("invalid MinDepth %d", c.MinDepth)
+	}
+	if c.FindDepth {
+		// Translate once; validate may run again on this Config.
+		c.FindDepth = false
+		c.IncludeRoot = c.IncludeRoot || c.MinDepth == 0
+		switch {
+		case c.MaxDepth == 0:
+			c.rootOnly = true
+		case c.MaxDepth > 0:
+			c.MaxDepth--
+		}
+	}
+	switch c.SchemaVersion {
+	case 0:
+		c.SchemaVersion = 1
+	case 1:
+	case 2:
+		c.ShowOwner = true
+	default:
+		return fmt.Errorf("unknown schema version %d (want 1 to %d)", c.SchemaVersion, LatestSchemaVersion)
+	}
+	c.lowerWhere()
+	if err := c.validateFields(); err != nil {
+		return err
+	}
+	if err := c.validateSample(); err != nil {
+		return err
+	}
+	for t := range c.Types {
+		if !slices.Contains(entryTypes, t) {
+			return fmt.Errorf("unknown entry type %q (want %s)", t, strings.Join(entryTypes, ", "))
+		}
+	}
+	if len(c.FSTypes) > 0 || len(c.ExcludeFSTypes) > 0 {
+		c.fsTypes = &fsTypeCache{m: make(map[uint64]string)}
+	}
+	if c.Git != "" {
+		c.git = gitstatus.New()
+	}
+	if c.IncludeHidden {
+		c.IncludeHiddenFiles, c.DescendHiddenDirs = true, true
+	}
+	if !(c.IncludeHiddenFiles && c.DescendHiddenDirs) && c.hiddenPolicy()&HiddenGitDotfiles != 0 {
+		c.gitTrees = c.git
+		if c.gitTrees == nil {
+			c.gitTrees = gitstatus.New()
+		}
+	}
+	if c.ContextLines < 0 {
+		return fmt.Errorf("invalid ContextLines %d", c.ContextLines)
+	}
+	if c.HashFormat != HashFormatNone && !slices.ContainsFunc(c.Enrichers, func(en Enricher) bool {
+		_, ok := en.(hashEnricher)
+		return ok
+	}) {
+		return errors.New("HashFormat needs the hash enricher")
+	}
+	c.whereLate = c.Where != nil && usesExtra(c.Where)
+	c.nameLiteral = literalRegexp(c.NameRegex)
+	c.exts = compileExts(c.Extensions)
+	if c.needsDedupe() {
+		c.seen = &seenFiles{m: make(map[fileKey]struct{})}
+	}
+	return c.loadIgnore()
+}
+
+// loadIgnore builds the ignore matcher for c.Root from c.Ignore.
+func (c *Config) loadIgnore() error {
+	c.ignorer = nil
+	if c.Ignore == nil {
+		return nil
+	}
+	ic := *c.Ignore
+	ic.Root = c.Root
+	m, err := ignore.New(ic)
+	if err != nil {
+		return err
+	}
+	c.ignorer = m
 	return nil
 }
 
+// ignored reports whether path is excluded by the ignore matcher.
+func (c *Config) ignored(path string, isDir bool) bool {
+	return c.ignorer != nil && c.ignorer.Match(path, isDir)
+}
+
 // Run executes the search using cfg, writing results to out.
 // It streams output and returns when traversal completes or ctx is canceled.
-func Run(ctx context.Context, out io.Writer, cfg Config) error {
-	if err := cfg.validate(); err != nil {
-		return err
+// The Result reports how much of the tree was visited even when err != nil.
+// On cancellation every entry already found is still written, JSON output is
+// properly terminated, and ctx.Err() is returned with Result.Interrupted set.
+// Reaching Config.Deadline stops it the same way, but is not an error: the
+// Result is marked Partial instead.
+func Run(ctx context.Context, out io.Writer, cfg Config) (Result, error) {
+	if err := cfg.validate(ctx); err != nil {
+		return Result{}, err
+	}
+
+	ctx, endSpan := cfg.span(ctx, "gofind.scan", Attr{"root", cfg.Root})
+	runCtx, stopDeadline := cfg.deadline(ctx)
+	defer stopDeadline()
+
+	// Single writer goroutine to keep output safe and ordered.
+	t := newTally(ctx, &cfg)
+	entryCh := make(chan Entry, 256)
+	waitWriter := startWriter(out, &cfg, entryCh, newRunMeta(&cfg, t))
+	diffCh, waitDiff := startBaseline(runCtx, &cfg, entryCh, t)
+	matchCh, waitEnrich := startEnrichers(runCtx, &cfg, diffCh, t)
+	stopProgress := startProgress(&cfg, t)
+	err := search(runCtx, &cfg, matchCh, t)
+	close(matchCh)
+	waitEnrich()
+	waitDiff()
+	stopProgress()
+	if err = t.stopped(err); err == nil {
+		err = t.exhausted(&cfg)
+	}
+	if werr := waitWriter(); err == nil {
+		err = werr
+	}
+	endSpan(err)
+	return t.result(), err
+}
+
+// Walk executes the search using cfg like Run, but hands each matched Entry to
+// fn instead of writing it. fn is called from a single goroutine, so it needs
+// no locking. If fn returns an error the search is stopped and Walk returns it.
+func Walk(ctx context.Context, cfg Config, fn func(Entry) error) (Result, error) {
+	if err := cfg.validate(ctx); err != nil {
+		return Result{}, err
+	}
+	ctx, endSpan := cfg.span(ctx, "gofind.scan", Attr{"root", cfg.Root})
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	runCtx, stopDeadline := cfg.deadline(ctx)
+	defer stopDeadline()
+
+	entryCh := make(chan Entry, 256)
+	done := make(chan error, 1)
+	go func() {
+		var firstErr error
+		for e := range entryCh {
+			if firstErr != nil {
+				// keep draining to avoid blocking producers
+				continue
+			}
+			if err := fn(e); err != nil {
+				firstErr = err
+				cancel()
+			}
+		}
+		done <- firstErr
+	}()
+	t := newTally(ctx, &cfg)
+	diffCh, waitDiff := startBaseline(runCtx, &cfg, entryCh, t)
+	matchCh, waitEnrich := startEnrichers(runCtx, &cfg, diffCh, t)
+	stopProgress := startProgress(&cfg, t)
+	err := search(runCtx, &cfg, matchCh, t)
+	close(matchCh)
+	waitEnrich()
+	waitDiff()
+	stopProgress()
+	if err = t.stopped(err); err == nil {
+		err = t.exhausted(&cfg)
+	}
+	if ferr := <-done; ferr != nil {
+		err = ferr
+	}
+	endSpan(err)
+	return t.result(), err
+}
+
+// search discovers entries below cfg.Root (or each of cfg.Roots, or the
+// explicit cfg.Paths) and sends those matching the filters to entryCh. It
+// returns when discovery completes or ctx is canceled; the caller owns entryCh
+// and closes it afterwards. Progress is recorded in t.
+func search(ctx context.Context, cfg *Config, entryCh chan<- Entry, t *tally) error {
+	if cfg.Paths != nil {
+		return searchPaths(ctx, cfg, entryCh, t)
+	}
+	if len(cfg.Roots) == 0 {
+		if slices.Contains(cfg.missing, cfg.Root) {
+			return nil
+		}
+		return searchRoot(ctx, cfg, entryCh, t)
+	}
+	for _, root := range cfg.Roots {
+		if slices.Contains(cfg.missing, root) {
+			continue
+		}
+		rc := *cfg
+		rc.Root, rc.Roots = root, nil
+		if err := rc.loadIgnore(); err != nil {
+			return err
+		}
+		if err := searchRoot(ctx, &rc, entryCh, t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// searchPaths filters the paths yielded by cfg.Paths without descending into
+// directories.
+func searchPaths(ctx context.Context, cfg *Config, entryCh chan<- Entry, t *tally) error {
+	log := newWalkLog(ctx, cfg.Logger)
+	quota := newDirQuota(cfg.MaxPerDir)
+	defer quota.report(t)
+	for p := range cfg.Paths {
+		if ctx.Err() != nil {
+			t.abandon()
+			break
+		}
+		if !cfg.sampled(filepath.Dir(p)) {
+			log.skip(p, "sample")
+			continue
+		}
+		name := filepath.Base(p)
+		t.seen.Add(1)
+		link, err := cfg.lstat(ctx, p)
+		if err != nil {
+			t.fail("lstat", p, err)
+			continue
+		}
+		info := link
+		if cfg.hidden(p, name, info.IsDir()) {
+			log.skip(p, "hidden")
+			continue
+		}
+		if cfg.ignored(p, info.IsDir()) {
+			log.skip(p, "ignored")
+			continue
+		}
+		if info.Mode()&fs.ModeSymlink != 0 && cfg.FollowSymlinks {
+			if info, err = cfg.stat(ctx, p); err != nil {
+				t.fail("stat", p, err)
+				continue
+			}
+		}
+		e, reason := buildEntry(ctx, cfg, p, name, info, link)
+		if reason != "" {
+			log.reject(p, reason)
+			continue
+		}
+		if quota.full(p) {
+			log.skip(p, "max-per-dir")
+			continue
+		}
+		if e.IsDir && cfg.DirStats != DirStatsOff {
+			e.DirStats = statDir(ctx, cfg, t, p, cfg.DirStats == DirStatsRecursive)
+		}
+		if emit(cfg, t, entryCh, e, info) {
+			quota.add(p)
+		} else {
+			log.skip(p, "duplicate")
+		}
+	}
+	return t.stopErr(ctx)
+}
+
+// dirQuota enforces Config.MaxPerDir where entries do not arrive one
+// directory at a time (listed paths, index backends). It is not safe for
+// concurrent use.
+type dirQuota struct {
+	max     int
+	kept    map[string]int
+	omitted map[string]int64
+}
+
+func newDirQuota(limit int) *dirQuota {
+	return &dirQuota{max: limit, kept: make(map[string]int), omitted: make(map[string]int64)}
+}
+
+// full reports whether the directory of path has no room for another match,
+// counting path as omitted if so.
+func (q *dirQuota) full(path string) bool {
+	if q.max <= 0 {
+		return false
+	}
+	dir := filepath.Dir(path)
+	if q.kept[dir] < q.max {
+		return false
+	}
+	q.omitted[dir]++
+	return true
+}
+
+// add counts an emitted match against the directory of path.
+func (q *dirQuota) add(path string) {
+	if q.max > 0 {
+		q.kept[filepath.Dir(path)]++
 	}
+}
 
-	// Track visited inodes (for follow-symlinks loop detection; best-effort on Unix).
-	type inode struct {
-		dev uint64
-		ino uint64
+// report records the truncated directories in t.
+func (q *dirQuota) report(t *tally) {
+	for dir, n := range q.omitted {
+		t.truncate(dir, n)
 	}
-	inodeOf := func(fi fs.FileInfo) (inode, bool) {
-		if ino, dev, ok := statFromFileInfo(fi); ok {
-			return inode{dev: dev, ino: ino}, true
+}
+
+// dirChunk is the number of entries of a directory listing a walker searches
+// at a time; the rest of a larger listing is shared with other walkers.
+var dirChunk = 1024
+
+// searchRoot walks a single cfg.Root, or queries the configured index backend.
+func searchRoot(ctx context.Context, cfg *Config, entryCh chan<- Entry, t *tally) error {
+	// Track visited directories for follow-symlinks loop detection, by file
+	// ID where available and by resolved path otherwise.
+	inodeOf := func(path string, fi fs.FileInfo) (fileKey, bool) {
+		if ino, dev, ok := fileID(path, fi); ok {
+			return fileKey{dev: dev, ino: ino}, true
+		}
+		if p, err := filepath.EvalSymlinks(path); err == nil {
+			return fileKey{path: normalizedPath(p)}, true
 		}
-		return inode{}, false
+		return fileKey{}, false
 	}
 
 	type inodeSet struct {
 		mu sync.Mutex
-		m  map[inode]struct{}
+		m  map[fileKey]struct{}
 	}
-	hasInode := func(s *inodeSet, i inode) bool {
+	hasInode := func(s *inodeSet, i fileKey) bool {
 		s.mu.Lock()
 		_, ok := s.m[i]
 		s.mu.Unlock()
 		return ok
 	}
-	addInode := func(s *inodeSet, i inode) {
+	addInode := func(s *inodeSet, i fileKey) {
 		s.mu.Lock()
 		s.m[i] = struct{}{}
 		s.mu.Unlock()
 	}
-	visited := &inodeSet{m: make(map[inode]struct{})}
+	visited := &inodeSet{m: make(map[fileKey]struct{})}
+	log := newWalkLog(ctx, cfg.Logger)
 	if cfg.FollowSymlinks {
-		if rfi, err := os.Stat(cfg.Root); err == nil {
-			if ino, ok := inodeOf(rfi); ok {
+		if rfi, err := stat(cfg.Root); err == nil {
+			if ino, ok := inodeOf(cfg.Root, rfi); ok {
 				addInode(visited, ino)
 			}
 		}
 	}
 
-	// Single writer goroutine to keep output safe and ordered.
-	entryCh := make(chan Entry, 256)
-	writeErr := make(chan error, 1)
+	// With IncludeRoot the root is a candidate too. The walker holds it back
+	// like any directory waiting for its DirStats.
+	var root *Entry
+	var rootInfo fs.FileInfo
+	if cfg.IncludeRoot && cfg.MinDepth == 0 && !(cfg.Checkpoint != nil && cfg.Checkpoint.completed(cfg.Root)) && cfg.sampled(filepath.Dir(cfg.Root)) {
+		root, rootInfo = rootEntry(ctx, cfg, log)
+	}
+	// emitRoot writes the root's entry outside the walk.
+	emitRoot := func() {
+		if root == nil {
+			return
+		}
+		if cfg.DirStats != DirStatsOff {
+			root.DirStats = statDir(ctx, cfg, t, cfg.Root, cfg.DirStats == DirStatsRecursive)
+		}
+		emit(cfg, t, entryCh, *root, rootInfo)
+		root = nil
+	}
 
-	var wgWriter sync.WaitGroup
-	wgWriter.Add(1)
-	go func() {
-		defer wgWriter.Done()
-		var firstErr error
-		record := func(err error) {
-			if err != nil && firstErr == nil {
-				firstErr = err
-			}
+	// Index backends replace the directory walk when they are usable here;
+	// errBackendUnavailable means nothing was emitted and we walk instead.
+	if cfg.Backend != BackendWalk {
+		rootOnly := cfg.rootOnly || cfg.PruneMatched && root != nil
+		emitRoot()
+		if rootOnly {
+			return nil
+		}
+		quota := newDirQuota(cfg.MaxPerDir)
+		var pruned *prunedDirs
+		if cfg.PruneMatched {
+			pruned = newPrunedDirs(cfg)
 		}
-		switch cfg.OutputFormat {
-		case OutputJSON:
-			if _, err := io.WriteString(out, "["); err != nil {
-				record(err)
+		err := scanIndex(ctx, cfg, func(h indexHit) {
+			if h.inHidden && !cfg.DescendHiddenDirs {
+				log.skip(h.path, "hidden")
+				return
 			}
-			first := true
-			for e := range entryCh {
-				if firstErr != nil {
-					// keep draining to avoid blocking producers
-					continue
-				}
-				if !first {
-					if cfg.PrettyJSON {
-						_, _ = io.WriteString(out, ",\n")
-					} else {
-						_, _ = io.WriteString(out, ",")
-					}
-				} else if cfg.PrettyJSON {
-					_, _ = io.WriteString(out, "\n")
-				}
-				first = false
-
-				var b []byte
-				var err error
-				if cfg.PrettyJSON {
-					b, err = json.MarshalIndent(e, "  ", "  ")
-				} else {
-					b, err = json.Marshal(e)
-				}
-				if err != nil {
-					record(err)
-					continue
-				}
-				if _, err := out.Write(b); err != nil {
-					record(err)
-					continue
-				}
+			if cfg.MaxDepth >= 0 && h.depth > cfg.MaxDepth {
+				log.skip(h.path, "max-depth")
+				return
 			}
-			if firstErr == nil {
-				if cfg.PrettyJSON {
-					_, _ = io.WriteString(out, "\n")
-				}
-				_, _ = io.WriteString(out, "]")
+			if h.depth+1 < cfg.MinDepth {
+				log.skip(h.path, "min-depth")
+				return
 			}
-			if firstErr != nil {
-				writeErr <- firstErr
+			if pruned != nil && pruned.below(ctx, h) {
+				log.skip(h.path, "pruned")
+				return
 			}
-		case OutputNDJSON:
-			enc := json.NewEncoder(out)
-			enc.SetEscapeHTML(false)
-			if cfg.PrettyJSON {
-				enc.SetIndent("", "  ")
+			if !cfg.sampled(filepath.Dir(h.path)) {
+				log.skip(h.path, "sample")
+				return
 			}
-			for e := range entryCh {
-				if firstErr != nil {
-					continue
-				}
-				if err := enc.Encode(e); err != nil {
-					record(err)
-					continue
-				}
+			t.seen.Add(1)
+			link, err := cfg.lstat(ctx, h.path)
+			if err != nil {
+				t.vanish("lstat", h.path, err)
+				return
 			}
-			if firstErr != nil {
-				writeErr <- firstErr
+			info := link
+			if h.hidden && !cfg.keepHidden(info.IsDir()) {
+				log.skip(h.path, "hidden")
+				return
 			}
-		default:
-			for e := range entryCh {
-				if firstErr != nil {
-					continue
-				}
-				if _, err := fmt.Fprintln(out, e.Path); err != nil {
-					record(err)
-					continue
+			if cfg.ignored(h.path, info.IsDir()) {
+				log.skip(h.path, "ignored")
+				return
+			}
+			if info.Mode()&fs.ModeSymlink != 0 && cfg.FollowSymlinks {
+				if info, err = cfg.stat(ctx, h.path); err != nil {
+					t.fail("stat", h.path, err)
+					return
 				}
 			}
-			if firstErr != nil {
-				writeErr <- firstErr
+			e, reason := buildEntry(ctx, cfg, h.path, filepath.Base(h.path), info, link)
+			if reason != "" {
+				log.reject(h.path, reason)
+				return
+			}
+			if quota.full(h.path) {
+				log.skip(h.path, "max-per-dir")
+				return
+			}
+			if e.IsDir && cfg.DirStats != DirStatsOff {
+				e.DirStats = statDir(ctx, cfg, t, h.path, cfg.DirStats == DirStatsRecursive)
+			}
+			if emit(cfg, t, entryCh, e, info) {
+				quota.add(h.path)
+			} else {
+				log.skip(h.path, "duplicate")
 			}
+		})
+		quota.report(t)
+		if err != nil && ctx.Err() != nil {
+			t.abandon()
 		}
-	}()
+		if !errors.Is(err, errBackendUnavailable) {
+			return err
+		}
+	}
 
-	// Bounded concurrency via semaphore.
-	sem := make(chan struct{}, cfg.Concurrency)
+	// Bounded concurrency via directory slots.
+	slots := newDirSlots(cfg)
 	var wg sync.WaitGroup
 
-	var walk func(string, int)
-	walk = func(dir string, depth int) {
-		defer wg.Done()
-
-		select {
-		case sem <- struct{}{}:
-		case <-ctx.Done():
-			return
+	// finish marks one part of n done and, bottom up, completes every
+	// directory whose subtree that finishes: it is recorded in the
+	// checkpoint, and its held-back entry is written with its DirStats.
+	finish := func(n *dirNode) {
+		for ; n != nil && n.pending.Add(-1) == 0; n = n.parent {
+			incomplete := n.incomplete.Load()
+			if cfg.Checkpoint != nil && !incomplete && !n.statsOnly {
+				cfg.Checkpoint.record(n.path)
+			}
+			if n.entry != nil {
+				if !incomplete {
+					n.entry.DirStats = n.totals.stats()
+				}
+				if !emit(cfg, t, entryCh, *n.entry, n.info) {
+					log.skip(n.path, "duplicate")
+				}
+			}
+			if p := n.parent; p != nil {
+				if incomplete {
+					p.incomplete.Store(true)
+				}
+				if cfg.DirStats == DirStatsRecursive {
+					p.totals.add(&n.totals)
+				}
+			}
 		}
-		defer func() { <-sem }()
+	}
 
-		entries, err := os.ReadDir(dir)
-		if err != nil {
-			// Non-fatal: skip this subtree.
-			return
-		}
+	// links counts the symlinked directories followed to reach dir; node
+	// tracks its subtree for cfg.Checkpoint and cfg.DirStats.
+	var walk func(dir string, depth, links int, node *dirNode)
+
+	// visit searches entries, all or a chunk of the listing of dir.
+	visit := func(dir string, depth, links int, node *dirNode, entries []fs.DirEntry) {
+		var kept, omitted int
+		// Outside Config.Sample only the subdirectories are looked at.
+		sampled := cfg.sampled(dir)
+		defer func() {
+			if omitted > 0 {
+				t.truncate(dir, int64(omitted))
+			}
+		}()
 		for _, de := range entries {
 			select {
 			case <-ctx.Done():
+				node.incomplete.Store(true)
+				t.abandon()
 				return
 			default:
 			}
 			name := de.Name()
 			full := filepath.Join(dir, name)
+			if !sampled && !de.IsDir() && (de.Type()&fs.ModeSymlink == 0 || !cfg.FollowSymlinks) {
+				continue
+			}
 
 			// Hidden?
-			if !cfg.IncludeHidden && isHidden(full, name) {
+			if cfg.hidden(full, name, de.IsDir()) {
+				log.skip(full, "hidden")
+				continue
+			}
+			// Ancestors were checked on the way down.
+			if cfg.ignorer != nil && cfg.ignorer.MatchLeaf(full, de.IsDir()) {
+				log.skip(full, "ignored")
 				continue
 			}
 
-			linfo, err := os.Lstat(full)
+			if !node.statsOnly && sampled {
+				t.seen.Add(1)
+			}
+			linfo, err := entryInfo(ctx, cfg, full, de)
 			if err != nil {
+				t.vanish("lstat", full, err)
+				log.skip(full, err.Error())
 				continue
 			}
 			info := linfo
 			isLink := linfo.Mode()&fs.ModeSymlink != 0
 			if isLink && cfg.FollowSymlinks {
-				if ti, err := os.Stat(full); err == nil {
+				if ti, err := cfg.stat(ctx, full); err == nil {
 					info = ti
 				} else {
+					t.fail("stat", full, err)
+					log.skip(full, err.Error())
 					continue
 				}
 			}
 			isDir := info.IsDir()
+			if cfg.DirStats != DirStatsOff {
+				node.totals.count(info)
+			}
 
-			// Emit when filters match.
-			if matches(&cfg, isDir, info) {
-				entryCh <- Entry{
-					Path:    full,
-					Name:    name,
-					Size:    info.Size(),
-					Mode:    info.Mode(),
-					ModTime: info.ModTime(),
-					IsDir:   isDir,
+			// Emit when filters match. A matching directory waits for its
+			// DirStats.
+			var deferred *Entry
+			matched := false
+			switch {
+			case node.statsOnly:
+			case !sampled && !(isDir && cfg.PruneMatched):
+			case depth+1 < cfg.MinDepth:
+				log.skip(full, "min-depth")
+			default:
+				if e, reason := buildEntry(ctx, cfg, full, name, info, linfo); reason == "" {
+					matched = true
+					switch {
+					case !sampled:
+						// Matched only to be pruned.
+						log.skip(full, "sample")
+					case cfg.MaxPerDir > 0 && kept >= cfg.MaxPerDir:
+						omitted++
+						log.skip(full, "max-per-dir")
+					case isDir && cfg.DirStats != DirStatsOff:
+						deferred = new(Entry)
+						*deferred = e
+						kept++
+					case emit(cfg, t, entryCh, e, info):
+						kept++
+					default:
+						log.skip(full, "duplicate")
+					}
+				} else {
+					log.reject(full, reason)
+				}
+			}
+			// skip gives up on descending into full, writing a held-back
+			// match without DirStats.
+			skip := func(reason string) {
+				log.skip(full, reason)
+				if deferred != nil && !emit(cfg, t, entryCh, *deferred, info) {
+					log.skip(full, "duplicate")
 				}
 			}
 
 			// Recurse into directories if within depth.
 			if isDir {
+				sublinks := links
+				if isLink {
+					sublinks++
+					if cfg.MaxSymlinkDepth > 0 && sublinks > cfg.MaxSymlinkDepth {
+						skip("max-symlink-depth")
+						continue
+					}
+				}
 				// Loop detection when following symlinks
 				if cfg.FollowSymlinks {
-					if ino, ok := inodeOf(info); ok {
+					if ino, ok := inodeOf(full, info); ok {
 						if hasInode(visited, ino) {
+							skip("symlink loop")
 							continue
 						}
 						addInode(visited, ino)
 					}
 				}
-				if cfg.MaxDepth >= 0 && depth >= cfg.MaxDepth {
+				statsOnly := false
+				limit := ""
+				switch {
+				case cfg.MaxDepth >= 0 && depth >= cfg.MaxDepth:
+					limit = "max-depth"
+				case matched && cfg.PruneMatched, node.statsOnly:
+					limit = "pruned"
+				}
+				if limit != "" {
+					// Below MaxDepth or a pruned match only DirStats are
+					// wanted: the immediate children of a held-back
+					// match, or everything when counting recursively.
+					if cfg.DirStats != DirStatsRecursive && deferred == nil {
+						skip(limit)
+						continue
+					}
+					statsOnly = true
+				}
+				if !statsOnly && cfg.Checkpoint != nil && cfg.Checkpoint.completed(full) {
+					skip("checkpoint")
 					continue
 				}
+				child := newDirNode(full, node)
+				child.entry, child.info, child.statsOnly = deferred, info, statsOnly
+				child.modTime = info.ModTime()
 				wg.Add(1)
-				go walk(full, depth+1)
+				go walk(full, depth+1, sublinks, child)
 			}
 		}
 	}
 
+	walk = func(dir string, depth, links int, node *dirNode) {
+		defer wg.Done()
+		defer finish(node)
+
+		if !slots.acquire(ctx, node.modTime) {
+			node.incomplete.Store(true)
+			t.abandon()
+			return
+		}
+		defer slots.release()
+
+		_, endSpan := cfg.span(ctx, "gofind.dir", Attr{"path", dir}, Attr{"depth", depth})
+		log.enter(dir, depth)
+		var entries []fs.DirEntry
+		var err error
+		if cfg.DirCache != nil {
+			entries, err = cfg.DirCache.list(ctx, cfg, dir)
+		} else {
+			entries, err = cfg.readDir(ctx, dir)
+		}
+		defer func() { endSpan(err) }()
+		if err != nil {
+			node.incomplete.Store(true)
+			// Non-fatal: skip this subtree. Only the root must exist.
+			switch {
+			case ctx.Err() != nil:
+				t.abandon() // retries given up on cancellation
+			case depth == 0:
+				t.fail("readdir", dir, err)
+			default:
+				t.vanish("readdir", dir, err)
+			}
+			log.skip(dir, err.Error())
+			return
+		}
+		if !node.statsOnly {
+			t.dirs.Add(1)
+		}
+
+		// A huge directory would keep one walker busy long after the rest
+		// of the tree is done, so its listing is searched in chunks that
+		// take slots of their own. MaxPerDir counts across the whole
+		// listing, so it keeps it in one piece.
+		chunks := [][]fs.DirEntry{entries}
+		if cfg.MaxPerDir == 0 && len(entries) > dirChunk {
+			chunks = slices.Collect(slices.Chunk(entries, dirChunk))
+		}
+		for _, chunk := range chunks[1:] {
+			node.pending.Add(1)
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer finish(node)
+				if !slots.acquire(ctx, node.modTime) {
+					node.incomplete.Store(true)
+					t.abandon()
+					return
+				}
+				defer slots.release()
+				visit(dir, depth, links, node, chunk)
+			}()
+		}
+		visit(dir, depth, links, node, chunks[0])
+	}
+
 	// Kick off
+	if cfg.Checkpoint != nil && cfg.Checkpoint.completed(cfg.Root) {
+		log.skip(cfg.Root, "checkpoint")
+		return nil
+	}
+	if cfg.rootOnly || cfg.PruneMatched && root != nil {
+		emitRoot()
+		return nil
+	}
+	rootNode := newDirNode(cfg.Root, nil)
+	if root != nil {
+		if cfg.DirStats != DirStatsOff {
+			rootNode.entry, rootNode.info = root, rootInfo
+		} else {
+			emitRoot()
+		}
+	}
 	wg.Add(1)
-	go walk(cfg.Root, 0)
+	go walk(cfg.Root, 0, 0, rootNode)
 	wg.Wait()
-	close(entryCh)
-	wgWriter.Wait()
+	return t.stopErr(ctx)
+}
 
-	select {
-	case err := <-writeErr:
-		return err
-	default:
-		return nil
+// buildEntry applies all filters to a candidate and, when it matches,
+// returns the Entry to emit, including any optional metadata. Otherwise it
+// returns the name of the filter that rejected the candidate.
+func buildEntry(ctx context.Context, cfg *Config, path, name string, info, link fs.FileInfo) (Entry, string) {
+	if reason := rejectReason(cfg, info.IsDir(), info); reason != "" {
+		return Entry{}, reason
+	}
+	if cfg.fsTypes != nil && !matchFSType(cfg, cfg.fsTypes.lookup(path, info)) {
+		return Entry{}, "fstype"
+	}
+	e := newEntry(path, name, info)
+	e.IsSymlink = link.Mode()&fs.ModeSymlink != 0
+	if len(cfg.Types) > 0 && !cfg.Types[entryType(&e)] {
+		return Entry{}, "type"
+	}
+	if len(cfg.XAttrs) > 0 || cfg.ShowXAttrs {
+		// info is the link itself unless symlinks were followed.
+		attrs := readXAttrs(path, info.Mode()&fs.ModeSymlink == 0)
+		if !matchXAttrs(cfg.XAttrs, attrs) {
+			return Entry{}, "xattr"
+		}
+		if cfg.ShowXAttrs {
+			e.XAttrs = attrs
+		}
+	}
+	if cfg.Sparse || cfg.ShowAllocated {
+		alloc, ok := allocatedSize(info)
+		if cfg.Sparse && (!ok || e.IsDir || float64(alloc) >= sparseRatio*float64(e.Size)) {
+			return Entry{}, "sparse"
+		}
+		if cfg.ShowAllocated {
+			e.AllocatedSize = alloc
+		}
+	}
+	if cfg.HasACL || cfg.ShowSecurity {
+		hasACL, selinux := readSecurity(path, info.Mode()&fs.ModeSymlink == 0)
+		if cfg.HasACL && !hasACL {
+			return Entry{}, "has-acl"
+		}
+		if cfg.ShowSecurity {
+			e.HasACL, e.SELinux = hasACL, selinux
+		}
+	}
+	if cfg.Where != nil && !cfg.whereLate {
+		// A copy escapes to the heap; e itself stays on the stack.
+		if w := e; !matchWhere(cfg, &w) {
+			return Entry{}, "where"
+		}
+	}
+	if cfg.git != nil && !matchGit(cfg, path, e.IsDir) {
+		return Entry{}, "git"
+	}
+	if cfg.ShowAccessTime {
+		e.AccessTime, _ = accessTime(info)
+	}
+	if cfg.ShowOwner {
+		e.Owner = ownerName(info)
+	}
+	if cfg.SchemaVersion >= 2 {
+		e.Depth = cfg.depth(path)
 	}
+	for _, f := range cfg.Filters {
+		if !f.Match(e) {
+			return Entry{}, "filter"
+		}
+	}
+	if e.IsSymlink {
+		linkMetadata(ctx, cfg, &e, info, link)
+	}
+	return e, ""
 }
 
-func matches(cfg *Config, isDir bool, info fs.FileInfo) bool {
-	name := info.Name()
+// linkMetadata sets the size and modification time of e, a symlink, from
+// the link or its target as Config.DereferenceOutput selects. info is the
+// target's when the link was followed, and link is the link's own.
+func linkMetadata(ctx context.Context, cfg *Config, e *Entry, info, link fs.FileInfo) {
+	src := link
+	if cfg.DereferenceOutput {
+		src = info
+		if info == link {
+			var err error
+			if src, err = cfg.stat(ctx, e.Path); err != nil {
+				return // dangling: keep the link's
+			}
+		}
+	}
+	e.Size, e.ModTime = src.Size(), src.ModTime()
+}
+
+// matchGit reports whether path has the status selected by cfg.Git. Reading
+// a repository is not tied to the search context: it runs once per
+// repository and is bounded by git itself.
+func matchGit(cfg *Config, path string, isDir bool) bool {
+	info, ok, err := cfg.git.Lookup(context.Background(), path, isDir)
+	if err != nil || !ok {
+		return false
+	}
+	return info.Status == cfg.Git || (cfg.Git == gitstatus.Tracked && info.Status == gitstatus.Modified)
+}
+
+// rootEntry returns the entry of cfg.Root for Config.IncludeRoot, or nil
+// when it does not match the filters. The root is searched through a
+// symlink, so its info is the target's.
+func rootEntry(ctx context.Context, cfg *Config, log *walkLog) (*Entry, fs.FileInfo) {
+	link, err := cfg.lstat(ctx, cfg.Root)
+	if err != nil {
+		return nil, nil // checked by validate; gone since
+	}
+	info := link
+	if link.Mode()&fs.ModeSymlink != 0 {
+		if info, err = cfg.stat(ctx, cfg.Root); err != nil {
+			return nil, nil
+		}
+	}
+	e, reason := buildEntry(ctx, cfg, cfg.Root, filepath.Base(cfg.Root), info, link)
+	if reason != "" {
+		log.reject(cfg.Root, reason)
+		return nil, nil
+	}
+	return &e, info
+}
+
+func newEntry(path, name string, info fs.FileInfo) Entry {
+	return Entry{
+		Path:    path,
+		Name:    name,
+		Size:    info.Size(),
+		Mode:    info.Mode(),
+		ModTime: info.ModTime(),
+		IsDir:   info.IsDir(),
+	}
+}
+
+// MatchName reports whether a base name passes the name-only filters
+// (Extensions and NameRegex). It lets index-based lookups such as the locate
+// database share filter semantics with the live walker without stat data.
+func (c *Config) MatchName(name string, isDir bool) bool {
+	return nameRejectReason(c, name, isDir) == ""
+}
+
+func nameRejectReason(cfg *Config, name string, isDir bool) string {
+	name = cfg.matchName(name)
 
 	// extension filter (files only)
 	if len(cfg.Extensions) > 0 && !isDir {
-		ext := stringsToLower(filepath.Ext(name))
-		if !cfg.Extensions[ext] {
-			return false
+		if cfg.exts != nil {
+			if !cfg.exts.match(name) {
+				return "ext"
+			}
+		} else if !cfg.Extensions[stringsToLower(filepath.Ext(name))] {
+			return "ext"
 		}
 	}
 
 	// name regex
-	if cfg.NameRegex != nil && !cfg.NameRegex.MatchString(name) {
-		return false
+	if cfg.NameRegex != nil && !cfg.matchNameRegex(name) {
+		return "name-regex"
+	}
+	return ""
+}
+
+// rejectReason returns the first stat-based filter that info fails, or "".
+func rejectReason(cfg *Config, isDir bool, info fs.FileInfo) string {
+	if reason := nameRejectReason(cfg, info.Name(), isDir); reason != "" {
+		return reason
 	}
 
 	// size (files only)
 	if !isDir {
 		if cfg.MinSize > 0 && info.Size() < cfg.MinSize {
-			return false
+			return "min-size"
 		}
 		if cfg.MaxSize > 0 && info.Size() > cfg.MaxSize {
-			return false
+			return "max-size"
 		}
 	}
 
 	// mod time
 	if !cfg.After.IsZero() && info.ModTime().Before(cfg.After) {
-		return false
+		return "after"
 	}
 	if !cfg.Before.IsZero() && info.ModTime().After(cfg.Before) {
-		return false
+		return "before"
 	}
 
-	return true
+	return ""
 }
 
-// stringsToLower is a tiny helper avoiding an extra strings import here.
+// stringsToLower lowers s the way extensions are compared. ASCII, nearly
+// every extension, is folded byte by byte, returning s itself when it has
+// no upper-case letters; anything else goes through strings.ToLower, so
+// ".ÜBER" lowers to ".über" as parseExts lowers --ext.
 func stringsToLower(s string) string {
-	b := []rune(s)
-	for i, r := range b {
-		if 'A' <= r && r <= 'Z' {
-			b[i] = r + ('a' - 'A')
+	upper := false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c >= utf8.RuneSelf {
+			return strings.ToLower(s)
 		}
+		upper = upper || 'A' <= c && c <= 'Z'
+	}
+	if !upper {
+		return s
+	}
+	b := []byte(s)
+	for i, c := range b {
+		b[i] = lowerASCII(c)
 	}
 	return string(b)
 }
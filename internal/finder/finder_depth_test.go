@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -57,7 +58,7 @@ func TestMaxDepth(t *testing.T) {
 			IncludeHidden: false,
 			Concurrency:   4,
 		}
-		if err := Run(context.Background(), &out, cfg); err != nil {
+		if _, err := Run(context.Background(), &out, cfg); err != nil {
 			t.Fatalf("run: %v", err)
 		}
 		entries := collectJSON(t, &out)
@@ -124,7 +125,7 @@ func TestFilters_Size_Time_Name_Ext(t *testing.T) {
 		OutputFormat: OutputJSON,
 		Concurrency:  2,
 	}
-	if err := Run(context.Background(), &out, cfg); err != nil {
+	if _, err := Run(context.Background(), &out, cfg); err != nil {
 		t.Fatalf("run: %v", err)
 	}
 	entries := collectJSON(t, &out)
@@ -132,3 +133,164 @@ func TestFilters_Size_Time_Name_Ext(t *testing.T) {
 		t.Fatalf("expected only keep.go, got: %+v", entries)
 	}
 }
+
+func TestMaxPerDir(t *testing.T) {
+	td := t.TempDir()
+	for i := range 10 {
+		mk(t, td, fmt.Sprintf("f%02d.txt", i), 1, time.Time{})
+	}
+	mk(t, td, "sub/a.txt", 1, time.Time{})
+	mk(t, td, "sub/b.txt", 1, time.Time{})
+
+	var paths []string
+	res, err := Walk(context.Background(), Config{Root: td, MaxDepth: -1, MaxPerDir: 3, Concurrency: 2}, func(e Entry) error {
+		if !e.IsDir {
+			rel, _ := filepath.Rel(td, e.Path)
+			paths = append(paths, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(paths)
+	// The root holds 11 matches (sub included); sub is still searched.
+	want := []string{"f00.txt", "f01.txt", "f02.txt", "sub/a.txt", "sub/b.txt"}
+	if fmt.Sprint(paths) != fmt.Sprint(want) {
+		t.Fatalf("got %v, want %v", paths, want)
+	}
+	if res.TruncatedCount != 1 || len(res.Truncated) != 1 || res.Truncated[0] != (TruncatedDir{Path: td, Omitted: 8}) {
+		t.Fatalf("truncated: %d %+v", res.TruncatedCount, res.Truncated)
+	}
+
+	// Listed paths are limited per directory as well.
+	cfg := Config{Root: td, MaxPerDir: 1, Paths: func(yield func(string) bool) {
+		for _, p := range []string{"f00.txt", "f01.txt", "sub/a.txt"} {
+			if !yield(filepath.Join(td, filepath.FromSlash(p))) {
+				return
+			}
+		}
+	}}
+	res, err = Walk(context.Background(), cfg, func(Entry) error { return nil })
+	if err != nil || res.Matched != 2 || res.TruncatedCount != 1 {
+		t.Fatalf("paths: %+v, %v", res, err)
+	}
+}
+
+func TestIncludeRoot(t *testing.T) {
+	td := t.TempDir()
+	mk(t, td, "sub/a.txt", 2, time.Time{})
+
+	walk := func(cfg Config) map[string]Entry {
+		t.Helper()
+		cfg.Root, cfg.MaxDepth, cfg.IncludeRoot = td, -1, true
+		got := map[string]Entry{}
+		if _, err := Walk(context.Background(), cfg, func(e Entry) error {
+			got[e.Path] = e
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+		return got
+	}
+
+	got := walk(Config{Types: map[string]bool{"dir": true}})
+	if len(got) != 2 || !got[td].IsDir || !got[filepath.Join(td, "sub")].IsDir {
+		t.Fatalf("dirs: %v", got)
+	}
+	// The root is filtered like any entry, and counted with DirStats.
+	if got := walk(Config{Extensions: map[string]bool{".txt": true}, Types: map[string]bool{"file": true}}); len(got) != 1 {
+		t.Fatalf("files: %v", got)
+	}
+	got = walk(Config{DirStats: DirStatsRecursive})
+	if s := got[td].DirStats; s == nil || *s != (DirStats{FileCount: 1, DirCount: 1, TotalSize: 2}) {
+		t.Fatalf("root DirStats: %+v", s)
+	}
+	if got := walk(Config{NameRegex: regexp.MustCompile(`^nope$`)}); len(got) != 0 {
+		t.Fatalf("rejected root emitted: %v", got)
+	}
+}
+
+func TestFindDepth(t *testing.T) {
+	td := t.TempDir()
+	mk(t, td, "x", 1, time.Time{})
+	mk(t, td, "a/y", 1, time.Time{})
+	mk(t, td, "a/b/z", 1, time.Time{})
+
+	for _, tc := range []struct {
+		max, min int
+		want     []string
+	}{
+		{0, 0, []string{"."}},
+		{1, 0, []string{".", "a", "x"}},
+		{2, 2, []string{"a/b", "a/y"}},
+		{-1, 1, []string{"a", "a/b", "a/b/z", "a/y", "x"}},
+		{-1, 3, []string{"a/b/z"}},
+		{0, 1, nil},
+	} {
+		cfg := Config{Root: td, MaxDepth: tc.max, MinDepth: tc.min, FindDepth: true}
+		var got []string
+		if _, err := Walk(context.Background(), cfg, func(e Entry) error {
+			rel, _ := filepath.Rel(td, e.Path)
+			got = append(got, filepath.ToSlash(rel))
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+		sort.Strings(got)
+		if fmt.Sprint(got) != fmt.Sprint(tc.want) {
+			t.Errorf("maxdepth %d mindepth %d: got %v, want %v", tc.max, tc.min, got, tc.want)
+		}
+	}
+
+	// MinDepth applies without FindDepth too, where MaxDepth 0 is the
+	// root's children.
+	var n int
+	if _, err := Walk(context.Background(), Config{Root: td, MaxDepth: 0, MinDepth: 2}, func(Entry) error { n++; return nil }); err != nil || n != 0 {
+		t.Fatalf("MinDepth below MaxDepth: %d entries, %v", n, err)
+	}
+}
+
+func TestPruneMatched(t *testing.T) {
+	td := t.TempDir()
+	mk(t, td, "node_modules/q/node_modules/r.js", 1, time.Time{})
+	mk(t, td, "node_modules/z.js", 2, time.Time{})
+	mk(t, td, "app/node_modules/y.js", 4, time.Time{})
+	mk(t, td, "app/main.js", 8, time.Time{})
+
+	walk := func(cfg Config) map[string]Entry {
+		t.Helper()
+		cfg.Root, cfg.MaxDepth = td, -1
+		got := map[string]Entry{}
+		if _, err := Walk(context.Background(), cfg, func(e Entry) error {
+			rel, _ := filepath.Rel(td, e.Path)
+			got[filepath.ToSlash(rel)] = e
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+		return got
+	}
+	vendor := regexp.MustCompile(`^node_modules$`)
+
+	if got := walk(Config{NameRegex: vendor}); len(got) != 3 {
+		t.Fatalf("without pruning: %v", got)
+	}
+	got := walk(Config{NameRegex: vendor, PruneMatched: true})
+	if len(got) != 2 || !got["node_modules"].IsDir || !got["app/node_modules"].IsDir {
+		t.Fatalf("pruned: %v", got)
+	}
+	// Pruned directories are still counted.
+	got = walk(Config{NameRegex: vendor, PruneMatched: true, DirStats: DirStatsRecursive})
+	if s := got["node_modules"].DirStats; len(got) != 2 || s == nil || *s != (DirStats{FileCount: 2, DirCount: 2, TotalSize: 3}) {
+		t.Fatalf("pruned DirStats: %v %+v", got, s)
+	}
+	got = walk(Config{NameRegex: vendor, PruneMatched: true, DirStats: DirStatsImmediate})
+	if s := got["node_modules"].DirStats; len(got) != 2 || s == nil || *s != (DirStats{FileCount: 1, DirCount: 1, TotalSize: 2}) {
+		t.Fatalf("pruned immediate DirStats: %v %+v", got, s)
+	}
+	// A matching root prunes everything.
+	if got := walk(Config{Types: map[string]bool{"dir": true}, IncludeRoot: true, PruneMatched: true}); len(got) != 1 || got["."].Path != td {
+		t.Fatalf("pruned root: %v", got)
+	}
+}
@@ -35,7 +35,7 @@ func TestSizeTimeAndNameFilters_JSON(t *testing.T) {
 		OutputFormat: OutputJSON,
 		Concurrency:  2,
 	}
-	if err := Run(context.Background(), &out, cfg); err != nil {
+	if _, err := Run(context.Background(), &out, cfg); err != nil {
 		t.Fatalf("run: %v", err)
 	}
 
@@ -66,7 +66,7 @@ func TestBeforeTimeFilter(t *testing.T) {
 		Before:       time.Now().Add(-2 * time.Hour),
 		OutputFormat: OutputJSON,
 	}
-	if err := Run(context.Background(), &out, cfg); err != nil {
+	if _, err := Run(context.Background(), &out, cfg); err != nil {
 		t.Fatalf("run: %v", err)
 	}
 	var entries []Entry
@@ -90,7 +90,7 @@ func TestNameRegex_NoExtFilter(t *testing.T) {
 		NameRegex:    regexp.MustCompile(`^(alpha|gamma)`),
 		OutputFormat: OutputJSON,
 	}
-	if err := Run(context.Background(), &out, cfg); err != nil {
+	if _, err := Run(context.Background(), &out, cfg); err != nil {
 		t.Fatalf("run: %v", err)
 	}
 	var entries []Entry
@@ -127,3 +127,24 @@ func mkFile(t *testing.T, base, rel string, size int, mod time.Time) string {
 	}
 	return p
 }
+
+func TestHumanText(t *testing.T) {
+	td := t.TempDir()
+	mod := time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC)
+	p := mkFile(t, td, "big.bin", 1500, mod)
+
+	var out bytes.Buffer
+	cfg := Config{Root: td, Human: true, TimeLayout: time.DateOnly}
+	if _, err := Run(context.Background(), &out, cfg); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if want := "  1.5 KB  " + mod.Local().Format(time.DateOnly) + "  " + p + "\n"; out.String() != want {
+		t.Fatalf("got %q, want %q", out.String(), want)
+	}
+
+	for n, want := range map[int64]string{0: "0 B", 1023: "1023 B", 1 << 20: "1.0 MB", 3 << 30: "3.0 GB"} {
+		if got := humanSize(n); got != want {
+			t.Errorf("humanSize(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
@@ -2,6 +2,7 @@
 package finder
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"runtime"
@@ -41,3 +42,90 @@ func TestSymlinkLoopExistsAndIsDetectable(t *testing.T) {
 	}
 
 }
+
+func TestFollowSymlinksStopsAtLoop(t *testing.T) {
+	td := t.TempDir()
+	sub := filepath.Join(td, "real", "sub")
+	if err := os.MkdirAll(sub, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	// real/sub/up -> real: following it would recurse forever.
+	if err := os.Symlink(filepath.Join(td, "real"), filepath.Join(sub, "up")); err != nil {
+		t.Skipf("symlink not permitted on this system: %v", err)
+	}
+
+	var dirs int
+	res, err := Walk(context.Background(), Config{Root: td, MaxDepth: -1, FollowSymlinks: true, NoDedupe: true}, func(e Entry) error {
+		if e.IsDir {
+			dirs++
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walk: %v", err)
+	}
+	// Emitted: real, real/sub and real/sub/up, which is not descended into.
+	// Visited: the root, real and real/sub.
+	if dirs != 3 || res.DirsVisited != 3 {
+		t.Fatalf("emitted %d dirs, visited %d; want 3 and 3", dirs, res.DirsVisited)
+	}
+}
+
+func TestFileIDSameThroughSymlink(t *testing.T) {
+	td := t.TempDir()
+	link := filepath.Join(td, "link")
+	if err := os.Symlink(td, link); err != nil {
+		t.Skipf("symlink not permitted on this system: %v", err)
+	}
+	a, err := os.Stat(td)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.Stat(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ia, da, ok1 := fileID(td, a)
+	ib, db, ok2 := fileID(link, b)
+	if !ok1 || !ok2 || ia != ib || da != db {
+		t.Fatalf("fileID(dir) = %d/%d %v, fileID(link) = %d/%d %v", da, ia, ok1, db, ib, ok2)
+	}
+}
+
+func TestMaxSymlinkDepth(t *testing.T) {
+	td := t.TempDir()
+	// root/a/l1 -> b, b/l2 -> c, c/f.txt
+	for _, d := range []string{"a", "b", "c"} {
+		if err := os.MkdirAll(filepath.Join(td, d), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(td, "c", "f.txt"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(td, "b"), filepath.Join(td, "a", "l1")); err != nil {
+		t.Skipf("symlink not permitted on this system: %v", err)
+	}
+	if err := os.Symlink(filepath.Join(td, "c"), filepath.Join(td, "b", "l2")); err != nil {
+		t.Fatal(err)
+	}
+
+	reached := func(limit int) map[string]bool {
+		got := map[string]bool{}
+		cfg := Config{Root: filepath.Join(td, "a"), MaxDepth: -1, FollowSymlinks: true, MaxSymlinkDepth: limit}
+		if _, err := Walk(context.Background(), cfg, func(e Entry) error {
+			rel, _ := filepath.Rel(filepath.Join(td, "a"), e.Path)
+			got[filepath.ToSlash(rel)] = true
+			return nil
+		}); err != nil {
+			t.Fatalf("walk: %v", err)
+		}
+		return got
+	}
+	if got := reached(0); !got["l1/l2/f.txt"] {
+		t.Fatalf("unlimited: %v", got)
+	}
+	if got := reached(1); !got["l1/l2"] || got["l1/l2/f.txt"] {
+		t.Fatalf("limit 1: %v", got)
+	}
+}
@@ -35,7 +35,7 @@ func TestExtFilterAndNameRegex(t *testing.T) {
 		OutputFormat: OutputJSON,
 		MaxDepth:     -1, // allow scanning into keep/
 	}
-	if err := Run(context.Background(), &out, cfg); err != nil {
+	if _, err := Run(context.Background(), &out, cfg); err != nil {
 		t.Fatalf("run: %v", err)
 	}
 	entries := decodeJSON(t, &out)
@@ -69,7 +69,7 @@ func TestSizeAndTimeFilters(t *testing.T) {
 		After:        time.Now().Add(-48 * time.Hour),
 		OutputFormat: OutputJSON,
 	}
-	if err := Run(context.Background(), &out, cfg); err != nil {
+	if _, err := Run(context.Background(), &out, cfg); err != nil {
 		t.Fatalf("run: %v", err)
 	}
 	entries := decodeJSON(t, &out)
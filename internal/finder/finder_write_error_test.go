@@ -44,7 +44,7 @@ func TestWriterFailure_Text_NoDeadlockAndError(t *testing.T) {
 	defer cancel()
 
 	fw := &failWriter{failAfter: 0} // fail on first write
-	err := Run(ctx, fw, cfg)
+	_, err := Run(ctx, fw, cfg)
 	if err == nil {
 		t.Fatalf("expected error from writer failure")
 	}
@@ -63,7 +63,7 @@ func TestWriterFailure_JSON_NoDeadlockAndError(t *testing.T) {
 
 	// allow the initial "[" to succeed, then fail
 	fw := &failWriter{failAfter: 1}
-	err := Run(ctx, fw, cfg)
+	_, err := Run(ctx, fw, cfg)
 	if err == nil {
 		t.Fatalf("expected error from writer failure")
 	}
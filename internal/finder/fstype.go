@@ -0,0 +1,59 @@
+package finder
+
+import (
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// fsTypeCache remembers the filesystem type per device so statfs runs once
+// per mount encountered rather than once per entry.
+type fsTypeCache struct {
+	mu sync.Mutex
+	m  map[uint64]string
+}
+
+// lookup returns the normalized filesystem type holding path ("" if unknown).
+func (c *fsTypeCache) lookup(path string, info fs.FileInfo) string {
+	_, dev, ok := statFromFileInfo(info)
+	if ok {
+		c.mu.Lock()
+		t, hit := c.m[dev]
+		c.mu.Unlock()
+		if hit {
+			return t
+		}
+	}
+	target := path
+	if info.Mode()&fs.ModeSymlink != 0 {
+		// statfs follows links; the link itself lives in its parent's filesystem.
+		target = filepath.Dir(path)
+	}
+	t := NormalizeFSType(fsTypeName(target))
+	if ok {
+		c.mu.Lock()
+		c.m[dev] = t
+		c.mu.Unlock()
+	}
+	return t
+}
+
+// NormalizeFSType lowercases a type name and folds aliases that the kernel
+// reports identically (ext2/ext3/ext4 share one superblock magic).
+func NormalizeFSType(s string) string {
+	s = strings.ToLower(strings.TrimSpace(s))
+	switch s {
+	case "ext2", "ext3":
+		return "ext4"
+	}
+	return s
+}
+
+// matchFSType applies the include/exclude filesystem type sets.
+func matchFSType(cfg *Config, t string) bool {
+	if len(cfg.FSTypes) > 0 && !cfg.FSTypes[t] {
+		return false
+	}
+	return !cfg.ExcludeFSTypes[t]
+}
@@ -0,0 +1,22 @@
+//go:build darwin
+
+package finder
+
+import (
+	"bytes"
+
+	"golang.org/x/sys/unix"
+)
+
+// fsTypeName returns the filesystem type of path via statfs.
+func fsTypeName(path string) string {
+	var st unix.Statfs_t
+	if err := unix.Statfs(path, &st); err != nil {
+		return ""
+	}
+	name := st.Fstypename[:]
+	if i := bytes.IndexByte(name, 0); i >= 0 {
+		name = name[:i]
+	}
+	return string(name)
+}
@@ -0,0 +1,45 @@
+//go:build linux
+
+package finder
+
+import "golang.org/x/sys/unix"
+
+// linuxFSMagic maps statfs f_type magic numbers to filesystem names.
+var linuxFSMagic = map[uint32]string{
+	0xEF53:     "ext4",
+	0x58465342: "xfs",
+	0x9123683E: "btrfs",
+	0x2FC12FC1: "zfs",
+	0xF2F52010: "f2fs",
+	0x3153464A: "jfs",
+	0x52654973: "reiserfs",
+	0x01021994: "tmpfs",
+	0x858458F6: "ramfs",
+	0x794C7630: "overlay",
+	0x73717368: "squashfs",
+	0x9660:     "iso9660",
+	0x6969:     "nfs",
+	0xFF534D42: "cifs",
+	0xFE534D42: "smb2",
+	0x65735546: "fuse",
+	0x4D44:     "vfat",
+	0x2011BAB0: "exfat",
+	0x5346544E: "ntfs",
+	0x9FA0:     "proc",
+	0x62656572: "sysfs",
+	0x1CD1:     "devpts",
+	0x27E0EB:   "cgroup",
+	0x63677270: "cgroup2",
+}
+
+// fsTypeName returns the filesystem type of path via statfs.
+func fsTypeName(path string) string {
+	var st unix.Statfs_t
+	if err := unix.Statfs(path, &st); err != nil {
+		return ""
+	}
+	if name, ok := linuxFSMagic[uint32(st.Type)]; ok {
+		return name
+	}
+	return ""
+}
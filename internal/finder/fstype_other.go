@@ -0,0 +1,8 @@
+//go:build !linux && !darwin
+
+package finder
+
+// fsTypeName is not implemented on this platform; the type is always unknown.
+func fsTypeName(_ string) string {
+	return ""
+}
@@ -0,0 +1,36 @@
+package finder
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+)
+
+func TestFSTypeFilter(t *testing.T) {
+	td := t.TempDir()
+	mk(t, td, "a.txt", 1, time.Now())
+	fsType := NormalizeFSType(fsTypeName(td))
+	if fsType == "" {
+		t.Skip("filesystem type unknown on this platform")
+	}
+
+	run := func(cfg Config) int {
+		cfg.Root, cfg.OutputFormat = td, OutputJSON
+		var out bytes.Buffer
+		if _, err := Run(context.Background(), &out, cfg); err != nil {
+			t.Fatalf("run: %v", err)
+		}
+		return len(collectJSON(t, &out))
+	}
+
+	if n := run(Config{FSTypes: map[string]bool{fsType: true}}); n != 1 {
+		t.Fatalf("include %q: expected 1 entry, got %d", fsType, n)
+	}
+	if n := run(Config{FSTypes: map[string]bool{"no-such-fs": true}}); n != 0 {
+		t.Fatalf("include unknown type: expected 0 entries, got %d", n)
+	}
+	if n := run(Config{ExcludeFSTypes: map[string]bool{fsType: true}}); n != 0 {
+		t.Fatalf("exclude %q: expected 0 entries, got %d", fsType, n)
+	}
+}
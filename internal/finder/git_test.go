@@ -0,0 +1,69 @@
+package finder
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/Hamed0406/gofind/internal/gitstatus"
+)
+
+func TestWalk_GitFilter(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	td := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = td
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=t", "GIT_AUTHOR_EMAIL=t@example.com",
+			"GIT_COMMITTER_NAME=t", "GIT_COMMITTER_EMAIL=t@example.com", "GIT_CONFIG_GLOBAL=/dev/null")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	git("init", "-q")
+	if err := os.WriteFile(filepath.Join(td, ".gitignore"), []byte("*.log\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	kept := mk(t, td, "kept.go", 1, time.Now())
+	changed := mk(t, td, "changed.go", 1, time.Now())
+	git("add", ".")
+	git("commit", "-q", "-m", "init")
+	if err := os.WriteFile(changed, []byte("xx"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	fresh := mk(t, td, "fresh.go", 1, time.Now())
+	logf := mk(t, td, "debug.log", 1, time.Now())
+
+	for _, tc := range []struct {
+		status gitstatus.Status
+		want   []string
+	}{
+		{gitstatus.Tracked, []string{filepath.Join(td, ".gitignore"), changed, kept}},
+		{gitstatus.Untracked, []string{fresh}},
+		{gitstatus.Ignored, []string{logf}},
+	} {
+		var got []string
+		cfg := Config{Root: td, MaxDepth: 0, IncludeHidden: true, Git: tc.status}
+		if _, err := Walk(context.Background(), cfg, func(e Entry) error {
+			if !e.IsDir {
+				got = append(got, e.Path)
+			}
+			return nil
+		}); err != nil {
+			t.Fatalf("walk: %v", err)
+		}
+		sort.Strings(got)
+		sort.Strings(tc.want)
+		if !slices.Equal(got, tc.want) {
+			t.Errorf("--git %s: got %v, want %v", tc.status, got, tc.want)
+		}
+	}
+}
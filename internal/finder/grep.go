@@ -0,0 +1,272 @@
+package finder
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Hamed0406/gofind/internal/doctext"
+)
+
+// LineMatch is a line of a file matching Config.ContentRegex, or a line of
+// context around one.
+type LineMatch struct {
+	// Line is the line number, from 1.
+	Line int    `json:"line"`
+	Text string `json:"text"`
+	// Context is set for the lines Config.ContextLines adds around matches.
+	Context bool `json:"context,omitempty"`
+}
+
+// binarySniff is how much of a file is looked at for its encoding and for
+// a NUL byte, which marks it binary, as grep and ripgrep do.
+const binarySniff = 8 << 10
+
+// errBinary is returned by matchContent for a binary file.
+var errBinary = errors.New("binary file")
+
+// matchContent reports whether the regular file of e has a line matching
+// cfg.ContentRegex. With cfg.MatchLines it reads the whole file and records
+// the matching lines, and their context, in e.Matches; otherwise it stops at
+// the first match.
+func matchContent(ctx context.Context, cfg *Config, e *Entry) (bool, error) {
+	if !e.Mode.IsRegular() {
+		return false, nil
+	}
+	r, closeFile, extracted, err := openText(ctx, cfg, e)
+	if err != nil {
+		return false, err
+	}
+	defer closeFile()
+	br := bufio.NewReaderSize(r, 64<<10)
+	if !extracted {
+		if br, err = decodeText(cfg, br); err != nil {
+			return false, err
+		}
+	}
+
+	lr := lineReader{br: br}
+	var (
+		found  bool
+		before []LineMatch // context waiting for a match
+		after  int         // context lines still to add after one
+	)
+	for n := 1; ; n++ {
+		line, err := lr.next()
+		if err != nil && !errors.Is(err, io.EOF) {
+			return false, err
+		}
+		if len(line) == 0 && err != nil {
+			break
+		}
+		switch {
+		case cfg.ContentRegex.Match(line):
+			if !cfg.MatchLines {
+				return true, nil
+			}
+			found = true
+			e.Matches = append(e.Matches, before...)
+			before = before[:0]
+			e.Matches = append(e.Matches, LineMatch{Line: n, Text: string(line)})
+			after = cfg.ContextLines
+		case after > 0:
+			e.Matches = append(e.Matches, LineMatch{Line: n, Text: string(line), Context: true})
+			after--
+		case cfg.ContextLines > 0:
+			if len(before) == cfg.ContextLines {
+				before = slices.Delete(before, 0, 1)
+			}
+			before = append(before, LineMatch{Line: n, Text: string(line), Context: true})
+		}
+		if err != nil {
+			break
+		}
+	}
+	return found, nil
+}
+
+// openText opens the text of e to match: with cfg.ExtractText, the text
+// extracted from a document doctext supports, and otherwise the contents of
+// the file. extracted reports the former.
+func openText(ctx context.Context, cfg *Config, e *Entry) (r io.Reader, close func() error, extracted bool, err error) {
+	if !cfg.ExtractText || !doctext.Supported(e.Name) {
+		r, close, err = openContents(ctx, e.Path, cfg.ReadMode)
+		return r, close, false, err
+	}
+	f, err := os.Open(sysPath(e.Path))
+	if err != nil {
+		return nil, nil, false, err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, nil, false, err
+	}
+	text, err := doctext.Extract(f, fi.Size(), e.Name)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	return bytes.NewReader(text), func() error { return nil }, true, nil
+}
+
+// lineReader reads lines of any length, without their line ending.
+type lineReader struct {
+	br *bufio.Reader
+	// buf holds a line longer than the reader's buffer.
+	buf []byte
+}
+
+// next returns the next line, valid until the following call, and io.EOF
+// with the last one.
+func (l *lineReader) next() ([]byte, error) {
+	line, err := l.br.ReadSlice('\n')
+	if errors.Is(err, bufio.ErrBufferFull) {
+		l.buf = append(l.buf[:0], line...)
+		for errors.Is(err, bufio.ErrBufferFull) {
+			line, err = l.br.ReadSlice('\n')
+			l.buf = append(l.buf, line...)
+		}
+		line = l.buf
+	}
+	line = bytes.TrimSuffix(line, []byte("\n"))
+	return bytes.TrimSuffix(line, []byte("\r")), err
+}
+
+// contentQueue is the number of entries the walk may get ahead of content
+// matching.
+const contentQueue = 1 << 16
+
+// startContentStage returns the channel matches should be sent to when
+// cfg.ContentRegex is set: Concurrency workers forward to out the entries
+// whose contents match and drop the rest, binary files and unreadable
+// files included. After cancellation entries are dropped unread, as they
+// cannot be told to match. wait returns once out has been closed.
+func startContentStage(ctx context.Context, cfg *Config, out chan Entry, t *tally) (in chan Entry, wait func()) {
+	work := make(chan Entry, cap(out))
+	var wg sync.WaitGroup
+	for range cfg.Concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for e := range work {
+				if ctx.Err() != nil {
+					t.matched.Add(-1)
+					t.abandon()
+					continue
+				}
+				ok, err := matchContent(ctx, cfg, &e)
+				switch {
+				case err == nil || errors.Is(err, errBinary):
+				case ctx.Err() != nil:
+					t.abandon()
+				default:
+					t.fail("read", e.Path, err)
+				}
+				if !ok {
+					t.matched.Add(-1)
+					continue
+				}
+				out <- e
+			}
+		}()
+	}
+	// Matching waits on file contents; queue entries for it rather than
+	// hold up the walk.
+	return queueEntries(work, contentQueue), func() {
+		wg.Wait()
+		close(out)
+	}
+}
+
+// ANSI colors of grep-style output, those of ripgrep.
+const (
+	colorPath  = "\x1b[35m"
+	colorLine  = "\x1b[32m"
+	colorMatch = "\x1b[1;31m"
+	colorReset = "\x1b[0m"
+)
+
+// grepText writes text output for entries with Entry.Matches: a
+// path:line:text line for each matching line, path-line-text for context,
+// and "--" between groups of lines that are not adjacent, as grep -n does.
+// Entries without matches are written as appendText does.
+type grepText struct {
+	cfg *Config
+	// path and last are the file and number of the last line written; last
+	// is 0 before the first.
+	path string
+	last int
+}
+
+func (g *grepText) append(b []byte, e Entry) []byte {
+	if len(e.Matches) == 0 {
+		return g.cfg.appendText(b, e)
+	}
+	c := g.cfg
+	for _, m := range e.Matches {
+		if c.ContextLines > 0 && g.last > 0 && (g.path != e.Path || m.Line != g.last+1) {
+			b = append(b, "--\n"...)
+		}
+		g.path, g.last = e.Path, m.Line
+		sep := byte(':')
+		if m.Context {
+			sep = '-'
+		}
+		b = c.colored(b, colorPath, func(b []byte) []byte { return appendQuoted(b, e.Path, c.Quote) })
+		b = append(b, sep)
+		b = c.colored(b, colorLine, func(b []byte) []byte { return strconv.AppendInt(b, int64(m.Line), 10) })
+		b = append(b, sep)
+		b = c.appendLineText(b, m)
+		b = append(b, '\n')
+	}
+	return b
+}
+
+// appendLineText appends the text of m, with its matches highlighted when
+// Color is set. Control characters are escaped unless Quote is QuoteNone,
+// as for paths, but tabs are kept.
+func (c *Config) appendLineText(b []byte, m LineMatch) []byte {
+	text := func(b []byte, s string) []byte {
+		if c.Quote == QuoteNone {
+			return append(b, s...)
+		}
+		for {
+			i := strings.IndexByte(s, '\t')
+			if i < 0 {
+				return appendEscaped(b, s, false)
+			}
+			b = append(appendEscaped(b, s[:i], false), '\t')
+			s = s[i+1:]
+		}
+	}
+	if !c.Color || m.Context {
+		return text(b, m.Text)
+	}
+	prev := 0
+	for _, loc := range c.ContentRegex.FindAllStringIndex(m.Text, -1) {
+		if loc[0] == loc[1] {
+			continue
+		}
+		b = text(b, m.Text[prev:loc[0]])
+		b = c.colored(b, colorMatch, func(b []byte) []byte { return text(b, m.Text[loc[0]:loc[1]]) })
+		prev = loc[1]
+	}
+	return text(b, m.Text[prev:])
+}
+
+// colored appends what add appends, in color when Color is set.
+func (c *Config) colored(b []byte, color string, add func([]byte) []byte) []byte {
+	if !c.Color {
+		return add(b)
+	}
+	b = append(b, color...)
+	b = add(b)
+	return append(b, colorReset...)
+}
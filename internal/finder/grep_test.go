@@ -0,0 +1,130 @@
+package finder
+
+import (
+	"bytes"
+	"context"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+func TestContentRegex(t *testing.T) {
+	td := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(td, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("a.txt", "one\ntwo TODO\nthree\nfour\nfive\nsix TODO\r\nseven\n")
+	write("b.txt", "nothing to do\n")
+	write("c.bin", "TODO\x00")
+	// A line longer than the read buffer.
+	write("d.txt", strings.Repeat("x", 100<<10)+"TODO")
+
+	cfg := Config{Root: td, MaxDepth: -1, SlashPaths: true, ContentRegex: regexp.MustCompile(`TODO`)}
+	var out bytes.Buffer
+	res, err := Run(context.Background(), &out, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, d := filepath.ToSlash(filepath.Join(td, "a.txt")), filepath.ToSlash(filepath.Join(td, "d.txt"))
+	lines := strings.Fields(out.String())
+	if len(lines) != 2 || res.Matched != 2 || !strings.Contains(out.String(), a) || !strings.Contains(out.String(), d) {
+		t.Fatalf("matched %d:\n%s", res.Matched, out.String())
+	}
+
+	cfg.MatchLines, cfg.ContextLines = true, 1
+	cfg.Filters = []Filter{FilterFunc(func(e Entry) bool { return e.Name == "a.txt" })}
+	out.Reset()
+	if _, err := Run(context.Background(), &out, cfg); err != nil {
+		t.Fatal(err)
+	}
+	want := a + "-1-one\n" + a + ":2:two TODO\n" + a + "-3-three\n--\n" +
+		a + "-5-five\n" + a + ":6:six TODO\n" + a + "-7-seven\n"
+	if out.String() != want {
+		t.Fatalf("grep output:\n%s\nwant:\n%s", out.String(), want)
+	}
+
+	cfg.Color, cfg.ContextLines = true, 0
+	out.Reset()
+	if _, err := Run(context.Background(), &out, cfg); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "\x1b[35m"+a+"\x1b[0m:\x1b[32m2\x1b[0m:two \x1b[1;31mTODO\x1b[0m\n") {
+		t.Fatalf("colored output:\n%q", out.String())
+	}
+}
+
+func TestContentRegexExtractText(t *testing.T) {
+	td := t.TempDir()
+	pdf := "%PDF-1.4\n1 0 obj\n<< /Length 30 >>\nstream\nBT (Quarterly report) Tj ET\nendstream\nendobj\n"
+	if err := os.WriteFile(filepath.Join(td, "a.pdf"), []byte(pdf), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := Config{Root: td, MaxDepth: -1, SlashPaths: true, ContentRegex: regexp.MustCompile(`Quarterly report$`), MatchLines: true}
+	var out bytes.Buffer
+	if res, err := Run(context.Background(), &out, cfg); err != nil || res.Matched != 0 {
+		t.Fatalf("raw PDF matched %d (%v):\n%s", res.Matched, err, out.String())
+	}
+	cfg.ExtractText = true
+	out.Reset()
+	if _, err := Run(context.Background(), &out, cfg); err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.ToSlash(filepath.Join(td, "a.pdf")) + ":1:Quarterly report\n"; out.String() != want {
+		t.Fatalf("got %q, want %q", out.String(), want)
+	}
+}
+
+func TestContentRegexEncoding(t *testing.T) {
+	utf16le := func(s string) []byte {
+		var b []byte
+		for _, r := range s {
+			b = append(b, byte(r), byte(r>>8))
+		}
+		return b
+	}
+	td := t.TempDir()
+	files := map[string][]byte{
+		// As Windows tools write logs: UTF-16LE, with and without BOM.
+		"bom.log":    append([]byte{0xff, 0xfe}, utf16le("start\r\nerror: café\r\n")...),
+		"nobom.log":  utf16le("error: café\r\n"),
+		"latin1.txt": []byte("error: caf\xe9\n"),
+		"utf8.txt":   []byte("error: café\n"),
+		"other.bin":  []byte("error: café\x00\x00\x00"),
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(td, name), data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cfg := Config{Root: td, MaxDepth: -1, MatchLines: true, ContentRegex: regexp.MustCompile(`^error: café$`)}
+	run := func() map[string]int {
+		t.Helper()
+		got := map[string]int{}
+		_, err := Walk(context.Background(), cfg, func(e Entry) error {
+			got[e.Name] = e.Matches[0].Line
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return got
+	}
+	want := map[string]int{"bom.log": 2, "nobom.log": 1, "latin1.txt": 1, "utf8.txt": 1}
+	if got := run(); !maps.Equal(got, want) {
+		t.Fatalf("detected: got %v, want %v", got, want)
+	}
+
+	// An explicit encoding applies to every file.
+	cfg.Encoding = charmap.ISO8859_1
+	if got := run(); !maps.Equal(got, map[string]int{"latin1.txt": 1}) {
+		t.Fatalf("ISO-8859-1: got %v", got)
+	}
+}
@@ -0,0 +1,61 @@
+package finder
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// HiddenPolicy selects which entries count as hidden, and so are skipped
+// (directories with everything below them) unless Config.IncludeHidden is
+// set. It is a set of the Hidden* flags; zero means DefaultHiddenPolicy.
+type HiddenPolicy uint8
+
+const (
+	// HiddenDotfiles hides names starting with ".".
+	HiddenDotfiles HiddenPolicy = 1 << iota
+	// HiddenGitDotfiles hides names starting with "." inside a Git working
+	// tree, where .git, .github and the like are meant to be hidden even on
+	// Windows.
+	HiddenGitDotfiles
+	// HiddenAttribute hides entries with the Windows hidden attribute.
+	HiddenAttribute
+	// HiddenSystem hides entries with the Windows system attribute, such as
+	// desktop.ini and $RECYCLE.BIN.
+	HiddenSystem
+)
+
+func (c *Config) hiddenPolicy() HiddenPolicy {
+	if c.HiddenPolicy == 0 {
+		return DefaultHiddenPolicy
+	}
+	return c.HiddenPolicy
+}
+
+// hidden reports whether the entry at path, named name, is skipped as hidden.
+func (c *Config) hidden(path, name string, isDir bool) bool {
+	if c.keepHidden(isDir) {
+		return false
+	}
+	p := c.hiddenPolicy()
+	return c.hiddenName(filepath.Dir(path), name) ||
+		p&(HiddenAttribute|HiddenSystem) != 0 && hasHiddenAttr(path, p)
+}
+
+// keepHidden reports whether hidden directories (isDir) or hidden files are
+// included.
+func (c *Config) keepHidden(isDir bool) bool {
+	if isDir {
+		return c.DescendHiddenDirs
+	}
+	return c.IncludeHiddenFiles
+}
+
+// hiddenName reports whether name, in directory dir, is hidden by one of
+// the dotfile rules.
+func (c *Config) hiddenName(dir, name string) bool {
+	if !strings.HasPrefix(name, ".") {
+		return false
+	}
+	p := c.hiddenPolicy()
+	return p&HiddenDotfiles != 0 || p&HiddenGitDotfiles != 0 && c.gitTrees != nil && c.gitTrees.InWorkTree(dir)
+}
@@ -0,0 +1,90 @@
+package finder
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestHiddenPolicy_GitDotfiles(t *testing.T) {
+	td := t.TempDir()
+	for _, p := range []string{
+		"repo/.git/HEAD",
+		"repo/.github/workflows/ci.yml",
+		"repo/src/.env",
+		"repo/src/main.go",
+		"plain/.profile",
+	} {
+		fp := filepath.Join(td, filepath.FromSlash(p))
+		if err := os.MkdirAll(filepath.Dir(fp), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(fp, []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var got []string
+	cfg := Config{Root: td, MaxDepth: -1, HiddenPolicy: HiddenGitDotfiles}
+	if _, err := Walk(context.Background(), cfg, func(e Entry) error {
+		if !e.IsDir {
+			rel, _ := filepath.Rel(td, e.Path)
+			got = append(got, filepath.ToSlash(rel))
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(got)
+	want := []string{"plain/.profile", "repo/src/main.go"}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	ex, err := Explain(cfg, filepath.Join(td, "repo", ".github", "workflows", "ci.yml"))
+	if err != nil || ex.Included || ex.Rule != "hidden" {
+		t.Fatalf("explain: %+v, %v", ex, err)
+	}
+}
+
+func TestHiddenFilesVersusDirs(t *testing.T) {
+	td := t.TempDir()
+	for _, p := range []string{".bashrc", "notes.txt", ".cache/blob", ".cache/.lock"} {
+		fp := filepath.Join(td, filepath.FromSlash(p))
+		if err := os.MkdirAll(filepath.Dir(fp), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(fp, []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	list := func(cfg Config) []string {
+		cfg.Root, cfg.MaxDepth, cfg.HiddenPolicy = td, -1, HiddenDotfiles
+		var got []string
+		if _, err := Walk(context.Background(), cfg, func(e Entry) error {
+			rel, _ := filepath.Rel(td, e.Path)
+			got = append(got, filepath.ToSlash(rel))
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+		sort.Strings(got)
+		return got
+	}
+	for _, tc := range []struct {
+		cfg  Config
+		want string
+	}{
+		{Config{}, "notes.txt"},
+		{Config{IncludeHiddenFiles: true}, ".bashrc notes.txt"},
+		{Config{DescendHiddenDirs: true}, ".cache .cache/blob notes.txt"},
+		{Config{IncludeHidden: true}, ".bashrc .cache .cache/.lock .cache/blob notes.txt"},
+	} {
+		if got := strings.Join(list(tc.cfg), " "); got != tc.want {
+			t.Errorf("%+v: got %q, want %q", tc.cfg, got, tc.want)
+		}
+	}
+}
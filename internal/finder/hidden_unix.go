@@ -2,10 +2,10 @@
 
 package finder
 
-import "strings"
+// DefaultHiddenPolicy follows the Unix convention: dotfiles are hidden.
+const DefaultHiddenPolicy = HiddenDotfiles
 
-// isHidden reports whether the entry should be considered hidden on Unix-like systems.
-// We ignore the path parameter on Unix; dotfiles are the hidden convention here.
-func isHidden(_ /*path*/ string, name string) bool {
-	return strings.HasPrefix(name, ".")
+// hasHiddenAttr reports false: Unix files have no hidden attribute.
+func hasHiddenAttr(_ string, _ HiddenPolicy) bool {
+	return false
 }
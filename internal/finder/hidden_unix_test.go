@@ -29,7 +29,7 @@ func TestHiddenUnixDotfiles(t *testing.T) {
 			OutputFormat:  OutputJSON,
 			Concurrency:   2,
 		}
-		if err := Run(context.Background(), &out, cfg); err != nil {
+		if _, err := Run(context.Background(), &out, cfg); err != nil {
 			t.Fatalf("run: %v", err)
 		}
 		var arr []Entry
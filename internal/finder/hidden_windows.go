@@ -5,16 +5,25 @@ package finder
 
 import "syscall"
 
-// Windows hidden detection using file attributes.
-func isHidden(path, name string) bool {
-	p, err := syscall.UTF16PtrFromString(path)
+// DefaultHiddenPolicy hides what Explorer hides (the hidden and system
+// attributes), and dotfiles inside Git working trees.
+const DefaultHiddenPolicy = HiddenAttribute | HiddenSystem | HiddenGitDotfiles
+
+// hasHiddenAttr reports whether path carries an attribute p hides.
+func hasHiddenAttr(path string, p HiddenPolicy) bool {
+	u, err := syscall.UTF16PtrFromString(path)
 	if err != nil {
 		return false
 	}
-	attrs, err := syscall.GetFileAttributes(p)
+	attrs, err := syscall.GetFileAttributes(u)
 	if err != nil {
 		return false
 	}
-	const FILE_ATTRIBUTE_HIDDEN = 0x2
-	return attrs&FILE_ATTRIBUTE_HIDDEN != 0
+	return hiddenAttrs(attrs, p)
+}
+
+// hiddenAttrs reports whether the file attributes attrs include one p hides.
+func hiddenAttrs(attrs uint32, p HiddenPolicy) bool {
+	return p&HiddenAttribute != 0 && attrs&syscall.FILE_ATTRIBUTE_HIDDEN != 0 ||
+		p&HiddenSystem != 0 && attrs&syscall.FILE_ATTRIBUTE_SYSTEM != 0
 }
@@ -45,7 +45,7 @@ func TestHiddenWindowsAttribute(t *testing.T) {
 			OutputFormat:  OutputJSON,
 			Concurrency:   2,
 		}
-		if err := Run(context.Background(), &out, cfg); err != nil {
+		if _, err := Run(context.Background(), &out, cfg); err != nil {
 			t.Fatalf("run: %v", err)
 		}
 		var arr []Entry
@@ -77,3 +77,33 @@ func TestHiddenWindowsAttribute(t *testing.T) {
 		t.Fatalf("expected hidden.txt when IncludeHidden=true; got %v", got)
 	}
 }
+
+func TestHiddenWindowsSystemAttribute(t *testing.T) {
+	td := t.TempDir()
+	sys := filepath.Join(td, "desktop.ini")
+	if err := os.WriteFile(sys, []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	utf := syscall.StringToUTF16Ptr(sys)
+	attrs, err := syscall.GetFileAttributes(utf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := syscall.SetFileAttributes(utf, attrs|syscall.FILE_ATTRIBUTE_SYSTEM); err != nil {
+		t.Fatal(err)
+	}
+
+	count := func(p HiddenPolicy) int64 {
+		res, err := Walk(context.Background(), Config{Root: td, MaxDepth: -1, HiddenPolicy: p}, func(Entry) error { return nil })
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res.Matched
+	}
+	if n := count(0); n != 0 {
+		t.Fatalf("system file listed by default")
+	}
+	if n := count(DefaultHiddenPolicy &^ HiddenSystem); n != 1 {
+		t.Fatalf("system file not listed without HiddenSystem")
+	}
+}
@@ -0,0 +1,45 @@
+package finder
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/Hamed0406/gofind/internal/ignore"
+)
+
+func TestWalk_NestedIgnoreFiles(t *testing.T) {
+	td := t.TempDir()
+	mk(t, td, "a.log", 1, time.Now())
+	mk(t, td, "src/main.go", 1, time.Now())
+	mk(t, td, "src/keep.log", 1, time.Now())
+	mk(t, td, "src/vendor/lib.go", 1, time.Now())
+	if err := os.WriteFile(filepath.Join(td, ".gitignore"), []byte("*.log\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(td, "src", ".gitignore"), []byte("!keep.log\nvendor/\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := Config{
+		Root:     td,
+		MaxDepth: -1,
+		Ignore:   &ignore.Config{Enabled: true, Files: []string{ignore.GitIgnoreFile}},
+	}
+	var got []string
+	if _, err := Walk(context.Background(), cfg, func(e Entry) error {
+		rel, _ := filepath.Rel(td, e.Path)
+		got = append(got, filepath.ToSlash(rel))
+		return nil
+	}); err != nil {
+		t.Fatalf("walk: %v", err)
+	}
+	sort.Strings(got)
+	if want := []string{"src", "src/keep.log", "src/main.go"}; !slices.Equal(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
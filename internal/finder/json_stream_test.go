@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"math"
 	"os"
 	"path/filepath"
 	"sort"
@@ -32,7 +34,7 @@ func TestJSONStreamingOutputIsValid(t *testing.T) {
 		MaxDepth:     -1,
 		Concurrency:  8,
 	}
-	if err := Run(context.Background(), &out, cfg); err != nil {
+	if _, err := Run(context.Background(), &out, cfg); err != nil {
 		t.Fatalf("run: %v", err)
 	}
 
@@ -64,3 +66,86 @@ func TestJSONStreamingOutputIsValid(t *testing.T) {
 		t.Fatalf("expected at least 5 files, got %d (%v)", len(names), names)
 	}
 }
+
+func TestJSONSeqOutput(t *testing.T) {
+	td := makeTree(t)
+	var out bytes.Buffer
+	if _, err := Run(context.Background(), &out, Config{Root: td, MaxDepth: -1, OutputFormat: OutputJSONSeq}); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	records := strings.Split(out.String(), "\x1e")
+	if records[0] != "" {
+		t.Fatalf("output must start with RS: %q", out.String())
+	}
+	for _, r := range records[1:] {
+		var e Entry
+		if !strings.HasSuffix(r, "\n") || json.Unmarshal([]byte(r), &e) != nil {
+			t.Fatalf("bad record %q", r)
+		}
+	}
+	if len(records) != 4 { // a.txt, sub, sub/b.txt
+		t.Fatalf("got %d records: %q", len(records)-1, out.String())
+	}
+}
+
+func TestJSONArrayValidWhenEntryFailsToEncode(t *testing.T) {
+	td := makeTree(t)
+	nan := EnricherFunc(func(_ context.Context, e *Entry) error {
+		if e.Name == "a.txt" {
+			e.SetExtra("score", math.NaN()) // not representable in JSON
+		}
+		return nil
+	})
+	for _, pretty := range []bool{false, true} {
+		var out bytes.Buffer
+		cfg := Config{Root: td, MaxDepth: -1, OutputFormat: OutputJSON, PrettyJSON: pretty, Enrichers: []Enricher{nan}}
+		_, err := Run(context.Background(), &out, cfg)
+		if err == nil || !strings.Contains(err.Error(), "a.txt") || errors.Is(err, ErrOutputTruncated) {
+			t.Fatalf("pretty=%v: err = %v, want an encode error naming a.txt", pretty, err)
+		}
+		var arr []Entry
+		if err := json.Unmarshal(out.Bytes(), &arr); err != nil {
+			t.Fatalf("pretty=%v: invalid JSON: %v\n%s", pretty, err, out.String())
+		}
+		if len(arr) != 2 {
+			t.Fatalf("pretty=%v: got %d entries, want 2 (sub, b.txt)", pretty, len(arr))
+		}
+	}
+}
+
+func TestWriteFailureReportsTruncation(t *testing.T) {
+	_, err := Run(context.Background(), &failWriter{failAfter: 1}, Config{Root: makeTree(t), MaxDepth: -1, OutputFormat: OutputJSON})
+	if !errors.Is(err, ErrOutputTruncated) {
+		t.Fatalf("err = %v, want ErrOutputTruncated", err)
+	}
+}
+
+func TestRunWritesSinks(t *testing.T) {
+	td := makeTree(t)
+	var text, nd bytes.Buffer
+	cfg := Config{
+		Root:     td,
+		MaxDepth: -1,
+		Sinks: []Sink{
+			{Writer: &nd, Format: OutputNDJSON},
+			{Writer: &failWriter{}, Format: OutputJSON},
+		},
+	}
+	_, err := Run(context.Background(), &text, cfg)
+	if !errors.Is(err, ErrOutputTruncated) {
+		t.Fatalf("err = %v, want the failing sink's ErrOutputTruncated", err)
+	}
+	if n := strings.Count(text.String(), "\n"); n != 3 {
+		t.Fatalf("text output has %d lines:\n%s", n, text.String())
+	}
+	dec := json.NewDecoder(&nd)
+	for range 3 {
+		var e Entry
+		if err := dec.Decode(&e); err != nil {
+			t.Fatalf("ndjson sink: %v", err)
+		}
+		if !strings.Contains(text.String(), e.Path+"\n") {
+			t.Fatalf("%s in the NDJSON sink but not the text output", e.Path)
+		}
+	}
+}
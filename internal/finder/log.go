@@ -0,0 +1,53 @@
+package finder
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+)
+
+// Filter rejections are logged for the first rejectLogHead entries and then
+// once every rejectLogEvery, so verbose runs stay readable on large trees.
+const (
+	rejectLogHead  = 100
+	rejectLogEvery = 1000
+)
+
+// walkLog emits the walker's debug events through Config.Logger.
+type walkLog struct {
+	l       *slog.Logger
+	debug   bool
+	rejects atomic.Int64
+}
+
+func newWalkLog(ctx context.Context, l *slog.Logger) *walkLog {
+	if l == nil {
+		return &walkLog{}
+	}
+	return &walkLog{l: l, debug: l.Enabled(ctx, slog.LevelDebug)}
+}
+
+// enter logs a directory about to be read.
+func (w *walkLog) enter(dir string, depth int) {
+	if w.debug {
+		w.l.Debug("enter directory", "dir", dir, "depth", depth)
+	}
+}
+
+// skip logs a path (usually a directory) that is not examined further.
+func (w *walkLog) skip(path, reason string) {
+	if w.debug {
+		w.l.Debug("skip", "path", path, "reason", reason)
+	}
+}
+
+// reject logs, with sampling, an entry dropped by the named filter.
+func (w *walkLog) reject(path, filter string) {
+	if !w.debug {
+		return
+	}
+	n := w.rejects.Add(1)
+	if n <= rejectLogHead || n%rejectLogEvery == 0 {
+		w.l.Debug("filter rejected", "path", path, "filter", filter, "rejections", n)
+	}
+}
@@ -0,0 +1,49 @@
+package finder
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+)
+
+// lstat, stat and readDir wrap their os counterparts with sysPath, keeping
+// the caller's path in errors.
+func lstat(p string) (fs.FileInfo, error) {
+	fi, err := os.Lstat(sysPath(p))
+	return fi, restorePath(err, p)
+}
+
+func stat(p string) (fs.FileInfo, error) {
+	fi, err := os.Stat(sysPath(p))
+	return fi, restorePath(err, p)
+}
+
+func readDir(p string) ([]fs.DirEntry, error) {
+	des, err := os.ReadDir(sysPath(p))
+	return des, restorePath(err, p)
+}
+
+func restorePath(err error, p string) error {
+	if err == nil {
+		return nil // pe below would be allocated for nothing
+	}
+	var pe *fs.PathError
+	if errors.As(err, &pe) {
+		pe.Path = p
+	}
+	return err
+}
+
+// openDir opens directory p to check that it can be listed, returning the
+// reason (without a PathError around it) if not.
+func openDir(p string) error {
+	f, err := os.Open(sysPath(p))
+	if err != nil {
+		var pe *fs.PathError
+		if errors.As(err, &pe) {
+			return pe.Err
+		}
+		return err
+	}
+	return f.Close()
+}
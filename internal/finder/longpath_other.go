@@ -0,0 +1,6 @@
+//go:build !windows
+
+package finder
+
+// sysPath returns p unchanged; only Windows limits path length.
+func sysPath(p string) string { return p }
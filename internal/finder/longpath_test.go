@@ -0,0 +1,46 @@
+package finder
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestWalk_LongRelativePaths builds a tree whose paths exceed MAX_PATH (260)
+// and walks it from a relative root, which Windows only handles with the
+// \\?\ prefix.
+func TestWalk_LongRelativePaths(t *testing.T) {
+	td := t.TempDir()
+	segs := make([]string, 8)
+	for i := range segs {
+		segs[i] = strings.Repeat(string(rune('a'+i)), 40)
+	}
+	deep := filepath.Join(append([]string{td}, segs...)...)
+	if err := os.MkdirAll(deep, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(deep, "deep.txt"), []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(td)
+
+	var found string
+	res, err := Walk(context.Background(), Config{Root: ".", MaxDepth: -1, Extensions: map[string]bool{".txt": true}}, func(e Entry) error {
+		if !e.IsDir {
+			found = e.Path
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walk: %v", err)
+	}
+	want := filepath.Join(append(segs, "deep.txt")...)
+	if len(want) <= 260 {
+		t.Fatalf("test path too short: %d", len(want))
+	}
+	if found != want || res.ErrorCount != 0 {
+		t.Fatalf("found %q (errors %v), want %q", found, res.Errors, want)
+	}
+}
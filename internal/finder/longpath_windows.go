@@ -0,0 +1,31 @@
+//go:build windows
+
+package finder
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// maxShortPath is the longest path the Win32 API accepts without the \\?\
+// prefix. Directories must leave room for an 8.3 file name, hence 248
+// rather than MAX_PATH (260).
+const maxShortPath = 248
+
+// sysPath returns p in extended-length form (\\?\C:\... or \\?\UNC\...)
+// when it is too long for the Win32 API, so deep trees such as node_modules
+// are not silently cut off. The os package already does this for absolute
+// paths, but not for relative ones like those below --root ".".
+func sysPath(p string) string {
+	if len(p) < maxShortPath || strings.HasPrefix(p, `\\?\`) {
+		return p
+	}
+	abs, err := filepath.Abs(p)
+	if err != nil {
+		return p
+	}
+	if strings.HasPrefix(abs, `\\`) {
+		return `\\?\UNC\` + abs[2:]
+	}
+	return `\\?\` + abs
+}
@@ -0,0 +1,26 @@
+//go:build windows
+
+package finder
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSysPath(t *testing.T) {
+	short := `C:\a\b.txt`
+	if got := sysPath(short); got != short {
+		t.Fatalf("short path changed: %q", got)
+	}
+	long := `C:\` + strings.Repeat(`x\`, 150) + "f.txt"
+	if got := sysPath(long); got != `\\?\`+long {
+		t.Fatalf("sysPath(long) = %q", got)
+	}
+	unc := `\\server\share\` + strings.Repeat(`x\`, 150) + "f.txt"
+	if got := sysPath(unc); got != `\\?\UNC\server\share\`+strings.Repeat(`x\`, 150)+"f.txt" {
+		t.Fatalf("sysPath(unc) = %q", got)
+	}
+	if got := sysPath(`\\?\` + long); got != `\\?\`+long {
+		t.Fatalf("prefixed path changed: %q", got)
+	}
+}
@@ -0,0 +1,69 @@
+package finder
+
+import "time"
+
+// MetaRecord is written before the first and after the last entry of JSON,
+// NDJSON and JSON sequence output when Config.EmitMeta is set. Its "type" of
+// "meta" tells it apart from entries; a consumer that finds no end record
+// knows the output was cut short.
+type MetaRecord struct {
+	Type string `json:"type"`
+	// Event is "start" or "end".
+	Event string `json:"event"`
+	// Config is Config.MetaConfig, in the start record only.
+	Config any       `json:"config,omitempty"`
+	Start  time.Time `json:"start"`
+	// MetaTotals is set in the end record.
+	*MetaTotals
+}
+
+// MetaTotals are the counts of the end MetaRecord; see Result.
+type MetaTotals struct {
+	End           time.Time `json:"end"`
+	DirsVisited   int64     `json:"dirsVisited"`
+	EntriesSeen   int64     `json:"entriesSeen"`
+	Matched       int64     `json:"matched"`
+	Errors        int64     `json:"errors"`
+	Vanished      int64     `json:"vanished"`
+	TruncatedDirs int64     `json:"truncatedDirs"`
+	Interrupted   bool      `json:"interrupted"`
+	// Partial is set when Config.Deadline stopped the search.
+	Partial bool `json:"partial"`
+}
+
+// Meta events.
+const (
+	MetaStart = "start"
+	MetaEnd   = "end"
+)
+
+// runMeta supplies the MetaRecords of a Run; end is called by the writer
+// once every entry has been written.
+type runMeta struct {
+	start MetaRecord
+	end   func() MetaRecord
+}
+
+func newRunMeta(cfg *Config, t *tally) *runMeta {
+	if !cfg.EmitMeta {
+		return nil
+	}
+	return &runMeta{
+		start: MetaRecord{Type: "meta", Event: MetaStart, Config: cfg.MetaConfig, Start: t.start},
+		end:   func() MetaRecord { return endMeta(t.result(), t.start) },
+	}
+}
+
+func endMeta(r Result, start time.Time) MetaRecord {
+	return MetaRecord{Type: "meta", Event: MetaEnd, Start: start, MetaTotals: &MetaTotals{
+		End:           start.Add(r.Duration),
+		DirsVisited:   r.DirsVisited,
+		EntriesSeen:   r.EntriesSeen,
+		Matched:       r.Matched,
+		Errors:        r.ErrorCount,
+		Vanished:      r.TransientCount,
+		TruncatedDirs: r.TruncatedCount,
+		Interrupted:   r.Interrupted,
+		Partial:       r.Partial,
+	}}
+}
@@ -0,0 +1,79 @@
+package finder
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEmitMeta(t *testing.T) {
+	td := t.TempDir()
+	mk(t, td, "a.txt", 1, time.Time{})
+	mk(t, td, "b.txt", 1, time.Time{})
+
+	type record struct {
+		Type    string         `json:"type"`
+		Event   string         `json:"event"`
+		Path    string         `json:"path"`
+		Config  map[string]any `json:"config"`
+		Matched int64          `json:"matched"`
+		End     time.Time      `json:"end"`
+	}
+	check := func(name string, recs []record) {
+		t.Helper()
+		if len(recs) != 4 {
+			t.Fatalf("%s: got %d records, want 4: %+v", name, len(recs), recs)
+		}
+		first, last := recs[0], recs[3]
+		if first.Type != "meta" || first.Event != MetaStart || first.Config["root"] != "x" || !first.End.IsZero() {
+			t.Errorf("%s: start record %+v", name, first)
+		}
+		if last.Type != "meta" || last.Event != MetaEnd || last.Matched != 2 || last.End.IsZero() || last.Config != nil {
+			t.Errorf("%s: end record %+v", name, last)
+		}
+		for _, r := range recs[1:3] {
+			if r.Type != "" || r.Path == "" {
+				t.Errorf("%s: entry %+v", name, r)
+			}
+		}
+	}
+
+	cfg := Config{Root: td, MaxDepth: -1, EmitMeta: true, MetaConfig: map[string]string{"root": "x"}}
+	var out strings.Builder
+	cfg.OutputFormat = OutputNDJSON
+	if _, err := Run(context.Background(), &out, cfg); err != nil {
+		t.Fatal(err)
+	}
+	var recs []record
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		var r record
+		if err := json.Unmarshal([]byte(line), &r); err != nil {
+			t.Fatalf("%v: %q", err, line)
+		}
+		recs = append(recs, r)
+	}
+	check("ndjson", recs)
+
+	out.Reset()
+	cfg.OutputFormat, cfg.PrettyJSON = OutputJSON, true
+	if _, err := Run(context.Background(), &out, cfg); err != nil {
+		t.Fatal(err)
+	}
+	recs = nil
+	if err := json.Unmarshal([]byte(out.String()), &recs); err != nil {
+		t.Fatalf("%v: %s", err, out.String())
+	}
+	check("json", recs)
+
+	// Text output has no meta records.
+	out.Reset()
+	cfg.OutputFormat = OutputText
+	if _, err := Run(context.Background(), &out, cfg); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(out.String(), "meta") {
+		t.Fatalf("text: %q", out.String())
+	}
+}
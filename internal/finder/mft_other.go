@@ -0,0 +1,10 @@
+//go:build !windows
+
+package finder
+
+import "context"
+
+// scanMFT is only implemented on Windows; elsewhere the walker is used.
+func scanMFT(_ context.Context, _ *Config, _ func(indexHit)) error {
+	return errBackendUnavailable
+}
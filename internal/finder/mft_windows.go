@@ -0,0 +1,182 @@
+//go:build windows
+
+package finder
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"math"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"unicode/utf16"
+)
+
+const fsctlEnumUsnData = 0x000900b3
+
+// mftNode is the subset of a USN_RECORD_V2 needed to rebuild paths.
+type mftNode struct {
+	parent uint64
+	name   string
+	attrs  uint32
+}
+
+// mftPath caches the resolved location of a node relative to Root.
+type mftPath struct {
+	rel              string
+	depth            int
+	hidden, inHidden bool
+	under            bool
+}
+
+// scanMFT enumerates every record of the volume's Master File Table with
+// FSCTL_ENUM_USN_DATA, rebuilds paths from parent references and visits the
+// ones below cfg.Root. Opening the raw volume requires administrator rights;
+// any failure before enumeration starts falls back to the walker.
+func scanMFT(ctx context.Context, cfg *Config, visit func(indexHit)) error {
+	root, err := filepath.Abs(cfg.Root)
+	if err != nil {
+		return errBackendUnavailable
+	}
+	vol := filepath.VolumeName(root)
+	if len(vol) != 2 || vol[1] != ':' {
+		// UNC shares and the like have no MFT we can read.
+		return errBackendUnavailable
+	}
+	rootFRN, err := fileReference(root)
+	if err != nil {
+		return errBackendUnavailable
+	}
+	volPath, err := syscall.UTF16PtrFromString(`\\.\` + vol)
+	if err != nil {
+		return errBackendUnavailable
+	}
+	h, err := syscall.CreateFile(volPath, syscall.GENERIC_READ,
+		syscall.FILE_SHARE_READ|syscall.FILE_SHARE_WRITE, nil, syscall.OPEN_EXISTING, 0, 0)
+	if err != nil {
+		return errBackendUnavailable
+	}
+	defer func() { _ = syscall.CloseHandle(h) }()
+
+	nodes, err := enumMFT(ctx, h)
+	if err != nil {
+		return err
+	}
+
+	resolved := make(map[uint64]mftPath, len(nodes))
+	var resolve func(frn uint64, hops int) mftPath
+	resolve = func(frn uint64, hops int) mftPath {
+		if frn == rootFRN {
+			return mftPath{depth: -1, under: true}
+		}
+		if p, ok := resolved[frn]; ok {
+			return p
+		}
+		n, ok := nodes[frn]
+		if !ok || hops > 4096 {
+			return mftPath{}
+		}
+		parent := resolve(n.parent, hops+1)
+		p := mftPath{}
+		if parent.under {
+			p = mftPath{
+				rel:      filepath.Join(parent.rel, n.name),
+				depth:    parent.depth + 1,
+				hidden:   hiddenAttrs(n.attrs, cfg.hiddenPolicy()) || cfg.hiddenName(filepath.Join(cfg.Root, parent.rel), n.name),
+				inHidden: parent.hidden || parent.inHidden,
+				under:    true,
+			}
+		}
+		resolved[frn] = p
+		return p
+	}
+
+	for frn := range nodes {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+		p := resolve(frn, 0)
+		if !p.under || frn == rootFRN {
+			continue
+		}
+		visit(indexHit{path: filepath.Join(cfg.Root, p.rel), depth: p.depth, hidden: p.hidden, inHidden: p.inHidden})
+	}
+	return nil
+}
+
+// enumMFT reads all USN records of the open volume handle.
+func enumMFT(ctx context.Context, h syscall.Handle) (map[uint64]mftNode, error) {
+	// MFT_ENUM_DATA_V0: StartFileReferenceNumber, LowUsn, HighUsn.
+	var in [24]byte
+	binary.LittleEndian.PutUint64(in[16:], math.MaxInt64)
+
+	buf := make([]byte, 1<<16)
+	nodes := make(map[uint64]mftNode)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+		}
+		var n uint32
+		err := syscall.DeviceIoControl(h, fsctlEnumUsnData, &in[0], uint32(len(in)),
+			&buf[0], uint32(len(buf)), &n, nil)
+		if err != nil {
+			if errors.Is(err, syscall.ERROR_HANDLE_EOF) {
+				return nodes, nil
+			}
+			if len(nodes) == 0 {
+				// e.g. not NTFS or no change journal access.
+				return nil, errBackendUnavailable
+			}
+			return nil, err
+		}
+		if n <= 8 {
+			return nodes, nil
+		}
+		copy(in[:8], buf[:8]) // next StartFileReferenceNumber
+		for off := uint32(8); off+60 <= n; {
+			rec := buf[off:n]
+			recLen := binary.LittleEndian.Uint32(rec[0:])
+			if recLen == 0 || recLen > uint32(len(rec)) {
+				break
+			}
+			nameLen := uint32(binary.LittleEndian.Uint16(rec[56:]))
+			nameOff := uint32(binary.LittleEndian.Uint16(rec[58:]))
+			if nameOff+nameLen <= recLen {
+				u := make([]uint16, nameLen/2)
+				for i := range u {
+					u[i] = binary.LittleEndian.Uint16(rec[nameOff+uint32(i)*2:])
+				}
+				nodes[binary.LittleEndian.Uint64(rec[8:])] = mftNode{
+					parent: binary.LittleEndian.Uint64(rec[16:]),
+					name:   string(utf16.Decode(u)),
+					attrs:  binary.LittleEndian.Uint32(rec[52:]),
+				}
+			}
+			off += recLen
+		}
+	}
+}
+
+// fileReference returns the 64-bit NTFS file reference number of path.
+func fileReference(path string) (uint64, error) {
+	p, err := syscall.UTF16PtrFromString(strings.TrimSuffix(path, `\`) + `\`)
+	if err != nil {
+		return 0, err
+	}
+	h, err := syscall.CreateFile(p, 0, syscall.FILE_SHARE_READ|syscall.FILE_SHARE_WRITE|syscall.FILE_SHARE_DELETE,
+		nil, syscall.OPEN_EXISTING, syscall.FILE_FLAG_BACKUP_SEMANTICS, 0)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = syscall.CloseHandle(h) }()
+	var fi syscall.ByHandleFileInformation
+	if err := syscall.GetFileInformationByHandle(h, &fi); err != nil {
+		return 0, err
+	}
+	return uint64(fi.FileIndexHigh)<<32 | uint64(fi.FileIndexLow), nil
+}
@@ -0,0 +1,13 @@
+//go:build !linux && !darwin
+
+package finder
+
+import (
+	"errors"
+	"os"
+)
+
+// mmapFile is not supported here; ReadMmap falls back to reads.
+func mmapFile(*os.File, int64) ([]byte, func() error, error) {
+	return nil, nil, errors.ErrUnsupported
+}
@@ -0,0 +1,69 @@
+package finder
+
+import (
+	"regexp"
+	"regexp/syntax"
+	"strings"
+	"unicode/utf8"
+)
+
+// literalName is a NameRegex that only matches a fixed string, optionally
+// anchored, such as "config", "^Makefile$" or "\.go$". It is matched with
+// the strings package, several times faster than the regexp engine.
+type literalName struct {
+	s          string
+	start, end bool // anchored with ^ and $
+}
+
+// literalRegexp returns re as a literalName, or nil if it is more than a
+// case-sensitive string between optional anchors.
+func literalRegexp(re *regexp.Regexp) *literalName {
+	if re == nil {
+		return nil
+	}
+	parsed, err := syntax.Parse(re.String(), syntax.Perl)
+	if err != nil {
+		return nil
+	}
+	subs := []*syntax.Regexp{parsed}
+	if parsed.Op == syntax.OpConcat {
+		subs = parsed.Sub
+	}
+	var lit literalName
+	if len(subs) > 0 && subs[0].Op == syntax.OpBeginText {
+		lit.start, subs = true, subs[1:]
+	}
+	if n := len(subs); n > 0 && subs[n-1].Op == syntax.OpEndText {
+		lit.end, subs = true, subs[:n-1]
+	}
+	if len(subs) != 1 || subs[0].Op != syntax.OpLiteral || subs[0].Flags&syntax.FoldCase != 0 {
+		return nil
+	}
+	lit.s = string(subs[0].Rune)
+	// The regexp engine reads invalid UTF-8 as U+FFFD; strings do not.
+	if strings.ContainsRune(lit.s, utf8.RuneError) {
+		return nil
+	}
+	return &lit
+}
+
+func (l *literalName) match(name string) bool {
+	switch {
+	case l.start && l.end:
+		return name == l.s
+	case l.start:
+		return strings.HasPrefix(name, l.s)
+	case l.end:
+		return strings.HasSuffix(name, l.s)
+	}
+	return strings.Contains(name, l.s)
+}
+
+// matchNameRegex reports whether name matches c.NameRegex, through the
+// literal fast path when validate found one.
+func (c *Config) matchNameRegex(name string) bool {
+	if c.nameLiteral != nil {
+		return c.nameLiteral.match(name)
+	}
+	return c.NameRegex.MatchString(name)
+}
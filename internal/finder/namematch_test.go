@@ -0,0 +1,41 @@
+package finder
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestLiteralRegexp(t *testing.T) {
+	names := []string{"config", "config.yaml", "my.config", "Config", "Makefile", "Makefile.am", "main.go", "main.go.orig", "x.go", "", "a\xffb"}
+	for _, tc := range []struct {
+		pattern string
+		literal bool
+	}{
+		{"config", true},
+		{"^config", true},
+		{`\.go$`, true},
+		{"^Makefile$", true},
+		{`^main\.go$`, true},
+		{"", false},
+		{"(?i)config", false},
+		{"^(?i)config", false},
+		{"conf.g", false},
+		{"a|b", false},
+		{"^config$|^Makefile$", false},
+		{"(?m)go$", false},
+		{`\x{FFFD}`, false},
+	} {
+		re := regexp.MustCompile(tc.pattern)
+		lit := literalRegexp(re)
+		if (lit != nil) != tc.literal {
+			t.Errorf("%q: literal %+v, want literal %v", tc.pattern, lit, tc.literal)
+			continue
+		}
+		cfg := &Config{NameRegex: re, nameLiteral: lit}
+		for _, name := range names {
+			if got, want := cfg.matchNameRegex(name), re.MatchString(name); got != want {
+				t.Errorf("%q on %q: %v, want %v", tc.pattern, name, got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package finder
+
+import (
+	"io/fs"
+	"os/user"
+	"strconv"
+	"sync"
+)
+
+// ownerNames caches uid -> user name lookups.
+var ownerNames sync.Map
+
+// ownerName returns the name of the user owning info, the numeric uid when
+// it has no name, or "" where ownership is not available (Windows).
+func ownerName(info fs.FileInfo) string {
+	uid, ok := ownerID(info)
+	if !ok {
+		return ""
+	}
+	if name, ok := ownerNames.Load(uid); ok {
+		return name.(string)
+	}
+	id := strconv.FormatUint(uint64(uid), 10)
+	name := id
+	if u, err := user.LookupId(id); err == nil {
+		name = u.Username
+	}
+	ownerNames.Store(uid, name)
+	return name
+}
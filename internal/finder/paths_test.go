@@ -0,0 +1,61 @@
+package finder
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestWalk_PathsAreFilteredNotWalked(t *testing.T) {
+	td := t.TempDir()
+	a := mk(t, td, "a.go", 1, time.Now())
+	b := mk(t, td, "b.txt", 1, time.Now())
+	mk(t, td, "sub/c.go", 1, time.Now())
+	sub := filepath.Join(td, "sub")
+	missing := filepath.Join(td, "missing.go")
+
+	cfg := Config{
+		Root:       td,
+		MaxDepth:   -1,
+		Extensions: map[string]bool{".go": true},
+		Paths:      slices.Values([]string{a, b, sub, missing}),
+	}
+	var got []string
+	res, err := Walk(context.Background(), cfg, func(e Entry) error {
+		got = append(got, e.Path)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walk: %v", err)
+	}
+	sort.Strings(got)
+	if want := []string{a, sub}; !slices.Equal(got, want) {
+		t.Fatalf("got %v, want %v (sub/ must not be descended)", got, want)
+	}
+	if res.ErrorCount != 1 {
+		t.Fatalf("expected the missing path to be counted as an error, got %d", res.ErrorCount)
+	}
+}
+
+func TestWalk_MultipleRoots(t *testing.T) {
+	r1, r2 := t.TempDir(), t.TempDir()
+	a := mk(t, r1, "a.txt", 1, time.Now())
+	b := mk(t, r2, "x/b.txt", 1, time.Now())
+
+	var got []string
+	if _, err := Walk(context.Background(), Config{Root: ".", Roots: []string{r1, r2}, MaxDepth: -1, Extensions: map[string]bool{".txt": true}}, func(e Entry) error {
+		got = append(got, e.Path)
+		return nil
+	}); err != nil {
+		t.Fatalf("walk: %v", err)
+	}
+	sort.Strings(got)
+	want := []string{a, filepath.Join(r2, "x"), b}
+	sort.Strings(want)
+	if !slices.Equal(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,30 @@
+package finder
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// outputPath applies the path normalization options to p as it is written.
+func (c *Config) outputPath(p string) string {
+	if c.CleanPaths {
+		p = filepath.Clean(p)
+	}
+	if c.DotSlash && !filepath.IsAbs(p) && filepath.VolumeName(p) == "" && !isDotRelative(p) {
+		p = "." + string(filepath.Separator) + p
+	}
+	if c.SlashPaths {
+		p = filepath.ToSlash(p)
+	}
+	return p
+}
+
+// isDotRelative reports whether p already starts with "." or "..".
+func isDotRelative(p string) bool {
+	for _, dot := range []string{".", ".."} {
+		if p == dot || strings.HasPrefix(p, dot+string(filepath.Separator)) || strings.HasPrefix(p, dot+"/") {
+			return true
+		}
+	}
+	return false
+}
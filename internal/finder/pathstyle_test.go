@@ -0,0 +1,53 @@
+package finder
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOutputPath(t *testing.T) {
+	abs, err := filepath.Abs("x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fs := filepath.FromSlash
+	for _, tc := range []struct {
+		in   string
+		cfg  Config
+		want string
+	}{
+		{fs("a//b/../c"), Config{}, fs("a//b/../c")},
+		{fs("a//b/../c"), Config{CleanPaths: true}, fs("a/c")},
+		{fs("a/b"), Config{DotSlash: true}, fs("./a/b")},
+		{fs("./a/b"), Config{DotSlash: true}, fs("./a/b")},
+		{fs("../a"), Config{DotSlash: true}, fs("../a")},
+		{fs("./a/b"), Config{CleanPaths: true, DotSlash: true}, fs("./a/b")},
+		{abs, Config{DotSlash: true}, abs},
+		{fs("a/b"), Config{SlashPaths: true, DotSlash: true}, "./a/b"},
+		{abs, Config{SlashPaths: true}, filepath.ToSlash(abs)},
+	} {
+		if got := tc.cfg.outputPath(tc.in); got != tc.want {
+			t.Errorf("%+v: outputPath(%q) = %q, want %q", tc.cfg, tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestRun_PathNormalization(t *testing.T) {
+	td := t.TempDir()
+	mk(t, td, "a.txt", 1, time.Now())
+	list := []string{td + string(filepath.Separator) + "." + string(filepath.Separator) + "a.txt"}
+
+	var buf bytes.Buffer
+	cfg := Config{Root: td, Paths: slices.Values(list), CleanPaths: true, SlashPaths: true}
+	if _, err := Run(context.Background(), &buf, cfg); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if got, want := strings.TrimSpace(buf.String()), filepath.ToSlash(filepath.Join(td, "a.txt")); got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
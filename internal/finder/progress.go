@@ -0,0 +1,101 @@
+package finder
+
+import (
+	"sync"
+	"time"
+)
+
+// defaultProgressInterval is Config.ProgressInterval when it is unset.
+const defaultProgressInterval = time.Second
+
+// Progress is a snapshot of a running search, passed to Config.OnProgress.
+type Progress struct {
+	DirsVisited int64
+	EntriesSeen int64
+	Matched     int64
+	Elapsed     time.Duration
+	// EntriesPerSec is the rate entries were examined at since the previous
+	// report, or over the whole search in the last one.
+	EntriesPerSec float64
+	// ExpectedDirs is Config.ExpectedDirs.
+	ExpectedDirs int64
+	// ETA estimates the time left from the directories still expected and
+	// the rate directories have been read at so far. It is 0 when there is
+	// no estimate: without ExpectedDirs, before the first directory, or once
+	// the search has read more directories than expected.
+	ETA time.Duration
+	// Done is set on the last report, sent when the search ends.
+	Done bool
+}
+
+// Fraction returns the share of ExpectedDirs read so far, between 0 and 1,
+// or -1 without ExpectedDirs.
+func (p Progress) Fraction() float64 {
+	if p.ExpectedDirs <= 0 {
+		return -1
+	}
+	return min(float64(p.DirsVisited)/float64(p.ExpectedDirs), 1)
+}
+
+// startProgress reports the counters of t to cfg.OnProgress every
+// cfg.ProgressInterval until the returned function is called, which sends
+// the final report.
+func startProgress(cfg *Config, t *tally) (stop func()) {
+	if cfg.OnProgress == nil {
+		return func() {}
+	}
+	every := cfg.ProgressInterval
+	if every <= 0 {
+		every = defaultProgressInterval
+	}
+	var (
+		lastSeen int64
+		lastAt   = t.start
+	)
+	// report is only called from the goroutine below, then after it has
+	// exited, so the calls are serialized.
+	report := func(done bool) {
+		now := time.Now()
+		p := Progress{
+			DirsVisited:  t.dirs.Load(),
+			EntriesSeen:  t.seen.Load(),
+			Matched:      t.matched.Load(),
+			Elapsed:      now.Sub(t.start),
+			ExpectedDirs: cfg.ExpectedDirs,
+			Done:         done,
+		}
+		if done {
+			lastSeen, lastAt = 0, t.start
+		}
+		if d := now.Sub(lastAt).Seconds(); d > 0 {
+			p.EntriesPerSec = float64(p.EntriesSeen-lastSeen) / d
+		}
+		lastSeen, lastAt = p.EntriesSeen, now
+		if left := p.ExpectedDirs - p.DirsVisited; !done && left > 0 && p.DirsVisited > 0 {
+			perDir := p.Elapsed / time.Duration(p.DirsVisited)
+			p.ETA = perDir * time.Duration(left)
+		}
+		cfg.OnProgress(p)
+	}
+	tick := time.NewTicker(every)
+	quit := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-tick.C:
+				report(false)
+			case <-quit:
+				return
+			}
+		}
+	}()
+	return func() {
+		tick.Stop()
+		close(quit)
+		wg.Wait()
+		report(true)
+	}
+}
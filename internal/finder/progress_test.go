@@ -0,0 +1,54 @@
+package finder
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestProgress(t *testing.T) {
+	root := t.TempDir()
+	for i := range 20 {
+		mk(t, root, fmt.Sprintf("d%d/f.txt", i), 1, time.Time{})
+	}
+	var reports []Progress
+	cfg := Config{
+		Root:             root,
+		MaxDepth:         -1,
+		Concurrency:      1,
+		ProgressInterval: time.Millisecond,
+		ExpectedDirs:     100,
+		OnProgress:       func(p Progress) { reports = append(reports, p) },
+		// Slow the search down enough for reports before the end.
+		Enrichers: []Enricher{EnricherFunc(func(context.Context, *Entry) error {
+			time.Sleep(2 * time.Millisecond)
+			return nil
+		})},
+	}
+	res, err := Walk(context.Background(), cfg, func(Entry) error { return nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reports) < 2 {
+		t.Fatalf("got %d reports, want periodic ones and a final one", len(reports))
+	}
+	last := reports[len(reports)-1]
+	if !last.Done || last.DirsVisited != res.DirsVisited || last.Matched != res.Matched || last.ETA != 0 {
+		t.Errorf("final report %+v does not match result %+v", last, res)
+	}
+	for _, p := range reports[:len(reports)-1] {
+		if p.Done {
+			t.Errorf("report before the end marked done: %+v", p)
+		}
+		if p.DirsVisited > 0 && p.DirsVisited < p.ExpectedDirs && p.ETA <= 0 {
+			t.Errorf("no ETA with %d of %d dirs read", p.DirsVisited, p.ExpectedDirs)
+		}
+	}
+	if f := (Progress{DirsVisited: 150, ExpectedDirs: 100}).Fraction(); f != 1 {
+		t.Errorf("Fraction past the expected count = %v, want 1", f)
+	}
+	if f := (Progress{DirsVisited: 5}).Fraction(); f != -1 {
+		t.Errorf("Fraction without ExpectedDirs = %v, want -1", f)
+	}
+}
@@ -0,0 +1,117 @@
+package finder
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// QuoteMode selects how text output writes paths; see Config.Quote.
+type QuoteMode int
+
+const (
+	// QuoteNone writes paths as they are.
+	QuoteNone QuoteMode = iota
+	// QuoteEscape writes control characters, including C1 controls and bytes
+	// that are not UTF-8, as \n, \t, \r or \xHH, so a file name cannot send
+	// escape sequences to a terminal.
+	QuoteEscape
+	// QuoteShell writes paths that a POSIX shell would split or expand, or
+	// that hold control characters, in shell quotes: 'a b', or $'a\nb' for
+	// control characters. Other paths are written as they are.
+	QuoteShell
+	// QuoteAlways writes every path in shell quotes, as QuoteShell does.
+	QuoteAlways
+)
+
+// appendQuoted appends p to b as mode writes it.
+func appendQuoted(b []byte, p string, mode QuoteMode) []byte {
+	switch mode {
+	case QuoteEscape:
+		return appendEscaped(b, p, false)
+	case QuoteShell, QuoteAlways:
+		switch {
+		case hasControl(p):
+			b = append(b, "$'"...)
+			b = appendEscaped(b, p, true)
+			return append(b, '\'')
+		case mode == QuoteShell && !needsShellQuote(p):
+			return append(b, p...)
+		}
+		b = append(b, '\'')
+		b = append(b, strings.ReplaceAll(p, "'", `'\''`)...)
+		return append(b, '\'')
+	}
+	return append(b, p...)
+}
+
+// isControl reports whether r is a C0 or C1 control character or DEL.
+func isControl(r rune) bool {
+	return r < 0x20 || (r >= 0x7f && r < 0xa0)
+}
+
+// hasControl reports whether s holds a control character or a byte that is
+// not UTF-8.
+func hasControl(s string) bool {
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if isControl(r) || (r == utf8.RuneError && size == 1) {
+			return true
+		}
+		i += size
+	}
+	return false
+}
+
+// appendEscaped appends s with its control characters and invalid bytes
+// escaped. In $'...' quotes (ansiC), backslashes and single quotes are
+// escaped too.
+func appendEscaped(b []byte, s string, ansiC bool) []byte {
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case r == utf8.RuneError && size == 1:
+			b = fmt.Appendf(b, `\x%02x`, s[i])
+		case r == '\n':
+			b = append(b, `\n`...)
+		case r == '\t':
+			b = append(b, `\t`...)
+		case r == '\r':
+			b = append(b, `\r`...)
+		case isControl(r):
+			if r < 0x80 {
+				b = fmt.Appendf(b, `\x%02x`, r)
+			} else {
+				// The UTF-8 bytes, which $'...' turns back into the character.
+				for _, c := range []byte(s[i : i+size]) {
+					b = fmt.Appendf(b, `\x%02x`, c)
+				}
+			}
+		case ansiC && (r == '\\' || r == '\''):
+			b = append(b, '\\', byte(r))
+		default:
+			b = append(b, s[i:i+size]...)
+		}
+		i += size
+	}
+	return b
+}
+
+// needsShellQuote reports whether a POSIX shell would not take p as one word
+// as it is: it holds a character other than letters, digits, non-ASCII
+// characters and _@%+=:,./- (and the path separator).
+func needsShellQuote(p string) bool {
+	if p == "" {
+		return true
+	}
+	for _, r := range p {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r >= utf8.RuneSelf:
+		case strings.ContainsRune("_@%+=:,./-", r), r == filepath.Separator:
+		default:
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,30 @@
+package finder
+
+import "testing"
+
+func TestAppendQuoted(t *testing.T) {
+	for _, tc := range []struct {
+		path string
+		mode QuoteMode
+		want string
+	}{
+		{"a/b.go", QuoteNone, "a/b.go"},
+		{"a\x1b[31mb", QuoteNone, "a\x1b[31mb"},
+		{"a b", QuoteEscape, "a b"},
+		{"a\x1b[31m\nb\t", QuoteEscape, `a\x1b[31m\nb\t`},
+		{"a\u009bb", QuoteEscape, `a\xc2\x9bb`},
+		{"bad\xffname", QuoteEscape, `bad\xffname`},
+		{"héllo", QuoteEscape, "héllo"},
+		{"a/b-c_1.go", QuoteShell, "a/b-c_1.go"},
+		{"a b", QuoteShell, "'a b'"},
+		{"it's", QuoteShell, `'it'\''s'`},
+		{"$HOME*", QuoteShell, "'$HOME*'"},
+		{"a\nb'c\\", QuoteShell, `$'a\nb\'c\\'`},
+		{"a/b.go", QuoteAlways, "'a/b.go'"},
+		{"", QuoteShell, "''"},
+	} {
+		if got := string(appendQuoted(nil, tc.path, tc.mode)); got != tc.want {
+			t.Errorf("appendQuoted(%q, %d) = %q, want %q", tc.path, tc.mode, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package finder
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// maxRecordedErrors caps Result.Errors so unreadable trees can't grow it without bound.
+const maxRecordedErrors = 1000
+
+// Result summarizes a completed (or interrupted) search.
+type Result struct {
+	// DirsVisited counts directories whose entries were read.
+	DirsVisited int64
+	// EntriesSeen counts entries examined before filters were applied.
+	EntriesSeen int64
+	// Matched counts entries that passed all filters and were emitted.
+	Matched int64
+	// Errors holds the first maxRecordedErrors per-path failures that caused an
+	// entry or subtree to be skipped; ErrorCount is the total.
+	Errors     []*fs.PathError
+	ErrorCount int64
+	// Transient holds the first maxRecordedErrors entries that vanished
+	// between being listed and being examined (see ErrVanished);
+	// TransientCount is the total. They are not counted as errors.
+	Transient      []*fs.PathError
+	TransientCount int64
+	// Truncated lists the first maxRecordedErrors directories whose matches
+	// were cut off by Config.MaxPerDir, sorted by path; TruncatedCount is the
+	// total number of such directories.
+	Truncated      []TruncatedDir
+	TruncatedCount int64
+	// SkippedRoots lists the roots Config.SkipMissingRoots skipped.
+	SkippedRoots []string
+	// Duration is the wall time of the search.
+	Duration time.Duration
+	// Interrupted is set when the context was canceled or timed out before
+	// the search completed and work was given up on; the counts then
+	// describe a partial run. A search that completed is not interrupted,
+	// whenever the context ends.
+	Interrupted bool
+	// Partial is set when Config.Deadline stopped the search before it
+	// completed.
+	Partial bool
+}
+
+// TruncatedDir is a directory that had more matches than Config.MaxPerDir.
+type TruncatedDir struct {
+	Path string `json:"path"`
+	// Omitted counts the matches left out.
+	Omitted int64 `json:"omitted"`
+}
+
+// tally accumulates Result counters from concurrent walkers.
+type tally struct {
+	start   time.Time
+	dirs    atomic.Int64
+	seen    atomic.Int64
+	matched atomic.Int64
+
+	mu       sync.Mutex
+	errs     []*fs.PathError
+	errCount int64
+	onError  func(*fs.PathError)
+	// fdErrCount counts the errors for lack of file descriptors.
+	fdErrCount int64
+
+	transient      []*fs.PathError
+	transientCount int64
+
+	truncated      []TruncatedDir
+	truncatedCount int64
+
+	skippedRoots []string
+	// ctx is the context of the search without Config.Deadline. Work
+	// abandoned once it is done was interrupted; other work abandoned was
+	// stopped by the deadline, which makes the search partial.
+	ctx         context.Context
+	interrupted atomic.Bool
+	partial     atomic.Bool
+}
+
+func newTally(ctx context.Context, cfg *Config) *tally {
+	return &tally{start: time.Now(), onError: cfg.OnError, skippedRoots: cfg.missing, ctx: ctx}
+}
+
+// abandon records that the search gave up on work because its context, or
+// the one bounded by Config.Deadline, was done.
+func (t *tally) abandon() {
+	if t.ctx.Err() != nil {
+		t.interrupted.Store(true)
+	} else {
+		t.partial.Store(true)
+	}
+}
+
+// stopErr returns the error of a search stage with ctx: ctx.Err() when it
+// abandoned work, and nil when it completed.
+func (t *tally) stopErr(ctx context.Context) error {
+	if t.interrupted.Load() || t.partial.Load() {
+		return ctx.Err()
+	}
+	return nil
+}
+
+// fail records a skipped path and forwards it to Config.OnError.
+func (t *tally) fail(op, path string, err error) {
+	var pe *fs.PathError
+	if !errors.As(err, &pe) {
+		pe = &fs.PathError{Op: op, Path: path, Err: err}
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.errCount++
+	if isTooManyOpenFiles(pe.Err) {
+		t.fdErrCount++
+	}
+	if len(t.errs) < maxRecordedErrors {
+		t.errs = append(t.errs, pe)
+	}
+	if t.onError != nil {
+		t.onError(pe)
+	}
+}
+
+// truncate records that dir had omitted more matches than Config.MaxPerDir.
+func (t *tally) truncate(dir string, omitted int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.truncatedCount++
+	if len(t.truncated) < maxRecordedErrors {
+		t.truncated = append(t.truncated, TruncatedDir{Path: dir, Omitted: omitted})
+	}
+}
+
+func (t *tally) result() Result {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	truncated := slices.Clone(t.truncated)
+	slices.SortFunc(truncated, func(a, b TruncatedDir) int { return strings.Compare(a.Path, b.Path) })
+	return Result{
+		DirsVisited:    t.dirs.Load(),
+		EntriesSeen:    t.seen.Load(),
+		Matched:        t.matched.Load(),
+		Errors:         append([]*fs.PathError(nil), t.errs...),
+		ErrorCount:     t.errCount,
+		Transient:      append([]*fs.PathError(nil), t.transient...),
+		TransientCount: t.transientCount,
+		Truncated:      truncated,
+		TruncatedCount: t.truncatedCount,
+		SkippedRoots:   slices.Clone(t.skippedRoots),
+		Duration:       time.Since(t.start),
+		Interrupted:    t.interrupted.Load(),
+		Partial:        t.partial.Load(),
+	}
+}
+
+// deadline returns ctx bounded by c.Deadline.
+func (c *Config) deadline(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.Deadline.IsZero() {
+		return ctx, func() {}
+	}
+	return context.WithDeadline(ctx, c.Deadline)
+}
+
+// stopped returns err, the outcome of a search, once its stages are done.
+// Reaching Config.Deadline does not fail it, but work abandoned after the
+// search itself returned still interrupts it.
+func (t *tally) stopped(err error) error {
+	if t.interrupted.Load() {
+		if err == nil {
+			err = t.ctx.Err()
+		}
+		return err
+	}
+	if t.partial.Load() && errors.Is(err, context.DeadlineExceeded) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,57 @@
+package finder
+
+import (
+	"bytes"
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestRunResultCountsAndErrors(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlink creation often requires admin/dev mode on Windows")
+	}
+	td := t.TempDir()
+	mk(t, td, "a.txt", 1, time.Now())
+	mk(t, td, "sub/b.go", 1, time.Now())
+	broken := filepath.Join(td, "broken")
+	if err := os.Symlink(filepath.Join(td, "missing"), broken); err != nil {
+		t.Skipf("symlink not permitted: %v", err)
+	}
+
+	var reported []*fs.PathError
+	var out bytes.Buffer
+	cfg := Config{
+		Root:           td,
+		MaxDepth:       -1,
+		FollowSymlinks: true,
+		Extensions:     map[string]bool{".go": true},
+		OnError:        func(pe *fs.PathError) { reported = append(reported, pe) },
+	}
+	res, err := Run(context.Background(), &out, cfg)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if res.DirsVisited != 2 {
+		t.Fatalf("DirsVisited = %d, want 2", res.DirsVisited)
+	}
+	if res.EntriesSeen != 4 { // a.txt, sub, broken, sub/b.go
+		t.Fatalf("EntriesSeen = %d, want 4", res.EntriesSeen)
+	}
+	if res.Matched != 2 { // sub (dirs pass the ext filter) and b.go
+		t.Fatalf("Matched = %d, want 2", res.Matched)
+	}
+	if res.ErrorCount != 1 || len(res.Errors) != 1 || res.Errors[0].Path != broken {
+		t.Fatalf("expected one error for %s, got %+v", broken, res.Errors)
+	}
+	if len(reported) != 1 {
+		t.Fatalf("OnError should see the same error, got %d calls", len(reported))
+	}
+	if res.Duration <= 0 {
+		t.Fatalf("Duration should be positive")
+	}
+}
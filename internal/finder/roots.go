@@ -0,0 +1,73 @@
+package finder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+)
+
+// errNotDir is the error of a RootError for a root that is not a directory.
+var errNotDir = errors.New("not a directory")
+
+// RootError reports a root that cannot be searched. Err tells why; it
+// matches fs.ErrNotExist for a missing root and fs.ErrPermission for one
+// that may not be listed.
+type RootError struct {
+	Root string
+	Err  error
+}
+
+func (e *RootError) Error() string {
+	switch {
+	case errors.Is(e.Err, fs.ErrNotExist):
+		return fmt.Sprintf("root %s does not exist", e.Root)
+	case errors.Is(e.Err, fs.ErrPermission):
+		return fmt.Sprintf("root %s is not accessible: permission denied", e.Root)
+	case errors.Is(e.Err, errNotDir):
+		return fmt.Sprintf("root %s is not a directory", e.Root)
+	}
+	return fmt.Sprintf("root %s: %v", e.Root, e.Err)
+}
+
+func (e *RootError) Unwrap() error { return e.Err }
+
+// checkRoots verifies before the search that every root is a directory that
+// can be listed. Missing roots are recorded in c.missing instead when
+// SkipMissingRoots is set.
+func (c *Config) checkRoots(ctx context.Context) error {
+	if c.Paths != nil {
+		return nil // roots are not walked
+	}
+	roots := c.Roots
+	if len(roots) == 0 {
+		roots = []string{c.Root}
+	}
+	c.missing = nil
+	for _, root := range roots {
+		err := c.checkRoot(ctx, root)
+		if c.SkipMissingRoots && errors.Is(err, fs.ErrNotExist) {
+			c.missing = append(c.missing, root)
+			continue
+		}
+		if err != nil {
+			return &RootError{Root: root, Err: err}
+		}
+	}
+	return nil
+}
+
+func (c *Config) checkRoot(ctx context.Context, root string) error {
+	fi, err := c.stat(ctx, root)
+	if err != nil {
+		var pe *fs.PathError
+		if errors.As(err, &pe) {
+			return pe.Err
+		}
+		return err
+	}
+	if !fi.IsDir() {
+		return errNotDir
+	}
+	return openDir(root)
+}
@@ -0,0 +1,47 @@
+package finder
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+)
+
+// validateSample checks Config.Sample.
+func (c *Config) validateSample() error {
+	switch {
+	case c.Sample < 0 || c.Sample > 1:
+		return fmt.Errorf("invalid Sample %g (want 0 to 1)", c.Sample)
+	case !c.sampling():
+		return nil
+	case c.DirStats != DirStatsOff || c.Checkpoint != nil || c.Baseline != nil:
+		// Their counts, records and removals would take the sample for
+		// the whole tree.
+		return errors.New("Sample cannot be combined with DirStats, Checkpoint or Baseline")
+	}
+	return nil
+}
+
+// sampling reports whether Config.Sample leaves directories out.
+func (c *Config) sampling() bool {
+	return c.Sample > 0 && c.Sample < 1
+}
+
+// sampled reports whether the entries of dir are searched under
+// Config.Sample. The choice hashes the path, so it is spread evenly over the
+// tree and repeated runs sample the same directories.
+func (c *Config) sampled(dir string) bool {
+	if !c.sampling() {
+		return true
+	}
+	h := fnv.New64a()
+	h.Write([]byte(normalizedPath(dir)))
+	// Finish with splitmix64's mixer: FNV's high bits depend little on the
+	// last bytes of the path, and siblings often differ only there.
+	x := h.Sum64()
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return float64(x>>11)/(1<<53) < c.Sample
+}
@@ -0,0 +1,66 @@
+package finder
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSample(t *testing.T) {
+	td := t.TempDir()
+	for i := 0; i < 20; i++ {
+		for j := 0; j < 20; j++ {
+			for k := 0; k < 5; k++ {
+				mk(t, td, fmt.Sprintf("d%02d/e%02d/f%d.txt", i, j, k), 1, time.Now())
+			}
+		}
+	}
+	const all = 20 + 20*20 + 20*20*5
+
+	cfg := Config{Root: td, MaxDepth: -1, Sample: 0.25}
+	var n int
+	res, err := Walk(context.Background(), cfg, func(e Entry) error {
+		if !cfg.sampled(filepath.Dir(e.Path)) {
+			t.Errorf("%s emitted from a directory not sampled", e.Path)
+		}
+		n++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Walk: %v", err)
+	}
+	if res.DirsVisited != 1+20+20*20 {
+		t.Fatalf("read %d directories, want all %d", res.DirsVisited, 1+20+20*20)
+	}
+	if res.EntriesSeen >= all/2 {
+		t.Fatalf("examined %d of %d entries", res.EntriesSeen, all)
+	}
+	// The hash picks about 105 of the 421 directories; allow 4.5 standard
+	// deviations.
+	if est := float64(n) / cfg.Sample; math.Abs(est-all) > 0.4*all {
+		t.Fatalf("estimated %.0f entries from %d, want about %d", est, n, all)
+	}
+}
+
+func TestSampleValidate(t *testing.T) {
+	td := t.TempDir()
+	for i, cfg := range []Config{
+		{Root: td, Sample: 1.5},
+		{Root: td, Sample: -0.1},
+		{Root: td, Sample: 0.5, DirStats: DirStatsImmediate},
+		{Root: td, Sample: 0.5, Checkpoint: &Checkpoint{}},
+	} {
+		if err := cfg.validate(context.Background()); err == nil {
+			t.Errorf("case %d: Sample %g accepted", i, cfg.Sample)
+		}
+	}
+	for _, s := range []float64{0, 1} {
+		cfg := Config{Root: td, Sample: s}
+		if err := cfg.validate(context.Background()); err != nil || !cfg.sampled(td) {
+			t.Errorf("Sample %g: err %v, sampled %v; want every directory", s, err, cfg.sampled(td))
+		}
+	}
+}
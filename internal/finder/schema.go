@@ -0,0 +1,71 @@
+package finder
+
+import (
+	"bytes"
+	_ "embed"
+	"maps"
+	"path/filepath"
+	"strings"
+)
+
+// LatestSchemaVersion is the newest shape of JSON output records; see
+// Config.SchemaVersion. A new version is added only for changes that would
+// break consumers of the previous one.
+const LatestSchemaVersion = 2
+
+var (
+	//go:embed entry.v1.schema.json
+	entrySchemaV1 []byte
+	//go:embed entry.v2.schema.json
+	entrySchemaV2 []byte
+)
+
+// EntrySchema returns the JSON Schema (draft 2020-12) of an Entry as Run
+// writes it in JSON, NDJSON and JSON sequence output with the given
+// Config.SchemaVersion, including the fields of the built-in enrichers. It
+// returns nil for an unknown version.
+func EntrySchema(version int) []byte {
+	switch version {
+	case 1:
+		return bytes.Clone(entrySchemaV1)
+	case 2:
+		return bytes.Clone(entrySchemaV2)
+	}
+	return nil
+}
+
+// entryV2 is an Entry as written in schema version 2: it carries the version
+// and its depth, and the hash enricher's result is a field of its own.
+type entryV2 struct {
+	SchemaVersion int `json:"schemaVersion"`
+	Entry
+	Depth int    `json:"depth"`
+	Hash  string `json:"hash,omitempty"`
+}
+
+// versioned returns the value JSON output encodes for e.
+func (c *Config) versioned(e Entry) any {
+	if c.SchemaVersion < 2 {
+		return e
+	}
+	v := entryV2{SchemaVersion: 2, Entry: e, Depth: e.Depth}
+	if sum, ok := e.Extra["sha256"].(string); ok {
+		v.Hash = "sha256:" + sum
+		v.Extra = maps.Clone(e.Extra)
+		delete(v.Extra, "sha256")
+		if len(v.Extra) == 0 {
+			v.Extra = nil
+		}
+	}
+	return v
+}
+
+// depth returns the number of levels path is below c.Root, or 0 when it is
+// not below it.
+func (c *Config) depth(path string) int {
+	rel, err := filepath.Rel(c.Root, path)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return 0
+	}
+	return strings.Count(rel, string(filepath.Separator)) + 1
+}
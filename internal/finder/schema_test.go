@@ -0,0 +1,107 @@
+package finder
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEntrySchemaMatchesEntry(t *testing.T) {
+	for version, typ := range map[int]reflect.Type{1: reflect.TypeFor[Entry](), 2: reflect.TypeFor[entryV2]()} {
+		var s struct {
+			ID         string                     `json:"$id"`
+			Properties map[string]json.RawMessage `json:"properties"`
+		}
+		if err := json.Unmarshal(EntrySchema(version), &s); err != nil {
+			t.Fatalf("v%d: %v", version, err)
+		}
+		want := map[string]bool{}
+		var fields func(reflect.Type)
+		fields = func(rt reflect.Type) {
+			if rt.Kind() == reflect.Pointer {
+				rt = rt.Elem()
+			}
+			for i := range rt.NumField() {
+				f := rt.Field(i)
+				if f.Anonymous {
+					fields(f.Type)
+					continue
+				}
+				if name, _, _ := strings.Cut(f.Tag.Get("json"), ","); name != "-" {
+					want[name] = true
+				}
+			}
+		}
+		fields(typ)
+		for name := range want {
+			if s.Properties[name] == nil {
+				t.Errorf("v%d: field %q missing from the schema", version, name)
+			}
+		}
+		for name := range s.Properties {
+			if !want[name] {
+				t.Errorf("v%d: schema property %q is not a field", version, name)
+			}
+		}
+		if !strings.Contains(s.ID, fmt.Sprintf("/v%d/", version)) {
+			t.Errorf("v%d: $id %q does not carry the version", version, s.ID)
+		}
+	}
+	if EntrySchema(LatestSchemaVersion+1) != nil {
+		t.Error("schema of an unknown version")
+	}
+}
+
+func TestSchemaVersion2(t *testing.T) {
+	td := t.TempDir()
+	mk(t, td, "a.txt", 1, time.Time{})
+	mk(t, td, "sub/b.txt", 1, time.Time{})
+
+	cfg := Config{Root: td, MaxDepth: -1, OutputFormat: OutputNDJSON, Enrichers: []Enricher{hashEnricher{}}}
+	run := func() map[string]map[string]any {
+		t.Helper()
+		var out strings.Builder
+		if _, err := Run(context.Background(), &out, cfg); err != nil {
+			t.Fatal(err)
+		}
+		recs := map[string]map[string]any{}
+		for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+			var r map[string]any
+			if err := json.Unmarshal([]byte(line), &r); err != nil {
+				t.Fatalf("%v: %q", err, line)
+			}
+			rel, _ := filepath.Rel(td, r["path"].(string))
+			recs[filepath.ToSlash(rel)] = r
+		}
+		return recs
+	}
+
+	// Version 1 keeps the original shape.
+	for path, r := range run() {
+		if _, ok := r["schemaVersion"]; ok {
+			t.Errorf("v1 %s: %v", path, r)
+		}
+		if _, ok := r["depth"]; ok {
+			t.Errorf("v1 %s: %v", path, r)
+		}
+	}
+
+	cfg.SchemaVersion = 2
+	recs := run()
+	if r := recs["a.txt"]; r["schemaVersion"] != 2.0 || r["depth"] != 1.0 || !strings.HasPrefix(fmt.Sprint(r["hash"]), "sha256:") || r["extra"] != nil {
+		t.Errorf("v2 a.txt: %v", r)
+	}
+	if r := recs["sub/b.txt"]; r["depth"] != 2.0 {
+		t.Errorf("v2 sub/b.txt: %v", r)
+	}
+
+	cfg.SchemaVersion = LatestSchemaVersion + 1
+	if _, err := Run(context.Background(), &strings.Builder{}, cfg); err == nil {
+		t.Error("unknown schema version accepted")
+	}
+}
@@ -0,0 +1,32 @@
+//go:build linux
+
+package finder
+
+import (
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// readSecurity reports whether path carries a POSIX ACL (access or default)
+// and returns its SELinux context, if any. When follow is false the
+// attributes of a symlink itself are read.
+func readSecurity(path string, follow bool) (hasACL bool, selinux string) {
+	get := unix.Lgetxattr
+	if follow {
+		get = unix.Getxattr
+	}
+	for _, name := range []string{"system.posix_acl_access", "system.posix_acl_default"} {
+		if sz, err := get(path, name, nil); err == nil && sz > 0 {
+			hasACL = true
+			break
+		}
+	}
+	if sz, err := get(path, "security.selinux", nil); err == nil && sz > 0 {
+		buf := make([]byte, sz)
+		if sz, err = get(path, "security.selinux", buf); err == nil {
+			selinux = strings.TrimRight(string(buf[:sz]), "\x00")
+		}
+	}
+	return hasACL, selinux
+}
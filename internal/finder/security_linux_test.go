@@ -0,0 +1,56 @@
+//go:build linux
+
+package finder
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// posixACL encodes a minimal access ACL with a named-user entry, so that
+// the kernel stores it instead of folding it into the mode bits.
+func posixACL() []byte {
+	type ent struct {
+		tag, perm uint16
+		id        uint32
+	}
+	ents := []ent{
+		{0x01, 6, 0xffffffff}, // ACL_USER_OBJ rw-
+		{0x02, 4, 12345},      // ACL_USER r--
+		{0x04, 4, 0xffffffff}, // ACL_GROUP_OBJ r--
+		{0x10, 4, 0xffffffff}, // ACL_MASK r--
+		{0x20, 4, 0xffffffff}, // ACL_OTHER r--
+	}
+	b := binary.LittleEndian.AppendUint32(nil, 2) // POSIX_ACL_XATTR_VERSION
+	for _, e := range ents {
+		b = binary.LittleEndian.AppendUint16(b, e.tag)
+		b = binary.LittleEndian.AppendUint16(b, e.perm)
+		b = binary.LittleEndian.AppendUint32(b, e.id)
+	}
+	return b
+}
+
+func TestHasACLFilter(t *testing.T) {
+	td := t.TempDir()
+	withACL := mk(t, td, "acl.txt", 1, time.Now())
+	mk(t, td, "plain.txt", 1, time.Now())
+	if err := unix.Setxattr(withACL, "system.posix_acl_access", posixACL(), 0); err != nil {
+		t.Skipf("POSIX ACLs not supported here: %v", err)
+	}
+
+	var out bytes.Buffer
+	cfg := Config{Root: td, OutputFormat: OutputJSON, HasACL: true, ShowSecurity: true}
+	if _, err := Run(context.Background(), &out, cfg); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	got := collectJSON(t, &out)
+	if len(got) != 1 || filepath.Base(got[0].Path) != "acl.txt" || !got[0].HasACL {
+		t.Fatalf("expected only acl.txt with hasAcl, got %+v", got)
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !linux
+
+package finder
+
+// readSecurity is only implemented on Linux; elsewhere entries report no ACL
+// and no SELinux context.
+func readSecurity(_ string, _ bool) (hasACL bool, selinux string) {
+	return false, ""
+}
@@ -0,0 +1,107 @@
+package finder
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+	"time"
+)
+
+// dirSlots bounds how many directories are read at once.
+type dirSlots interface {
+	// acquire waits for a slot for a directory last modified at mod,
+	// reporting false if ctx ended first.
+	acquire(ctx context.Context, mod time.Time) bool
+	release()
+}
+
+func newDirSlots(cfg *Config) dirSlots {
+	if cfg.RecentFirst {
+		return &recentSlots{free: cfg.Concurrency}
+	}
+	return make(chanSlots, cfg.Concurrency)
+}
+
+// chanSlots hands out slots in no particular order.
+type chanSlots chan struct{}
+
+func (s chanSlots) acquire(ctx context.Context, _ time.Time) bool {
+	select {
+	case s <- struct{}{}:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (s chanSlots) release() { <-s }
+
+// recentSlots hands a freed slot to the waiting directory modified most
+// recently, for Config.RecentFirst.
+type recentSlots struct {
+	mu      sync.Mutex
+	free    int
+	waiting slotQueue
+}
+
+type slotWaiter struct {
+	mod time.Time
+	// ready is closed when the slot is granted.
+	ready             chan struct{}
+	granted, canceled bool
+}
+
+func (s *recentSlots) acquire(ctx context.Context, mod time.Time) bool {
+	s.mu.Lock()
+	if s.free > 0 {
+		s.free--
+		s.mu.Unlock()
+		return true
+	}
+	w := &slotWaiter{mod: mod, ready: make(chan struct{})}
+	heap.Push(&s.waiting, w)
+	s.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		return true
+	case <-ctx.Done():
+	}
+	s.mu.Lock()
+	granted := w.granted
+	w.canceled = true
+	s.mu.Unlock()
+	if granted {
+		s.release() // pass on the slot that raced with cancellation
+	}
+	return false
+}
+
+func (s *recentSlots) release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for s.waiting.Len() > 0 {
+		w := heap.Pop(&s.waiting).(*slotWaiter)
+		if !w.canceled {
+			w.granted = true
+			close(w.ready)
+			return
+		}
+	}
+	s.free++
+}
+
+// slotQueue is a heap of waiters, newest modification time first.
+type slotQueue []*slotWaiter
+
+func (q slotQueue) Len() int           { return len(q) }
+func (q slotQueue) Less(i, j int) bool { return q[i].mod.After(q[j].mod) }
+func (q slotQueue) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+func (q *slotQueue) Push(x any)        { *q = append(*q, x.(*slotWaiter)) }
+func (q *slotQueue) Pop() any {
+	old := *q
+	w := old[len(old)-1]
+	old[len(old)-1] = nil
+	*q = old[:len(old)-1]
+	return w
+}
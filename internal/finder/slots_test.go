@@ -0,0 +1,57 @@
+package finder
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRecentSlots(t *testing.T) {
+	s := newDirSlots(&Config{Concurrency: 1, RecentFirst: true}).(*recentSlots)
+	ctx := context.Background()
+	if !s.acquire(ctx, time.Time{}) {
+		t.Fatal("no free slot")
+	}
+
+	base := time.Now()
+	order := make(chan int, 3)
+	canceled, cancel := context.WithCancel(ctx)
+	cancel() // this waiter gives up its place
+	waiters := map[int]context.Context{1: ctx, 3: ctx, 2: canceled}
+	for age, wctx := range waiters {
+		go func() {
+			if s.acquire(wctx, base.Add(-time.Duration(age)*time.Hour)) {
+				order <- age
+			}
+		}()
+	}
+	// Wait for all three to queue, and the canceled one to give up.
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(time.Millisecond) {
+		s.mu.Lock()
+		queued, gaveUp := s.waiting.Len(), 0
+		for _, w := range s.waiting {
+			if w.canceled {
+				gaveUp++
+			}
+		}
+		s.mu.Unlock()
+		if queued == 3 && gaveUp == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d waiters queued, %d canceled", queued, gaveUp)
+		}
+	}
+
+	// Each release hands the slot to the newest waiter left.
+	for _, want := range []int{1, 3} {
+		s.release()
+		if got := <-order; got != want {
+			t.Fatalf("slot went to the directory %dh old, want %dh", got, want)
+		}
+	}
+	s.release()
+	if s.free != 1 {
+		t.Fatalf("free = %d after the last release, want 1", s.free)
+	}
+}
@@ -0,0 +1,42 @@
+//go:build !windows
+
+package finder
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSparseFilter(t *testing.T) {
+	td := t.TempDir()
+	mk(t, td, "dense.bin", 64<<10, time.Now())
+	sparsePath := filepath.Join(td, "sparse.bin")
+	f, err := os.Create(sparsePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Truncate(8 << 20); err != nil {
+		t.Fatal(err)
+	}
+	_ = f.Close()
+
+	var out bytes.Buffer
+	cfg := Config{Root: td, OutputFormat: OutputJSON, Sparse: true, ShowAllocated: true}
+	if _, err := Run(context.Background(), &out, cfg); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	got := collectJSON(t, &out)
+	if len(got) == 0 {
+		t.Skip("filesystem does not create sparse files")
+	}
+	if len(got) != 1 || got[0].Name != "sparse.bin" {
+		t.Fatalf("expected only sparse.bin, got %+v", got)
+	}
+	if got[0].AllocatedSize >= got[0].Size {
+		t.Fatalf("allocatedSize %d should be below size %d", got[0].AllocatedSize, got[0].Size)
+	}
+}
@@ -0,0 +1,45 @@
+package finder
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// spotlightQuery translates the filters Spotlight can evaluate into an mdfind
+// query string. It only narrows the candidate set: every hit is still run
+// through matches, so filters without an equivalent (NameRegex) are skipped.
+func spotlightQuery(cfg *Config) string {
+	var terms []string
+	if len(cfg.Extensions) > 0 {
+		exts := make([]string, 0, len(cfg.Extensions))
+		for e := range cfg.Extensions {
+			exts = append(exts, e)
+		}
+		sort.Strings(exts)
+		alts := make([]string, 0, len(exts))
+		for _, e := range exts {
+			alts = append(alts, fmt.Sprintf(`kMDItemFSName == "*%s"c`, mdEscape(e)))
+		}
+		// Directories are not subject to the extension filter.
+		alts = append(alts, `kMDItemContentType == "public.folder"`)
+		terms = append(terms, "("+strings.Join(alts, " || ")+")")
+	}
+	if !cfg.After.IsZero() {
+		terms = append(terms, "kMDItemFSContentChangeDate >= $time.iso("+cfg.After.UTC().Format(time.RFC3339)+")")
+	}
+	if !cfg.Before.IsZero() {
+		terms = append(terms, "kMDItemFSContentChangeDate <= $time.iso("+cfg.Before.UTC().Format(time.RFC3339)+")")
+	}
+	if len(terms) == 0 {
+		return `kMDItemFSName == "*"`
+	}
+	return strings.Join(terms, " && ")
+}
+
+// mdEscape escapes characters that are special inside a quoted mdfind value.
+func mdEscape(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, `*`, `\*`, `?`, `\?`)
+	return r.Replace(s)
+}
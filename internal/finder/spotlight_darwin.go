@@ -0,0 +1,54 @@
+//go:build darwin
+
+package finder
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"os/exec"
+	"path/filepath"
+)
+
+// scanSpotlight runs mdfind restricted to cfg.Root and visits each reported
+// path. If mdfind cannot be started the walker is used instead.
+func scanSpotlight(ctx context.Context, cfg *Config, visit func(indexHit)) error {
+	root, err := filepath.Abs(cfg.Root)
+	if err != nil {
+		return errBackendUnavailable
+	}
+	cmd := exec.CommandContext(ctx, "mdfind", "-0", "-onlyin", root, spotlightQuery(cfg))
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return errBackendUnavailable
+	}
+	if err := cmd.Start(); err != nil {
+		return errBackendUnavailable
+	}
+
+	sc := bufio.NewScanner(stdout)
+	sc.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	sc.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+		if i := bytes.IndexByte(data, 0); i >= 0 {
+			return i + 1, data[:i], nil
+		}
+		if atEOF && len(data) > 0 {
+			return len(data), data, nil
+		}
+		return 0, nil, nil
+	})
+	for sc.Scan() {
+		if h, ok := hitFor(cfg, root, sc.Text()); ok {
+			visit(h)
+		}
+	}
+	scanErr := sc.Err()
+	if err := cmd.Wait(); err != nil {
+		if ctx.Err() != nil {
+			// mdfind was killed before it was done.
+			return ctx.Err()
+		}
+		return err
+	}
+	return scanErr
+}
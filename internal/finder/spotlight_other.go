@@ -0,0 +1,10 @@
+//go:build !darwin
+
+package finder
+
+import "context"
+
+// scanSpotlight is only implemented on macOS; elsewhere the walker is used.
+func scanSpotlight(_ context.Context, _ *Config, _ func(indexHit)) error {
+	return errBackendUnavailable
+}
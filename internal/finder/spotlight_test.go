@@ -0,0 +1,47 @@
+package finder
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSpotlightQuery(t *testing.T) {
+	if got := spotlightQuery(&Config{}); got != `kMDItemFSName == "*"` {
+		t.Fatalf("empty config: got %q", got)
+	}
+
+	cfg := &Config{
+		Extensions: map[string]bool{".md": true, ".go": true},
+		After:      time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+	got := spotlightQuery(cfg)
+	for _, want := range []string{
+		`kMDItemFSName == "*.go"c || kMDItemFSName == "*.md"c`,
+		`kMDItemFSContentChangeDate >= $time.iso(2024-01-02T03:04:05Z)`,
+		" && ",
+	} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("query %q missing %q", got, want)
+		}
+	}
+}
+
+func TestHitFor(t *testing.T) {
+	cfg := &Config{Root: "rel"}
+	abs := filepath.FromSlash("/abs/rel")
+	h, ok := hitFor(cfg, abs, filepath.FromSlash("/abs/rel/.cache/x/y.txt"))
+	if !ok || h.depth != 2 || !h.inHidden || h.hidden || h.path != filepath.FromSlash("rel/.cache/x/y.txt") {
+		t.Fatalf("unexpected hit: %+v ok=%v", h, ok)
+	}
+	if _, ok := hitFor(cfg, abs, filepath.FromSlash("/abs/other/z")); ok {
+		t.Fatalf("paths outside root must be rejected")
+	}
+	// Names starting with ".." are still below the root.
+	for _, name := range []string{"..foo", "..."} {
+		if h, ok := hitFor(cfg, abs, filepath.Join(abs, name)); !ok || h.path != filepath.Join("rel", name) {
+			t.Fatalf("%s: unexpected hit: %+v ok=%v", name, h, ok)
+		}
+	}
+}
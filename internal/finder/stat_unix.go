@@ -7,10 +7,6 @@ import (
 	"syscall"
 )
 
-// Keep a package-level reference so linters don't mark this as unused on Unix.
-// Remove this once the walker calls statFromFileInfo directly.
-var _ = statFromFileInfo
-
 // statFromFileInfo extracts inode and device numbers from a FileInfo on Unix.
 // Returns ok=false if syscall.Stat_t is not available.
 func statFromFileInfo(info fs.FileInfo) (inode, dev uint64, ok bool) {
@@ -20,3 +16,26 @@ func statFromFileInfo(info fs.FileInfo) (inode, dev uint64, ok bool) {
 	}
 	return uint64(st.Ino), uint64(st.Dev), true
 }
+
+// fileID returns the inode and device numbers of the file described by info.
+func fileID(_ string, info fs.FileInfo) (ino, dev uint64, ok bool) {
+	return statFromFileInfo(info)
+}
+
+// allocatedSize returns the bytes actually allocated on disk (st_blocks*512).
+func allocatedSize(info fs.FileInfo) (int64, bool) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok || st == nil {
+		return 0, false
+	}
+	return int64(st.Blocks) * 512, true
+}
+
+// ownerID returns the uid owning info.
+func ownerID(info fs.FileInfo) (uint32, bool) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok || st == nil {
+		return 0, false
+	}
+	return st.Uid, true
+}
@@ -2,12 +2,46 @@
 
 package finder
 
-import "io/fs"
+import (
+	"io/fs"
+	"syscall"
+)
 
-// Windows doesn't expose Unix inode/dev semantics the same way.
-// Return ok=false so callers can skip inode/dev-only paths.
-//
-//nolint:unused // referenced once we wire inode/dev-based logic; keep shim compiled on Unix
+// Windows FileInfo doesn't expose Unix inode/dev semantics; see fileID.
+// Return ok=false so callers fall back to paths.
 func statFromFileInfo(info fs.FileInfo) (inode, dev uint64, ok bool) {
 	return 0, 0, false
 }
+
+// allocatedSize is not derived from FileInfo on Windows.
+func allocatedSize(_ fs.FileInfo) (int64, bool) {
+	return 0, false
+}
+
+// ownerID is not derived from FileInfo on Windows.
+func ownerID(_ fs.FileInfo) (uint32, bool) {
+	return 0, false
+}
+
+// fileID returns the volume serial number and file index of path, which
+// identify a file like dev/ino do on Unix. FileInfo from os.Stat does not
+// carry them, so the file is opened (directories need
+// FILE_FLAG_BACKUP_SEMANTICS).
+func fileID(path string, _ fs.FileInfo) (ino, dev uint64, ok bool) {
+	p, err := syscall.UTF16PtrFromString(sysPath(path))
+	if err != nil {
+		return 0, 0, false
+	}
+	h, err := syscall.CreateFile(p, 0,
+		syscall.FILE_SHARE_READ|syscall.FILE_SHARE_WRITE|syscall.FILE_SHARE_DELETE,
+		nil, syscall.OPEN_EXISTING, syscall.FILE_FLAG_BACKUP_SEMANTICS, 0)
+	if err != nil {
+		return 0, 0, false
+	}
+	defer syscall.CloseHandle(h)
+	var d syscall.ByHandleFileInformation
+	if err := syscall.GetFileInformationByHandle(h, &d); err != nil {
+		return 0, 0, false
+	}
+	return uint64(d.FileIndexHigh)<<32 | uint64(d.FileIndexLow), uint64(d.VolumeSerialNumber), true
+}
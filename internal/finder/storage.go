@@ -0,0 +1,67 @@
+package finder
+
+import "runtime"
+
+// Storage is the kind of device a tree is stored on, as far as it matters for
+// how many directories to read at once.
+type Storage int
+
+const (
+	// StorageUnknown is a device that could not be told apart, or a
+	// platform without detection.
+	StorageUnknown Storage = iota
+	// StorageRotational is a spinning disk, where parallel reads mostly add
+	// seeks.
+	StorageRotational
+	// StorageSolidState is an SSD, NVMe drive or memory-backed filesystem,
+	// which serves many reads in parallel.
+	StorageSolidState
+	// StorageNetwork is a network filesystem (NFS, SMB, ...), where each
+	// read waits on a round trip.
+	StorageNetwork
+)
+
+func (s Storage) String() string {
+	switch s {
+	case StorageRotational:
+		return "rotational"
+	case StorageSolidState:
+		return "ssd"
+	case StorageNetwork:
+		return "network"
+	}
+	return "unknown"
+}
+
+// networkFSTypes are the filesystem types, as fsTypeName reports them, that
+// are served over the network.
+var networkFSTypes = map[string]bool{
+	"nfs": true, "cifs": true, "smb2": true, "smbfs": true, "afpfs": true, "webdav": true,
+}
+
+// DetectStorage probes the device holding path. It returns StorageUnknown
+// when it cannot tell, e.g. for btrfs, overlay and FUSE filesystems on Linux,
+// local volumes on Windows, and on other platforms.
+func DetectStorage(path string) Storage {
+	if networkFSTypes[NormalizeFSType(fsTypeName(path))] {
+		return StorageNetwork
+	}
+	return detectStorage(path)
+}
+
+// AutoConcurrency picks a Concurrency for a tree on storage s: two workers
+// for a spinning disk, which thrashes with more, twice the CPUs (at least 8,
+// at most 64) for solid state, 16 for a network filesystem, whose latency
+// rather than the CPUs bounds it, and the CPU count when s is unknown.
+func AutoConcurrency(s Storage) int {
+	n := runtime.NumCPU()
+	switch s {
+	case StorageRotational:
+		return 2
+	case StorageSolidState:
+		return min(max(2*n, 8), 64)
+	case StorageNetwork:
+		return 16
+	}
+	return n
+}
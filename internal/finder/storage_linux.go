@@ -0,0 +1,42 @@
+//go:build linux
+
+package finder
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// detectStorage reads whether the block device holding path rotates from
+// sysfs. Filesystems without a block device of their own (btrfs and ZFS
+// volumes, overlay, FUSE) are unknown. Virtual disks often claim to rotate
+// whatever backs them.
+func detectStorage(path string) Storage {
+	switch NormalizeFSType(fsTypeName(path)) {
+	case "tmpfs", "ramfs":
+		return StorageSolidState
+	}
+	var st unix.Stat_t
+	if err := unix.Stat(path, &st); err != nil {
+		return StorageUnknown
+	}
+	dev := fmt.Sprintf("/sys/dev/block/%d:%d", unix.Major(st.Dev), unix.Minor(st.Dev))
+	// A partition has no queue of its own; its disk, the parent in sysfs,
+	// does.
+	for _, queue := range []string{dev + "/queue", dev + "/../queue"} {
+		b, err := os.ReadFile(queue + "/rotational")
+		if err != nil {
+			continue
+		}
+		switch strings.TrimSpace(string(b)) {
+		case "1":
+			return StorageRotational
+		case "0":
+			return StorageSolidState
+		}
+	}
+	return StorageUnknown
+}
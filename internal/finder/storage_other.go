@@ -0,0 +1,9 @@
+//go:build !linux && !windows
+
+package finder
+
+// detectStorage cannot tell local devices apart on this platform; network
+// filesystems are recognized by DetectStorage from their type.
+func detectStorage(_ string) Storage {
+	return StorageUnknown
+}
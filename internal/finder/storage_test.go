@@ -0,0 +1,35 @@
+package finder
+
+import (
+	"runtime"
+	"testing"
+)
+
+func TestAutoConcurrency(t *testing.T) {
+	if n := AutoConcurrency(StorageRotational); n != 2 {
+		t.Errorf("rotational: %d workers, want 2", n)
+	}
+	if n := AutoConcurrency(StorageSolidState); n < 8 || n > 64 || n < min(2*runtime.NumCPU(), 64) {
+		t.Errorf("ssd: %d workers for %d CPUs", n, runtime.NumCPU())
+	}
+	if n := AutoConcurrency(StorageNetwork); n != 16 {
+		t.Errorf("network: %d workers, want 16", n)
+	}
+	if n := AutoConcurrency(StorageUnknown); n != runtime.NumCPU() {
+		t.Errorf("unknown: %d workers, want NumCPU", n)
+	}
+}
+
+func TestDetectStorage(t *testing.T) {
+	// Whatever the disk is, detection must not fail outright or call a
+	// missing path anything but unknown.
+	t.Logf("temp dir is on %s storage", DetectStorage(t.TempDir()))
+	if s := DetectStorage("/does/not/exist"); s != StorageUnknown {
+		t.Errorf("missing path: %s, want unknown", s)
+	}
+	if runtime.GOOS == "linux" && fsTypeName("/dev/shm") == "tmpfs" {
+		if s := DetectStorage("/dev/shm"); s != StorageSolidState {
+			t.Errorf("/dev/shm (tmpfs): %s, want ssd", s)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+//go:build windows
+
+package finder
+
+import (
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/sys/windows"
+)
+
+// detectStorage tells network shares apart from local volumes; whether a
+// local volume rotates is not detected.
+func detectStorage(path string) Storage {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return StorageUnknown
+	}
+	vol := filepath.VolumeName(abs)
+	switch {
+	case strings.HasPrefix(vol, `\\?\UNC\`):
+		return StorageNetwork
+	case strings.HasPrefix(vol, `\\?\`), strings.HasPrefix(vol, `\\.\`):
+		vol = vol[4:] // \\?\C: is drive C:
+	case strings.HasPrefix(vol, `\\`):
+		return StorageNetwork // \\server\share
+	}
+	root, err := windows.UTF16PtrFromString(vol + `\`)
+	if err != nil {
+		return StorageUnknown
+	}
+	if windows.GetDriveType(root) == windows.DRIVE_REMOTE {
+		return StorageNetwork
+	}
+	return StorageUnknown
+}
@@ -0,0 +1,64 @@
+package finder
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSymlinkEntries(t *testing.T) {
+	td := t.TempDir()
+	old := time.Now().Add(-48 * time.Hour).Truncate(time.Second)
+	target := mk(t, td, "target.bin", 100, old)
+	if err := os.Symlink(target, filepath.Join(td, "link")); err != nil {
+		t.Skipf("symlink not permitted on this system: %v", err)
+	}
+	link, err := os.Lstat(filepath.Join(td, "link"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	walk := func(cfg Config) map[string]Entry {
+		t.Helper()
+		cfg.Root, cfg.MaxDepth = td, -1
+		got := map[string]Entry{}
+		if _, err := Walk(context.Background(), cfg, func(e Entry) error {
+			got[e.Name] = e
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+		return got
+	}
+
+	for _, follow := range []bool{false, true} {
+		got := walk(Config{FollowSymlinks: follow})
+		e := got["link"]
+		if !e.IsSymlink || got["target.bin"].IsSymlink {
+			t.Errorf("follow=%v: IsSymlink: link %v, target %v", follow, e.IsSymlink, got["target.bin"].IsSymlink)
+		}
+		if e.Size != link.Size() || !e.ModTime.Equal(link.ModTime()) {
+			t.Errorf("follow=%v: link reports %d bytes at %v, want the link's own", follow, e.Size, e.ModTime)
+		}
+
+		e = walk(Config{FollowSymlinks: follow, DereferenceOutput: true})["link"]
+		if e.Size != 100 || !e.ModTime.Equal(old) {
+			t.Errorf("follow=%v dereferenced: link reports %d bytes at %v, want the target's", follow, e.Size, e.ModTime)
+		}
+
+		got = walk(Config{FollowSymlinks: follow, Types: map[string]bool{"symlink": true}})
+		if len(got) != 1 || !got["link"].IsSymlink {
+			t.Errorf("follow=%v: symlink type matched %v", follow, got)
+		}
+		got = walk(Config{FollowSymlinks: follow, Types: map[string]bool{"file": true}})
+		if len(got) != 1 || got["target.bin"].Name == "" {
+			t.Errorf("follow=%v: file type matched %v", follow, got)
+		}
+	}
+
+	if _, err := Walk(context.Background(), Config{Root: td, Types: map[string]bool{"socket": true}}, func(Entry) error { return nil }); err == nil {
+		t.Error("unknown type accepted")
+	}
+}
@@ -0,0 +1,28 @@
+package finder
+
+import "context"
+
+// Tracer receives spans around the stages of a search, so a service
+// embedding the finder can see where the time goes: the whole scan
+// ("gofind.scan"), each directory read and filtered ("gofind.dir") and the
+// enrichment of each entry ("gofind.enrich"). Build with -tags otel for an
+// OpenTelemetry implementation (OTelTracer).
+type Tracer interface {
+	// Start begins a span as a child of any span in ctx, returning the
+	// context to pass to nested work and the function that ends the span.
+	Start(ctx context.Context, name string, attrs ...Attr) (context.Context, func(err error))
+}
+
+// Attr is a span attribute; Value is a string, bool, int, int64 or float64.
+type Attr struct {
+	Key   string
+	Value any
+}
+
+// span starts a span with c.Tracer, if set.
+func (c *Config) span(ctx context.Context, name string, attrs ...Attr) (context.Context, func(error)) {
+	if c.Tracer == nil {
+		return ctx, func(error) {}
+	}
+	return c.Tracer.Start(ctx, name, attrs...)
+}
@@ -0,0 +1,46 @@
+//go:build otel
+
+package finder
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// OTelTracer returns a Tracer recording spans with t, e.g.
+// otel.Tracer("gofind").
+func OTelTracer(t trace.Tracer) Tracer { return otelTracer{t} }
+
+type otelTracer struct{ t trace.Tracer }
+
+func (o otelTracer) Start(ctx context.Context, name string, attrs ...Attr) (context.Context, func(error)) {
+	kv := make([]attribute.KeyValue, 0, len(attrs))
+	for _, a := range attrs {
+		switch v := a.Value.(type) {
+		case string:
+			kv = append(kv, attribute.String(a.Key, v))
+		case bool:
+			kv = append(kv, attribute.Bool(a.Key, v))
+		case int:
+			kv = append(kv, attribute.Int(a.Key, v))
+		case int64:
+			kv = append(kv, attribute.Int64(a.Key, v))
+		case float64:
+			kv = append(kv, attribute.Float64(a.Key, v))
+		default:
+			kv = append(kv, attribute.String(a.Key, fmt.Sprint(v)))
+		}
+	}
+	ctx, span := o.t.Start(ctx, name, trace.WithAttributes(kv...))
+	return ctx, func(err error) {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}
+}
@@ -0,0 +1,51 @@
+package finder
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+type recordingTracer struct {
+	mu    sync.Mutex
+	spans map[string]int
+	errs  int
+}
+
+func (r *recordingTracer) Start(ctx context.Context, name string, _ ...Attr) (context.Context, func(error)) {
+	return ctx, func(err error) {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.spans[name]++
+		if err != nil {
+			r.errs++
+		}
+	}
+}
+
+func TestTracerSpans(t *testing.T) {
+	td := t.TempDir()
+	mk(t, td, "a.txt", 1, time.Now())
+	mk(t, td, "sub/b.txt", 1, time.Now())
+
+	rt := &recordingTracer{spans: map[string]int{}}
+	failing := EnricherFunc(func(_ context.Context, e *Entry) error {
+		if e.Name == "b.txt" {
+			return errors.New("boom")
+		}
+		return nil
+	})
+	cfg := Config{Root: td, MaxDepth: -1, Tracer: rt, Enrichers: []Enricher{failing}}
+	if _, err := Walk(context.Background(), cfg, func(Entry) error { return nil }); err != nil {
+		t.Fatalf("walk: %v", err)
+	}
+	// One scan, two directories, three matched entries (a.txt, sub, sub/b.txt).
+	if rt.spans["gofind.scan"] != 1 || rt.spans["gofind.dir"] != 2 || rt.spans["gofind.enrich"] != 3 {
+		t.Fatalf("spans = %v", rt.spans)
+	}
+	if rt.errs != 1 {
+		t.Fatalf("%d spans ended with an error, want 1", rt.errs)
+	}
+}
@@ -0,0 +1,123 @@
+package finder
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"time"
+)
+
+// ErrVanished wraps the error of an entry that disappeared between being
+// listed and being examined, usually because it was deleted during the walk.
+// Such entries are reported in Result.Transient rather than Result.Errors.
+var ErrVanished = errors.New("vanished during the walk")
+
+// retryDelay is the pause before the first retry of a transient failure; it
+// doubles with every further attempt, up to maxRetryDelay.
+const (
+	retryDelay    = 10 * time.Millisecond
+	maxRetryDelay = time.Second
+)
+
+// withRetry calls op until it succeeds, fails permanently, c.RetryTransient
+// retries are used up, or ctx is done, which returns the last failure.
+// Timeouts count as transient. A missing file counts as transient only when
+// notExist is set: a broken symlink target stays missing.
+func withRetry[T any](ctx context.Context, c *Config, notExist bool, op func() (T, error)) (T, error) {
+	v, err := op()
+	delay := retryDelay
+	for i := 0; i < c.RetryTransient && err != nil && isTransient(err, notExist); i++ {
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return v, err
+		case <-timer.C:
+		}
+		delay = min(2*delay, maxRetryDelay)
+		v, err = op()
+	}
+	return v, err
+}
+
+func isTransient(err error, notExist bool) bool {
+	return notExist && errors.Is(err, fs.ErrNotExist) ||
+		errors.Is(err, os.ErrDeadlineExceeded) || isTransientErrno(err)
+}
+
+// The walker's filesystem operations, with timeouts and retries. Without
+// either, the stats skip the closures, which would cost an allocation per
+// entry.
+
+func (c *Config) lstat(ctx context.Context, p string) (fs.FileInfo, error) {
+	if c.RetryTransient == 0 && c.StatTimeout <= 0 {
+		return lstat(p)
+	}
+	return withRetry(ctx, c, true, func() (fs.FileInfo, error) {
+		return withTimeout(c.StatTimeout, "lstat", p, func() (fs.FileInfo, error) { return lstat(p) })
+	})
+}
+
+func (c *Config) stat(ctx context.Context, p string) (fs.FileInfo, error) {
+	if c.RetryTransient == 0 && c.StatTimeout <= 0 {
+		return stat(p)
+	}
+	return withRetry(ctx, c, false, func() (fs.FileInfo, error) {
+		return withTimeout(c.StatTimeout, "stat", p, func() (fs.FileInfo, error) { return stat(p) })
+	})
+}
+
+// readDir holds a handle from the c.openDirs budget for each attempt. A
+// timed-out read gives its handle back before the system call returns.
+func (c *Config) readDir(ctx context.Context, p string) ([]fs.DirEntry, error) {
+	return withRetry(ctx, c, true, func() ([]fs.DirEntry, error) {
+		return c.openDirs.hold(func() ([]fs.DirEntry, error) {
+			return withTimeout(c.ReadDirTimeout, "readdir", p, func() ([]fs.DirEntry, error) { return readDir(p) })
+		})
+	})
+}
+
+// withTimeout runs op, giving up after d (if positive) with a PathError
+// wrapping os.ErrDeadlineExceeded. A system call cannot be interrupted, so
+// op keeps running in the background until the filesystem answers; the walker
+// just stops waiting for it.
+func withTimeout[T any](d time.Duration, op, path string, fn func() (T, error)) (T, error) {
+	if d <= 0 {
+		return fn()
+	}
+	type result struct {
+		v   T
+		err error
+	}
+	ch := make(chan result, 1)
+	go func() {
+		v, err := fn()
+		ch <- result{v, err}
+	}()
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case r := <-ch:
+		return r.v, r.err
+	case <-timer.C:
+		var zero T
+		return zero, &fs.PathError{Op: op, Path: path, Err: os.ErrDeadlineExceeded}
+	}
+}
+
+// vanish records path as transient when err says it no longer exists, and
+// as a failure otherwise.
+func (t *tally) vanish(op, path string, err error) {
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.fail(op, path, err)
+		return
+	}
+	pe := &fs.PathError{Op: op, Path: path, Err: errors.Join(ErrVanished, err)}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.transientCount++
+	if len(t.transient) < maxRecordedErrors {
+		t.transient = append(t.transient, pe)
+	}
+}
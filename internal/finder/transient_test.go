@@ -0,0 +1,124 @@
+package finder
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWithRetry(t *testing.T) {
+	calls := 0
+	flaky := func() (int, error) {
+		if calls++; calls < 3 {
+			return 0, fs.ErrNotExist
+		}
+		return 42, nil
+	}
+
+	if _, err := withRetry(context.Background(), &Config{}, true, flaky); !errors.Is(err, fs.ErrNotExist) || calls != 1 {
+		t.Fatalf("no retries: err=%v calls=%d", err, calls)
+	}
+	calls = 0
+	if v, err := withRetry(context.Background(), &Config{RetryTransient: 2}, true, flaky); err != nil || v != 42 || calls != 3 {
+		t.Fatalf("two retries: v=%d err=%v calls=%d", v, err, calls)
+	}
+	// A missing symlink target is not worth retrying.
+	calls = 0
+	if _, err := withRetry(context.Background(), &Config{RetryTransient: 2}, false, flaky); err == nil || calls != 1 {
+		t.Fatalf("notExist=false: err=%v calls=%d", err, calls)
+	}
+}
+
+func TestWithRetryCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	c := &Config{RetryTransient: 1000}
+	start := time.Now()
+	// A missing entry is retried as if it might reappear.
+	_, err := c.lstat(ctx, filepath.Join(t.TempDir(), "gone"))
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("err = %v, want the last failure", err)
+	}
+	if d := time.Since(start); d > 5*time.Second {
+		t.Fatalf("cancellation took %v to stop the retries", d)
+	}
+}
+
+func TestWithTimeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	hung := func() (int, error) {
+		<-release
+		return 1, nil
+	}
+	_, err := withTimeout(10*time.Millisecond, "stat", "/mnt/nfs/x", hung)
+	var pe *fs.PathError
+	if !errors.As(err, &pe) || pe.Path != "/mnt/nfs/x" || !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Fatalf("err = %v, want a PathError wrapping os.ErrDeadlineExceeded", err)
+	}
+	if !isTransient(err, false) {
+		t.Fatal("timeouts should be retried")
+	}
+	if v, err := withTimeout(time.Second, "stat", "x", func() (int, error) { return 7, nil }); v != 7 || err != nil {
+		t.Fatalf("fast op: %d, %v", v, err)
+	}
+}
+
+func TestTallyVanish(t *testing.T) {
+	var reported int
+	tl := newTally(context.Background(), &Config{OnError: func(*fs.PathError) { reported++ }})
+	tl.vanish("lstat", "gone", &fs.PathError{Op: "lstat", Path: "gone", Err: fs.ErrNotExist})
+	tl.vanish("lstat", "denied", &fs.PathError{Op: "lstat", Path: "denied", Err: fs.ErrPermission})
+
+	res := tl.result()
+	if res.TransientCount != 1 || len(res.Transient) != 1 || res.Transient[0].Path != "gone" {
+		t.Fatalf("Transient = %v (%d)", res.Transient, res.TransientCount)
+	}
+	if !errors.Is(res.Transient[0], ErrVanished) || !errors.Is(res.Transient[0], fs.ErrNotExist) {
+		t.Fatalf("transient error %v should wrap ErrVanished and fs.ErrNotExist", res.Transient[0])
+	}
+	if res.ErrorCount != 1 || reported != 1 || res.Errors[0].Path != "denied" {
+		t.Fatalf("errors = %v, OnError calls = %d", res.Errors, reported)
+	}
+}
+
+func TestMissingRootIsAnError(t *testing.T) {
+	td := t.TempDir()
+	root := filepath.Join(td, "missing")
+	_, err := Walk(context.Background(), Config{Root: root, MaxDepth: -1}, func(Entry) error { return nil })
+	var re *RootError
+	if !errors.As(err, &re) || re.Root != root || !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("walk: %v, want a RootError for %s", err, root)
+	}
+	if !strings.Contains(err.Error(), "does not exist") {
+		t.Errorf("error %q does not say the root is missing", err)
+	}
+
+	file := filepath.Join(td, "file")
+	if err := os.WriteFile(file, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, err = Walk(context.Background(), Config{Root: file}, func(Entry) error { return nil })
+	if !errors.As(err, &re) || !strings.Contains(err.Error(), "not a directory") {
+		t.Fatalf("walk of a file: %v", err)
+	}
+
+	// SkipMissingRoots searches the roots that exist, and only forgives
+	// missing ones.
+	mk(t, td, "here/a.txt", 1, time.Time{})
+	cfg := Config{Root: td, Roots: []string{root, filepath.Join(td, "here")}, MaxDepth: -1, SkipMissingRoots: true}
+	var n int
+	res, err := Walk(context.Background(), cfg, func(Entry) error { n++; return nil })
+	if err != nil || n != 1 || res.ErrorCount != 0 || len(res.SkippedRoots) != 1 || res.SkippedRoots[0] != root {
+		t.Fatalf("skip missing: err=%v matched=%d errors=%d skipped=%v", err, n, res.ErrorCount, res.SkippedRoots)
+	}
+	cfg.Roots = []string{file}
+	if _, err := Walk(context.Background(), cfg, func(Entry) error { return nil }); err == nil {
+		t.Fatal("SkipMissingRoots skipped a root that is not a directory")
+	}
+}
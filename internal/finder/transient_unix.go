@@ -0,0 +1,21 @@
+//go:build !windows
+
+package finder
+
+import (
+	"errors"
+	"syscall"
+)
+
+// isTransientErrno reports errors a network filesystem may clear on retry.
+func isTransientErrno(err error) bool {
+	var errno syscall.Errno
+	if !errors.As(err, &errno) {
+		return false
+	}
+	switch errno {
+	case syscall.ESTALE, syscall.EIO, syscall.EAGAIN, syscall.EINTR, syscall.ETIMEDOUT:
+		return true
+	}
+	return false
+}
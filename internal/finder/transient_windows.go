@@ -0,0 +1,29 @@
+//go:build windows
+
+package finder
+
+import (
+	"errors"
+	"syscall"
+)
+
+// Win32 errors raised by flaky SMB connections.
+const (
+	errorUnexpNetErr     syscall.Errno = 59
+	errorNetnameDeleted  syscall.Errno = 64
+	errorSemTimeout      syscall.Errno = 121
+	errorSharingViolated syscall.Errno = 32
+)
+
+// isTransientErrno reports errors a network filesystem may clear on retry.
+func isTransientErrno(err error) bool {
+	var errno syscall.Errno
+	if !errors.As(err, &errno) {
+		return false
+	}
+	switch errno {
+	case errorUnexpNetErr, errorNetnameDeleted, errorSemTimeout, errorSharingViolated:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,18 @@
+package finder
+
+import "golang.org/x/text/unicode/norm"
+
+// NFC returns s in Unicode normalization form C. macOS stores file names
+// decomposed (NFD), so "café" typed on a keyboard (NFC) only matches them
+// after both sides are normalized; see Config.NormalizeUnicode.
+func NFC(s string) string {
+	return norm.NFC.String(s)
+}
+
+// matchName returns name prepared for name filters.
+func (c *Config) matchName(name string) string {
+	if c.NormalizeUnicode {
+		return NFC(name)
+	}
+	return name
+}
@@ -0,0 +1,41 @@
+package finder
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestWalk_NormalizeUnicode(t *testing.T) {
+	td := t.TempDir()
+	mk(t, td, "café.txt", 1, time.Now()) // NFD, as macOS stores it
+	mk(t, td, "other.txt", 1, time.Now())
+
+	count := func(cfg Config) int {
+		n := 0
+		if _, err := Walk(context.Background(), cfg, func(Entry) error {
+			n++
+			return nil
+		}); err != nil {
+			t.Fatalf("walk: %v", err)
+		}
+		return n
+	}
+	cfg := Config{Root: td, MaxDepth: -1, NameRegex: regexp.MustCompile("^café")}
+	if n := count(cfg); n != 0 {
+		t.Fatalf("matched %d without normalization, want 0", n)
+	}
+	cfg.NormalizeUnicode = true
+	if n := count(cfg); n != 1 {
+		t.Fatalf("matched %d with normalization, want 1", n)
+	}
+
+	x, err := CompileWhere("name == \"café.txt\"")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := count(Config{Root: td, MaxDepth: -1, Where: x, NormalizeUnicode: true}); n != 1 {
+		t.Fatalf("--where matched %d with normalization, want 1", n)
+	}
+}
@@ -0,0 +1,36 @@
+package finder
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWalk_DeliversEntriesAndStopsOnError(t *testing.T) {
+	td := t.TempDir()
+	for _, rel := range []string{"a.txt", "b.txt", "sub/c.txt"} {
+		mk(t, td, rel, 1, time.Now())
+	}
+
+	var n int
+	if _, err := Walk(context.Background(), Config{Root: td, MaxDepth: -1}, func(Entry) error {
+		n++
+		return nil
+	}); err != nil {
+		t.Fatalf("walk: %v", err)
+	}
+	if n != 4 { // three files plus sub/
+		t.Fatalf("expected 4 entries, got %d", n)
+	}
+
+	stop := errors.New("stop")
+	n = 0
+	_, err := Walk(context.Background(), Config{Root: td, MaxDepth: -1}, func(Entry) error {
+		n++
+		return stop
+	})
+	if !errors.Is(err, stop) || n != 1 {
+		t.Fatalf("expected callback error after one entry; err=%v n=%d", err, n)
+	}
+}
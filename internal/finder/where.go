@@ -0,0 +1,287 @@
+package finder
+
+import (
+	"errors"
+	"io/fs"
+	"maps"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Hamed0406/gofind/internal/expr"
+)
+
+// extraPrefix selects Entry.Extra values in a Where expression and in
+// Config.Fields.
+const extraPrefix = "extra."
+
+// whereSchema lists the Entry fields a Where expression may use. type is
+// "file", "dir", "symlink" or "other"; ext is the lowercase extension with
+// its dot; rel is the slash-separated path below the search root.
+var whereSchema = expr.Schema{
+	Fields: map[string]expr.Kind{
+		"name":  expr.String,
+		"path":  expr.String,
+		"rel":   expr.String,
+		"ext":   expr.String,
+		"size":  expr.Number,
+		"mtime": expr.Time,
+		"type":  expr.String,
+		"isDir": expr.Bool,
+	},
+	DynamicPrefix: extraPrefix,
+}
+
+// CompileWhere compiles a filter expression over entry fields for
+// Config.Where, e.g. `size > 10MB && ext in (".log", ".gz")`. Fields under
+// "extra." refer to values added by Config.Enrichers. now() is the time of
+// the call.
+func CompileWhere(src string) (*expr.Expr, error) {
+	return expr.Compile(src, whereSchema, time.Now())
+}
+
+// The filter flags are shorthand for Where terms: ExtWhere and the
+// functions below build them, nil for a flag not set, so they combine with
+// --where through expr.And into one expression. validate turns those at the
+// top of Config.Where back into the fields they stand for, which the walk
+// checks faster, index backends can use and Explain names; see lowerWhere.
+
+// flagTerm notes a term of a filter flag: it sets its Config field, unless
+// that is already set, and reports whether it did.
+type flagTerm func(c *Config) bool
+
+// flagWhere returns x, a term of whereSchema, noted with lower.
+func flagWhere(x *expr.Expr, err error, lower flagTerm) *expr.Expr {
+	if err != nil {
+		panic(err) // the fields and their kinds are fixed
+	}
+	return expr.Annotate(x, lower)
+}
+
+// filesOnly returns "isDir || x", for the filters that apply to files.
+func filesOnly(x *expr.Expr, err error) (*expr.Expr, error) {
+	isDir, derr := expr.Comparison(whereSchema, "isDir", "==", expr.BoolValue(true))
+	return expr.Or(isDir, x), errors.Join(err, derr)
+}
+
+// ExtWhere returns the term of Config.Extensions, `isDir || ext in (...)`.
+func ExtWhere(exts map[string]bool) *expr.Expr {
+	var vs []expr.Value
+	for _, e := range slices.Sorted(maps.Keys(exts)) {
+		if exts[e] {
+			vs = append(vs, expr.StringValue(e))
+		}
+	}
+	if len(vs) == 0 {
+		return nil
+	}
+	x, err := filesOnly(expr.In(whereSchema, "ext", vs...))
+	return flagWhere(x, err, func(c *Config) bool {
+		if len(c.Extensions) > 0 {
+			return false
+		}
+		c.Extensions = exts
+		return true
+	})
+}
+
+// NameWhere returns the term of Config.NameRegex, `name =~ re`.
+func NameWhere(re *regexp.Regexp) *expr.Expr {
+	if re == nil {
+		return nil
+	}
+	x, err := expr.MatchRegexp(whereSchema, "name", re)
+	return flagWhere(x, err, func(c *Config) bool {
+		if c.NameRegex != nil {
+			return false
+		}
+		c.NameRegex = re
+		return true
+	})
+}
+
+// MinSizeWhere returns the term of Config.MinSize, `isDir || size >= n`.
+func MinSizeWhere(n int64) *expr.Expr {
+	if n <= 0 {
+		return nil
+	}
+	x, err := filesOnly(expr.Comparison(whereSchema, "size", ">=", expr.NumberValue(float64(n))))
+	return flagWhere(x, err, func(c *Config) bool {
+		if c.MinSize > 0 {
+			return false
+		}
+		c.MinSize = n
+		return true
+	})
+}
+
+// MaxSizeWhere returns the term of Config.MaxSize, `isDir || size <= n`.
+func MaxSizeWhere(n int64) *expr.Expr {
+	if n <= 0 {
+		return nil
+	}
+	x, err := filesOnly(expr.Comparison(whereSchema, "size", "<=", expr.NumberValue(float64(n))))
+	return flagWhere(x, err, func(c *Config) bool {
+		if c.MaxSize > 0 {
+			return false
+		}
+		c.MaxSize = n
+		return true
+	})
+}
+
+// AfterWhere returns the term of Config.After, `mtime >= t`.
+func AfterWhere(t time.Time) *expr.Expr {
+	if t.IsZero() {
+		return nil
+	}
+	x, err := expr.Comparison(whereSchema, "mtime", ">=", expr.TimeValue(t))
+	return flagWhere(x, err, func(c *Config) bool {
+		if !c.After.IsZero() {
+			return false
+		}
+		c.After = t
+		return true
+	})
+}
+
+// BeforeWhere returns the term of Config.Before, `mtime <= t`.
+func BeforeWhere(t time.Time) *expr.Expr {
+	if t.IsZero() {
+		return nil
+	}
+	x, err := expr.Comparison(whereSchema, "mtime", "<=", expr.TimeValue(t))
+	return flagWhere(x, err, func(c *Config) bool {
+		if !c.Before.IsZero() {
+			return false
+		}
+		c.Before = t
+		return true
+	})
+}
+
+// TypeWhere returns the term of Config.Types, `type in (...)`.
+func TypeWhere(types map[string]bool) *expr.Expr {
+	var vs []expr.Value
+	for _, t := range slices.Sorted(maps.Keys(types)) {
+		if types[t] {
+			vs = append(vs, expr.StringValue(t))
+		}
+	}
+	if len(vs) == 0 {
+		return nil
+	}
+	x, err := expr.In(whereSchema, "type", vs...)
+	return flagWhere(x, err, func(c *Config) bool {
+		if len(c.Types) > 0 {
+			return false
+		}
+		c.Types = types
+		return true
+	})
+}
+
+// lowerWhere moves the terms of c.Where built by ExtWhere and the like into
+// their Config fields. A term whose field is already set stays, and is
+// evaluated with the rest of the expression.
+func (c *Config) lowerWhere() {
+	if c.Where == nil {
+		return
+	}
+	var rest []*expr.Expr
+	for _, x := range c.Where.Terms() {
+		if lower, ok := x.Note().(flagTerm); ok && lower(c) {
+			continue
+		}
+		rest = append(rest, x)
+	}
+	c.Where = expr.And(rest...)
+}
+
+// usesExtra reports whether x refers to enrichment results and so can only be
+// evaluated after the enrichers ran.
+func usesExtra(x *expr.Expr) bool {
+	for _, f := range x.Fields() {
+		if strings.HasPrefix(f, extraPrefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchWhere evaluates cfg.Where against e.
+func matchWhere(cfg *Config, e *Entry) bool {
+	return cfg.Where.Match(func(field string) expr.Value {
+		return entryField(cfg.Root, e, field, cfg.matchName)
+	})
+}
+
+// WhereField returns the kind of a field Where expressions may use, such as
+// "size" or "extra.lines", and whether there is such a field.
+func WhereField(name string) (expr.Kind, bool) {
+	if k, ok := whereSchema.Fields[name]; ok {
+		return k, true
+	}
+	if key, ok := strings.CutPrefix(name, extraPrefix); ok && key != "" {
+		return expr.Any, true
+	}
+	return expr.Invalid, false
+}
+
+// EntryField returns the value of a WhereField of e, an entry found below
+// root. Names are returned as found, without Config.NormalizeUnicode.
+func EntryField(root string, e *Entry, field string) expr.Value {
+	return entryField(root, e, field, func(s string) string { return s })
+}
+
+// entryField returns field of e, with the names and paths passed through
+// norm.
+func entryField(root string, e *Entry, field string, norm func(string) string) expr.Value {
+	switch field {
+	case "name":
+		return expr.StringValue(norm(e.Name))
+	case "path":
+		return expr.StringValue(norm(e.Path))
+	case "rel":
+		rel, err := filepath.Rel(root, e.Path)
+		if err != nil {
+			return expr.Value{}
+		}
+		return expr.StringValue(norm(filepath.ToSlash(rel)))
+	case "ext":
+		return expr.StringValue(stringsToLower(filepath.Ext(norm(e.Name))))
+	case "size":
+		return expr.NumberValue(float64(e.Size))
+	case "mtime":
+		return expr.TimeValue(e.ModTime)
+	case "type":
+		return expr.StringValue(entryType(e))
+	case "isDir":
+		return expr.BoolValue(e.IsDir)
+	}
+	if v, ok := e.Extra[strings.TrimPrefix(field, extraPrefix)]; ok {
+		return expr.ValueOf(v)
+	}
+	return expr.Value{}
+}
+
+// entryTypes are the types entryType returns.
+var entryTypes = []string{"file", "dir", "symlink", "other"}
+
+// entryType classifies e for the type field and Config.Types. A symlink is
+// "symlink" even when it was followed.
+func entryType(e *Entry) string {
+	switch m := e.Mode; {
+	case e.IsSymlink:
+		return "symlink"
+	case m.IsDir():
+		return "dir"
+	case m&fs.ModeSymlink != 0:
+		return "symlink"
+	case m.IsRegular():
+		return "file"
+	}
+	return "other"
+}
@@ -0,0 +1,136 @@
+package finder
+
+import (
+	"context"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/Hamed0406/gofind/internal/expr"
+)
+
+func TestWalk_Where(t *testing.T) {
+	td := t.TempDir()
+	old := time.Now().Add(-60 * 24 * time.Hour)
+	big := mk(t, td, "big.log", 4096, old)
+	mk(t, td, "small.log", 10, old)
+	mk(t, td, "new.log", 4096, time.Now())
+	gz := mk(t, td, "sub/archive.gz", 8192, old)
+
+	x, err := CompileWhere(`size > 1KB && ext in (".log", ".gz") && mtime < now() - 30d`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	res, err := Walk(context.Background(), Config{Root: td, MaxDepth: -1, Where: x}, func(e Entry) error {
+		got = append(got, e.Path)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walk: %v", err)
+	}
+	sort.Strings(got)
+	if want := []string{big, gz}; !slices.Equal(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if res.Matched != 2 {
+		t.Fatalf("matched %d, want 2", res.Matched)
+	}
+
+	ex, err := Explain(Config{Root: td, MaxDepth: -1, Where: x}, filepath.Join(td, "small.log"))
+	if err != nil || ex.Included || ex.Rule != "where" {
+		t.Fatalf("explain: %+v, %v", ex, err)
+	}
+}
+
+func TestFlagWhere(t *testing.T) {
+	td := t.TempDir()
+	old := time.Now().Add(-60 * 24 * time.Hour)
+	mk(t, td, "big.LOG", 4096, old)
+	mk(t, td, "small.log", 10, old)
+	mk(t, td, "new.log", 4096, time.Now())
+	mk(t, td, "sub/big.txt", 4096, old)
+	mk(t, td, "sub/old.log", 2048, old)
+	mk(t, td, "old-tiny.log", 10, old)
+
+	walk := func(cfg Config) []string {
+		t.Helper()
+		var got []string
+		cfg.Root, cfg.MaxDepth = td, -1
+		if _, err := Walk(context.Background(), cfg, func(e Entry) error {
+			got = append(got, e.Path)
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+		sort.Strings(got)
+		return got
+	}
+	exts := map[string]bool{".log": true}
+	cutoff := time.Now().Add(-30 * 24 * time.Hour)
+	re := regexp.MustCompile(`^(big|old)`)
+	flags := expr.And(ExtWhere(exts), NameWhere(re), MinSizeWhere(1024), MaxSizeWhere(4096),
+		BeforeWhere(cutoff), AfterWhere(old.Add(-time.Hour)), TypeWhere(map[string]bool{"file": true, "dir": true}))
+
+	want := walk(Config{Extensions: exts, NameRegex: re, MinSize: 1024, MaxSize: 4096,
+		Before: cutoff, After: old.Add(-time.Hour), Types: map[string]bool{"file": true, "dir": true}})
+	if len(want) != 2 {
+		t.Fatalf("native filters found %v", want)
+	}
+	// The terms are turned back into the fields, or evaluated as an
+	// expression when nested, with the same result.
+	if got := walk(Config{Where: flags}); !slices.Equal(got, want) {
+		t.Errorf("lowered terms found %v, want %v", got, want)
+	}
+	if got := walk(Config{Where: expr.Not(expr.Not(flags))}); !slices.Equal(got, want) {
+		t.Errorf("nested terms found %v, want %v", got, want)
+	}
+
+	cfg := Config{Root: td, Where: flags}
+	if err := cfg.validate(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Where != nil || cfg.MinSize != 1024 || cfg.NameRegex != re {
+		t.Errorf("validate left Where %v, MinSize %d, NameRegex %v", cfg.Where, cfg.MinSize, cfg.NameRegex)
+	}
+	ex, err := Explain(Config{Root: td, Where: flags}, filepath.Join(td, "old-tiny.log"))
+	if err != nil || ex.Rule != "min-size" {
+		t.Errorf("explain: %+v, %v; want the min-size rule", ex, err)
+	}
+	// A term whose field is set stays in the expression.
+	cfg = Config{Root: td, MinSize: 1, Where: MinSizeWhere(1024)}
+	if err := cfg.validate(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Where == nil || cfg.MinSize != 1 {
+		t.Errorf("validate lowered a term over a set field")
+	}
+}
+
+func TestWalk_WhereOverEnrichedFields(t *testing.T) {
+	td := t.TempDir()
+	keep := mk(t, td, "a.txt", 30, time.Now())
+	mk(t, td, "b.txt", 3, time.Now())
+	sizeTwice := EnricherFunc(func(_ context.Context, e *Entry) error {
+		e.SetExtra("double", e.Size*2)
+		return nil
+	})
+	x, err := CompileWhere(`extra.double > 10`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	res, err := Walk(context.Background(), Config{Root: td, MaxDepth: -1, Where: x, Enrichers: []Enricher{sizeTwice}}, func(e Entry) error {
+		got = append(got, e.Path)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walk: %v", err)
+	}
+	if !slices.Equal(got, []string{keep}) || res.Matched != 1 {
+		t.Fatalf("got %v (matched %d), want [%s]", got, res.Matched, keep)
+	}
+}
@@ -0,0 +1,358 @@
+package finder
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// recordSeparator starts every record of RFC 7464 JSON text sequences.
+const recordSeparator = 0x1E
+
+// ErrOutputTruncated wraps the error of a failed write to the output. What
+// was written before is incomplete (a JSON array is left unterminated) and
+// must not be trusted.
+var ErrOutputTruncated = errors.New("output truncated")
+
+// RecordWriter may be implemented by the io.Writer passed to Run, or a
+// Sink's Writer, to receive
+// whole records together with the entry they encode, e.g. to split the output
+// into several files at record boundaries. For text, NDJSON and JSON
+// sequence output every record goes through WriteRecord and Write is never
+// called; JSON array output ignores the interface. Meta records
+// (Config.EmitMeta) come with a zero Entry.
+type RecordWriter interface {
+	WriteRecord(e Entry, record []byte) error
+}
+
+// Sink is an additional output of Run, written in its own format alongside
+// the main one, e.g. NDJSON to a file while text goes to the terminal.
+type Sink struct {
+	Writer io.Writer
+	Format OutputFormat
+	// Pretty indents JSON output, like Config.PrettyJSON.
+	Pretty bool
+}
+
+// startWriter launches the writer goroutine that drains entryCh into out
+// using cfg.OutputFormat, and into each of cfg.Sinks, framed by the records of
+// meta unless it is nil. The returned function
+// blocks until entryCh has been closed and drained, and reports the first
+// write/encode error of each output (if any); a failed write takes
+// precedence.
+//
+// An entry that cannot be encoded is skipped and reported, and the output
+// stays well-formed. Once a write fails nothing more is written to that
+// output and the error wraps ErrOutputTruncated; the other outputs carry on.
+// Cancellation closes entryCh like a normal end of the search, so JSON output
+// is terminated.
+func startWriter(out io.Writer, cfg *Config, entryCh <-chan Entry, meta *runMeta) func() error {
+	sinks := append([]Sink{{Writer: out, Format: cfg.OutputFormat, Pretty: cfg.PrettyJSON}}, cfg.Sinks...)
+	done := make(chan error, 1)
+	if len(sinks) == 1 {
+		go func() { done <- writeEntries(sinks[0], cfg, entryCh, meta) }()
+		return func() error { return <-done }
+	}
+
+	// Fan entries out to one goroutine per sink.
+	chans := make([]chan Entry, len(sinks))
+	errs := make([]error, len(sinks))
+	var wg sync.WaitGroup
+	for i, s := range sinks {
+		chans[i] = make(chan Entry, cap(entryCh))
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = writeEntries(s, cfg, chans[i], meta)
+		}()
+	}
+	go func() {
+		for e := range entryCh {
+			for _, ch := range chans {
+				ch <- e
+			}
+		}
+		for _, ch := range chans {
+			close(ch)
+		}
+		wg.Wait()
+		done <- errors.Join(errs...)
+	}()
+	return func() error { return <-done }
+}
+
+// WriteEntries writes entries to w as Run writes its matches: in
+// cfg.OutputFormat, with the path style, quoting, schema and fields of cfg.
+func WriteEntries(w io.Writer, cfg Config, entries []Entry) error {
+	ch := make(chan Entry, len(entries))
+	for _, e := range entries {
+		ch <- e
+	}
+	close(ch)
+	return writeEntries(Sink{Writer: w, Format: cfg.OutputFormat, Pretty: cfg.PrettyJSON}, &cfg, ch, nil)
+}
+
+// writeEntries drains entryCh into s and returns its first error. With meta,
+// JSON output is framed by its start and end records.
+func writeEntries(s Sink, cfg *Config, entryCh <-chan Entry, meta *runMeta) error {
+	w := &entryWriter{out: s.Writer}
+	switch s.Format {
+	case OutputJSON:
+		w.write([]byte("["))
+		first := true
+		element := func(b []byte) {
+			switch {
+			case !first && s.Pretty:
+				w.write([]byte(",\n"), b)
+			case !first:
+				w.write([]byte(","), b)
+			case s.Pretty:
+				w.write([]byte("\n"), b)
+			default:
+				w.write(b)
+			}
+			first = false
+		}
+		marshal := func(v any) ([]byte, error) {
+			if s.Pretty {
+				return json.MarshalIndent(v, "  ", "  ")
+			}
+			return json.Marshal(v)
+		}
+		if meta != nil {
+			if b, ok := w.encodeMeta(meta.start, marshal); ok {
+				element(b)
+			}
+		}
+		for e := range entryCh {
+			e.Path = cfg.outputPath(e.Path)
+			if b, ok := w.encode(e, func(e Entry) ([]byte, error) {
+				v, err := cfg.record(e)
+				if err != nil {
+					return nil, err
+				}
+				return marshal(v)
+			}); ok {
+				element(b)
+			}
+		}
+		if meta != nil {
+			if b, ok := w.encodeMeta(meta.end(), marshal); ok {
+				element(b)
+			}
+		}
+		if s.Pretty {
+			w.write([]byte("\n]"))
+		} else {
+			w.write([]byte("]"))
+		}
+	case OutputNDJSON, OutputJSONSeq:
+		// RFC 7464: each record is RS, a JSON text and a newline.
+		seq := s.Format == OutputJSONSeq
+		line := func(v any) ([]byte, error) { return encodeLine(v, seq, s.Pretty) }
+		if meta != nil {
+			if b, ok := w.encodeMeta(meta.start, line); ok {
+				w.record(Entry{}, b)
+			}
+		}
+		for e := range entryCh {
+			e.Path = cfg.outputPath(e.Path)
+			if b, ok := w.encode(e, func(e Entry) ([]byte, error) {
+				v, err := cfg.record(e)
+				if err != nil {
+					return nil, err
+				}
+				return line(v)
+			}); ok {
+				w.record(e, b)
+			}
+		}
+		if meta != nil {
+			if b, ok := w.encodeMeta(meta.end(), line); ok {
+				w.record(Entry{}, b)
+			}
+		}
+	default:
+		appendText := cfg.appendText
+		switch {
+		case cfg.HashFormat != HashFormatNone:
+			appendText = cfg.appendChecksum
+		case cfg.MatchLines:
+			appendText = (&grepText{cfg: cfg}).append
+		}
+		if _, ok := s.Writer.(RecordWriter); ok {
+			for e := range entryCh {
+				e.Path = cfg.outputPath(e.Path)
+				if b := appendText(nil, e); len(b) > 0 {
+					w.record(e, b)
+				}
+			}
+			break
+		}
+		// Lines are appended to one buffer and written through a pooled
+		// bufio.Writer, flushed whenever the search has nothing more
+		// waiting, so a slow search still streams its output.
+		bw := textWriters.Get().(*bufio.Writer)
+		bw.Reset(s.Writer)
+		w.out = bw
+		var line []byte
+		for e := range entryCh {
+			e.Path = cfg.outputPath(e.Path)
+			line = appendText(line[:0], e)
+			w.write(line)
+			if len(entryCh) == 0 {
+				w.flush(bw)
+			}
+		}
+		w.flush(bw)
+		bw.Reset(nil)
+		textWriters.Put(bw)
+	}
+	return w.err
+}
+
+// textWriters holds the buffers of text output.
+var textWriters = sync.Pool{New: func() any { return bufio.NewWriterSize(nil, 64<<10) }}
+
+// changePrefix marks the lines of text output against a Baseline, like a
+// diff.
+var changePrefix = map[string]string{ChangeAdded: "+ ", ChangeRemoved: "- ", ChangeChanged: "~ "}
+
+// DefaultTimeLayout formats modification times in text output with
+// Config.Human.
+const DefaultTimeLayout = "2006-01-02 15:04"
+
+// appendText appends the line of text output for e to b.
+func (c *Config) appendText(b []byte, e Entry) []byte {
+	b = append(b, changePrefix[e.Change]...)
+	if !c.Human {
+		b = appendQuoted(b, e.Path, c.Quote)
+		return append(b, '\n')
+	}
+	size := "-"
+	if !e.IsDir {
+		size = humanSize(e.Size)
+	}
+	layout := c.TimeLayout
+	if layout == "" {
+		layout = DefaultTimeLayout
+	}
+	b = fmt.Appendf(b, "%8s  %s  ", size, e.ModTime.Local().Format(layout))
+	b = appendQuoted(b, e.Path, c.Quote)
+	return append(b, '\n')
+}
+
+// humanSize renders n with binary units, e.g. "1.4 MB".
+func humanSize(n int64) string {
+	const unit = 1 << 10
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// entryWriter tracks the first error of a writer goroutine. After a failed
+// write it drops everything, so producers are drained without blocking.
+type entryWriter struct {
+	out    io.Writer
+	err    error
+	broken bool
+}
+
+// encode runs enc on e, recording (and reporting !ok for) a failure.
+func (w *entryWriter) encode(e Entry, enc func(Entry) ([]byte, error)) ([]byte, bool) {
+	if w.broken {
+		return nil, false
+	}
+	b, err := enc(e)
+	if err != nil {
+		if w.err == nil {
+			w.err = fmt.Errorf("encode %s: %w", e.Path, err)
+		}
+		return nil, false
+	}
+	return b, true
+}
+
+// encodeMeta runs enc on m like encode.
+func (w *entryWriter) encodeMeta(m MetaRecord, enc func(any) ([]byte, error)) ([]byte, bool) {
+	if w.broken {
+		return nil, false
+	}
+	b, err := enc(m)
+	if err != nil {
+		if w.err == nil {
+			w.err = fmt.Errorf("encode %s meta record: %w", m.Event, err)
+		}
+		return nil, false
+	}
+	return b, true
+}
+
+// write writes the chunks in order unless an earlier write failed.
+func (w *entryWriter) write(chunks ...[]byte) {
+	for _, b := range chunks {
+		if w.broken || len(b) == 0 {
+			continue
+		}
+		if _, err := w.out.Write(b); err != nil {
+			w.broken = true
+			w.err = fmt.Errorf("%w: %w", ErrOutputTruncated, err)
+		}
+	}
+}
+
+// flush flushes bw, the buffered output, unless an earlier write failed.
+func (w *entryWriter) flush(bw *bufio.Writer) {
+	if w.broken {
+		return
+	}
+	if err := bw.Flush(); err != nil {
+		w.broken = true
+		w.err = fmt.Errorf("%w: %w", ErrOutputTruncated, err)
+	}
+}
+
+// record writes the encoding b of e, through WriteRecord when the output
+// implements RecordWriter.
+func (w *entryWriter) record(e Entry, b []byte) {
+	rw, ok := w.out.(RecordWriter)
+	if !ok {
+		w.write(b)
+		return
+	}
+	if w.broken {
+		return
+	}
+	if err := rw.WriteRecord(e, b); err != nil {
+		w.broken = true
+		w.err = fmt.Errorf("%w: %w", ErrOutputTruncated, err)
+	}
+}
+
+// encodeLine encodes v as one JSON value followed by a newline, without
+// escaping HTML characters, and prefixed with RS when seq is set.
+func encodeLine(v any, seq, pretty bool) ([]byte, error) {
+	var buf bytes.Buffer
+	if seq {
+		buf.WriteByte(recordSeparator)
+	}
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if pretty {
+		enc.SetIndent("", "  ")
+	}
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,35 @@
+package finder
+
+import (
+	"errors"
+	"strings"
+)
+
+// XAttrFilter requires an extended attribute to be present, optionally with
+// an exact value.
+type XAttrFilter struct {
+	Name  string
+	Value string
+	// HasValue distinguishes "name=" (must be empty) from a bare "name" (any value).
+	HasValue bool
+}
+
+// ParseXAttrFilter parses "name" or "name=value".
+func ParseXAttrFilter(s string) (XAttrFilter, error) {
+	name, value, hasValue := strings.Cut(strings.TrimSpace(s), "=")
+	if name == "" {
+		return XAttrFilter{}, errors.New("attribute name is required")
+	}
+	return XAttrFilter{Name: name, Value: value, HasValue: hasValue}, nil
+}
+
+// matchXAttrs reports whether attrs satisfies every filter.
+func matchXAttrs(filters []XAttrFilter, attrs map[string]string) bool {
+	for _, f := range filters {
+		v, ok := attrs[f.Name]
+		if !ok || (f.HasValue && v != f.Value) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,8 @@
+//go:build !linux && !darwin
+
+package finder
+
+// readXAttrs is unsupported on this platform; entries never carry attributes.
+func readXAttrs(_ string, _ bool) map[string]string {
+	return nil
+}
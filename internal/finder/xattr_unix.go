@@ -0,0 +1,45 @@
+//go:build linux || darwin
+
+package finder
+
+import (
+	"bytes"
+
+	"golang.org/x/sys/unix"
+)
+
+// readXAttrs returns all extended attributes of path. When follow is false the
+// attributes of a symlink itself are read. Errors yield a nil map.
+func readXAttrs(path string, follow bool) map[string]string {
+	list, get := unix.Llistxattr, unix.Lgetxattr
+	if follow {
+		list, get = unix.Listxattr, unix.Getxattr
+	}
+	sz, err := list(path, nil)
+	if err != nil || sz <= 0 {
+		return nil
+	}
+	buf := make([]byte, sz)
+	if sz, err = list(path, buf); err != nil {
+		return nil
+	}
+	attrs := make(map[string]string)
+	for _, name := range bytes.Split(buf[:sz], []byte{0}) {
+		if len(name) == 0 {
+			continue
+		}
+		n := string(name)
+		vsz, err := get(path, n, nil)
+		if err != nil {
+			continue
+		}
+		val := make([]byte, vsz)
+		if vsz > 0 {
+			if vsz, err = get(path, n, val); err != nil {
+				continue
+			}
+		}
+		attrs[n] = string(val[:vsz])
+	}
+	return attrs
+}
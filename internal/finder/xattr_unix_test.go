@@ -0,0 +1,49 @@
+//go:build linux || darwin
+
+package finder
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+func TestXAttrFilterAndOutput(t *testing.T) {
+	td := t.TempDir()
+	tagged := mk(t, td, "tagged.txt", 1, time.Now())
+	mk(t, td, "plain.txt", 1, time.Now())
+	if err := unix.Setxattr(tagged, "user.backup", []byte("skip"), 0); err != nil {
+		t.Skipf("xattrs not supported here: %v", err)
+	}
+
+	run := func(filter string) []Entry {
+		f, err := ParseXAttrFilter(filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out bytes.Buffer
+		cfg := Config{Root: td, OutputFormat: OutputJSON, XAttrs: []XAttrFilter{f}, ShowXAttrs: true}
+		if _, err := Run(context.Background(), &out, cfg); err != nil {
+			t.Fatalf("run: %v", err)
+		}
+		return collectJSON(t, &out)
+	}
+
+	got := run("user.backup=skip")
+	if len(got) != 1 || filepath.Base(got[0].Path) != "tagged.txt" {
+		t.Fatalf("expected only tagged.txt, got %+v", got)
+	}
+	if got[0].XAttrs["user.backup"] != "skip" {
+		t.Fatalf("expected xattrs in output, got %+v", got[0].XAttrs)
+	}
+	if got := run("user.backup"); len(got) != 1 {
+		t.Fatalf("name-only filter: got %+v", got)
+	}
+	if got := run("user.backup=keep"); len(got) != 0 {
+		t.Fatalf("wrong value should not match: %+v", got)
+	}
+}
@@ -0,0 +1,259 @@
+// Package gitstatus reports the Git repository, branch and working-tree
+// status of paths. It shells out to the git CLI once per repository and
+// caches the answer, so looking up every file of a large checkout costs two
+// git invocations.
+package gitstatus
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Status is the working-tree state of a path.
+type Status string
+
+const (
+	// Tracked paths are committed and unchanged.
+	Tracked Status = "tracked"
+	// Modified paths are tracked but changed (or staged) in the working tree.
+	Modified Status = "modified"
+	// Untracked paths are neither tracked nor ignored.
+	Untracked Status = "untracked"
+	// Ignored paths match the repository's ignore rules.
+	Ignored Status = "ignored"
+)
+
+// Info describes a path inside a repository.
+type Info struct {
+	// Repo is the repository's working-tree root.
+	Repo string
+	// Branch is the checked-out branch, or the abbreviated commit when HEAD
+	// is detached.
+	Branch string
+	Status Status
+}
+
+// Cache resolves paths to their repository and status. It is safe for
+// concurrent use. Repositories are read on first use; later changes to the
+// working tree are not seen.
+type Cache struct {
+	mu    sync.Mutex
+	dirs  map[string]*repo // directory -> containing repo (nil = none)
+	repos map[string]*repo // root -> repo
+}
+
+// New returns an empty Cache.
+func New() *Cache {
+	return &Cache{dirs: make(map[string]*repo), repos: make(map[string]*repo)}
+}
+
+type repo struct {
+	root string
+	once sync.Once
+	err  error
+
+	branch string
+	// files maps slash-separated paths relative to root to their status;
+	// tracked files missing from it are clean.
+	files map[string]Status
+	// tracked holds every tracked file and each of its parent directories.
+	tracked map[string]bool
+	// dirs lists collapsed untracked/ignored directories ("build/").
+	dirs []dirStatus
+}
+
+type dirStatus struct {
+	prefix string
+	status Status
+}
+
+// Lookup returns the status of path, which must exist. ok is false when path
+// is not inside a Git working tree (or is inside the .git directory itself).
+// A repository that cannot be read is reported by the first lookup in it;
+// later ones return ok == false.
+func (c *Cache) Lookup(ctx context.Context, path string, isDir bool) (info Info, ok bool, err error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return Info{}, false, err
+	}
+	dir := abs
+	if !isDir {
+		dir = filepath.Dir(abs)
+	}
+	r := c.repoFor(dir)
+	if r == nil {
+		return Info{}, false, nil
+	}
+	loaded := false
+	r.once.Do(func() { r.err, loaded = r.load(ctx), true })
+	if r.err != nil {
+		if loaded {
+			return Info{}, false, r.err
+		}
+		return Info{}, false, nil
+	}
+	rel, err := filepath.Rel(r.root, abs)
+	if err != nil {
+		return Info{}, false, err
+	}
+	rel = filepath.ToSlash(rel)
+	if rel == ".git" || strings.HasPrefix(rel, ".git/") {
+		return Info{}, false, nil
+	}
+	return Info{Repo: r.root, Branch: r.branch, Status: r.status(rel)}, true, nil
+}
+
+// InWorkTree reports whether dir lies inside a Git working tree, judged by a
+// .git entry in dir or one of its ancestors. It does not run git.
+func (c *Cache) InWorkTree(dir string) bool {
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	return c.repoFor(dir) != nil
+}
+
+// repoFor finds the repository containing dir by looking for a .git entry
+// in dir and its ancestors, caching every directory on the way.
+func (c *Cache) repoFor(dir string) *repo {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var walked []string
+	var found *repo
+	for d := dir; ; {
+		if r, ok := c.dirs[d]; ok {
+			found = r
+			break
+		}
+		walked = append(walked, d)
+		if _, err := os.Lstat(filepath.Join(d, ".git")); err == nil {
+			found = c.repos[d]
+			if found == nil {
+				found = &repo{root: d}
+				c.repos[d] = found
+			}
+			break
+		}
+		parent := filepath.Dir(d)
+		if parent == d {
+			break
+		}
+		d = parent
+	}
+	for _, d := range walked {
+		c.dirs[d] = found
+	}
+	return found
+}
+
+func (r *repo) status(rel string) Status {
+	if rel == "." {
+		return Tracked
+	}
+	if s, ok := r.files[rel]; ok {
+		return s
+	}
+	for _, d := range r.dirs {
+		if strings.HasPrefix(rel+"/", d.prefix) {
+			return d.status
+		}
+	}
+	if r.tracked[rel] {
+		return Tracked
+	}
+	// With -uall git lists untracked files rather than their directories,
+	// so a directory holding no tracked file is untracked.
+	return Untracked
+}
+
+// load reads the branch, the tracked files and the working-tree status.
+func (r *repo) load(ctx context.Context) error {
+	if out, err := r.git(ctx, "symbolic-ref", "-q", "--short", "HEAD"); err == nil {
+		r.branch = strings.TrimSpace(string(out))
+	} else if out, err := r.git(ctx, "rev-parse", "--short", "HEAD"); err == nil {
+		r.branch = strings.TrimSpace(string(out))
+	}
+
+	out, err := r.git(ctx, "ls-files", "-z")
+	if err != nil {
+		return err
+	}
+	r.tracked = make(map[string]bool)
+	for _, f := range splitNUL(out) {
+		for p := f; p != "." && !r.tracked[p]; p = parentOf(p) {
+			r.tracked[p] = true
+		}
+	}
+
+	out, err = r.git(ctx, "status", "--porcelain=v1", "-z", "--untracked-files=all", "--ignored=matching")
+	if err != nil {
+		return err
+	}
+	r.files = make(map[string]Status)
+	recs := splitNUL(out)
+	for i := 0; i < len(recs); i++ {
+		rec := recs[i]
+		if len(rec) < 4 {
+			continue
+		}
+		xy, p := rec[:2], rec[3:]
+		var s Status
+		switch xy {
+		case "??":
+			s = Untracked
+		case "!!":
+			s = Ignored
+		default:
+			s = Modified
+			if xy[0] == 'R' || xy[0] == 'C' {
+				i++ // the original path of a rename or copy follows
+			}
+		}
+		if strings.HasSuffix(p, "/") {
+			r.dirs = append(r.dirs, dirStatus{prefix: p, status: s})
+			continue
+		}
+		r.files[p] = s
+	}
+	return nil
+}
+
+func (r *repo) git(ctx context.Context, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-c", "core.quotepath=off"}, args...)...)
+	cmd.Dir = r.root
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		var ee *exec.ExitError
+		if errors.As(err, &ee) {
+			return nil, fmt.Errorf("git %s in %s: %s", args[0], r.root, strings.TrimSpace(stderr.String()))
+		}
+		return nil, err
+	}
+	return out, nil
+}
+
+func splitNUL(b []byte) []string {
+	var out []string
+	for _, f := range bytes.Split(b, []byte{0}) {
+		if len(f) > 0 {
+			out = append(out, string(f))
+		}
+	}
+	return out
+}
+
+// parentOf returns the parent of a slash-separated relative path ("." at the top).
+func parentOf(p string) string {
+	if i := strings.LastIndexByte(p, '/'); i >= 0 {
+		return p[:i]
+	}
+	return "."
+}
@@ -0,0 +1,90 @@
+package gitstatus
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func gitRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	root := t.TempDir()
+	run := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = root
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=t", "GIT_AUTHOR_EMAIL=t@example.com",
+			"GIT_COMMITTER_NAME=t", "GIT_COMMITTER_EMAIL=t@example.com", "GIT_CONFIG_GLOBAL=/dev/null")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	write := func(rel, data string) {
+		t.Helper()
+		p := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	run("init", "-q", "-b", "main")
+	write(".gitignore", "build/\n*.log\n")
+	write("clean.go", "package a\n")
+	write("src/changed.go", "package a\n")
+	run("add", ".")
+	run("commit", "-q", "-m", "init")
+	write("src/changed.go", "package b\n")
+	write("new/untracked.txt", "x")
+	write("build/out.bin", "x")
+	write("debug.log", "x")
+	return root
+}
+
+func TestLookup(t *testing.T) {
+	root := gitRepo(t)
+	c := New()
+	cases := []struct {
+		rel   string
+		isDir bool
+		want  Status
+	}{
+		{"clean.go", false, Tracked},
+		{"src", true, Tracked},
+		{"src/changed.go", false, Modified},
+		{"new", true, Untracked},
+		{"new/untracked.txt", false, Untracked},
+		{"build", true, Ignored},
+		{"build/out.bin", false, Ignored},
+		{"debug.log", false, Ignored},
+	}
+	for _, tc := range cases {
+		info, ok, err := c.Lookup(context.Background(), filepath.Join(root, filepath.FromSlash(tc.rel)), tc.isDir)
+		if err != nil || !ok {
+			t.Fatalf("%s: ok=%v err=%v", tc.rel, ok, err)
+		}
+		if info.Status != tc.want {
+			t.Errorf("%s: status %q, want %q", tc.rel, info.Status, tc.want)
+		}
+		if info.Branch != "main" || info.Repo != root {
+			t.Errorf("%s: repo %q branch %q", tc.rel, info.Repo, info.Branch)
+		}
+	}
+}
+
+func TestLookup_OutsideRepo(t *testing.T) {
+	td := t.TempDir()
+	p := filepath.Join(td, "a.txt")
+	if err := os.WriteFile(p, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, err := New().Lookup(context.Background(), p, false); ok || err != nil {
+		t.Fatalf("ok=%v err=%v, want not in a repository", ok, err)
+	}
+}
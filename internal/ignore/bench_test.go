@@ -0,0 +1,82 @@
+package ignore_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Hamed0406/gofind/internal/ignore"
+)
+
+// benchPatterns is a typical mix of root-level ignore rules.
+var benchPatterns = []string{
+	"node_modules/", "vendor/", ".git/", "dist/", "build/", "target/",
+	"*.log", "*.tmp", "*.o", "*.pyc", "*.class", "*.swp", "*~",
+	"/coverage", "docs/**/*.bak", "**/testdata/*.golden", "cache-*",
+	"!important.log", "tmp/", "*.min.js",
+}
+
+var (
+	millionOnce  sync.Once
+	millionPaths []string
+)
+
+// paths1M returns one million synthetic paths, 1000 directories of 1000 files.
+func paths1M() []string {
+	millionOnce.Do(func() {
+		exts := []string{".go", ".js", ".log", ".md", ".o", ".txt", ".min.js", ".pyc"}
+		millionPaths = make([]string, 0, 1_000_000)
+		for d := 0; d < 1000; d++ {
+			dir := fmt.Sprintf("/src/pkg%d/sub%d/internal", d%37, d)
+			for f := 0; f < 1000; f++ {
+				millionPaths = append(millionPaths, filepath.Join(dir, fmt.Sprintf("file%d%s", f, exts[f%len(exts)])))
+			}
+		}
+	})
+	return millionPaths
+}
+
+// BenchmarkMatchLeaf_1M matches one million paths per iteration, the
+// per-entry work of a million-file walk.
+func BenchmarkMatchLeaf_1M(b *testing.B) {
+	paths := paths1M()
+	m, err := ignore.New(ignore.Config{Root: "/src", Enabled: true, Patterns: benchPatterns})
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, p := range paths {
+			m.MatchLeaf(p, false)
+		}
+	}
+}
+
+// BenchmarkMatchLeaf_1M_Files is like BenchmarkMatchLeaf_1M with the
+// patterns read from a root .gitignore, so every match also resolves the
+// cached per-directory rule chain.
+func BenchmarkMatchLeaf_1M_Files(b *testing.B) {
+	root := b.TempDir()
+	data := []byte(strings.Join(benchPatterns, "\n"))
+	if err := os.WriteFile(filepath.Join(root, ignore.GitIgnoreFile), data, 0o644); err != nil {
+		b.Fatal(err)
+	}
+	src := paths1M()
+	paths := make([]string, len(src))
+	for i, p := range src {
+		paths[i] = root + p
+	}
+	m, err := ignore.New(ignore.Config{Root: root, Enabled: true, Files: []string{ignore.GitIgnoreFile}})
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, p := range paths {
+			m.MatchLeaf(p, false)
+		}
+	}
+}
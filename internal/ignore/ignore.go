@@ -2,15 +2,56 @@
 package ignore
 
 import (
+	"bufio"
+	"errors"
+	"io/fs"
+	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
+	"sync"
+	"sync/atomic"
 )
 
-// Matcher evaluates whether a path should be ignored according to simple patterns.
+// Ignore file names recognized in each directory, listed in increasing
+// precedence (the ripgrep/fd convention).
+const (
+	GitIgnoreFile = ".gitignore"
+	DotIgnoreFile = ".ignore"
+	FdIgnoreFile  = ".fdignore"
+)
+
+// Matcher evaluates whether a path should be ignored according to
+// gitignore-style patterns. It is safe for concurrent use.
 type Matcher struct {
-	enabled  bool
-	root     string
-	patterns []string
+	enabled bool
+	root    string
+	// prefix is the cleaned root plus a separator, to cut relative paths
+	// without filepath.Rel in the common case.
+	prefix string
+	files  []string
+	fold   bool
+
+	global    []rule // from Config.Smart and Config.GlobalFile; lowest precedence
+	whitelist []rule // from Config.Whitelist; overrides everything
+
+	// patterns holds the rules of Config.Patterns plus AddPattern, replaced
+	// copy-on-write so Match never locks.
+	patterns atomic.Pointer[[]rule]
+	pmu      sync.Mutex
+
+	// dirs caches the compiled ignore files of each directory, keyed by the
+	// slash-separated path relative to root ("" for root itself). Every
+	// directory seen gets a node so its files are only read once.
+	mu   sync.RWMutex
+	dirs map[string]*dirNode
+}
+
+// dirNode holds one directory's rules and links to the nearest ancestor that
+// has any, so a lookup yields the whole root-to-leaf chain.
+type dirNode struct {
+	up    *dirNode
+	rules []rule
 }
 
 // Config configures the Matcher.
@@ -19,76 +60,313 @@ type Config struct {
 	Root string
 	// Patterns is a list of glob-like patterns to ignore (e.g., "node_modules/", "*.tmp").
 	Patterns []string
+	// Whitelist lists patterns that are never ignored, like a top-level
+	// "!pattern" that also wins over ignored ancestors: with Patterns
+	// ["vendor/"] and Whitelist ["vendor/patches/**"], vendor/patches is still
+	// searched. Directories leading to a whitelisted path containing a "/" are
+	// traversed (and reported) so the walk can reach it.
+	Whitelist []string
 	// Enabled toggles matching on or off.
 	Enabled bool
+	// Files lists ignore file names (e.g. GitIgnoreFile) read from Root and
+	// every directory below it. Missing files are skipped.
+	Files []string
+	// GlobalFile, when set, is an ignore file applied to every search, with
+	// lower precedence than the files in the tree. Missing files are skipped.
+	GlobalFile string
+	// CaseInsensitive matches patterns regardless of case, as the default
+	// filesystems of Windows and macOS do (see DefaultCaseInsensitive). A
+	// pattern prefixed with "(?-i)" stays case-sensitive, and one prefixed
+	// with "(?i)" is case-insensitive either way.
+	CaseInsensitive bool
+	// Smart adds the built-in set of SmartPatterns (node_modules, .git,
+	// __pycache__, ...) with the lowest precedence, so ignore files can still
+	// re-include them.
+	Smart bool
+}
+
+// DefaultCaseInsensitive reports whether the platform's default filesystem
+// ignores case: true on Windows and macOS, false elsewhere.
+var DefaultCaseInsensitive = runtime.GOOS == "windows" || runtime.GOOS == "darwin"
+
+// GlobalFilePath returns the default global ignore file,
+// $XDG_CONFIG_HOME/gofind/ignore or ~/.config/gofind/ignore. It returns ""
+// when no home directory is known.
+func GlobalFilePath() string {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "gofind", "ignore")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".config", "gofind", "ignore")
 }
 
-// New creates a new Matcher with the provided config.
+// New creates a new Matcher with the provided config, compiling all
+// patterns up front; an invalid pattern in Patterns or Whitelist is an error.
+// The global file and the ignore files of Root are read immediately so errors
+// surface here; those of subdirectories are read on first use and cached.
 func New(cfg Config) (*Matcher, error) {
 	m := &Matcher{
-		enabled:  cfg.Enabled,
-		root:     cfg.Root,
-		patterns: append([]string(nil), cfg.Patterns...),
+		enabled: cfg.Enabled,
+		root:    cfg.Root,
+		files:   append([]string(nil), cfg.Files...),
+		fold:    cfg.CaseInsensitive,
+		dirs:    make(map[string]*dirNode),
+	}
+	if cfg.Root != "" {
+		m.prefix = filepath.Clean(cfg.Root)
+		if !strings.HasSuffix(m.prefix, string(filepath.Separator)) {
+			m.prefix += string(filepath.Separator)
+		}
+	}
+	patterns, err := compileRules(cfg.Patterns, m.fold)
+	if err != nil {
+		return nil, err
+	}
+	m.patterns.Store(&patterns)
+	if m.whitelist, err = compileRules(cfg.Whitelist, m.fold); err != nil {
+		return nil, err
+	}
+	if !cfg.Enabled {
+		return m, nil
 	}
+	if cfg.Smart {
+		m.global = smartRules(m.fold)
+	}
+	if cfg.GlobalFile != "" {
+		ps, err := ReadFile(cfg.GlobalFile)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+		m.global = append(m.global, parseRules(ps, "", m.fold)...)
+	}
+	rules, err := m.readDir("")
+	if err != nil {
+		return nil, err
+	}
+	m.dirs[""] = &dirNode{rules: rules}
 	return m, nil
 }
 
-// Match reports whether the given path (relative or absolute) should be ignored.
-// If isDir is true, directory-only patterns (ending with "/") can apply.
-// Semantics:
-//   - "node_modules/" matches the directory itself AND anything under it.
-//   - "*.tmp" matches basenames by glob.
-//   - Simple prefix matching for directory globs.
-func (m *Matcher) Match(path string, isDir bool) bool {
-	if !m.enabled {
-		return false
+// AddPattern compiles pattern and adds it with the precedence of
+// Config.Patterns, after those already present. It is safe to call
+// concurrently with Match.
+func (m *Matcher) AddPattern(pattern string) error {
+	r, ok, err := parseRule(pattern, "", m.fold)
+	if err != nil || !ok {
+		return err
 	}
-	// Make path relative to root if possible.
-	if m.root != "" {
-		if rel, err := filepath.Rel(m.root, path); err == nil {
-			path = rel
+	m.pmu.Lock()
+	defer m.pmu.Unlock()
+	old := *m.patterns.Load()
+	next := append(make([]rule, 0, len(old)+1), old...)
+	next = append(next, r)
+	m.patterns.Store(&next)
+	return nil
+}
+
+// RemovePattern removes every rule added from pattern through
+// Config.Patterns or AddPattern, and reports whether there was any.
+func (m *Matcher) RemovePattern(pattern string) bool {
+	pattern = strings.TrimSpace(pattern)
+	m.pmu.Lock()
+	defer m.pmu.Unlock()
+	old := *m.patterns.Load()
+	next := make([]rule, 0, len(old))
+	for _, r := range old {
+		if r.src != pattern {
+			next = append(next, r)
 		}
 	}
-	path = filepath.ToSlash(path)
+	if len(next) == len(old) {
+		return false
+	}
+	m.patterns.Store(&next)
+	return true
+}
 
-	for _, p := range m.patterns {
-		pp := strings.TrimSpace(p)
-		if pp == "" {
+// ReadFile returns the patterns of a gitignore-style file: one per line,
+// skipping blank lines and "#" comments.
+func ReadFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	var patterns []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimRight(sc.Text(), " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
 			continue
 		}
-		dirOnly := strings.HasSuffix(pp, "/")
-		ppNoSlash := strings.TrimSuffix(pp, "/")
-
-		// If pattern is directory-only:
-		// - match the directory itself (when isDir && base == ppNoSlash)
-		// - match any descendant (prefix "ppNoSlash/")
-		if dirOnly {
-			if isDir && filepath.Base(path) == ppNoSlash {
-				return true
-			}
-			if strings.HasPrefix(path, ppNoSlash+"/") {
-				return true
-			}
-			// Also match the directory exact relative path.
-			if path == ppNoSlash {
-				return true
+		patterns = append(patterns, line)
+	}
+	return patterns, sc.Err()
+}
+
+// readDir parses the ignore files of the directory rel (relative to root).
+func (m *Matcher) readDir(rel string) ([]rule, error) {
+	var rules []rule
+	for _, name := range m.files {
+		ps, err := ReadFile(filepath.Join(m.root, filepath.FromSlash(rel), name))
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
 			}
-			continue
+			return nil, err
 		}
+		rules = append(rules, parseRules(ps, rel, m.fold)...)
+	}
+	return rules, nil
+}
 
-		// File/basename glob match (e.g., "*.tmp")
-		if ok, _ := filepath.Match(ppNoSlash, filepath.Base(path)); ok {
+// dirNode returns the cached node of directory rel, reading its ignore files
+// on first use. Unreadable ignore files are treated as empty.
+func (m *Matcher) dirNode(rel string) *dirNode {
+	m.mu.RLock()
+	n, ok := m.dirs[rel]
+	m.mu.RUnlock()
+	if ok {
+		return n
+	}
+	parent := m.dirNode(rel[:max(strings.LastIndexByte(rel, '/'), 0)])
+	n = &dirNode{up: parent}
+	if len(parent.rules) == 0 {
+		n.up = parent.up
+	}
+	n.rules, _ = m.readDir(rel)
+	m.mu.Lock()
+	if existing, ok := m.dirs[rel]; ok {
+		n = existing
+	} else {
+		m.dirs[rel] = n
+	}
+	m.mu.Unlock()
+	return n
+}
+
+// eval applies the chain's rules from root down to n.
+func (n *dirNode) eval(rel string, isDir, ignored bool) bool {
+	if n.up != nil {
+		ignored = n.up.eval(rel, isDir, ignored)
+	}
+	return evalRules(n.rules, rel, isDir, ignored)
+}
+
+// evalRules returns the verdict of the last rule matching rel, or ignored
+// when none does.
+func evalRules(rules []rule, rel string, isDir, ignored bool) bool {
+	for i := range rules {
+		if rules[i].match(rel, isDir) {
+			ignored = !rules[i].negate
+		}
+	}
+	return ignored
+}
+
+// Match reports whether the given path (relative or absolute) should be ignored.
+// If isDir is true, directory-only patterns (ending with "/") can apply.
+// A path is also ignored when any of its ancestors below Root is, unless it is
+// covered by Config.Whitelist. Semantics follow gitignore:
+//   - "node_modules/" matches directories named node_modules, and so
+//     everything under them.
+//   - "*.tmp" (no slash) matches basenames at any depth.
+//   - "/build" or "docs/*.md" (with a slash) are anchored to the directory
+//     of the ignore file; "**" matches any number of directories.
+//   - "!pattern" re-includes what an earlier pattern ignored. Rules are
+//     applied from Root down to the path's directory and the last match wins.
+func (m *Matcher) Match(path string, isDir bool) bool {
+	rel, ok := m.rel(path)
+	if !ok || m.whitelisted(rel, isDir) {
+		return false
+	}
+	// Check ancestors top-down, as a walk would.
+	for i := 0; i < len(rel); i++ {
+		if rel[i] == '/' && m.matchRel(rel[:i], true) {
 			return true
 		}
+	}
+	return m.matchRel(rel, isDir)
+}
 
-		// Fallback: prefix match for simple directory-like globs without trailing slash.
-		if strings.HasPrefix(path, ppNoSlash+"/") {
+// MatchLeaf is like Match but assumes every ancestor of path was already
+// checked and not ignored, as in a top-down directory walk.
+func (m *Matcher) MatchLeaf(path string, isDir bool) bool {
+	if len(m.whitelist) > 0 {
+		// Ancestors may only have been traversed to reach a whitelisted
+		// path, so they have to be taken into account.
+		return m.Match(path, isDir)
+	}
+	rel, ok := m.rel(path)
+	if !ok {
+		return false
+	}
+	return m.matchRel(rel, isDir)
+}
+
+// whitelisted reports whether rel or one of its ancestors matches a whitelist
+// pattern, or rel is a directory leading to an anchored one.
+func (m *Matcher) whitelisted(rel string, isDir bool) bool {
+	if len(m.whitelist) == 0 {
+		return false
+	}
+	for i := range m.whitelist {
+		w := &m.whitelist[i]
+		if w.match(rel, isDir) || (isDir && w.leadsTo(rel)) {
 			return true
 		}
+		for j := 0; j < len(rel); j++ {
+			if rel[j] == '/' && w.match(rel[:j], true) {
+				return true
+			}
+		}
 	}
 	return false
 }
 
+// rel returns path relative to root in slash form. ok is false when matching
+// is disabled or path lies outside root.
+func (m *Matcher) rel(p string) (string, bool) {
+	if !m.enabled {
+		return "", false
+	}
+	// Make path relative to root if possible.
+	switch {
+	case m.root == "":
+	case m.prefix == "."+string(filepath.Separator) && !filepath.IsAbs(p):
+		// Walking "." yields already-relative paths.
+		p = filepath.Clean(p)
+	case strings.HasPrefix(p, m.prefix):
+		p = p[len(m.prefix):]
+	default:
+		r, err := filepath.Rel(m.root, p)
+		if err != nil {
+			return "", false
+		}
+		p = r
+	}
+	p = filepath.ToSlash(p)
+	if p == "." || p == ".." || strings.HasPrefix(p, "../") {
+		return "", false
+	}
+	return p, true
+}
+
+// matchRel evaluates the rules applying to rel, lowest precedence first, and
+// returns the verdict of the last one that matches.
+func (m *Matcher) matchRel(rel string, isDir bool) bool {
+	ignored := evalRules(m.global, rel, isDir, false)
+	if len(m.files) > 0 {
+		dir := rel[:max(strings.LastIndexByte(rel, '/'), 0)]
+		ignored = m.dirNode(dir).eval(rel, isDir, ignored)
+	}
+	return evalRules(*m.patterns.Load(), rel, isDir, ignored)
+}
+
 // Enabled reports whether matching is active.
 func (m *Matcher) Enabled() bool { return m.enabled }
 
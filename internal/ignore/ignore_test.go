@@ -88,3 +88,230 @@ func TestMatcher_Disabled(t *testing.T) {
 		t.Fatalf("with Enabled=false, nothing should match")
 	}
 }
+
+func TestNew_ReadsIgnoreFiles(t *testing.T) {
+	td := t.TempDir()
+	global := filepath.Join(t.TempDir(), "ignore")
+	for path, data := range map[string]string{
+		global:                          "*.bak\n",
+		filepath.Join(td, ".gitignore"): "# build output\nbuild/\n\n!keep.log\n",
+		filepath.Join(td, ".ignore"):    "*.log\n",
+		filepath.Join(td, ".fdignore"):  "*.tmp\n",
+	} {
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	all := ignore.Config{
+		Root:       td,
+		Enabled:    true,
+		Files:      []string{ignore.GitIgnoreFile, ignore.DotIgnoreFile, ignore.FdIgnoreFile},
+		GlobalFile: global,
+	}
+	m, err := ignore.New(all)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	for _, p := range []string{"build/out.bin", "a.log", "a.tmp", "a.bak"} {
+		if !m.Match(filepath.Join(td, p), false) {
+			t.Errorf("expected %s to be ignored", p)
+		}
+	}
+	if m.Match(filepath.Join(td, "main.go"), false) {
+		t.Errorf("did not expect main.go to be ignored")
+	}
+
+	// Each source can be left out on its own.
+	only := all
+	only.Files = []string{ignore.GitIgnoreFile}
+	only.GlobalFile = ""
+	m, err = ignore.New(only)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if !m.Match(filepath.Join(td, "build", "out.bin"), false) || m.Match(filepath.Join(td, "a.log"), false) || m.Match(filepath.Join(td, "a.bak"), false) {
+		t.Errorf("expected only .gitignore patterns to apply")
+	}
+}
+
+func TestMatcher_Hierarchical(t *testing.T) {
+	td := t.TempDir()
+	for rel, data := range map[string]string{
+		".gitignore":          "*.log\n/build\ndocs/**/*.tmp\n",
+		"sub/.gitignore":      "!keep.log\ngen/\n",
+		"sub/deep/.gitignore": "keep.log\n",
+	} {
+		p := filepath.Join(td, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	m, err := ignore.New(ignore.Config{Root: td, Enabled: true, Files: []string{ignore.GitIgnoreFile}})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	for rel, want := range map[string]bool{
+		"a.log":              true,  // root rule
+		"sub/keep.log":       false, // re-included by sub/.gitignore
+		"sub/other.log":      true,  // root rule still applies below
+		"sub/deep/keep.log":  true,  // deeper file wins again
+		"build/x.o":          true,  // anchored, via ignored ancestor
+		"sub/build/x.o":      false, // "/build" only matches at root
+		"docs/a/b/c.tmp":     true,  // "**" spans directories
+		"sub/gen/file.go":    true,  // dir-only rule from sub/.gitignore
+		"gen/file.go":        false, // sub's rules don't apply at root
+		"sub/deep/notes.txt": false,
+	} {
+		if got := m.Match(filepath.Join(td, filepath.FromSlash(rel)), false); got != want {
+			t.Errorf("Match(%s) = %v, want %v", rel, got, want)
+		}
+	}
+
+	// Ignore files are read once per directory and then cached.
+	if err := os.WriteFile(filepath.Join(td, "sub", ".gitignore"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if m.Match(filepath.Join(td, "sub", "keep.log"), false) {
+		t.Errorf("expected cached rules for sub/ to be reused")
+	}
+}
+
+func TestMatcher_Whitelist(t *testing.T) {
+	td := t.TempDir()
+	m, err := ignore.New(ignore.Config{
+		Root:      td,
+		Enabled:   true,
+		Patterns:  []string{"vendor/", "*.orig"},
+		Whitelist: []string{"vendor/patches/**"},
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	for _, c := range []struct {
+		rel   string
+		isDir bool
+		want  bool
+	}{
+		{"vendor", true, false},         // traversed to reach patches
+		{"vendor/patches", true, false}, // leads to the whitelisted files
+		{"vendor/patches/fix.diff", false, false},
+		{"vendor/patches/a/b.orig", false, false}, // whitelist wins over *.orig
+		{"vendor/lib", true, true},
+		{"vendor/lib/x.go", false, true},
+		{"vendor/README", false, true},
+		{"src/y.orig", false, true},
+	} {
+		p := filepath.Join(td, filepath.FromSlash(c.rel))
+		if got := m.Match(p, c.isDir); got != c.want {
+			t.Errorf("Match(%s) = %v, want %v", c.rel, got, c.want)
+		}
+		if got := m.MatchLeaf(p, c.isDir); got != c.want {
+			t.Errorf("MatchLeaf(%s) = %v, want %v", c.rel, got, c.want)
+		}
+	}
+}
+
+func TestMatcher_AddRemovePattern(t *testing.T) {
+	td := t.TempDir()
+	m, err := ignore.New(ignore.Config{Root: td, Enabled: true, Patterns: []string{"*.tmp"}})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	log := filepath.Join(td, "a", "b.log")
+	if m.Match(log, false) {
+		t.Fatalf("b.log should not be ignored yet")
+	}
+	if err := m.AddPattern("*.log"); err != nil {
+		t.Fatalf("AddPattern: %v", err)
+	}
+	if !m.Match(log, false) {
+		t.Fatalf("expected b.log to be ignored after AddPattern")
+	}
+	if !m.RemovePattern("*.log") || m.Match(log, false) {
+		t.Fatalf("expected b.log to be matched again after RemovePattern")
+	}
+	if m.RemovePattern("*.log") {
+		t.Fatalf("removing an absent pattern should report false")
+	}
+	if !m.Match(filepath.Join(td, "x.tmp"), false) {
+		t.Fatalf("configured patterns must survive add/remove")
+	}
+
+	if err := m.AddPattern("[z-a"); err == nil {
+		t.Fatalf("expected an error for a malformed pattern")
+	}
+	if _, err := ignore.New(ignore.Config{Root: td, Enabled: true, Patterns: []string{"a/[bad"}}); err == nil {
+		t.Fatalf("expected New to reject a malformed pattern")
+	}
+}
+
+func TestMatcher_CaseInsensitive(t *testing.T) {
+	td := t.TempDir()
+	patterns := []string{"*.log", "Build/", "(?-i)Makefile", "(?i)*.TMP", "docs/*.MD", "!(?-i)KEEP.log"}
+	for _, fold := range []bool{false, true} {
+		m, err := ignore.New(ignore.Config{Root: td, Enabled: true, Patterns: patterns, CaseInsensitive: fold})
+		if err != nil {
+			t.Fatalf("New: %v", err)
+		}
+		for _, c := range []struct {
+			rel         string
+			isDir       bool
+			sensitive   bool // expected with CaseInsensitive=false
+			insensitive bool // expected with CaseInsensitive=true
+		}{
+			{"app.log", false, true, true},
+			{"APP.LOG", false, false, true},
+			{"build", true, false, true},
+			{"makefile", false, false, false}, // (?-i) always case-sensitive
+			{"Makefile", false, true, true},
+			{"a.tmp", false, true, true}, // (?i) always case-insensitive
+			{"Docs/readme.md", false, false, true},
+			{"KEEP.log", false, false, false}, // re-included, exact case
+			{"keep.log", false, true, true},
+		} {
+			want := c.sensitive
+			if fold {
+				want = c.insensitive
+			}
+			if got := m.Match(filepath.Join(td, filepath.FromSlash(c.rel)), c.isDir); got != want {
+				t.Errorf("CaseInsensitive=%v: Match(%s) = %v, want %v", fold, c.rel, got, want)
+			}
+		}
+	}
+}
+
+func TestMatcher_Smart(t *testing.T) {
+	td := t.TempDir()
+	if err := os.WriteFile(filepath.Join(td, ".gitignore"), []byte("!dist/\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	ignore.RegisterSmartPatterns(ignore.SmartPattern{Pattern: "*.bench-artifact", Category: "test"})
+	var listed bool
+	for _, p := range ignore.SmartPatterns() {
+		listed = listed || p.Pattern == "*.bench-artifact"
+	}
+	if !listed {
+		t.Fatalf("registered pattern missing from SmartPatterns()")
+	}
+
+	m, err := ignore.New(ignore.Config{Root: td, Enabled: true, Smart: true, Files: []string{ignore.GitIgnoreFile}})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	for rel, want := range map[string]bool{
+		"web/node_modules/react/index.js": true,
+		"pkg/__pycache__/mod.pyc":         true,
+		"x.bench-artifact":                true,
+		"dist/app.js":                     false, // re-included by .gitignore
+		"src/main.go":                     false,
+	} {
+		if got := m.Match(filepath.Join(td, filepath.FromSlash(rel)), false); got != want {
+			t.Errorf("Match(%s) = %v, want %v", rel, got, want)
+		}
+	}
+}
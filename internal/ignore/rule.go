@@ -0,0 +1,280 @@
+package ignore
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// rule is a pattern compiled at load time so matching never re-parses it.
+type rule struct {
+	// src is the pattern as written, used by RemovePattern.
+	src string
+	// base is the directory of the ignore file, relative to root in slash
+	// form ("" for root); rules only apply below it.
+	base     string
+	negate   bool
+	dirOnly  bool
+	anchored bool
+	// name matches the basename of unanchored rules; segs matches the path
+	// below base, segment by segment, for anchored ones.
+	name segment
+	segs []segment
+}
+
+// segKind selects how a segment is matched; the common shapes avoid
+// path.Match entirely.
+type segKind uint8
+
+const (
+	segLiteral   segKind = iota // "vendor"
+	segAny                      // "*"
+	segSuffix                   // "*.log"
+	segPrefix                   // "cache-*"
+	segGlob                     // anything else, via path.Match
+	segRecursive                // "**"
+)
+
+// segment is one compiled slash-free pattern component.
+type segment struct {
+	kind segKind
+	// lit is the literal name, suffix or prefix; the full glob for segGlob
+	// (lowercased when fold is set).
+	lit string
+	// fold makes the match case-insensitive.
+	fold bool
+}
+
+const globMeta = `*?[\`
+
+func compileSegment(s string, fold bool) (segment, error) {
+	if s == "**" {
+		return segment{kind: segRecursive}, nil
+	}
+	if _, err := path.Match(s, ""); err != nil {
+		return segment{}, fmt.Errorf("invalid pattern %q: %w", s, err)
+	}
+	seg := segment{fold: fold}
+	switch {
+	case !strings.ContainsAny(s, globMeta):
+		seg.kind, seg.lit = segLiteral, s
+	case s == "*":
+		seg.kind = segAny
+	case s[0] == '*' && !strings.ContainsAny(s[1:], globMeta):
+		seg.kind, seg.lit = segSuffix, s[1:]
+	case s[len(s)-1] == '*' && !strings.ContainsAny(s[:len(s)-1], globMeta):
+		seg.kind, seg.lit = segPrefix, s[:len(s)-1]
+	default:
+		seg.kind, seg.lit = segGlob, s
+		if fold {
+			seg.lit = strings.ToLower(s)
+		}
+	}
+	return seg, nil
+}
+
+func (s *segment) match(name string) bool {
+	switch s.kind {
+	case segLiteral:
+		if s.fold {
+			return strings.EqualFold(name, s.lit)
+		}
+		return name == s.lit
+	case segAny, segRecursive:
+		return true
+	case segSuffix:
+		if s.fold {
+			return len(name) >= len(s.lit) && strings.EqualFold(name[len(name)-len(s.lit):], s.lit)
+		}
+		return strings.HasSuffix(name, s.lit)
+	case segPrefix:
+		if s.fold {
+			return len(name) >= len(s.lit) && strings.EqualFold(name[:len(s.lit)], s.lit)
+		}
+		return strings.HasPrefix(name, s.lit)
+	default:
+		if s.fold {
+			name = strings.ToLower(name)
+		}
+		ok, _ := path.Match(s.lit, name)
+		return ok
+	}
+}
+
+// Pattern prefixes overriding Config.CaseInsensitive for a single pattern.
+const (
+	foldPrefix   = "(?i)"
+	nofoldPrefix = "(?-i)"
+)
+
+// parseRules compiles patterns declared in directory base, skipping invalid
+// ones as git does.
+func parseRules(patterns []string, base string, fold bool) []rule {
+	var rules []rule
+	for _, p := range patterns {
+		if r, ok, err := parseRule(p, base, fold); ok && err == nil {
+			rules = append(rules, r)
+		}
+	}
+	return rules
+}
+
+// compileRules compiles patterns given programmatically, reporting the first
+// invalid one.
+func compileRules(patterns []string, fold bool) ([]rule, error) {
+	var rules []rule
+	for _, p := range patterns {
+		r, ok, err := parseRule(p, "", fold)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			rules = append(rules, r)
+		}
+	}
+	return rules, nil
+}
+
+// parseRule compiles a single pattern, case-insensitively when fold is set
+// unless the pattern starts with "(?-i)" (or "(?i)" to force it). The prefix
+// may also follow a leading "!". ok is false for patterns that match nothing
+// (e.g. "/" or "!").
+func parseRule(p, base string, fold bool) (rule, bool, error) {
+	p = strings.TrimSpace(p)
+	r := rule{src: p, base: base}
+	p, fold = caseFlag(p, fold)
+	switch {
+	case strings.HasPrefix(p, "!"):
+		r.negate = true
+		p, fold = caseFlag(p[1:], fold)
+	case strings.HasPrefix(p, `\!`), strings.HasPrefix(p, `\#`):
+		p = p[1:]
+	}
+	if strings.HasSuffix(p, "/") {
+		r.dirOnly = true
+		p = strings.TrimRight(p, "/")
+	}
+	if strings.HasPrefix(p, "/") {
+		r.anchored = true
+		p = strings.TrimLeft(p, "/")
+	}
+	if strings.Contains(p, "/") {
+		r.anchored = true
+	}
+	if p == "" {
+		return rule{}, false, nil
+	}
+	if !r.anchored {
+		seg, err := compileSegment(p, fold)
+		if err != nil {
+			return rule{}, false, err
+		}
+		r.name = seg
+		return r, true, nil
+	}
+	for _, s := range strings.Split(p, "/") {
+		if s == "" {
+			continue
+		}
+		seg, err := compileSegment(s, fold)
+		if err != nil {
+			return rule{}, false, err
+		}
+		r.segs = append(r.segs, seg)
+	}
+	return r, true, nil
+}
+
+// caseFlag strips a leading case prefix from p and returns the resulting
+// case folding.
+func caseFlag(p string, fold bool) (string, bool) {
+	switch {
+	case strings.HasPrefix(p, foldPrefix):
+		return p[len(foldPrefix):], true
+	case strings.HasPrefix(p, nofoldPrefix):
+		return p[len(nofoldPrefix):], false
+	}
+	return p, fold
+}
+
+// match reports whether the rule applies to rel, a slash-separated path
+// relative to root.
+func (r *rule) match(rel string, isDir bool) bool {
+	if r.dirOnly && !isDir {
+		return false
+	}
+	if r.base != "" {
+		if len(rel) <= len(r.base) || rel[len(r.base)] != '/' || !strings.HasPrefix(rel, r.base) {
+			return false
+		}
+		rel = rel[len(r.base)+1:]
+	}
+	if !r.anchored {
+		return r.name.match(rel[strings.LastIndexByte(rel, '/')+1:])
+	}
+	return matchSegments(r.segs, rel)
+}
+
+// leadsTo reports whether the directory rel is an ancestor of paths an
+// anchored rule can match, e.g. "vendor" for "vendor/patches/**".
+func (r *rule) leadsTo(rel string) bool {
+	if !r.anchored || r.base != "" {
+		return false
+	}
+	pat := r.segs
+	for rel != "" {
+		if len(pat) == 0 {
+			return false
+		}
+		if pat[0].kind == segRecursive {
+			return true
+		}
+		var name string
+		name, rel = nextSegment(rel)
+		if !pat[0].match(name) {
+			return false
+		}
+		pat = pat[1:]
+	}
+	return len(pat) > 0
+}
+
+// matchSegments matches the slash-separated rel against pattern segments,
+// where "**" stands for zero or more path segments.
+func matchSegments(pat []segment, rel string) bool {
+	for len(pat) > 0 {
+		if pat[0].kind == segRecursive {
+			for len(pat) > 0 && pat[0].kind == segRecursive {
+				pat = pat[1:]
+			}
+			if len(pat) == 0 {
+				return true
+			}
+			for rel != "" {
+				if matchSegments(pat, rel) {
+					return true
+				}
+				_, rel = nextSegment(rel)
+			}
+			return false
+		}
+		if rel == "" {
+			return false
+		}
+		var name string
+		name, rel = nextSegment(rel)
+		if !pat[0].match(name) {
+			return false
+		}
+		pat = pat[1:]
+	}
+	return rel == ""
+}
+
+// nextSegment splits the first component off a slash-separated path.
+func nextSegment(rel string) (name, rest string) {
+	if i := strings.IndexByte(rel, '/'); i >= 0 {
+		return rel[:i], rel[i+1:]
+	}
+	return rel, ""
+}
@@ -0,0 +1,71 @@
+package ignore
+
+import "sync"
+
+// SmartPattern is an entry of the built-in ignore set used by Config.Smart.
+type SmartPattern struct {
+	// Pattern uses the same syntax as an ignore file line.
+	Pattern string
+	// Category groups related patterns (e.g. "vcs", "python").
+	Category string
+}
+
+// smartPatterns is the curated set of build artifacts, dependency caches and
+// VCS metadata that are almost never what a search is looking for.
+var smartPatterns = []SmartPattern{
+	{".git/", "vcs"},
+	{".hg/", "vcs"},
+	{".svn/", "vcs"},
+	{"node_modules/", "javascript"},
+	{"bower_components/", "javascript"},
+	{".next/", "javascript"},
+	{".nuxt/", "javascript"},
+	{".parcel-cache/", "javascript"},
+	{"dist/", "build"},
+	{"build/", "build"},
+	{"target/", "build"}, // Rust, Maven
+	{"*.o", "build"},
+	{"*.obj", "build"},
+	{"*.class", "build"},
+	{".gradle/", "build"},
+	{"__pycache__/", "python"},
+	{"*.pyc", "python"},
+	{".venv/", "python"},
+	{".tox/", "python"},
+	{".mypy_cache/", "python"},
+	{".pytest_cache/", "python"},
+	{"*.egg-info/", "python"},
+	{".terraform/", "tools"},
+	{".idea/", "editor"},
+	{"*.swp", "editor"},
+	{".DS_Store", "os"},
+	{"Thumbs.db", "os"},
+}
+
+var smartMu sync.RWMutex
+
+// SmartPatterns returns a copy of the built-in ignore set.
+func SmartPatterns() []SmartPattern {
+	smartMu.RLock()
+	defer smartMu.RUnlock()
+	return append([]SmartPattern(nil), smartPatterns...)
+}
+
+// RegisterSmartPatterns extends the built-in ignore set. It affects Matchers
+// created afterwards.
+func RegisterSmartPatterns(ps ...SmartPattern) {
+	smartMu.Lock()
+	defer smartMu.Unlock()
+	smartPatterns = append(smartPatterns, ps...)
+}
+
+// smartRules compiles the built-in set, skipping invalid entries.
+func smartRules(fold bool) []rule {
+	smartMu.RLock()
+	defer smartMu.RUnlock()
+	patterns := make([]string, len(smartPatterns))
+	for i, p := range smartPatterns {
+		patterns[i] = p.Pattern
+	}
+	return parseRules(patterns, "", fold)
+}
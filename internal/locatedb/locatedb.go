@@ -0,0 +1,156 @@
+// Package locatedb implements a compact, mlocate-style path database used by
+// "gofind updatedb" and "gofind locate".
+//
+// The file starts with a magic line followed by a gzip stream. Inside the
+// stream the indexed root is stored first, then one record per path in sorted
+// order. Each record is front-coded against the previous path: the length of
+// the shared prefix, the length and bytes of the remaining suffix, and a flag
+// byte (1 for directories).
+package locatedb
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"slices"
+	"sort"
+)
+
+const magic = "gofind-locatedb 1\n"
+
+// ErrBadFormat is returned when a file is not a gofind locate database.
+var ErrBadFormat = errors.New("not a gofind locate database")
+
+// Record is a single indexed path.
+type Record struct {
+	Path  string
+	IsDir bool
+}
+
+// Write encodes records (sorted by path first) into w, tagging the database
+// with the root directory it was built from.
+func Write(w io.Writer, root string, records []Record) error {
+	sort.Slice(records, func(i, j int) bool { return records[i].Path < records[j].Path })
+	return WriteSorted(w, root, slices.Values(records))
+}
+
+// WriteSorted is Write for records already sorted by path, which it reads
+// one at a time.
+func WriteSorted(w io.Writer, root string, records iter.Seq[Record]) error {
+	if _, err := io.WriteString(w, magic); err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(w)
+	bw := bufio.NewWriter(zw)
+	var num [binary.MaxVarintLen64]byte
+	putString := func(s string) error {
+		n := binary.PutUvarint(num[:], uint64(len(s)))
+		if _, err := bw.Write(num[:n]); err != nil {
+			return err
+		}
+		_, err := bw.WriteString(s)
+		return err
+	}
+	if err := putString(root); err != nil {
+		return err
+	}
+
+	prev := ""
+	for r := range records {
+		shared := commonPrefix(prev, r.Path)
+		n := binary.PutUvarint(num[:], uint64(shared))
+		if _, err := bw.Write(num[:n]); err != nil {
+			return err
+		}
+		if err := putString(r.Path[shared:]); err != nil {
+			return err
+		}
+		flag := byte(0)
+		if r.IsDir {
+			flag = 1
+		}
+		if err := bw.WriteByte(flag); err != nil {
+			return err
+		}
+		prev = r.Path
+	}
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// Read decodes the database in r, calling fn for each record in path order
+// until fn returns false. It returns the root the database was built from.
+func Read(r io.Reader, fn func(Record) bool) (string, error) {
+	br := bufio.NewReader(r)
+	head := make([]byte, len(magic))
+	if _, err := io.ReadFull(br, head); err != nil || string(head) != magic {
+		return "", ErrBadFormat
+	}
+	zr, err := gzip.NewReader(br)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrBadFormat, err)
+	}
+	defer func() { _ = zr.Close() }()
+	dr := bufio.NewReader(zr)
+
+	getString := func() (string, error) {
+		n, err := binary.ReadUvarint(dr)
+		if err != nil {
+			return "", err
+		}
+		b := make([]byte, n)
+		if _, err := io.ReadFull(dr, b); err != nil {
+			return "", err
+		}
+		return string(b), nil
+	}
+	root, err := getString()
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrBadFormat, err)
+	}
+
+	prev := ""
+	for {
+		shared, err := binary.ReadUvarint(dr)
+		if err == io.EOF {
+			return root, nil
+		}
+		if err != nil {
+			return root, err
+		}
+		if shared > uint64(len(prev)) {
+			return root, ErrBadFormat
+		}
+		suffix, err := getString()
+		if err != nil {
+			return root, err
+		}
+		flag, err := dr.ReadByte()
+		if err != nil {
+			return root, err
+		}
+		p := prev[:shared] + suffix
+		if !fn(Record{Path: p, IsDir: flag == 1}) {
+			return root, nil
+		}
+		prev = p
+	}
+}
+
+func commonPrefix(a, b string) int {
+	n := len(a)
+	if len(b) < n {
+		n = len(b)
+	}
+	i := 0
+	for i < n && a[i] == b[i] {
+		i++
+	}
+	return i
+}
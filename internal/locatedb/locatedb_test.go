@@ -0,0 +1,54 @@
+package locatedb_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Hamed0406/gofind/internal/locatedb"
+)
+
+func TestWriteReadRoundTrip(t *testing.T) {
+	in := []locatedb.Record{
+		{Path: "/src/b/two.go"},
+		{Path: "/src/a", IsDir: true},
+		{Path: "/src/a/one.go"},
+		{Path: "/src/a/one.md"},
+	}
+	var buf bytes.Buffer
+	if err := locatedb.Write(&buf, "/src", in); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	var got []locatedb.Record
+	root, err := locatedb.Read(&buf, func(r locatedb.Record) bool {
+		got = append(got, r)
+		return true
+	})
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if root != "/src" {
+		t.Fatalf("root = %q", root)
+	}
+	want := []string{"/src/a", "/src/a/one.go", "/src/a/one.md", "/src/b/two.go"}
+	if len(got) != len(want) {
+		t.Fatalf("got %+v", got)
+	}
+	for i, w := range want {
+		if got[i].Path != w {
+			t.Fatalf("record %d: got %q want %q", i, got[i].Path, w)
+		}
+	}
+	if !got[0].IsDir || got[1].IsDir {
+		t.Fatalf("dir flags not preserved: %+v", got)
+	}
+}
+
+func TestReadRejectsForeignFile(t *testing.T) {
+	_, err := locatedb.Read(strings.NewReader("hello"), func(locatedb.Record) bool { return true })
+	if !errors.Is(err, locatedb.ErrBadFormat) {
+		t.Fatalf("expected ErrBadFormat, got %v", err)
+	}
+}
@@ -0,0 +1,170 @@
+// Package manifest reads checksum manifests in the sha256sum format
+// ("<hex digest>  <path>" per line, as in a release's SHA256SUMS file) and
+// verifies a directory tree against them.
+package manifest
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// Entry is one manifest line.
+type Entry struct {
+	// Path is slash-separated and relative to the manifest's root.
+	Path   string
+	SHA256 string
+}
+
+// ErrBadFormat is returned for a line that is not "<sha256>  <path>".
+var ErrBadFormat = errors.New("manifest: bad line")
+
+// Parse reads a sha256sum-style manifest. Both the text ("  ") and binary
+// (" *") separators are accepted; blank lines and "#" comments are skipped.
+func Parse(r io.Reader) ([]Entry, error) {
+	var entries []Entry
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimRight(sc.Text(), "\r")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		sum, p, ok := strings.Cut(line, " ")
+		if !ok || len(sum) != sha256.Size*2 || len(p) < 2 || (p[0] != ' ' && p[0] != '*') {
+			return nil, fmt.Errorf("%w %d: %q", ErrBadFormat, n, line)
+		}
+		if _, err := hex.DecodeString(sum); err != nil {
+			return nil, fmt.Errorf("%w %d: %q", ErrBadFormat, n, line)
+		}
+		p = path.Clean(strings.TrimPrefix(filepath.ToSlash(p[1:]), "./"))
+		if path.IsAbs(p) || p == ".." || strings.HasPrefix(p, "../") {
+			return nil, fmt.Errorf("%w %d: path %q escapes the root", ErrBadFormat, n, p)
+		}
+		entries = append(entries, Entry{Path: p, SHA256: strings.ToLower(sum)})
+	}
+	return entries, sc.Err()
+}
+
+// Open returns the manifest at src: an http(s) URL, "-" for stdin, or a
+// local file.
+func Open(ctx context.Context, src string) (io.ReadCloser, error) {
+	switch {
+	case src == "-":
+		return io.NopCloser(os.Stdin), nil
+	case strings.HasPrefix(src, "http://"), strings.HasPrefix(src, "https://"):
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, src, nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			_ = resp.Body.Close()
+			return nil, fmt.Errorf("fetch %s: %s", src, resp.Status)
+		}
+		return resp.Body, nil
+	default:
+		return os.Open(src)
+	}
+}
+
+// Result statuses.
+const (
+	StatusOK       = "ok"
+	StatusMismatch = "mismatch"
+	StatusMissing  = "missing"
+	StatusError    = "error"
+	// StatusExtra marks a file present in the tree but not in the manifest.
+	StatusExtra = "extra"
+)
+
+// Result is the verification outcome of one path.
+type Result struct {
+	Path     string `json:"path"`
+	Status   string `json:"status"`
+	Expected string `json:"expected,omitempty"`
+	Actual   string `json:"actual,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Verify hashes the file of every entry below root, using up to workers
+// goroutines (<= 0 = NumCPU), and calls fn with each result as it completes.
+// fn calls are serialized. Verify stops early when ctx is canceled.
+func Verify(ctx context.Context, root string, entries []Entry, workers int, fn func(Result)) error {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	jobs := make(chan Entry)
+	results := make(chan Result)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for e := range jobs {
+				results <- check(root, e)
+			}
+		}()
+	}
+	go func() {
+		defer close(jobs)
+		for _, e := range entries {
+			select {
+			case jobs <- e:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+	for r := range results {
+		fn(r)
+	}
+	return ctx.Err()
+}
+
+func check(root string, e Entry) Result {
+	r := Result{Path: e.Path, Expected: e.SHA256}
+	sum, err := HashFile(filepath.Join(root, filepath.FromSlash(e.Path)))
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		r.Status = StatusMissing
+	case err != nil:
+		r.Status, r.Error = StatusError, err.Error()
+	case sum != e.SHA256:
+		r.Status, r.Actual = StatusMismatch, sum
+	default:
+		r.Status, r.Actual = StatusOK, sum
+	}
+	return r
+}
+
+// HashFile returns the hex SHA-256 of the file at p.
+func HashFile(p string) (string, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = f.Close() }()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
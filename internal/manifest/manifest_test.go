@@ -0,0 +1,75 @@
+package manifest_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/Hamed0406/gofind/internal/manifest"
+)
+
+func sum(s string) string {
+	h := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(h[:])
+}
+
+func TestParseRejectsBadLines(t *testing.T) {
+	for _, line := range []string{"nothex  a.txt", sum("x") + "  ../etc/passwd", sum("x") + " a.txt"} {
+		if _, err := manifest.Parse(strings.NewReader(line + "\n")); !errors.Is(err, manifest.ErrBadFormat) {
+			t.Errorf("Parse(%q): expected ErrBadFormat, got %v", line, err)
+		}
+	}
+}
+
+func TestFetchAndVerify(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{"bin/app": "app-v1", "README": "hello", "changed.txt": "new"}
+	for rel, data := range files {
+		p := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	body := "# release manifest\n" +
+		sum("app-v1") + "  ./bin/app\n" +
+		sum("hello") + " *README\n" +
+		sum("old") + "  changed.txt\n" +
+		sum("gone") + "  missing.txt\n"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(body))
+	}))
+	defer srv.Close()
+
+	rc, err := manifest.Open(context.Background(), srv.URL+"/SHA256SUMS")
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	entries, err := manifest.Parse(rc)
+	_ = rc.Close()
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	var got []string
+	if err := manifest.Verify(context.Background(), root, entries, 2, func(r manifest.Result) {
+		got = append(got, r.Path+"="+r.Status)
+	}); err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	sort.Strings(got)
+	want := "README=ok,bin/app=ok,changed.txt=mismatch,missing.txt=missing"
+	if strings.Join(got, ",") != want {
+		t.Fatalf("got %v, want %s", got, want)
+	}
+}
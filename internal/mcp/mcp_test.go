@@ -0,0 +1,88 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestServe(t *testing.T) {
+	s := &Server{Name: "test", Version: "1", Tools: []Tool{{
+		Name:        "echo",
+		Description: "Echoes its argument.",
+		InputSchema: json.RawMessage(`{"type":"object"}`),
+		Call: func(_ context.Context, args json.RawMessage) (any, error) {
+			var a struct{ Say string }
+			if err := json.Unmarshal(args, &a); err != nil {
+				return nil, err
+			}
+			if a.Say == "" {
+				return nil, errors.New("nothing to say")
+			}
+			return map[string]string{"said": a.Say}, nil
+		},
+	}}}
+	in := strings.Join([]string{
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2024-11-05","capabilities":{}}}`,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/list"}`,
+		`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"echo","arguments":{"say":"hi"}}}`,
+		`{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"echo","arguments":{}}}`,
+		`{"jsonrpc":"2.0","id":5,"method":"tools/call","params":{"name":"nope"}}`,
+		`{"jsonrpc":"2.0","id":"x","method":"resources/list"}`,
+		`not json`,
+	}, "\n")
+	var out strings.Builder
+	if err := s.Serve(context.Background(), strings.NewReader(in), &out); err != nil {
+		t.Fatal(err)
+	}
+
+	type resp struct {
+		ID     json.RawMessage `json:"id"`
+		Result struct {
+			ProtocolVersion string `json:"protocolVersion"`
+			Tools           []Tool `json:"tools"`
+			Content         []struct {
+				Text string `json:"text"`
+			} `json:"content"`
+			IsError bool `json:"isError"`
+		} `json:"result"`
+		Error *struct {
+			Code int `json:"code"`
+		} `json:"error"`
+	}
+	var resps []resp
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		var r resp
+		if err := json.Unmarshal([]byte(line), &r); err != nil {
+			t.Fatalf("%v: %s", err, line)
+		}
+		resps = append(resps, r)
+	}
+	// The notification gets no response.
+	if len(resps) != 7 {
+		t.Fatalf("got %d responses, want 7:\n%s", len(resps), out.String())
+	}
+	if string(resps[0].ID) != "1" || resps[0].Result.ProtocolVersion != "2024-11-05" {
+		t.Errorf("initialize: %+v", resps[0])
+	}
+	if len(resps[1].Result.Tools) != 1 || resps[1].Result.Tools[0].Name != "echo" {
+		t.Errorf("tools/list: %+v", resps[1])
+	}
+	if c := resps[2].Result.Content; resps[2].Result.IsError || len(c) != 1 || c[0].Text != `{"said":"hi"}` {
+		t.Errorf("tools/call: %+v", resps[2])
+	}
+	if c := resps[3].Result.Content; !resps[3].Result.IsError || len(c) != 1 || c[0].Text != "nothing to say" {
+		t.Errorf("failing tools/call: %+v", resps[3])
+	}
+	for i, code := range map[int]int{4: codeInvalidParams, 5: codeMethodNotFound, 6: codeParseError} {
+		if resps[i].Error == nil || resps[i].Error.Code != code {
+			t.Errorf("response %d: %+v, want error %d", i, resps[i], code)
+		}
+	}
+	if string(resps[5].ID) != `"x"` {
+		t.Errorf("string id not echoed: %s", resps[5].ID)
+	}
+}
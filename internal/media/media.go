@@ -0,0 +1,297 @@
+// Package media reads basic metadata from image and video files: pixel
+// dimensions, capture date and duration. It only parses container headers
+// (JPEG/EXIF, PNG, GIF, MP4/QuickTime) and never decodes pixel data.
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	_ "image/gif" // register decoders for image.DecodeConfig
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Info is the metadata found in a media file. Fields the format does not
+// carry are left zero.
+type Info struct {
+	Width  int
+	Height int
+	// Taken is the EXIF original capture time for photos (in the local time
+	// zone, as EXIF stores no zone) or the creation time for videos.
+	Taken time.Time
+	// Duration is the playing time of audio and video files.
+	Duration time.Duration
+}
+
+var (
+	imageExts = map[string]bool{".jpg": true, ".jpeg": true, ".png": true, ".gif": true}
+	movieExts = map[string]bool{".mp4": true, ".m4v": true, ".m4a": true, ".mov": true, ".3gp": true}
+)
+
+// Supported reports whether Read understands files with this name's
+// extension.
+func Supported(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	return imageExts[ext] || movieExts[ext]
+}
+
+// Read returns the metadata of the file at path. ok is false when the
+// extension is not supported.
+func Read(path string) (info Info, ok bool, err error) {
+	ext := strings.ToLower(filepath.Ext(path))
+	if !imageExts[ext] && !movieExts[ext] {
+		return Info{}, false, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return Info{}, false, err
+	}
+	defer f.Close()
+	if movieExts[ext] {
+		info, err = readMovie(f)
+		return info, true, err
+	}
+	cfg, _, err := image.DecodeConfig(f)
+	if err != nil {
+		return Info{}, true, err
+	}
+	info.Width, info.Height = cfg.Width, cfg.Height
+	if ext == ".jpg" || ext == ".jpeg" {
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return info, true, err
+		}
+		info.Taken, _ = jpegTaken(f) // missing or broken EXIF is common
+	}
+	return info, true, nil
+}
+
+var errNoExif = errors.New("no EXIF data")
+
+// jpegTaken returns DateTimeOriginal (or DateTime) from the EXIF APP1
+// segment of a JPEG stream.
+func jpegTaken(r io.Reader) (time.Time, error) {
+	var soi [2]byte
+	if _, err := io.ReadFull(r, soi[:]); err != nil || soi != [2]byte{0xFF, 0xD8} {
+		return time.Time{}, errNoExif
+	}
+	for {
+		var hdr [4]byte
+		if _, err := io.ReadFull(r, hdr[:]); err != nil {
+			return time.Time{}, err
+		}
+		if hdr[0] != 0xFF || hdr[1] == 0xDA { // start of scan: no more metadata
+			return time.Time{}, errNoExif
+		}
+		n := int(binary.BigEndian.Uint16(hdr[2:])) - 2
+		if n < 0 {
+			return time.Time{}, errNoExif
+		}
+		seg := make([]byte, n)
+		if _, err := io.ReadFull(r, seg); err != nil {
+			return time.Time{}, err
+		}
+		if hdr[1] == 0xE1 && bytes.HasPrefix(seg, []byte("Exif\x00\x00")) {
+			return exifTaken(seg[6:])
+		}
+	}
+}
+
+// EXIF tags used by exifTaken.
+const (
+	tagDateTime         = 0x0132
+	tagExifIFD          = 0x8769
+	tagDateTimeOriginal = 0x9003
+)
+
+// exifTaken reads the capture time from a TIFF-structured EXIF block.
+func exifTaken(tiff []byte) (time.Time, error) {
+	if len(tiff) < 8 {
+		return time.Time{}, errNoExif
+	}
+	var bo binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		bo = binary.LittleEndian
+	case "MM":
+		bo = binary.BigEndian
+	default:
+		return time.Time{}, errNoExif
+	}
+	ifd0 := ifdTags(tiff, bo, bo.Uint32(tiff[4:]))
+	if off, ok := ifd0[tagExifIFD]; ok {
+		if s := exifString(tiff, bo, ifdTags(tiff, bo, bo.Uint32(off[8:]))[tagDateTimeOriginal]); s != "" {
+			return parseExifTime(s)
+		}
+	}
+	if s := exifString(tiff, bo, ifd0[tagDateTime]); s != "" {
+		return parseExifTime(s)
+	}
+	return time.Time{}, errNoExif
+}
+
+// ifdTags returns the raw 12-byte entries of the IFD at off, keyed by tag.
+func ifdTags(tiff []byte, bo binary.ByteOrder, off uint32) map[uint16][]byte {
+	tags := make(map[uint16][]byte)
+	if uint64(off)+2 > uint64(len(tiff)) {
+		return tags
+	}
+	n := int(bo.Uint16(tiff[off:]))
+	p := int(off) + 2
+	for i := 0; i < n && p+12 <= len(tiff); i, p = i+1, p+12 {
+		tags[bo.Uint16(tiff[p:])] = tiff[p : p+12]
+	}
+	return tags
+}
+
+// exifString decodes an ASCII-typed IFD entry.
+func exifString(tiff []byte, bo binary.ByteOrder, ent []byte) string {
+	const typeASCII = 2
+	if len(ent) != 12 || bo.Uint16(ent[2:]) != typeASCII {
+		return ""
+	}
+	n := bo.Uint32(ent[4:])
+	var b []byte
+	if n <= 4 {
+		b = ent[8 : 8+n]
+	} else {
+		off := bo.Uint32(ent[8:])
+		if uint64(off)+uint64(n) > uint64(len(tiff)) {
+			return ""
+		}
+		b = tiff[off : off+n]
+	}
+	return strings.TrimRight(string(b), "\x00 ")
+}
+
+func parseExifTime(s string) (time.Time, error) {
+	return time.ParseInLocation("2006:01:02 15:04:05", s, time.Local)
+}
+
+// maxMoovSize bounds how much of a movie header is read into memory.
+const maxMoovSize = 32 << 20
+
+// quickTimeEpoch is the zero of MP4/QuickTime timestamps.
+var quickTimeEpoch = time.Date(1904, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// readMovie finds the moov box of an ISO base media (MP4/QuickTime) file and
+// reads its duration, creation time and first video track dimensions.
+func readMovie(r io.ReadSeeker) (Info, error) {
+	for {
+		typ, size, hdr, err := readBoxHeader(r)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return Info{}, errors.New("no moov box")
+			}
+			return Info{}, err
+		}
+		if typ != "moov" {
+			if size == 0 {
+				return Info{}, errors.New("no moov box")
+			}
+			if _, err := r.Seek(size-hdr, io.SeekCurrent); err != nil {
+				return Info{}, err
+			}
+			continue
+		}
+		if size == 0 || size-hdr > maxMoovSize {
+			return Info{}, errors.New("moov box too large")
+		}
+		moov := make([]byte, size-hdr)
+		if _, err := io.ReadFull(r, moov); err != nil {
+			return Info{}, err
+		}
+		return parseMoov(moov), nil
+	}
+}
+
+// readBoxHeader reads a box header, returning its type, total size (0 =
+// extends to end of file) and header length.
+func readBoxHeader(r io.Reader) (typ string, size, hdr int64, err error) {
+	var b [8]byte
+	if _, err := io.ReadFull(r, b[:]); err != nil {
+		return "", 0, 0, err
+	}
+	size, hdr = int64(binary.BigEndian.Uint32(b[:4])), 8
+	typ = string(b[4:])
+	if size == 1 {
+		if _, err := io.ReadFull(r, b[:]); err != nil {
+			return "", 0, 0, err
+		}
+		size, hdr = int64(binary.BigEndian.Uint64(b[:])), 16
+	}
+	if size != 0 && size < hdr {
+		return "", 0, 0, errors.New("invalid box size")
+	}
+	return typ, size, hdr, nil
+}
+
+// boxes iterates over the child boxes in b, calling fn with each type and
+// payload.
+func boxes(b []byte, fn func(typ string, payload []byte)) {
+	for len(b) >= 8 {
+		size, hdr := uint64(binary.BigEndian.Uint32(b)), uint64(8)
+		typ := string(b[4:8])
+		if size == 1 && len(b) >= 16 {
+			size, hdr = binary.BigEndian.Uint64(b[8:]), 16
+		} else if size == 0 {
+			size = uint64(len(b))
+		}
+		if size < hdr || size > uint64(len(b)) {
+			return
+		}
+		fn(typ, b[hdr:size])
+		b = b[size:]
+	}
+}
+
+func parseMoov(moov []byte) Info {
+	var info Info
+	boxes(moov, func(typ string, p []byte) {
+		switch typ {
+		case "mvhd":
+			var created, scale, dur uint64
+			switch {
+			case len(p) >= 20 && p[0] == 0:
+				created = uint64(binary.BigEndian.Uint32(p[4:]))
+				scale = uint64(binary.BigEndian.Uint32(p[12:]))
+				dur = uint64(binary.BigEndian.Uint32(p[16:]))
+			case len(p) >= 32 && p[0] == 1:
+				created = binary.BigEndian.Uint64(p[4:])
+				scale = uint64(binary.BigEndian.Uint32(p[20:]))
+				dur = binary.BigEndian.Uint64(p[24:])
+			}
+			if scale > 0 {
+				info.Duration = time.Duration(float64(dur) / float64(scale) * float64(time.Second))
+			}
+			if created > 0 {
+				info.Taken = quickTimeEpoch.Add(time.Duration(created) * time.Second)
+			}
+		case "trak":
+			if info.Width > 0 {
+				return
+			}
+			boxes(p, func(typ string, p []byte) {
+				if typ != "tkhd" {
+					return
+				}
+				off := 76
+				if len(p) > 0 && p[0] == 1 {
+					off = 88
+				}
+				if len(p) >= off+8 {
+					// 16.16 fixed point
+					info.Width = int(binary.BigEndian.Uint32(p[off:]) >> 16)
+					info.Height = int(binary.BigEndian.Uint32(p[off+4:]) >> 16)
+				}
+			})
+		}
+	})
+	return info
+}
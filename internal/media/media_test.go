@@ -0,0 +1,122 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, name string, data []byte) string {
+	t.Helper()
+	p := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(p, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+// exifSegment builds an APP1 segment whose Exif IFD holds DateTimeOriginal.
+func exifSegment(taken string) []byte {
+	bo := binary.LittleEndian
+	val := append([]byte(taken), 0)
+	var tiff []byte
+	tiff = append(tiff, 'I', 'I', 0x2A, 0)
+	tiff = bo.AppendUint32(tiff, 8)
+	// IFD0 at 8: one entry pointing to the Exif IFD at 26.
+	tiff = bo.AppendUint16(tiff, 1)
+	tiff = bo.AppendUint16(tiff, tagExifIFD)
+	tiff = bo.AppendUint16(tiff, 4) // LONG
+	tiff = bo.AppendUint32(tiff, 1)
+	tiff = bo.AppendUint32(tiff, 26)
+	tiff = bo.AppendUint32(tiff, 0)
+	// Exif IFD at 26: DateTimeOriginal stored at 44.
+	tiff = bo.AppendUint16(tiff, 1)
+	tiff = bo.AppendUint16(tiff, tagDateTimeOriginal)
+	tiff = bo.AppendUint16(tiff, 2) // ASCII
+	tiff = bo.AppendUint32(tiff, uint32(len(val)))
+	tiff = bo.AppendUint32(tiff, 44)
+	tiff = bo.AppendUint32(tiff, 0)
+	tiff = append(tiff, val...)
+
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	seg := []byte{0xFF, 0xE1}
+	seg = binary.BigEndian.AppendUint16(seg, uint16(len(payload)+2))
+	return append(seg, payload...)
+}
+
+func TestRead_JPEGWithExif(t *testing.T) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewGray(image.Rect(0, 0, 40, 30)), nil); err != nil {
+		t.Fatal(err)
+	}
+	b := buf.Bytes()
+	data := append(append(append([]byte(nil), b[:2]...), exifSegment("2014:07:04 12:30:00")...), b[2:]...)
+
+	info, ok, err := Read(writeFile(t, "a.JPG", data))
+	if err != nil || !ok {
+		t.Fatalf("ok=%v err=%v", ok, err)
+	}
+	if info.Width != 40 || info.Height != 30 {
+		t.Errorf("size %dx%d, want 40x30", info.Width, info.Height)
+	}
+	if want := time.Date(2014, 7, 4, 12, 30, 0, 0, time.Local); !info.Taken.Equal(want) {
+		t.Errorf("taken %v, want %v", info.Taken, want)
+	}
+}
+
+func TestRead_PNG(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, 7, 3))); err != nil {
+		t.Fatal(err)
+	}
+	info, ok, err := Read(writeFile(t, "a.png", buf.Bytes()))
+	if err != nil || !ok || info.Width != 7 || info.Height != 3 || !info.Taken.IsZero() {
+		t.Fatalf("got %+v ok=%v err=%v", info, ok, err)
+	}
+}
+
+func box(typ string, payload ...[]byte) []byte {
+	body := bytes.Join(payload, nil)
+	b := binary.BigEndian.AppendUint32(nil, uint32(8+len(body)))
+	return append(append(b, typ...), body...)
+}
+
+func TestRead_MP4(t *testing.T) {
+	be := binary.BigEndian
+	created := time.Date(2012, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	mvhd := make([]byte, 100)
+	be.PutUint32(mvhd[4:], uint32(created.Sub(quickTimeEpoch)/time.Second))
+	be.PutUint32(mvhd[12:], 1000)  // timescale
+	be.PutUint32(mvhd[16:], 12500) // 12.5s
+
+	tkhd := make([]byte, 84)
+	be.PutUint32(tkhd[76:], 1920<<16)
+	be.PutUint32(tkhd[80:], 1080<<16)
+
+	data := bytes.Join([][]byte{
+		box("ftyp", []byte("isom\x00\x00\x02\x00")),
+		box("mdat", make([]byte, 64)),
+		box("moov", box("mvhd", mvhd), box("trak", box("tkhd", tkhd))),
+	}, nil)
+
+	info, ok, err := Read(writeFile(t, "clip.mp4", data))
+	if err != nil || !ok {
+		t.Fatalf("ok=%v err=%v", ok, err)
+	}
+	if info.Duration != 12500*time.Millisecond || info.Width != 1920 || info.Height != 1080 || !info.Taken.Equal(created) {
+		t.Fatalf("got %+v", info)
+	}
+}
+
+func TestRead_Unsupported(t *testing.T) {
+	if _, ok, err := Read(writeFile(t, "a.txt", []byte("x"))); ok || err != nil {
+		t.Fatalf("ok=%v err=%v", ok, err)
+	}
+}
@@ -0,0 +1,145 @@
+// Package pathtab holds many paths compactly. A path is kept as a reference
+// to its interned parent directory plus the rest of its name, so the
+// directory prefixes a tree's paths share are stored once instead of in
+// every path.
+//
+// Paths are split at their last separator, which stays with the name, so
+// any string round-trips exactly: "./a/b", "C:\x" and "dir/" included.
+package pathtab
+
+import (
+	"bytes"
+	"os"
+	"strings"
+)
+
+// ID identifies an interned directory of a Table.
+type ID int32
+
+// Key is a path of a Table: its directory and the name below it, starting
+// with the separator unless the path has none.
+type Key struct {
+	Dir  ID
+	Name string
+}
+
+// root is the empty prefix, the directory of paths without a separator.
+const root ID = 0
+
+type dir struct {
+	parent ID
+	name   string
+}
+
+// Table interns directories. The zero value is ready to use. A Table is not
+// safe for concurrent use.
+type Table struct {
+	dirs []dir
+	ids  map[Key]ID
+	// last caches the most recent directory, as paths tend to come in
+	// runs from the same one.
+	last   string
+	lastID ID
+	// a and b are scratch space for Compare.
+	a, b []byte
+}
+
+// Key returns the key of path, interning its directories.
+func (t *Table) Key(path string) Key {
+	i := lastSeparator(path)
+	if i < 0 {
+		return Key{Dir: root, Name: strings.Clone(path)}
+	}
+	return Key{Dir: t.intern(path[:i]), Name: strings.Clone(path[i:])}
+}
+
+// Find returns the key of path if its directory has been interned.
+func (t *Table) Find(path string) (Key, bool) {
+	i := lastSeparator(path)
+	if i < 0 {
+		return Key{Dir: root, Name: path}, true
+	}
+	id, ok := t.find(path[:i])
+	return Key{Dir: id, Name: path[i:]}, ok
+}
+
+// Path returns the path of k.
+func (t *Table) Path(k Key) string {
+	return string(t.AppendPath(nil, k))
+}
+
+// AppendPath appends the path of k to b.
+func (t *Table) AppendPath(b []byte, k Key) []byte {
+	b = t.appendDir(b, k.Dir)
+	return append(b, k.Name...)
+}
+
+// Compare compares the paths of a and b like strings.Compare.
+func (t *Table) Compare(a, b Key) int {
+	t.a, t.b = t.AppendPath(t.a[:0], a), t.AppendPath(t.b[:0], b)
+	return bytes.Compare(t.a, t.b)
+}
+
+func (t *Table) appendDir(b []byte, id ID) []byte {
+	if id == root {
+		return b
+	}
+	d := t.dirs[id-1]
+	return append(t.appendDir(b, d.parent), d.name...)
+}
+
+// intern returns the ID of directory p, adding it and its parents as needed.
+func (t *Table) intern(p string) ID {
+	if p == "" {
+		return root
+	}
+	if p == t.last {
+		return t.lastID
+	}
+	parent, name := root, p
+	if i := lastSeparator(p); i >= 0 {
+		parent, name = t.intern(p[:i]), p[i:]
+	}
+	id, ok := t.ids[Key{parent, name}]
+	if !ok {
+		if t.ids == nil {
+			t.ids = make(map[Key]ID)
+		}
+		name = strings.Clone(name)
+		t.dirs = append(t.dirs, dir{parent: parent, name: name})
+		id = ID(len(t.dirs))
+		t.ids[Key{parent, name}] = id
+	}
+	t.last, t.lastID = strings.Clone(p), id
+	return id
+}
+
+// find is intern without adding anything.
+func (t *Table) find(p string) (ID, bool) {
+	if p == "" {
+		return root, true
+	}
+	if p == t.last {
+		return t.lastID, true
+	}
+	parent, name := root, p
+	if i := lastSeparator(p); i >= 0 {
+		var ok bool
+		if parent, ok = t.find(p[:i]); !ok {
+			return 0, false
+		}
+		name = p[i:]
+	}
+	id, ok := t.ids[Key{parent, name}]
+	return id, ok
+}
+
+// lastSeparator returns the index of the last path separator in p, or -1.
+func lastSeparator(p string) int {
+	for i := len(p) - 1; i >= 0; i-- {
+		if os.IsPathSeparator(p[i]) {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,67 @@
+package pathtab
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestRoundTrip(t *testing.T) {
+	paths := []string{
+		"/", "/a", "/a/b", "/a/b/c.txt", "/a/bc", "a", "a/b", "./a/b", "a/", "a//b", "",
+		filepath.Join("x", "y", "z"),
+	}
+	if runtime.GOOS == "windows" {
+		paths = append(paths, `C:\`, `C:\x\y`, `C:\x/y`, `\\server\share\f`)
+	}
+	var tab Table
+	keys := make([]Key, len(paths))
+	for i, p := range paths {
+		keys[i] = tab.Key(p)
+	}
+	for i, p := range paths {
+		if got := tab.Path(keys[i]); got != p {
+			t.Errorf("Path(Key(%q)) = %q", p, got)
+		}
+		if k, ok := tab.Find(p); !ok || k != keys[i] {
+			t.Errorf("Find(%q) = %v, %v; want %v", p, k, ok, keys[i])
+		}
+	}
+	for _, p := range []string{"/b/c", "/a/b/c/d", "b/c"} {
+		if k, ok := tab.Find(p); ok {
+			t.Errorf("Find(%q) = %v for a directory never added", p, k)
+		}
+	}
+	if k, ok := tab.Find("/a/b/new.txt"); !ok || tab.Path(k) != "/a/b/new.txt" {
+		t.Errorf("Find of a new name in a known directory = %v, %v", k, ok)
+	}
+
+	slices.SortFunc(keys, tab.Compare)
+	sorted := slices.Clone(paths)
+	slices.Sort(sorted)
+	for i, k := range keys {
+		if got := tab.Path(k); got != sorted[i] {
+			t.Errorf("sorted %d: %q, want %q", i, got, sorted[i])
+		}
+	}
+}
+
+func TestSharedDirectories(t *testing.T) {
+	var tab Table
+	for d := 0; d < 10; d++ {
+		for f := 0; f < 100; f++ {
+			tab.Key(fmt.Sprintf("/home/user/projects/repo/dir%d/file%d.go", d, f))
+		}
+	}
+	// home, user, projects, repo and the ten dirN.
+	if len(tab.dirs) != 14 {
+		t.Errorf("%d directories interned, want 14", len(tab.dirs))
+	}
+	k := tab.Key("/home/user/projects/repo/dir3/file7.go")
+	if k.Name != "/file7.go" || !strings.HasSuffix(tab.Path(Key{Dir: k.Dir}), "/dir3") {
+		t.Errorf("got %+v", k)
+	}
+}
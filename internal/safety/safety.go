@@ -0,0 +1,101 @@
+// Package safety refuses destructive actions on roots where a mistaken filter
+// would be catastrophic: the root of a filesystem (/, C:\), the user's home
+// directory, or a directory containing it.
+package safety
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// OverrideFlag is the flag that lets actions run on a dangerous root.
+const OverrideFlag = "--i-know-what-im-doing"
+
+// RootError reports a dangerous root, and how to proceed.
+type RootError struct {
+	// Root is the root as given; Reason says what it is.
+	Root   string
+	Reason string
+}
+
+func (e *RootError) Error() string {
+	return fmt.Sprintf("refusing to delete or move files below %s: it is %s, where a mistaken filter could destroy the system or your data; "+
+		"search a narrower --root, review the operations with --plan, or pass %s if this is intended", e.Root, e.Reason, OverrideFlag)
+}
+
+// CheckRoot returns a *RootError when actions on the files below root could
+// be catastrophic, and nil otherwise. Symlinks in root and in the home
+// directory are resolved where possible, so an alias of either is caught.
+func CheckRoot(root string) error {
+	home, _ := os.UserHomeDir()
+	return checkRoot(root, home)
+}
+
+// CheckRoots is CheckRoot for each of roots, returning the first error.
+func CheckRoots(roots []string) error {
+	for _, r := range roots {
+		if err := CheckRoot(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkRoot(root, home string) error {
+	p, err := canonical(root)
+	if err != nil {
+		return fmt.Errorf("checking root %s: %w", root, err)
+	}
+	if filepath.Dir(p) == p {
+		return &RootError{Root: root, Reason: "the root of a filesystem"}
+	}
+	if home == "" {
+		return nil
+	}
+	h, err := canonical(home)
+	if err != nil {
+		return nil
+	}
+	switch {
+	case samePath(p, h):
+		return &RootError{Root: root, Reason: "your home directory"}
+	case within(h, p):
+		return &RootError{Root: root, Reason: "a directory containing your home directory (" + home + ")"}
+	}
+	return nil
+}
+
+// canonical returns the absolute, clean form of p with its symlinks
+// resolved when p exists.
+func canonical(p string) (string, error) {
+	abs, err := filepath.Abs(p)
+	if err != nil {
+		return "", err
+	}
+	if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+		return resolved, nil
+	}
+	return abs, nil
+}
+
+// foldCase is set where the default filesystems ignore case.
+var foldCase = runtime.GOOS == "windows" || runtime.GOOS == "darwin"
+
+func samePath(a, b string) bool {
+	if foldCase {
+		return strings.EqualFold(a, b)
+	}
+	return a == b
+}
+
+// within reports whether path is below dir.
+func within(path, dir string) bool {
+	prefix := dir
+	if !strings.HasSuffix(prefix, string(filepath.Separator)) {
+		prefix += string(filepath.Separator)
+	}
+	return len(path) > len(prefix) && samePath(path[:len(prefix)], prefix)
+}
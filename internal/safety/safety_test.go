@@ -0,0 +1,49 @@
+package safety
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckRoot(t *testing.T) {
+	base := t.TempDir()
+	home := filepath.Join(base, "home", "me")
+	project := filepath.Join(home, "src", "project")
+	if err := os.MkdirAll(project, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	fsRoot := filepath.VolumeName(base) + string(filepath.Separator)
+
+	for _, tc := range []struct {
+		root      string
+		dangerous bool
+	}{
+		{fsRoot, true},
+		{home, true},
+		{home + string(filepath.Separator) + ".", true},
+		{filepath.Join(base, "home"), true},
+		{base, true},
+		{project, false},
+		{filepath.Join(home, "src"), false},
+		{filepath.Join(base, "elsewhere"), false},
+	} {
+		err := checkRoot(tc.root, home)
+		var re *RootError
+		if got := errors.As(err, &re); got != tc.dangerous {
+			t.Errorf("checkRoot(%s) = %v, want dangerous=%v", tc.root, err, tc.dangerous)
+		}
+	}
+
+	// A symlink to the home directory is the home directory.
+	link := filepath.Join(base, "link")
+	if err := os.Symlink(home, link); err == nil {
+		if err := checkRoot(link, home); err == nil {
+			t.Errorf("checkRoot(%s) accepted a link to the home directory", link)
+		}
+	}
+	if err := checkRoot(project, ""); err != nil {
+		t.Errorf("without a home directory: %v", err)
+	}
+}
@@ -0,0 +1,210 @@
+// Package secrets finds credentials in lines of text: cloud access keys,
+// private keys, tokens and passwords assigned in configuration. Each Rule
+// is a regular expression with a severity; rules for free-form values also
+// require the value to look random, measured by its Shannon entropy, so
+// that placeholders and words do not count.
+package secrets
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Severity ranks how much damage a leaked secret can do.
+type Severity int
+
+// The severities, lowest first.
+const (
+	Low Severity = iota
+	Medium
+	High
+	Critical
+)
+
+var severityNames = []string{"low", "medium", "high", "critical"}
+
+func (s Severity) String() string {
+	if s < Low || s > Critical {
+		return fmt.Sprintf("Severity(%d)", int(s))
+	}
+	return severityNames[s]
+}
+
+// MarshalText writes the severity by name.
+func (s Severity) MarshalText() ([]byte, error) { return []byte(s.String()), nil }
+
+// ParseSeverity returns the severity called name, ignoring case.
+func ParseSeverity(name string) (Severity, error) {
+	i := slices.Index(severityNames, strings.ToLower(strings.TrimSpace(name)))
+	if i < 0 {
+		return 0, fmt.Errorf("unknown severity %q (want %s)", name, strings.Join(severityNames, ", "))
+	}
+	return Severity(i), nil
+}
+
+// Rule describes one kind of secret.
+type Rule struct {
+	Name     string
+	Severity Severity
+	// Pattern matches the secret, or the text around it with the secret
+	// in its first group.
+	Pattern *regexp.Regexp
+	// Entropy, when set, requires the secret to have at least
+	// Scanner.MinEntropy bits of entropy per character.
+	Entropy bool
+	// Header marks a Pattern matching the line that starts a secret, such
+	// as a PEM header, rather than the secret; its findings have no Secret.
+	Header bool
+}
+
+// Rules are the built-in rules.
+var Rules = []Rule{
+	{Name: "private-key", Severity: Critical, Header: true,
+		Pattern: regexp.MustCompile(`-----BEGIN (?:[A-Z0-9]+ )*PRIVATE KEY(?: BLOCK)?-----`)},
+	{Name: "aws-access-key-id", Severity: High,
+		Pattern: regexp.MustCompile(`\b((?:AKIA|ASIA|ABIA|ACCA)[A-Z2-7]{16})\b`)},
+	{Name: "aws-secret-access-key", Severity: Critical, Entropy: true,
+		Pattern: regexp.MustCompile(`(?i)aws.{0,20}?(?:secret|private).{0,20}?['"]?\s*[:=]\s*['"]?([A-Za-z0-9/+]{40})\b`)},
+	{Name: "github-token", Severity: High,
+		Pattern: regexp.MustCompile(`\b((?:ghp|gho|ghu|ghs|ghr)_[A-Za-z0-9]{36}|github_pat_[A-Za-z0-9_]{82})\b`)},
+	{Name: "slack-token", Severity: High,
+		Pattern: regexp.MustCompile(`\b(xox[abposr]-[0-9A-Za-z-]{10,})`)},
+	{Name: "jwt", Severity: Medium,
+		Pattern: regexp.MustCompile(`\b(eyJ[A-Za-z0-9_-]{8,}\.eyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{16,})`)},
+	{Name: "generic-secret", Severity: Low, Entropy: true,
+		Pattern: regexp.MustCompile(`(?i)(?:password|passwd|pwd|secret|api[_-]?key|access[_-]?token|auth[_-]?token|client[_-]?secret)["']?\s*[:=]\s*["']?([^\s"'` + "`" + `,;]{8,})`)},
+}
+
+// RuleNames returns the names of Rules.
+func RuleNames() []string {
+	names := make([]string, len(Rules))
+	for i, r := range Rules {
+		names[i] = r.Name
+	}
+	return names
+}
+
+// IgnoreMarker on a line keeps its secrets from being reported, for test
+// fixtures and documented examples.
+const IgnoreMarker = "gofind:ignore"
+
+// DefaultMinEntropy is the entropy, in bits per character, a secret of a
+// Rule with Entropy must reach by default. Words and placeholders stay
+// below it; random keys of 16 characters or more are well above.
+const DefaultMinEntropy = 3.5
+
+// placeholders are parts of values that mark them as examples.
+var placeholders = []string{"example", "placeholder", "changeme", "dummy", "xxxxxx", "<", "${", "{{"}
+
+// Finding is a secret found in a line.
+type Finding struct {
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	// Column is the byte offset of the secret in the line, from 1.
+	Column int `json:"column"`
+	// Secret is the secret itself; see Redact.
+	Secret  string  `json:"secret,omitempty"`
+	Entropy float64 `json:"entropy,omitempty"`
+}
+
+// Scanner reports the secrets Rules find.
+type Scanner struct {
+	Rules []Rule
+	// MinSeverity drops findings of rules below it.
+	MinSeverity Severity
+	// MinEntropy is the entropy a secret of a Rule with Entropy must
+	// reach, usually DefaultMinEntropy.
+	MinEntropy float64
+}
+
+// Pattern returns a regular expression matching every line in which the
+// scanner's rules may find a secret, for searching files before Scan.
+func (s *Scanner) Pattern() *regexp.Regexp {
+	var alts []string
+	for _, r := range s.Rules {
+		if r.Severity >= s.MinSeverity {
+			alts = append(alts, "(?:"+r.Pattern.String()+")")
+		}
+	}
+	if len(alts) == 0 {
+		return regexp.MustCompile(`$^`)
+	}
+	return regexp.MustCompile(strings.Join(alts, "|"))
+}
+
+// Scan returns the secrets in line, in the order of the scanner's rules.
+func (s *Scanner) Scan(line string) []Finding {
+	if strings.Contains(line, IgnoreMarker) {
+		return nil
+	}
+	var found []Finding
+	for _, r := range s.Rules {
+		if r.Severity < s.MinSeverity {
+			continue
+		}
+		for _, m := range r.Pattern.FindAllStringSubmatchIndex(line, -1) {
+			start, end := m[0], m[1]
+			if len(m) >= 4 && m[2] >= 0 {
+				start, end = m[2], m[3]
+			}
+			if r.Header {
+				found = append(found, Finding{Rule: r.Name, Severity: r.Severity, Column: start + 1})
+				continue
+			}
+			secret := line[start:end]
+			if isPlaceholder(secret) {
+				continue
+			}
+			e := Entropy(secret)
+			if r.Entropy && e < s.MinEntropy {
+				continue
+			}
+			found = append(found, Finding{Rule: r.Name, Severity: r.Severity, Column: start + 1, Secret: secret, Entropy: math.Round(e*100) / 100})
+		}
+	}
+	return found
+}
+
+func isPlaceholder(s string) bool {
+	s = strings.ToLower(s)
+	for _, p := range placeholders {
+		if strings.Contains(s, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// Entropy returns the Shannon entropy of s in bits per byte.
+func Entropy(s string) float64 {
+	if s == "" {
+		return 0
+	}
+	var counts [256]int
+	for i := 0; i < len(s); i++ {
+		counts[s[i]]++
+	}
+	var h float64
+	n := float64(len(s))
+	for _, c := range counts {
+		if c > 0 {
+			p := float64(c) / n
+			h -= p * math.Log2(p)
+		}
+	}
+	return h
+}
+
+// Redact returns secret with all but its first four characters masked, or
+// all of them for secrets of eight characters or fewer, so reports can be
+// shared without spreading what they report.
+func Redact(secret string) string {
+	r := []rune(secret)
+	if len(r) <= 8 {
+		return strings.Repeat("*", len(r))
+	}
+	return string(r[:4]) + strings.Repeat("*", len(r)-4)
+}
@@ -0,0 +1,271 @@
+// Package stats aggregates matched entries into summaries such as size and
+// age histograms and per-extension totals.
+package stats
+
+import (
+	"fmt"
+	"math"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Hamed0406/gofind/internal/finder"
+)
+
+// DefaultSizeBounds are the upper bounds (exclusive) of the size histogram
+// buckets; a final bucket holds everything larger.
+var DefaultSizeBounds = []int64{1 << 10, 10 << 10, 100 << 10, 1 << 20, 10 << 20, 100 << 20, 1 << 30}
+
+// DefaultAgeBounds are the upper bounds (exclusive) of the age histogram
+// buckets, measured from Options.Now to the modification time.
+var DefaultAgeBounds = []time.Duration{24 * time.Hour, 7 * 24 * time.Hour, 30 * 24 * time.Hour, 90 * 24 * time.Hour, 365 * 24 * time.Hour}
+
+// Options configures an Aggregator. Zero values select the defaults.
+type Options struct {
+	// Now is the reference time for ages (default time.Now()).
+	Now time.Time
+	// SizeBounds and AgeBounds must be ascending.
+	SizeBounds []int64
+	AgeBounds  []time.Duration
+	// Sample is the finder.Config.Sample of the search the entries come
+	// from; the Report then estimates the counts of the whole search.
+	Sample float64
+}
+
+// Bucket is one histogram bar.
+type Bucket struct {
+	Label string `json:"label"`
+	Files int64  `json:"files"`
+	Bytes int64  `json:"bytes"`
+}
+
+// ExtStat totals the files sharing an extension ("" for none).
+type ExtStat struct {
+	Ext   string `json:"ext"`
+	Files int64  `json:"files"`
+	Bytes int64  `json:"bytes"`
+}
+
+// Report is the summary produced by an Aggregator.
+type Report struct {
+	Files int64 `json:"files"`
+	Dirs  int64 `json:"dirs"`
+	Bytes int64 `json:"bytes"`
+	// Lines and BlankLines total the counts of entries enriched with them
+	// (finder's "lines" enricher).
+	Lines      int64    `json:"lines,omitempty"`
+	BlankLines int64    `json:"blankLines,omitempty"`
+	Sizes      []Bucket `json:"sizes"`
+	Ages       []Bucket `json:"ages"`
+	// ExtByCount and ExtByBytes list the top extensions, most first.
+	ExtByCount []ExtStat `json:"extByCount"`
+	ExtByBytes []ExtStat `json:"extByBytes"`
+	// Sample is Options.Sample when the counts are estimates, scaled up
+	// from the SampledFiles and SampledDirs actually seen.
+	Sample       float64 `json:"sample,omitempty"`
+	SampledFiles int64   `json:"sampledFiles,omitempty"`
+	SampledDirs  int64   `json:"sampledDirs,omitempty"`
+	// Truncated lists the directories whose matches were cut off by
+	// finder.Config.MaxPerDir; the caller copies it from finder.Result.
+	Truncated []finder.TruncatedDir `json:"truncated,omitempty"`
+}
+
+// Aggregator accumulates entries. It is not safe for concurrent use; feed it
+// from finder.Walk, whose callback is serialized.
+type Aggregator struct {
+	opts  Options
+	files int64
+	dirs  int64
+	bytes int64
+	lines int64
+	blank int64
+	sizes []Bucket
+	ages  []Bucket
+	exts  map[string]*ExtStat
+}
+
+// New returns an empty Aggregator.
+func New(opts Options) *Aggregator {
+	if opts.Now.IsZero() {
+		opts.Now = time.Now()
+	}
+	if opts.SizeBounds == nil {
+		opts.SizeBounds = DefaultSizeBounds
+	}
+	if opts.AgeBounds == nil {
+		opts.AgeBounds = DefaultAgeBounds
+	}
+	return &Aggregator{
+		opts:  opts,
+		sizes: sizeBuckets(opts.SizeBounds),
+		ages:  ageBuckets(opts.AgeBounds),
+		exts:  make(map[string]*ExtStat),
+	}
+}
+
+// Add records e. Directories are only counted.
+func (a *Aggregator) Add(e finder.Entry) {
+	if e.IsDir {
+		a.dirs++
+		return
+	}
+	a.files++
+	a.bytes += e.Size
+	if n, ok := e.Extra["lines"].(int64); ok {
+		a.lines += n
+	}
+	if n, ok := e.Extra["blankLines"].(int64); ok {
+		a.blank += n
+	}
+
+	b := &a.sizes[sort.Search(len(a.opts.SizeBounds), func(i int) bool { return e.Size < a.opts.SizeBounds[i] })]
+	b.Files++
+	b.Bytes += e.Size
+
+	age := a.opts.Now.Sub(e.ModTime)
+	b = &a.ages[AgeIndex(a.opts.AgeBounds, age)]
+	b.Files++
+	b.Bytes += e.Size
+
+	ext := strings.ToLower(filepath.Ext(e.Name))
+	s := a.exts[ext]
+	if s == nil {
+		s = &ExtStat{Ext: ext}
+		a.exts[ext] = s
+	}
+	s.Files++
+	s.Bytes += e.Size
+}
+
+// AgeIndex returns the index of the bucket holding age among buckets bounded
+// by bounds; len(bounds) is the open-ended last bucket.
+func AgeIndex(bounds []time.Duration, age time.Duration) int {
+	return sort.Search(len(bounds), func(i int) bool { return age < bounds[i] })
+}
+
+// Report returns the summary, listing at most topExt extensions (<= 0 = all).
+func (a *Aggregator) Report(topExt int) Report {
+	r := Report{
+		Files:      a.files,
+		Dirs:       a.dirs,
+		Bytes:      a.bytes,
+		Lines:      a.lines,
+		BlankLines: a.blank,
+		Sizes:      append([]Bucket(nil), a.sizes...),
+		Ages:       append([]Bucket(nil), a.ages...),
+	}
+	exts := make([]ExtStat, 0, len(a.exts))
+	for _, s := range a.exts {
+		exts = append(exts, *s)
+	}
+	r.ExtByCount = topExts(exts, topExt, func(s ExtStat) int64 { return s.Files })
+	r.ExtByBytes = topExts(exts, topExt, func(s ExtStat) int64 { return s.Bytes })
+	if p := a.opts.Sample; p > 0 && p < 1 {
+		r.extrapolate(p)
+	}
+	return r
+}
+
+// extrapolate scales the counts of r, drawn from share p of the directories,
+// to estimates for all of them.
+func (r *Report) extrapolate(p float64) {
+	scale := func(n *int64) { *n = int64(math.Round(float64(*n) / p)) }
+	r.Sample, r.SampledFiles, r.SampledDirs = p, r.Files, r.Dirs
+	for _, n := range []*int64{&r.Files, &r.Dirs, &r.Bytes, &r.Lines, &r.BlankLines} {
+		scale(n)
+	}
+	for _, bs := range [][]Bucket{r.Sizes, r.Ages} {
+		for i := range bs {
+			scale(&bs[i].Files)
+			scale(&bs[i].Bytes)
+		}
+	}
+	for _, es := range [][]ExtStat{r.ExtByCount, r.ExtByBytes} {
+		for i := range es {
+			scale(&es[i].Files)
+			scale(&es[i].Bytes)
+		}
+	}
+}
+
+func topExts(exts []ExtStat, n int, key func(ExtStat) int64) []ExtStat {
+	out := append([]ExtStat(nil), exts...)
+	sort.Slice(out, func(i, j int) bool {
+		if ki, kj := key(out[i]), key(out[j]); ki != kj {
+			return ki > kj
+		}
+		return out[i].Ext < out[j].Ext
+	})
+	if n > 0 && len(out) > n {
+		out = out[:n]
+	}
+	return out
+}
+
+func sizeBuckets(bounds []int64) []Bucket {
+	b := make([]Bucket, len(bounds)+1)
+	var lo int64
+	for i, hi := range bounds {
+		b[i].Label = fmt.Sprintf("%s-%s", FormatBytes(lo), FormatBytes(hi))
+		lo = hi
+	}
+	b[len(bounds)].Label = ">=" + FormatBytes(lo)
+	return b
+}
+
+func ageBuckets(bounds []time.Duration) []Bucket {
+	labels := AgeLabels(bounds)
+	b := make([]Bucket, len(labels))
+	for i, l := range labels {
+		b[i].Label = l
+	}
+	return b
+}
+
+// AgeLabels returns the labels of the age histogram buckets bounded by
+// bounds (e.g. "<7d", ">=1y"), indexed like AgeIndex.
+func AgeLabels(bounds []time.Duration) []string {
+	labels := make([]string, len(bounds)+1)
+	for i, hi := range bounds {
+		labels[i] = "<" + FormatAge(hi)
+	}
+	if n := len(bounds); n > 0 {
+		labels[n] = ">=" + FormatAge(bounds[n-1])
+	} else {
+		labels[0] = "all"
+	}
+	return labels
+}
+
+// FormatBytes renders n with binary units (e.g. "1.5MB").
+func FormatBytes(n int64) string {
+	const unit = 1 << 10
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	s := fmt.Sprintf("%.1f", float64(n)/float64(div))
+	s = strings.TrimSuffix(s, ".0")
+	return s + string("KMGTPE"[exp]) + "B"
+}
+
+// FormatAge renders d in the largest whole unit of days, weeks or years,
+// falling back to a time.Duration string below a day (e.g. "30d", "1y").
+func FormatAge(d time.Duration) string {
+	const day = 24 * time.Hour
+	switch {
+	case d >= 365*day && d%(365*day) == 0:
+		return fmt.Sprintf("%dy", d/(365*day))
+	case d >= 7*day && d%(7*day) == 0 && d < 30*day:
+		return fmt.Sprintf("%dw", d/(7*day))
+	case d >= day && d%day == 0:
+		return fmt.Sprintf("%dd", d/day)
+	default:
+		return d.String()
+	}
+}
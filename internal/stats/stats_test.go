@@ -0,0 +1,82 @@
+package stats_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Hamed0406/gofind/internal/finder"
+	"github.com/Hamed0406/gofind/internal/stats"
+)
+
+func TestAggregator(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	agg := stats.New(stats.Options{
+		Now:        now,
+		SizeBounds: []int64{100, 1000},
+		AgeBounds:  []time.Duration{24 * time.Hour, 30 * 24 * time.Hour},
+	})
+	for _, e := range []finder.Entry{
+		{Name: "a.go", Size: 10, ModTime: now.Add(-time.Hour)},
+		{Name: "b.GO", Size: 500, ModTime: now.Add(-48 * time.Hour)},
+		{Name: "c.iso", Size: 5000, ModTime: now.Add(-400 * 24 * time.Hour)},
+		{Name: "Makefile", Size: 50, ModTime: now},
+		{Name: "sub", IsDir: true},
+	} {
+		agg.Add(e)
+	}
+	r := agg.Report(2)
+
+	if r.Files != 4 || r.Dirs != 1 || r.Bytes != 5560 {
+		t.Fatalf("totals: %+v", r)
+	}
+	if r.Sizes[0].Files != 2 || r.Sizes[1].Files != 1 || r.Sizes[2].Files != 1 {
+		t.Fatalf("size histogram: %+v", r.Sizes)
+	}
+	if r.Sizes[2].Label != ">=1000B" || r.Ages[2].Label != ">=30d" {
+		t.Fatalf("labels: %+v %+v", r.Sizes, r.Ages)
+	}
+	if r.Ages[0].Files != 2 || r.Ages[1].Files != 1 || r.Ages[2].Bytes != 5000 {
+		t.Fatalf("age histogram: %+v", r.Ages)
+	}
+	if len(r.ExtByCount) != 2 || r.ExtByCount[0].Ext != ".go" || r.ExtByCount[0].Files != 2 {
+		t.Fatalf("ext by count: %+v", r.ExtByCount)
+	}
+	if r.ExtByBytes[0].Ext != ".iso" || r.ExtByBytes[1].Ext != ".go" {
+		t.Fatalf("ext by bytes: %+v", r.ExtByBytes)
+	}
+}
+
+func TestAggregator_Lines(t *testing.T) {
+	agg := stats.New(stats.Options{})
+	agg.Add(finder.Entry{Name: "a.go", Extra: map[string]any{"lines": int64(10), "blankLines": int64(2)}})
+	agg.Add(finder.Entry{Name: "b.go", Extra: map[string]any{"lines": int64(5), "blankLines": int64(0)}})
+	agg.Add(finder.Entry{Name: "c.bin"})
+	if r := agg.Report(0); r.Lines != 15 || r.BlankLines != 2 {
+		t.Fatalf("lines %d blank %d, want 15 and 2", r.Lines, r.BlankLines)
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	for n, want := range map[int64]string{0: "0B", 1023: "1023B", 1024: "1KB", 1536: "1.5KB", 10 << 20: "10MB", 1 << 30: "1GB"} {
+		if got := stats.FormatBytes(n); got != want {
+			t.Errorf("FormatBytes(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
+
+func TestAggregator_Sample(t *testing.T) {
+	agg := stats.New(stats.Options{Sample: 0.25})
+	agg.Add(finder.Entry{Name: "a.go", Size: 10})
+	agg.Add(finder.Entry{Name: "b.go", Size: 30})
+	agg.Add(finder.Entry{Name: "sub", IsDir: true})
+	r := agg.Report(0)
+	if r.Files != 8 || r.Dirs != 4 || r.Bytes != 160 {
+		t.Fatalf("estimates: %d files, %d dirs, %d bytes; want 8, 4, 160", r.Files, r.Dirs, r.Bytes)
+	}
+	if r.Sample != 0.25 || r.SampledFiles != 2 || r.SampledDirs != 1 {
+		t.Fatalf("sample %g of %d files, %d dirs", r.Sample, r.SampledFiles, r.SampledDirs)
+	}
+	if r.ExtByCount[0].Files != 8 || r.Sizes[0].Bytes != 160 {
+		t.Fatalf("tables not scaled: %+v %+v", r.ExtByCount, r.Sizes)
+	}
+}
@@ -0,0 +1,123 @@
+package stats
+
+import (
+	"container/heap"
+	"path/filepath"
+	"sort"
+
+	"github.com/Hamed0406/gofind/internal/finder"
+)
+
+// TopFiles keeps the n largest files added, in O(n) memory.
+type TopFiles struct {
+	n int
+	h entryHeap
+}
+
+// NewTopFiles returns a TopFiles keeping n entries.
+func NewTopFiles(n int) *TopFiles {
+	return &TopFiles{n: n}
+}
+
+// Add offers e; directories are ignored.
+func (t *TopFiles) Add(e finder.Entry) {
+	if e.IsDir || t.n <= 0 {
+		return
+	}
+	if len(t.h) < t.n {
+		heap.Push(&t.h, e)
+		return
+	}
+	if e.Size > t.h[0].Size {
+		t.h[0] = e
+		heap.Fix(&t.h, 0)
+	}
+}
+
+// Result returns the kept entries, largest first.
+func (t *TopFiles) Result() []finder.Entry {
+	out := append([]finder.Entry(nil), t.h...)
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Size != out[j].Size {
+			return out[i].Size > out[j].Size
+		}
+		return out[i].Path < out[j].Path
+	})
+	return out
+}
+
+// entryHeap is a min-heap on Size, so the smallest kept entry is evicted first.
+type entryHeap []finder.Entry
+
+func (h entryHeap) Len() int           { return len(h) }
+func (h entryHeap) Less(i, j int) bool { return h[i].Size < h[j].Size }
+func (h entryHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *entryHeap) Push(x any)        { *h = append(*h, x.(finder.Entry)) }
+func (h *entryHeap) Pop() any {
+	old := *h
+	e := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return e
+}
+
+// DirSize is the total of the files below a directory.
+type DirSize struct {
+	Path  string `json:"path"`
+	Files int64  `json:"files"`
+	Bytes int64  `json:"bytes"`
+}
+
+// DirSizes totals file sizes per directory, recursively, up to the roots.
+type DirSizes struct {
+	roots map[string]bool
+	dirs  map[string]*DirSize
+}
+
+// NewDirSizes returns a DirSizes counting directories below the given roots
+// (exclusive).
+func NewDirSizes(roots ...string) *DirSizes {
+	d := &DirSizes{roots: make(map[string]bool), dirs: make(map[string]*DirSize)}
+	for _, r := range roots {
+		d.roots[filepath.Clean(r)] = true
+	}
+	return d
+}
+
+// Add credits e's size to every ancestor directory below the root.
+func (d *DirSizes) Add(e finder.Entry) {
+	if e.IsDir {
+		return
+	}
+	for dir := filepath.Dir(e.Path); !d.roots[dir]; {
+		s := d.dirs[dir]
+		if s == nil {
+			s = &DirSize{Path: dir}
+			d.dirs[dir] = s
+		}
+		s.Files++
+		s.Bytes += e.Size
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+}
+
+// Top returns the n largest directories (n <= 0 = all), largest first.
+func (d *DirSizes) Top(n int) []DirSize {
+	out := make([]DirSize, 0, len(d.dirs))
+	for _, s := range d.dirs {
+		out = append(out, *s)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Bytes != out[j].Bytes {
+			return out[i].Bytes > out[j].Bytes
+		}
+		return out[i].Path < out[j].Path
+	})
+	if n > 0 && len(out) > n {
+		out = out[:n]
+	}
+	return out
+}
@@ -0,0 +1,45 @@
+package stats_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/Hamed0406/gofind/internal/finder"
+	"github.com/Hamed0406/gofind/internal/stats"
+)
+
+func TestTopFilesAndDirSizes(t *testing.T) {
+	root := filepath.FromSlash("/r")
+	entries := []finder.Entry{
+		{Path: filepath.FromSlash("/r/a/x.bin"), Size: 100},
+		{Path: filepath.FromSlash("/r/a/b/y.bin"), Size: 300},
+		{Path: filepath.FromSlash("/r/c/z.bin"), Size: 50},
+		{Path: filepath.FromSlash("/r/top.bin"), Size: 200},
+		{Path: filepath.FromSlash("/r/a"), IsDir: true},
+	}
+	top := stats.NewTopFiles(2)
+	dirs := stats.NewDirSizes(root)
+	for _, e := range entries {
+		top.Add(e)
+		dirs.Add(e)
+	}
+
+	got := top.Result()
+	if len(got) != 2 || got[0].Size != 300 || got[1].Size != 200 {
+		t.Fatalf("top files: %+v", got)
+	}
+	ds := dirs.Top(0)
+	want := []stats.DirSize{
+		{Path: filepath.FromSlash("/r/a"), Files: 2, Bytes: 400},
+		{Path: filepath.FromSlash("/r/a/b"), Files: 1, Bytes: 300},
+		{Path: filepath.FromSlash("/r/c"), Files: 1, Bytes: 50},
+	}
+	if len(ds) != len(want) {
+		t.Fatalf("dir sizes: %+v", ds)
+	}
+	for i := range want {
+		if ds[i] != want[i] {
+			t.Fatalf("dir sizes[%d] = %+v, want %+v", i, ds[i], want[i])
+		}
+	}
+}
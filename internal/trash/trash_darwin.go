@@ -0,0 +1,28 @@
+package trash
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// infoDir holds gofind's records of the files it put in a macOS trash, which
+// keeps none that Restore could use. Emptying the trash removes them too.
+const infoDir = ".gofind-info"
+
+// homeBin returns the home trash, ~/.Trash.
+func homeBin() (bin, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return bin{}, err
+	}
+	dir := filepath.Join(home, ".Trash")
+	return bin{files: dir, info: filepath.Join(dir, infoDir)}, nil
+}
+
+// topBins returns the trash of the volume at top, $top/.Trashes/$uid.
+func topBins(top string) (write bin, all []bin) {
+	dir := filepath.Join(top, ".Trashes", strconv.Itoa(os.Getuid()))
+	b := bin{files: dir, info: filepath.Join(dir, infoDir)}
+	return b, []bin{b}
+}
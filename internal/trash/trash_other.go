@@ -0,0 +1,18 @@
+//go:build !unix && !windows
+
+package trash
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Put is not supported on this platform.
+func Put(path string) error {
+	return fmt.Errorf("trash %s: %w", path, errors.ErrUnsupported)
+}
+
+// List is not supported on this platform.
+func List(paths ...string) ([]Item, error) {
+	return nil, fmt.Errorf("trash: %w", errors.ErrUnsupported)
+}
@@ -0,0 +1,112 @@
+//go:build unix
+
+package trash
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+)
+
+// Put moves the file at path to the trash: the home trash when it is on the
+// same filesystem, and otherwise the trash at the top of its own filesystem,
+// so the file is never copied.
+func Put(path string) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	fi, err := os.Lstat(abs)
+	if err != nil {
+		return err
+	}
+	dev := devOf(fi)
+	home, err := homeBin()
+	if err != nil {
+		return err
+	}
+	b := home
+	if _, hdev, ok := nearestDev(home.files); !ok || hdev != dev {
+		b, _ = topBins(topDir(filepath.Dir(abs), dev))
+	}
+	if err := b.put(abs, time.Now()); err != nil {
+		return fmt.Errorf("trash %s: %w", path, err)
+	}
+	return nil
+}
+
+// List returns the items in the home trash and in the trashes of the
+// filesystems of paths, the most recently trashed first.
+func List(paths ...string) ([]Item, error) {
+	home, err := homeBin()
+	if err != nil {
+		return nil, err
+	}
+	bins := []bin{home}
+	_, hdev, _ := nearestDev(home.files)
+	for _, p := range paths {
+		abs, err := filepath.Abs(p)
+		if err != nil {
+			return nil, err
+		}
+		existing, dev, ok := nearestDev(abs)
+		if !ok || dev == hdev {
+			continue
+		}
+		_, all := topBins(topDir(existing, dev))
+		bins = append(bins, all...)
+	}
+	var items []Item
+	seen := make(map[string]bool)
+	for _, b := range bins {
+		if seen[b.info] {
+			continue
+		}
+		seen[b.info] = true
+		found, err := b.list()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, found...)
+	}
+	sortItems(items)
+	return items, nil
+}
+
+func devOf(fi os.FileInfo) uint64 {
+	if st, ok := fi.Sys().(*syscall.Stat_t); ok {
+		return uint64(st.Dev)
+	}
+	return 0
+}
+
+// nearestDev returns p, or its nearest existing ancestor, and its device.
+func nearestDev(p string) (string, uint64, bool) {
+	for {
+		if fi, err := os.Stat(p); err == nil {
+			return p, devOf(fi), true
+		}
+		parent := filepath.Dir(p)
+		if parent == p {
+			return "", 0, false
+		}
+		p = parent
+	}
+}
+
+// topDir returns the top directory of the filesystem dev that dir is on.
+func topDir(dir string, dev uint64) string {
+	for {
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return dir
+		}
+		fi, err := os.Stat(parent)
+		if err != nil || devOf(fi) != dev {
+			return dir
+		}
+		dir = parent
+	}
+}
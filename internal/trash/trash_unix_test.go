@@ -0,0 +1,74 @@
+//go:build unix
+
+package trash
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPutListRestore(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_DATA_HOME", filepath.Join(home, "data"))
+	dir := filepath.Join(home, "my files")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	a := filepath.Join(dir, "a 100%.log")
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(a, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("first")
+	if err := Put(a); err != nil {
+		t.Fatal(err)
+	}
+	write("second")
+	if err := Put(a); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Lstat(a); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("trashed file still there: %v", err)
+	}
+
+	items, err := Find(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 2 || items[0].Path != a || items[1].Path != a || items[0].Trashed == items[1].Trashed {
+		t.Fatalf("Find(%s) = %+v", dir, items)
+	}
+	if other, err := Find(filepath.Join(home, "elsewhere")); err != nil || len(other) != 0 {
+		t.Fatalf("Find(elsewhere) = %+v, %v", other, err)
+	}
+
+	// Both have the same deletion second, so the order is that of the
+	// records; restore each, checking the second one cannot overwrite.
+	if err := Restore(items[0]); err != nil {
+		t.Fatal(err)
+	}
+	if err := Restore(items[1]); !errors.Is(err, ErrExists) {
+		t.Fatalf("Restore over a file: %v", err)
+	}
+	if err := os.Remove(a); err != nil {
+		t.Fatal(err)
+	}
+	if err := Restore(items[1]); err != nil {
+		t.Fatal(err)
+	}
+	if left, err := List(); err != nil || len(left) != 0 {
+		t.Fatalf("List after restoring = %+v, %v", left, err)
+	}
+}
+
+func TestEscapePath(t *testing.T) {
+	if got, want := escapePath("/a b/100%/ü"), "/a%20b/100%25/%C3%BC"; got != want {
+		t.Fatalf("escapePath = %q, want %q", got, want)
+	}
+}
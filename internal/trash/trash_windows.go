@@ -0,0 +1,183 @@
+package trash
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var procSHFileOperationW = windows.NewLazySystemDLL("shell32.dll").NewProc("SHFileOperationW")
+
+// shFileOpStruct is SHFILEOPSTRUCTW as laid out on 64-bit Windows; the 32-bit
+// headers pack it differently.
+type shFileOpStruct struct {
+	hwnd                  uintptr
+	wFunc                 uint32
+	pFrom                 *uint16
+	pTo                   *uint16
+	fFlags                uint16
+	fAnyOperationsAborted int32
+	hNameMappings         uintptr
+	lpszProgressTitle     *uint16
+}
+
+const (
+	foDelete           = 0x3
+	fofSilent          = 0x4
+	fofNoConfirmation  = 0x10
+	fofAllowUndo       = 0x40
+	fofNoErrorUI       = 0x400
+	fofWantNukeWarning = 0x4000
+)
+
+// Put moves the file at path to the Recycle Bin of its drive. Windows asks
+// before deleting a file the Recycle Bin cannot hold outright.
+func Put(path string) error {
+	if runtime.GOARCH == "386" || runtime.GOARCH == "arm" {
+		return fmt.Errorf("trash %s: %w on 32-bit Windows", path, errors.ErrUnsupported)
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Lstat(abs); err != nil {
+		return err
+	}
+	from, err := windows.UTF16FromString(abs)
+	if err != nil {
+		return err
+	}
+	from = append(from, 0) // pFrom is a list of paths ending in an empty one
+
+	op := shFileOpStruct{
+		wFunc:  foDelete,
+		pFrom:  &from[0],
+		fFlags: fofAllowUndo | fofNoConfirmation | fofNoErrorUI | fofSilent | fofWantNukeWarning,
+	}
+	if r, _, _ := procSHFileOperationW.Call(uintptr(unsafe.Pointer(&op))); r != 0 {
+		return fmt.Errorf("trash %s: SHFileOperation failed with code %#x", path, r)
+	}
+	if op.fAnyOperationsAborted != 0 {
+		return fmt.Errorf("trash %s: canceled", path)
+	}
+	return nil
+}
+
+// List returns the items in the Recycle Bins of the drive of the home
+// directory and of the drives of paths, the most recently trashed first.
+func List(paths ...string) ([]Item, error) {
+	sid, err := userSID()
+	if err != nil {
+		return nil, err
+	}
+	var vols []string
+	if home, err := os.UserHomeDir(); err == nil {
+		vols = append(vols, filepath.VolumeName(home))
+	}
+	for _, p := range paths {
+		abs, err := filepath.Abs(p)
+		if err != nil {
+			return nil, err
+		}
+		vols = append(vols, filepath.VolumeName(abs))
+	}
+	var items []Item
+	seen := make(map[string]bool)
+	for _, vol := range vols {
+		if vol == "" || seen[strings.ToUpper(vol)] {
+			continue
+		}
+		seen[strings.ToUpper(vol)] = true
+		found, err := listRecycleBin(filepath.Join(vol+`\`, "$Recycle.Bin", sid))
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, found...)
+	}
+	sortItems(items)
+	return items, nil
+}
+
+// listRecycleBin reads the $I records of a Recycle Bin directory; each
+// describes the trashed file of the same name with $R for $I.
+func listRecycleBin(dir string) ([]Item, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var items []Item
+	for _, e := range entries {
+		rest, ok := strings.CutPrefix(e.Name(), "$I")
+		if !ok {
+			continue
+		}
+		it := Item{Trashed: filepath.Join(dir, "$R"+rest), info: filepath.Join(dir, e.Name())}
+		if _, err := os.Lstat(it.Trashed); err != nil {
+			continue
+		}
+		b, err := os.ReadFile(it.info)
+		if err != nil || !parseRecord(b, &it) {
+			continue
+		}
+		items = append(items, it)
+	}
+	return items, nil
+}
+
+// parseRecord reads a $I record: a version, the file size, the deletion
+// time as a FILETIME and the original path, in a fixed 260-character field
+// (version 1) or after its length (version 2).
+func parseRecord(b []byte, it *Item) bool {
+	if len(b) < 24 {
+		return false
+	}
+	var name []byte
+	switch binary.LittleEndian.Uint64(b) {
+	case 1:
+		name = b[24:]
+	case 2:
+		if len(b) < 28 {
+			return false
+		}
+		n := int(binary.LittleEndian.Uint32(b[24:]))
+		if len(b) < 28+2*n {
+			return false
+		}
+		name = b[28 : 28+2*n]
+	default:
+		return false
+	}
+	u := make([]uint16, len(name)/2)
+	for i := range u {
+		u[i] = binary.LittleEndian.Uint16(name[2*i:])
+	}
+	it.Path = windows.UTF16ToString(u)
+	ft := binary.LittleEndian.Uint64(b[16:])
+	it.Deleted = time.Unix(0, (&windows.Filetime{LowDateTime: uint32(ft), HighDateTime: uint32(ft >> 32)}).Nanoseconds())
+	return it.Path != ""
+}
+
+func userSID() (string, error) {
+	tok, err := windows.OpenCurrentProcessToken()
+	if err != nil {
+		return "", err
+	}
+	defer tok.Close()
+	u, err := tok.GetTokenUser()
+	if err != nil {
+		return "", err
+	}
+	return u.User.Sid.String(), nil
+}
@@ -0,0 +1,39 @@
+//go:build unix && !darwin
+
+package trash
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// homeBin returns the home trash, $XDG_DATA_HOME/Trash.
+func homeBin() (bin, error) {
+	data := os.Getenv("XDG_DATA_HOME")
+	if data == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return bin{}, err
+		}
+		data = filepath.Join(home, ".local", "share")
+	}
+	dir := filepath.Join(data, "Trash")
+	return bin{files: filepath.Join(dir, "files"), info: filepath.Join(dir, "info")}, nil
+}
+
+// topBins returns the trash of the filesystem at top that files are put in,
+// and all the trashes there may be: $top/.Trash/$uid when the administrator
+// set up $top/.Trash (a sticky directory, not a symlink), and $top/.Trash-$uid.
+func topBins(top string) (write bin, all []bin) {
+	uid := strconv.Itoa(os.Getuid())
+	shared := filepath.Join(top, ".Trash", uid)
+	own := filepath.Join(top, ".Trash-"+uid)
+	for _, dir := range []string{shared, own} {
+		all = append(all, bin{files: filepath.Join(dir, "files"), info: filepath.Join(dir, "info"), top: top})
+	}
+	if fi, err := os.Lstat(filepath.Join(top, ".Trash")); err == nil && fi.IsDir() && fi.Mode()&os.ModeSticky != 0 {
+		return all[0], all
+	}
+	return all[1], all
+}